@@ -20,23 +20,109 @@ var tokenService services.TokenService = &services.TokenServiceImpl{}
 var userService services.UserService = &services.UserServiceImpl{}
 var tokenManager auth.TokenManager = auth.NewTokenManagerImpl()
 var diaryEntryService services.DiaryEntryService = &services.DefaultDiaryEntryService{}
+var personalAccessTokenService services.PersonalAccessTokenService = &services.PersonalAccessTokenServiceImpl{}
+
+var errMethodNotAllowed = errors.New("method not allowed")
+
+type scopedEndpoint struct {
+	pattern     *regexp.Regexp
+	readScopes  []string
+	writeScopes []string
+}
+
+// Endpoints usable with personal access tokens, and the scopes they need to be read or written.
+var personalAccessTokenScopedEndpoints = []scopedEndpoint{
+	{
+		pattern:     regexp.MustCompile(`^` + constants.ApiV1UrlRoot + constants.ApiUrlDiaryEntries + `(/|$)`),
+		readScopes:  []string{models.ScopeDiaryRead},
+		writeScopes: []string{models.ScopeDiaryWrite},
+	},
+	{
+		pattern:    regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/attachments/usage$`),
+		readScopes: []string{models.ScopeDiaryRead},
+	},
+	{
+		pattern:     regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/tags(/|$)`),
+		readScopes:  []string{models.ScopeDiaryRead},
+		writeScopes: []string{models.ScopeDiaryWrite},
+	},
+	{
+		pattern:     regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/activityRegistrations(/|$)`),
+		readScopes:  []string{models.ScopeActivitiesRead},
+		writeScopes: []string{models.ScopeActivitiesWrite},
+	},
+	{
+		pattern:    regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/activityTypes$`),
+		readScopes: []string{models.ScopeActivitiesRead},
+	},
+	{
+		pattern:     regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/shareLinks(/|$)`),
+		readScopes:  []string{models.ScopeDiaryRead},
+		writeScopes: []string{models.ScopeDiaryWrite},
+	},
+	{
+		pattern:     regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/imports(/|$)`),
+		readScopes:  []string{models.ScopeDiaryRead},
+		writeScopes: []string{models.ScopeDiaryWrite},
+	},
+	{
+		pattern:    regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/mood/correlation$`),
+		readScopes: []string{models.ScopeDiaryRead, models.ScopeActivitiesRead},
+	},
+	{
+		pattern:    regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/activities$`),
+		readScopes: []string{models.ScopeDiaryRead, models.ScopeActivitiesRead},
+	},
+	{
+		pattern:    regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/calendar$`),
+		readScopes: []string{models.ScopeDiaryRead, models.ScopeActivitiesRead},
+	},
+	{
+		pattern:    regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/readingTime$`),
+		readScopes: []string{models.ScopeActivitiesRead},
+	},
+	{
+		pattern:    regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/mood$`),
+		readScopes: []string{models.ScopeDiaryRead},
+	},
+	{
+		// the export contains every kind of user data
+		pattern:    regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/export$`),
+		readScopes: []string{models.ScopeProfileRead, models.ScopeDiaryRead, models.ScopeActivitiesRead},
+	},
+	{
+		// the trash holds both diary entries and activity registrations
+		pattern:     regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/me/trash(/|$)`),
+		readScopes:  []string{models.ScopeDiaryRead, models.ScopeActivitiesRead},
+		writeScopes: []string{models.ScopeDiaryWrite, models.ScopeActivitiesWrite},
+	},
+	{
+		pattern:    regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/prompts/today$`),
+		readScopes: []string{models.ScopeDiaryRead},
+	},
+	{
+		pattern:     regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/(me$|users/)`),
+		readScopes:  []string{models.ScopeProfileRead},
+		writeScopes: []string{models.ScopeProfileWrite},
+	},
+}
 
 // AuthMiddleware is a middleware to check if each request is correctly authorized.
 // Returs the next http handler to be processed.
 func AuthMiddleware(next http.Handler) http.Handler {
-	authEndpoints := regexp.MustCompile(constants.ApiV1UrlRoot + `/(auth|swagger)/*`)
+	authEndpoints := regexp.MustCompile(constants.ApiV1UrlRoot + `/(auth|swagger|shared)/*`)
 
 	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
 		//If the endpoint is not allowed, check its auth token.
 		if authEndpoints.MatchString(req.URL.Path) {
 			next.ServeHTTP(res, req)
 		} else {
-			authErr := checkAuth(req)
+			user, authErr := checkAuth(req)
 
-			//If the token is valid, execute the next function. Otherwise, respond with an error.
+			//If the token is valid, execute the next function with the user in the request context. Otherwise, respond with an error.
 			if authErr == nil {
-				next.ServeHTTP(res, req)
-			} else if authErr.Error() != "method not allowed" {
+				next.ServeHTTP(res, utils.SetRequestUser(req, user))
+			} else if !errors.Is(authErr, errMethodNotAllowed) {
 				utils.WriteJSON(res, 401,
 					models.HttpError{Status: 401, Description: authErr.Error()})
 			} else {
@@ -122,55 +208,136 @@ func checkUserOwnershipMiddleware(req *http.Request) error {
 
 // checkAuth checks if a request is correctly authorized.
 // To a request to be correctly authorized it is needed to provide
-// an Authorization header with a valid and unexpired access token.
-// Returns error if one of the following happens:
+// an Authorization header with a valid and unexpired access token or personal access token.
+// Returns the authenticated user, or error if one of the following happens:
 //   - The Authorization header is not provided
 //   - The token is expired
-//   - The token is not a valid JWT
+//   - The token is not a valid JWT nor personal access token
 //   - The request method is not authorized
-func checkAuth(req *http.Request) error {
+//   - The scopes of the personal access token do not allow the request
+func checkAuth(req *http.Request) (*models.User, error) {
 	fullToken := req.Header.Get("Authorization")
 
 	if fullToken == "" || !strings.HasPrefix(fullToken, "Bearer") {
-		return errors.New("authorization token must be provided, starting with Bearer")
+		return nil, errors.New("authorization token must be provided, starting with Bearer")
 	}
 
 	tokenString := fullToken[7:]
 
+	if services.IsPersonalAccessToken(tokenString) {
+		return checkPersonalAccessTokenAuth(req, tokenString)
+	}
+
 	//Validate token
 	if err := tokenManager.ValidateToken(tokenString); err != nil {
 		validationErr, ok := err.(*jwt.ValidationError)
 		if ok && validationErr.Errors == jwt.ValidationErrorExpired {
-			return errors.New("token expired. Please, get a new one at /auth/refresh-token")
+			return nil, errors.New("token expired. Please, get a new one at /auth/refresh-token")
 		} else {
-			return errors.New("token not valid")
+			return nil, errors.New("token not valid")
 		}
 	}
 
 	//Then check if token is in the database
 	if _, tokenNotFoundErr := tokenService.GetTokenByValue(tokenString); tokenNotFoundErr != nil {
-		return errors.New("token revoked")
+		return nil, errors.New("token revoked")
 	}
 
 	claims, claimsErr := tokenManager.GetClaims(tokenString)
 
 	if claimsErr != nil {
-		return claimsErr
+		return nil, claimsErr
+	}
+
+	user, getUserErr := userService.GetUserByEmail(claims["email"].(string))
+
+	if getUserErr != nil {
+		return nil, getUserErr
+	}
+
+	if permissionErr := checkUserPermissions(req, user); permissionErr != nil {
+		return nil, permissionErr
+	}
+
+	return user, nil
+}
+
+// checkPersonalAccessTokenAuth authorizes a request made with a personal access token.
+// On top of the permissions of its owner, the request must be allowed by the token scopes.
+func checkPersonalAccessTokenAuth(req *http.Request, tokenString string) (*models.User, error) {
+	personalAccessToken, authErr := personalAccessTokenService.AuthenticatePersonalAccessToken(tokenString)
+
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	user, getUserErr := userService.GetUserById(personalAccessToken.UserRefer)
+
+	if getUserErr != nil {
+		return nil, getUserErr
+	}
+
+	if permissionErr := checkUserPermissions(req, user); permissionErr != nil {
+		return nil, permissionErr
+	}
+
+	requiredScopes, scopesFound := getRequiredScopes(req)
+
+	if !scopesFound {
+		return nil, errMethodNotAllowed
 	}
 
-	user, _ := userService.GetUserByEmail(claims["email"].(string))
+	for _, requiredScope := range requiredScopes {
+		if !services.HasScope(personalAccessToken, requiredScope) {
+			return nil, errMethodNotAllowed
+		}
+	}
+
+	return user, nil
+}
+
+// checkUserPermissions checks if the role of the user allows the request.
+func checkUserPermissions(req *http.Request, user *models.User) error {
 	// user-accessible endpoints
 	userDiaryEntryEndpoints := regexp.MustCompile(`/api/v1/diaryEntries/*`)
 	userActivityRegistrationEndpoints := regexp.MustCompile(`/api/v1/activityRegistrations/*`)
-	// for POST, PUT and DELETE methods, check if user is admin and that the endpoint is not user accessible
-	if ((req.Method == "POST" || req.Method == "PUT" || req.Method == "DELETE") && (user.Role != models.Admin)) &&
-		(!userDiaryEntryEndpoints.MatchString(req.URL.Path) && !userActivityRegistrationEndpoints.MatchString(req.URL.Path)) {
-		return errors.New("method not allowed")
+	userMeEndpoints := regexp.MustCompile(`^/api/v1/me(/|$)`)
+	// for POST, PUT, PATCH and DELETE methods, check if user is admin and that the endpoint is not user accessible
+	if ((req.Method == "POST" || req.Method == "PUT" || req.Method == "PATCH" || req.Method == "DELETE") && (user.Role != models.Admin)) &&
+		(!userDiaryEntryEndpoints.MatchString(req.URL.Path) && !userActivityRegistrationEndpoints.MatchString(req.URL.Path) &&
+			!userMeEndpoints.MatchString(req.URL.Path)) {
+		return errMethodNotAllowed
+	}
+
+	// admin endpoints are restricted for every method
+	adminEndpoints := regexp.MustCompile(`^/api/v1/admin/`)
+	if adminEndpoints.MatchString(req.URL.Path) && user.Role != models.Admin {
+		return errMethodNotAllowed
 	}
 
 	return nil
 }
 
+// getRequiredScopes returns the personal access token scopes needed by a request.
+// The second return value is false for endpoints that cannot be used with personal access tokens,
+// like the ones managing the tokens themselves.
+func getRequiredScopes(req *http.Request) ([]string, bool) {
+	readOnly := req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	for _, scopedEndpoint := range personalAccessTokenScopedEndpoints {
+		if !scopedEndpoint.pattern.MatchString(req.URL.Path) {
+			continue
+		}
+
+		if readOnly {
+			return scopedEndpoint.readScopes, true
+		}
+		return scopedEndpoint.writeScopes, len(scopedEndpoint.writeScopes) > 0
+	}
+
+	return nil, false
+}
+
 // Check if a user's email ,identified by the id passed as parameter, corresponds to the email contained in token claims.
 func checkUserEmailOwnership(userId uint, tokenEmail string) error {
 	entryUser, getUserErr := userService.GetUserById(userId)
@@ -93,7 +93,7 @@ func (m *mockTokenService) UpdateToken(tokenBody *models.Token) (*models.Token,
 	return nil, nil
 }
 
-func (m *mockTokenService) DeleteToken(id uint) error {
+func (m *mockTokenService) DeleteToken(id uint, auditMetadata *services.AuditMetadata) error {
 	if m.DeleteTokenFunc != nil {
 		return m.DeleteTokenFunc(id)
 	}
@@ -101,11 +101,11 @@ func (m *mockTokenService) DeleteToken(id uint) error {
 }
 
 type mockUserService struct {
-	GetUserByIdFunc    func(id uint) (*models.User, error)
-	GetUserByEmailFunc func(email string) (*models.User, error)
-	DeleteUserFunc     func(id uint) error
-	SaveUserFunc       func(userBody services.UserBody) (*models.User, error)
-	UpdateUserFunc     func(userBody services.UserBody) (*models.User, error)
+	GetUserByIdFunc       func(id uint) (*models.User, error)
+	GetUserByEmailFunc    func(email string) (*models.User, error)
+	DeleteUserFunc        func(id uint) error
+	SaveUserFunc          func(userBody services.UserBody) (*models.User, error)
+	UpdateUserProfileFunc func(userId uint, profileBody *services.UpdateUserProfileBody) (*models.User, error)
 }
 
 func (m *mockUserService) GetUserById(id uint) (*models.User, error) {
@@ -122,6 +122,13 @@ func (m *mockUserService) GetUserByEmail(email string) (*models.User, error) {
 	return nil, nil
 }
 
+func (m *mockUserService) UpdateUserProfile(userId uint, profileBody *services.UpdateUserProfileBody) (*models.User, error) {
+	if m.UpdateUserProfileFunc != nil {
+		return m.UpdateUserProfileFunc(userId, profileBody)
+	}
+	return nil, nil
+}
+
 func (m *mockUserService) DeleteUser(id uint) error {
 	if m.DeleteUserFunc != nil {
 		return m.DeleteUserFunc(id)
@@ -136,18 +143,13 @@ func (m *mockUserService) SaveUser(userBody services.UserBody) (*models.User, er
 	return nil, nil
 }
 
-func (m *mockUserService) UpdateUser(userBody services.UserBody) (*models.User, error) {
-	if m.UpdateUserFunc != nil {
-		return m.UpdateUserFunc(userBody)
-	}
-	return nil, nil
-}
-
 type mockDiaryEntryService struct {
 	GetDiaryEntryByIdFunc       func(id uint) (*models.DiaryEntry, error)
 	GetUserEntriesFunc          func(userId uint) ([]*models.DiaryEntry, error)
 	GetUserEntriesTimeRangeFunc func(userId uint, startDate int64, endDate int64) ([]*models.DiaryEntry, error)
-	SaveDiaryEntryFunc          func(diaryEntryBody *services.SaveDiaryEntryBody) (*models.DiaryEntry, error)
+	GetUserEntriesPageFunc      func(userId uint, pageQuery *services.DiaryEntryPageQuery) (*services.DiaryEntryPage, error)
+	SearchUserEntriesFunc       func(userId uint, searchQuery *services.DiaryEntrySearchQuery) (*services.DiaryEntrySearchPage, error)
+	SaveDiaryEntryFunc          func(userId uint, diaryEntryBody *services.SaveDiaryEntryBody) (*models.DiaryEntry, error)
 	UpdateDiaryEntryFunc        func(diaryEntryId uint, diaryEntryBody *services.UpdateDiaryEntryBody) (*models.DiaryEntry, error)
 	DeleteDiaryEntryFunc        func(id uint) error
 }
@@ -173,21 +175,39 @@ func (m *mockDiaryEntryService) GetUserEntriesTimeRange(userId uint, startDate i
 	return nil, nil
 }
 
-func (m *mockDiaryEntryService) SaveDiaryEntry(diaryEntryBody *services.SaveDiaryEntryBody) (*models.DiaryEntry, error) {
+func (m *mockDiaryEntryService) GetUserEntriesPage(userId uint, pageQuery *services.DiaryEntryPageQuery) (*services.DiaryEntryPage, error) {
+	if m.GetUserEntriesPageFunc != nil {
+		return m.GetUserEntriesPageFunc(userId, pageQuery)
+	}
+	return nil, nil
+}
+
+func (m *mockDiaryEntryService) SearchUserEntries(userId uint, searchQuery *services.DiaryEntrySearchQuery) (*services.DiaryEntrySearchPage, error) {
+	if m.SearchUserEntriesFunc != nil {
+		return m.SearchUserEntriesFunc(userId, searchQuery)
+	}
+	return nil, nil
+}
+
+func (m *mockDiaryEntryService) SaveDiaryEntry(userId uint, diaryEntryBody *services.SaveDiaryEntryBody, auditMetadata *services.AuditMetadata) (*models.DiaryEntry, error) {
 	if m.SaveDiaryEntryFunc != nil {
-		return m.SaveDiaryEntryFunc(diaryEntryBody)
+		return m.SaveDiaryEntryFunc(userId, diaryEntryBody)
 	}
 	return nil, nil
 }
 
-func (m *mockDiaryEntryService) UpdateDiaryEntry(diaryEntryId uint, diaryEntryBody *services.UpdateDiaryEntryBody) (*models.DiaryEntry, error) {
+func (m *mockDiaryEntryService) UpdateDiaryEntry(diaryEntryId uint, diaryEntryBody *services.UpdateDiaryEntryBody, auditMetadata *services.AuditMetadata) (*models.DiaryEntry, error) {
 	if m.UpdateDiaryEntryFunc != nil {
 		return m.UpdateDiaryEntryFunc(diaryEntryId, diaryEntryBody)
 	}
 	return nil, nil
 }
 
-func (m *mockDiaryEntryService) DeleteDiaryEntry(id uint) error {
+func (m *mockDiaryEntryService) PatchDiaryEntry(userId uint, diaryEntryId uint, patch []byte, expectedVersion int64, auditMetadata *services.AuditMetadata) (*models.DiaryEntry, error) {
+	return nil, nil
+}
+
+func (m *mockDiaryEntryService) DeleteDiaryEntry(id uint, auditMetadata *services.AuditMetadata) error {
 	if m.DeleteDiaryEntryFunc != nil {
 		return m.DeleteDiaryEntryFunc(id)
 	}
@@ -307,6 +327,46 @@ func TestCheckAuth(t *testing.T) {
 			reqURLPath:             "/api/v1/diaryEntries/123",
 			expectedErr:            nil,
 		},
+		{
+			name:                "Valid token, non-admin user, user-accessible PATCH (me)",
+			authHeader:          "Bearer user.token",
+			mockGetTokenByValue: &models.Token{},
+			mockGetClaims:       jwt.MapClaims{"email": "user@example.com"},
+			mockGetUserByEmail:  &models.User{Role: models.Standard},
+			reqMethod:           http.MethodPatch,
+			reqURLPath:          "/api/v1/me",
+			expectedErr:         nil,
+		},
+		{
+			name:                "Valid token, non-admin user, non-user-accessible PATCH",
+			authHeader:          "Bearer user.token",
+			mockGetTokenByValue: &models.Token{},
+			mockGetClaims:       jwt.MapClaims{"email": "user@example.com"},
+			mockGetUserByEmail:  &models.User{Role: models.Standard},
+			reqMethod:           http.MethodPatch,
+			reqURLPath:          "/api/v1/users/1",
+			expectedErr:         errors.New("method not allowed"),
+		},
+		{
+			name:                "Valid token, non-admin user, admin GET",
+			authHeader:          "Bearer user.token",
+			mockGetTokenByValue: &models.Token{},
+			mockGetClaims:       jwt.MapClaims{"email": "user@example.com"},
+			mockGetUserByEmail:  &models.User{Role: models.Standard},
+			reqMethod:           http.MethodGet,
+			reqURLPath:          "/api/v1/admin/auditEvents",
+			expectedErr:         errors.New("method not allowed"),
+		},
+		{
+			name:                "Valid token, admin user, admin GET",
+			authHeader:          "Bearer admin.token",
+			mockGetTokenByValue: &models.Token{},
+			mockGetClaims:       jwt.MapClaims{"email": "admin@example.com"},
+			mockGetUserByEmail:  &models.User{Role: models.Admin},
+			reqMethod:           http.MethodGet,
+			reqURLPath:          "/api/v1/admin/auditEvents",
+			expectedErr:         nil,
+		},
 	}
 
 	for _, testCase := range tests {
@@ -333,7 +393,6 @@ func TestCheckAuth(t *testing.T) {
 				GetUserByIdFunc: func(id uint) (*models.User, error) { return &models.User{}, nil },
 				DeleteUserFunc:  func(id uint) error { return nil },
 				SaveUserFunc:    func(userBody services.UserBody) (*models.User, error) { return &models.User{}, nil },
-				UpdateUserFunc:  func(userBody services.UserBody) (*models.User, error) { return &models.User{}, nil },
 			}
 
 			req, _ := http.NewRequest(testCase.reqMethod, testCase.reqURLPath, nil)
@@ -341,7 +400,7 @@ func TestCheckAuth(t *testing.T) {
 				req.Header.Set("Authorization", testCase.authHeader)
 			}
 
-			err := checkAuth(req)
+			_, err := checkAuth(req)
 
 			if (err == nil && testCase.expectedErr != nil) || (err != nil && testCase.expectedErr == nil) || (err != nil && err.Error() != testCase.expectedErr.Error()) {
 				t.Errorf("checkAuth() error = %v, wantErr %v", err, testCase.expectedErr)
@@ -548,3 +607,124 @@ func TestCheckUserOwnershipMiddleware(t *testing.T) {
 		})
 	}
 }
+
+type mockPersonalAccessTokenService struct {
+	AuthenticatePersonalAccessTokenFunc func(tokenValue string) (*models.PersonalAccessToken, error)
+}
+
+func (m *mockPersonalAccessTokenService) GetUserPersonalAccessTokens(userId uint) ([]*models.PersonalAccessToken, error) {
+	return nil, nil
+}
+
+func (m *mockPersonalAccessTokenService) CreatePersonalAccessToken(userId uint, createBody *services.CreatePersonalAccessTokenBody, auditMetadata *services.AuditMetadata) (*services.CreatedPersonalAccessTokenResponse, error) {
+	return nil, nil
+}
+
+func (m *mockPersonalAccessTokenService) DeletePersonalAccessToken(userId uint, id uint, auditMetadata *services.AuditMetadata) error {
+	return nil
+}
+
+func (m *mockPersonalAccessTokenService) AuthenticatePersonalAccessToken(tokenValue string) (*models.PersonalAccessToken, error) {
+	if m.AuthenticatePersonalAccessTokenFunc != nil {
+		return m.AuthenticatePersonalAccessTokenFunc(tokenValue)
+	}
+	return nil, errors.New("personal access token not valid")
+}
+
+// Test checkAuth function with personal access tokens
+func TestCheckAuth_PersonalAccessToken(t *testing.T) {
+	originalPersonalAccessTokenService := personalAccessTokenService
+	originalUserService := userService
+	defer func() {
+		personalAccessTokenService = originalPersonalAccessTokenService
+		userService = originalUserService
+	}()
+
+	personalAccessTokenService = &mockPersonalAccessTokenService{
+		AuthenticatePersonalAccessTokenFunc: func(tokenValue string) (*models.PersonalAccessToken, error) {
+			if tokenValue != "alk_pat_valid" {
+				return nil, errors.New("personal access token not valid")
+			}
+			return &models.PersonalAccessToken{UserRefer: 1, Scopes: []string{models.ScopeDiaryRead, models.ScopeActivitiesWrite}}, nil
+		},
+	}
+	userService = &mockUserService{
+		GetUserByIdFunc: func(id uint) (*models.User, error) { return &models.User{Id: id, Role: models.Standard}, nil },
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		reqMethod   string
+		reqURLPath  string
+		expectedErr error
+	}{
+		{"Invalid token", "alk_pat_invalid", http.MethodGet, "/api/v1/diaryEntries/1", errors.New("personal access token not valid")},
+		{"Read with read scope", "alk_pat_valid", http.MethodGet, "/api/v1/diaryEntries/user/1", nil},
+		{"Write without write scope", "alk_pat_valid", http.MethodPost, "/api/v1/diaryEntries", errMethodNotAllowed},
+		{"Write with write scope", "alk_pat_valid", http.MethodPost, "/api/v1/activityRegistrations/books", nil},
+		{"Read without read scope", "alk_pat_valid", http.MethodGet, "/api/v1/users/1", errMethodNotAllowed},
+		{"Token management endpoint", "alk_pat_valid", http.MethodGet, "/api/v1/me/tokens", errMethodNotAllowed},
+		{"Key backups endpoint", "alk_pat_valid", http.MethodGet, "/api/v1/me/keyBackups", errMethodNotAllowed},
+		{"Mood with diary read scope", "alk_pat_valid", http.MethodGet, "/api/v1/me/mood", nil},
+		{"Attachment usage with diary read scope", "alk_pat_valid", http.MethodGet, "/api/v1/me/attachments/usage", nil},
+		{"Mood correlation without activities read scope", "alk_pat_valid", http.MethodGet, "/api/v1/me/mood/correlation", errMethodNotAllowed},
+		{"Trash without activities read scope", "alk_pat_valid", http.MethodGet, "/api/v1/me/trash", errMethodNotAllowed},
+		{"Activity types without activities read scope", "alk_pat_valid", http.MethodGet, "/api/v1/activityTypes", errMethodNotAllowed},
+		{"Custom activity registration with activities write scope", "alk_pat_valid", http.MethodPost, "/api/v1/activityRegistrations/walk", nil},
+		{"Activity timeline without activities read scope", "alk_pat_valid", http.MethodGet, "/api/v1/me/activities", errMethodNotAllowed},
+		{"Calendar without activities read scope", "alk_pat_valid", http.MethodGet, "/api/v1/me/calendar", errMethodNotAllowed},
+		{"Reading time without activities read scope", "alk_pat_valid", http.MethodGet, "/api/v1/me/readingTime", errMethodNotAllowed},
+		{"Reading session start with activities write scope", "alk_pat_valid", http.MethodPost, "/api/v1/activityRegistrations/books/1/readingSessions", nil},
+		{"Import without diary write scope", "alk_pat_valid", http.MethodPost, "/api/v1/me/imports", errMethodNotAllowed},
+		{"Share links with diary read scope", "alk_pat_valid", http.MethodGet, "/api/v1/me/shareLinks", nil},
+		{"Share link revocation without diary write scope", "alk_pat_valid", http.MethodDelete, "/api/v1/me/shareLinks/1", errMethodNotAllowed},
+		{"Daily prompt with diary read scope", "alk_pat_valid", http.MethodGet, "/api/v1/prompts/today", nil},
+		{"Trash restore without diary write scope", "alk_pat_valid", http.MethodPost, "/api/v1/me/trash/books/1/restore", errMethodNotAllowed},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			req, _ := http.NewRequest(testCase.reqMethod, testCase.reqURLPath, nil)
+			req.Header.Set("Authorization", "Bearer "+testCase.token)
+
+			user, err := checkAuth(req)
+
+			if (err == nil && testCase.expectedErr != nil) || (err != nil && testCase.expectedErr == nil) || (err != nil && err.Error() != testCase.expectedErr.Error()) {
+				t.Errorf("checkAuth() error = %v, wantErr %v", err, testCase.expectedErr)
+			}
+			if err == nil && user.Id != 1 {
+				t.Errorf("checkAuth() user = %v, want token owner", user)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_PublicEndpoints(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) { res.WriteHeader(http.StatusOK) })
+	handler := AuthMiddleware(nextHandler)
+
+	tests := []struct {
+		name           string
+		reqURLPath     string
+		expectedStatus int
+	}{
+		{"Shared diary entry", "/api/v1/shared/alk_shr_token", http.StatusOK},
+		{"Authentication", "/api/v1/auth/authenticate", http.StatusOK},
+		{"Share links of the user", "/api/v1/me/shareLinks", http.StatusUnauthorized},
+		{"Diary entries", "/api/v1/diaryEntries/1", http.StatusUnauthorized},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, testCase.reqURLPath, nil)
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+
+			if res.Code != testCase.expectedStatus {
+				t.Errorf("AuthMiddleware() status = %d, want %d", res.Code, testCase.expectedStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adfer-dev/analock-api/constants"
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/utils"
+)
+
+// RateLimitKeyKind defines what identifies the client a rate limit is applied to.
+type RateLimitKeyKind int
+
+const (
+	RateLimitByIP RateLimitKeyKind = iota + 1
+	RateLimitByUser
+	RateLimitByIPAndUser
+)
+
+const (
+	inMemoryRateLimitSweepInterval = 5 * time.Minute
+	rateLimitRulesFileEnv          = "RATE_LIMIT_RULES_FILE"
+)
+
+// rateLimitKeyKinds maps the client identifiers of the rules file to their kind.
+var rateLimitKeyKinds = map[string]RateLimitKeyKind{
+	"ip":        RateLimitByIP,
+	"user":      RateLimitByUser,
+	"ipAndUser": RateLimitByIPAndUser,
+}
+
+var ErrInvalidRateLimitRules = errors.New("invalid rate limit rules")
+
+// RateLimitPolicy is a token bucket that allows Limit requests per Window,
+// refilling the bucket continuously.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	KeyBy  RateLimitKeyKind
+}
+
+// RateLimitRule applies a policy to the requests whose path matches the pattern and whose
+// method is one of the listed ones. An empty method list matches every method.
+type RateLimitRule struct {
+	Pattern *regexp.Regexp
+	Methods []string
+	Policy  RateLimitPolicy
+}
+
+// RateLimitResult is the state of a bucket after trying to take a token from it.
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps the token buckets. Implementations backed by a shared database
+// allow multiple replicas of the API to enforce the same limits.
+type RateLimitStore interface {
+	Take(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error)
+}
+
+// Default rate limit rules, used when no rules file is configured. The first rule matching a request is the one applied.
+var defaultRateLimitRules = []RateLimitRule{
+	{
+		// Each authentication triggers an outbound Google token validation call.
+		Pattern: regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/auth/authenticate$`),
+		Methods: []string{http.MethodPost},
+		Policy:  RateLimitPolicy{Name: "authenticate", Limit: 10, Window: time.Minute, KeyBy: RateLimitByIP},
+	},
+	{
+		Pattern: regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/auth/refreshToken$`),
+		Methods: []string{http.MethodPost},
+		Policy:  RateLimitPolicy{Name: "refresh", Limit: 30, Window: time.Minute, KeyBy: RateLimitByIP},
+	},
+	{
+		// Shared diary entries are public, and their passphrases must not be guessed by brute force.
+		Pattern: regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/shared/`),
+		Policy:  RateLimitPolicy{Name: "shared", Limit: 30, Window: time.Minute, KeyBy: RateLimitByIP},
+	},
+	{
+		Pattern: regexp.MustCompile(`^` + constants.ApiV1UrlRoot + `/`),
+		Methods: []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		Policy:  RateLimitPolicy{Name: "write", Limit: 60, Window: time.Minute, KeyBy: RateLimitByIPAndUser},
+	},
+}
+
+// rateLimitRuleConfig is a rate limit rule as written in the rules file. Patterns are matched against the whole
+// request path, windows are Go durations like 1m, and keyBy is one of ip, user or ipAndUser.
+type rateLimitRuleConfig struct {
+	Name    string   `json:"name"`
+	Pattern string   `json:"pattern"`
+	Methods []string `json:"methods"`
+	Limit   int      `json:"limit"`
+	Window  string   `json:"window"`
+	KeyBy   string   `json:"keyBy"`
+}
+
+// LoadRateLimitRules reads the rate limit rules from the JSON file set in the environment, which replace the default
+// rules as a whole. The default rules are returned when no file is configured.
+func LoadRateLimitRules() ([]RateLimitRule, error) {
+	rulesFile := os.Getenv(rateLimitRulesFileEnv)
+
+	if len(rulesFile) == 0 {
+		return defaultRateLimitRules, nil
+	}
+
+	rulesData, readErr := os.ReadFile(rulesFile)
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	return parseRateLimitRules(rulesData)
+}
+
+// parseRateLimitRules reads a JSON array of rate limit rules, keeping their order.
+func parseRateLimitRules(rulesData []byte) ([]RateLimitRule, error) {
+	ruleConfigs := []rateLimitRuleConfig{}
+	decoder := json.NewDecoder(bytes.NewReader(rulesData))
+	decoder.DisallowUnknownFields()
+
+	if decodeErr := decoder.Decode(&ruleConfigs); decodeErr != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRateLimitRules, decodeErr.Error())
+	}
+
+	rules := make([]RateLimitRule, 0, len(ruleConfigs))
+
+	for index, ruleConfig := range ruleConfigs {
+		pattern, patternErr := regexp.Compile(ruleConfig.Pattern)
+		window, windowErr := time.ParseDuration(ruleConfig.Window)
+		keyBy, keyByFound := rateLimitKeyKinds[ruleConfig.KeyBy]
+
+		switch {
+		case len(ruleConfig.Name) == 0:
+			return nil, fmt.Errorf("%w: rule %d has no name", ErrInvalidRateLimitRules, index)
+		case len(ruleConfig.Pattern) == 0 || patternErr != nil:
+			return nil, fmt.Errorf("%w: rule %s has an invalid pattern", ErrInvalidRateLimitRules, ruleConfig.Name)
+		case ruleConfig.Limit <= 0:
+			return nil, fmt.Errorf("%w: rule %s must have a positive limit", ErrInvalidRateLimitRules, ruleConfig.Name)
+		case windowErr != nil || window <= 0:
+			return nil, fmt.Errorf("%w: rule %s must have a positive window", ErrInvalidRateLimitRules, ruleConfig.Name)
+		case !keyByFound:
+			return nil, fmt.Errorf("%w: rule %s must be keyed by ip, user or ipAndUser", ErrInvalidRateLimitRules, ruleConfig.Name)
+		}
+
+		methods := make([]string, 0, len(ruleConfig.Methods))
+		for _, method := range ruleConfig.Methods {
+			methods = append(methods, strings.ToUpper(method))
+		}
+
+		rules = append(rules, RateLimitRule{
+			Pattern: pattern,
+			Methods: methods,
+			Policy:  RateLimitPolicy{Name: ruleConfig.Name, Limit: ruleConfig.Limit, Window: window, KeyBy: keyBy},
+		})
+	}
+
+	return rules, nil
+}
+
+// NewRateLimitMiddleware returns a middleware applying the first matching rule to each request.
+// It must run before AuthMiddleware, so requests failing authentication are limited too. User keyed policies
+// read the user set on the request by earlier middlewares, and fall back to the client IP otherwise.
+// Requests not matching any rule are not limited.
+func NewRateLimitMiddleware(store RateLimitStore, rules []RateLimitRule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			rule := findRateLimitRule(rules, req)
+
+			if rule == nil {
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			result, takeErr := store.Take(getRateLimitKey(rule.Policy, req), rule.Policy, time.Now())
+
+			// fail open: an unavailable store must not take the API down
+			if takeErr != nil {
+				logger.ErrorLogger.Printf("error when applying rate limit policy %s: %s", rule.Policy.Name, takeErr.Error())
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			setRateLimitHeaders(res, rule.Policy, result)
+
+			if !result.Allowed {
+				res.Header().Set("Retry-After", strconv.Itoa(durationToCeilSeconds(result.RetryAfter)))
+				utils.WriteJSON(res, http.StatusTooManyRequests,
+					models.HttpError{Status: http.StatusTooManyRequests, Description: "too many requests, please try again later"})
+				return
+			}
+
+			next.ServeHTTP(res, req)
+		})
+	}
+}
+
+// InMemoryRateLimitStore keeps the token buckets in the memory of the process.
+type InMemoryRateLimitStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+	policy     RateLimitPolicy
+}
+
+// NewInMemoryRateLimitStore creates a new empty InMemoryRateLimitStore.
+func NewInMemoryRateLimitStore() *InMemoryRateLimitStore {
+	return &InMemoryRateLimitStore{buckets: make(map[string]*tokenBucket), lastSweep: time.Now()}
+}
+
+func (store *InMemoryRateLimitStore) Take(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.sweep(now)
+
+	bucket, bucketPresent := store.buckets[key]
+	if !bucketPresent {
+		bucket = &tokenBucket{tokens: float64(policy.Limit), lastRefill: now, policy: policy}
+		store.buckets[key] = bucket
+	}
+
+	bucket.refill(now)
+
+	result := RateLimitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - bucket.tokens) / bucket.refillRate() * float64(time.Second))
+	}
+
+	result.Remaining = int(math.Floor(bucket.tokens))
+	result.ResetAfter = time.Duration((float64(policy.Limit) - bucket.tokens) / bucket.refillRate() * float64(time.Second))
+
+	return result, nil
+}
+
+// sweep removes the buckets that are already full again, as they are equivalent to new ones.
+func (store *InMemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < inMemoryRateLimitSweepInterval {
+		return
+	}
+
+	for key, bucket := range store.buckets {
+		bucket.refill(now)
+		if bucket.tokens >= float64(bucket.policy.Limit) {
+			delete(store.buckets, key)
+		}
+	}
+	store.lastSweep = now
+}
+
+// refillRate returns the tokens added to the bucket per second.
+func (bucket *tokenBucket) refillRate() float64 {
+	return float64(bucket.policy.Limit) / bucket.policy.Window.Seconds()
+}
+
+func (bucket *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+
+	if elapsed > 0 {
+		bucket.tokens = math.Min(float64(bucket.policy.Limit), bucket.tokens+elapsed*bucket.refillRate())
+		bucket.lastRefill = now
+	}
+}
+
+// AUX FUNCTIONS
+
+func findRateLimitRule(rules []RateLimitRule, req *http.Request) *RateLimitRule {
+	for index := range rules {
+		rule := &rules[index]
+
+		if rule.Pattern.MatchString(req.URL.Path) && (len(rule.Methods) == 0 || slices.Contains(rule.Methods, req.Method)) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// getRateLimitKey returns the bucket key of the request for a policy.
+// User keyed policies fall back to the client IP on unauthenticated requests.
+func getRateLimitKey(policy RateLimitPolicy, req *http.Request) string {
+	clientIP := utils.GetClientIP(req)
+	user, userPresent := utils.GetRequestUser(req)
+
+	switch {
+	case policy.KeyBy == RateLimitByUser && userPresent:
+		return fmt.Sprintf("%s:user:%d", policy.Name, user.Id)
+	case policy.KeyBy == RateLimitByIPAndUser && userPresent:
+		return fmt.Sprintf("%s:ip:%s:user:%d", policy.Name, clientIP, user.Id)
+	default:
+		return fmt.Sprintf("%s:ip:%s", policy.Name, clientIP)
+	}
+}
+
+func setRateLimitHeaders(res http.ResponseWriter, policy RateLimitPolicy, result RateLimitResult) {
+	res.Header().Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+	res.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	res.Header().Set("RateLimit-Reset", strconv.Itoa(durationToCeilSeconds(result.ResetAfter)))
+	res.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+}
+
+func durationToCeilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
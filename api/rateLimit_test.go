@@ -0,0 +1,206 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRateLimitStore struct {
+	TakeFunc func(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error)
+	Keys     []string
+}
+
+func (m *mockRateLimitStore) Take(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+	m.Keys = append(m.Keys, key)
+	return m.TakeFunc(key, policy, now)
+}
+
+func TestInMemoryRateLimitStore_Take(t *testing.T) {
+	store := NewInMemoryRateLimitStore()
+	policy := RateLimitPolicy{Name: "test", Limit: 2, Window: 10 * time.Second, KeyBy: RateLimitByIP}
+	now := time.Now()
+
+	first, _ := store.Take("key", policy, now)
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 1, first.Remaining)
+
+	second, _ := store.Take("key", policy, now)
+	assert.True(t, second.Allowed)
+	assert.Equal(t, 0, second.Remaining)
+	assert.Equal(t, 10*time.Second, second.ResetAfter)
+
+	denied, _ := store.Take("key", policy, now)
+	assert.False(t, denied.Allowed)
+	assert.Equal(t, 5*time.Second, denied.RetryAfter)
+
+	// other keys have their own bucket
+	otherKey, _ := store.Take("other", policy, now)
+	assert.True(t, otherKey.Allowed)
+
+	// one token is refilled every 5 seconds
+	refilled, _ := store.Take("key", policy, now.Add(5*time.Second))
+	assert.True(t, refilled.Allowed)
+	assert.Equal(t, 0, refilled.Remaining)
+
+	// full buckets are removed when sweeping
+	store.Take("sweep", policy, now.Add(inMemoryRateLimitSweepInterval))
+	assert.Len(t, store.buckets, 1)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	rules := []RateLimitRule{
+		{
+			Pattern: regexp.MustCompile(`^/api/v1/auth/authenticate$`),
+			Methods: []string{http.MethodPost},
+			Policy:  RateLimitPolicy{Name: "authenticate", Limit: 1, Window: time.Minute, KeyBy: RateLimitByIP},
+		},
+		{
+			Pattern: regexp.MustCompile(`^/api/v1/`),
+			Methods: []string{http.MethodPost},
+			Policy:  RateLimitPolicy{Name: "write", Limit: 5, Window: time.Minute, KeyBy: RateLimitByIPAndUser},
+		},
+	}
+	nextHandler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) { res.WriteHeader(http.StatusOK) })
+	handler := NewRateLimitMiddleware(NewInMemoryRateLimitStore(), rules)(nextHandler)
+
+	t.Run("limit_exceeded", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/authenticate", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+
+		allowedRes := httptest.NewRecorder()
+		handler.ServeHTTP(allowedRes, req)
+		assert.Equal(t, http.StatusOK, allowedRes.Code)
+		assert.Equal(t, "1", allowedRes.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", allowedRes.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1;w=60", allowedRes.Header().Get("RateLimit-Policy"))
+
+		deniedRes := httptest.NewRecorder()
+		handler.ServeHTTP(deniedRes, req)
+		assert.Equal(t, http.StatusTooManyRequests, deniedRes.Code)
+		assert.Equal(t, "60", deniedRes.Header().Get("Retry-After"))
+	})
+
+	t.Run("not_matching_rule", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Empty(t, res.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("keyed_by_ip_and_user", func(t *testing.T) {
+		store := &mockRateLimitStore{TakeFunc: func(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+			return RateLimitResult{Allowed: true}, nil
+		}}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/diaryEntries", nil)
+		req.RemoteAddr = "10.0.0.2:1234"
+		req = utils.SetRequestUser(req, &models.User{Id: 3})
+
+		NewRateLimitMiddleware(store, rules)(nextHandler).ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, []string{"write:ip:10.0.0.2:user:3"}, store.Keys)
+	})
+
+	t.Run("store_error_fails_open", func(t *testing.T) {
+		store := &mockRateLimitStore{TakeFunc: func(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+			return RateLimitResult{}, errors.New("store unavailable")
+		}}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/diaryEntries", nil)
+		res := httptest.NewRecorder()
+
+		NewRateLimitMiddleware(store, rules)(nextHandler).ServeHTTP(res, req)
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+}
+
+func TestRateLimitMiddleware_LimitsFailedAuthentications(t *testing.T) {
+	rules := []RateLimitRule{
+		{
+			Pattern: regexp.MustCompile(`^/api/v1/`),
+			Methods: []string{http.MethodPost},
+			Policy:  RateLimitPolicy{Name: "write", Limit: 2, Window: time.Minute, KeyBy: RateLimitByIPAndUser},
+		},
+	}
+	router := mux.NewRouter()
+	useMiddlewares(router, NewInMemoryRateLimitStore(), rules)
+	router.HandleFunc("/api/v1/diaryEntries", func(res http.ResponseWriter, req *http.Request) { res.WriteHeader(http.StatusCreated) }).Methods(http.MethodPost)
+
+	statuses := []int{}
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/diaryEntries", nil)
+		req.RemoteAddr = "10.0.0.3:1234"
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		statuses = append(statuses, res.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}, statuses)
+}
+
+func TestGetRateLimitKey_ClientIP(t *testing.T) {
+	policy := RateLimitPolicy{Name: "authenticate", KeyBy: RateLimitByIP}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	assert.Equal(t, "authenticate:ip:10.0.0.1", getRateLimitKey(policy, req))
+
+	t.Setenv("TRUST_PROXY_HEADERS", "true")
+	assert.Equal(t, "authenticate:ip:203.0.113.7", getRateLimitKey(policy, req))
+}
+
+func TestLoadRateLimitRules(t *testing.T) {
+	// Test the default rules are used without a rules file
+	t.Setenv(rateLimitRulesFileEnv, "")
+	rules, err := LoadRateLimitRules()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultRateLimitRules, rules)
+
+	rulesFile := filepath.Join(t.TempDir(), "rateLimitRules.json")
+	assert.NoError(t, os.WriteFile(rulesFile, []byte(`[
+		{"name": "authenticate", "pattern": "^/api/v1/auth/", "methods": ["post"], "limit": 5, "window": "30s", "keyBy": "ip"},
+		{"name": "all", "pattern": "^/api/v1/", "limit": 100, "window": "1m", "keyBy": "ipAndUser"}
+	]`), 0o600))
+	t.Setenv(rateLimitRulesFileEnv, rulesFile)
+
+	rules, err = LoadRateLimitRules()
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, RateLimitPolicy{Name: "authenticate", Limit: 5, Window: 30 * time.Second, KeyBy: RateLimitByIP}, rules[0].Policy)
+	assert.Equal(t, []string{http.MethodPost}, rules[0].Methods)
+	assert.True(t, rules[0].Pattern.MatchString("/api/v1/auth/authenticate"))
+	assert.Equal(t, RateLimitByIPAndUser, rules[1].Policy.KeyBy)
+	assert.Empty(t, rules[1].Methods)
+
+	// Test missing files are reported
+	t.Setenv(rateLimitRulesFileEnv, filepath.Join(t.TempDir(), "missing.json"))
+	_, err = LoadRateLimitRules()
+	assert.Error(t, err)
+}
+
+func TestParseRateLimitRules_Invalid(t *testing.T) {
+	invalidRules := []string{
+		`{"name": "all"}`,
+		`[{"name": "all", "pattern": "^/", "limit": 1, "window": "1m", "keyBy": "ip", "burst": 2}]`,
+		`[{"pattern": "^/", "limit": 1, "window": "1m", "keyBy": "ip"}]`,
+		`[{"name": "all", "pattern": "(", "limit": 1, "window": "1m", "keyBy": "ip"}]`,
+		`[{"name": "all", "pattern": "^/", "limit": 0, "window": "1m", "keyBy": "ip"}]`,
+		`[{"name": "all", "pattern": "^/", "limit": 1, "window": "a minute", "keyBy": "ip"}]`,
+		`[{"name": "all", "pattern": "^/", "limit": 1, "window": "1m", "keyBy": "session"}]`,
+	}
+
+	for _, invalidRule := range invalidRules {
+		_, err := parseRateLimitRules([]byte(invalidRule))
+		assert.ErrorIs(t, err, ErrInvalidRateLimitRules, invalidRule)
+	}
+}
@@ -3,20 +3,27 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"os"
 
 	"github.com/adfer-dev/analock-api/constants"
 	"github.com/adfer-dev/analock-api/docs"
 	"github.com/adfer-dev/analock-api/handlers"
+	"github.com/adfer-dev/analock-api/utils"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+var logger *utils.CustomLogger = utils.GetCustomLogger()
+
 type APIServer struct {
-	Port   int
-	router *mux.Router
+	Port int
+	// RateLimitRules are the rate limit rules applied to the requests, in order.
+	RateLimitRules []RateLimitRule
+	router         *mux.Router
 }
 
 func (server *APIServer) Run() error {
@@ -30,16 +37,25 @@ func (server *APIServer) Run() error {
 		docs.SwaggerInfo.Host = fmt.Sprintf("%s:%d", "localhost", server.Port)
 	}
 
+	// credentials (the refresh cookie) are allowed, so only the listed origins can make cross-origin requests
+	allowedOrigins := getCorsAllowedOrigins()
+	if len(allowedOrigins) == 0 {
+		logger.InfoLogger.Println("CORS_ALLOWED_ORIGINS is not set, cross-origin requests will be rejected")
+	}
+
 	corsHandler := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
+		AllowOriginFunc: func(origin string) bool {
+			return slices.Contains(allowedOrigins, origin)
+		},
 		AllowCredentials: true,
-		AllowedHeaders:   []string{"Authorization", "Content-Type"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "If-Match", constants.CsrfHeaderName, constants.ShareLinkPassphraseHeaderName},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		ExposedHeaders:   []string{"ETag", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
 		MaxAge:           86400,
 		Debug:            false,
 	}).Handler(server.router)
 
-	server.router.Use(AuthMiddleware, ValidatePathParams)
+	useMiddlewares(server.router, NewInMemoryRateLimitStore(), server.RateLimitRules)
 
 	// Swagger documentation
 	server.router.PathPrefix(constants.ApiV1UrlRoot + "/swagger/").Handler(httpSwagger.Handler(
@@ -54,9 +70,43 @@ func (server *APIServer) Run() error {
 	return http.ListenAndServe(fmt.Sprintf(":%d", server.Port), corsHandler)
 }
 
+// useMiddlewares registers the middlewares applied to every request. The rate limiter runs first,
+// so requests failing authentication are limited too.
+func useMiddlewares(router *mux.Router, rateLimitStore RateLimitStore, rateLimitRules []RateLimitRule) {
+	router.Use(NewRateLimitMiddleware(rateLimitStore, rateLimitRules), AuthMiddleware, ValidatePathParams)
+}
+
+// getCorsAllowedOrigins returns the origins listed in the comma separated CORS_ALLOWED_ORIGINS env variable.
+func getCorsAllowedOrigins() []string {
+	allowedOrigins := make([]string, 0)
+
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if trimmedOrigin := strings.TrimSpace(origin); len(trimmedOrigin) > 0 {
+			allowedOrigins = append(allowedOrigins, trimmedOrigin)
+		}
+	}
+
+	return allowedOrigins
+}
+
 func (server *APIServer) initRoutes() {
 	handlers.InitUserRoutes(server.router)
 	handlers.InitAuthRoutes(server.router)
 	handlers.InitDiaryEntryRoutes(server.router)
+	handlers.InitDiaryEntryRevisionRoutes(server.router)
+	handlers.InitDiaryEntryAttachmentRoutes(server.router)
 	handlers.InitActivityRegistrationRoutes(server.router)
+	handlers.InitReadingSessionRoutes(server.router)
+	handlers.InitCustomActivityRegistrationRoutes(server.router)
+	handlers.InitAuditRoutes(server.router)
+	handlers.InitPersonalAccessTokenRoutes(server.router)
+	handlers.InitTagRoutes(server.router)
+	handlers.InitMoodRoutes(server.router)
+	handlers.InitUserKeyBackupRoutes(server.router)
+	handlers.InitTrashRoutes(server.router)
+	handlers.InitPromptRoutes(server.router)
+	handlers.InitImportRoutes(server.router)
+	handlers.InitShareLinkRoutes(server.router)
+	handlers.InitCalendarRoutes(server.router)
+	handlers.InitActivityRoutes(server.router)
 }
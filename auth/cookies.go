@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adfer-dev/analock-api/constants"
+)
+
+// RefreshCookieConfig holds the attributes of the refresh token and CSRF cookies. When Enabled is false, refresh tokens
+// are sent in the request and response bodies instead.
+type RefreshCookieConfig struct {
+	Enabled  bool
+	Name     string
+	Path     string
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// GetRefreshCookieConfig returns the refresh cookie configuration loaded from the environment.
+// The following variables are read, falling back to safe defaults when they are not set:
+//   - REFRESH_COOKIE_ENABLED: whether refresh tokens are only sent in the refresh cookie, false by default
+//   - REFRESH_COOKIE_NAME: cookie name, "refreshToken" by default
+//   - REFRESH_COOKIE_PATH: cookie path, "/api/v1/auth" by default
+//   - REFRESH_COOKIE_DOMAIN: cookie domain, host-only by default
+//   - REFRESH_COOKIE_SECURE: whether the cookie is only sent over HTTPS, true unless running locally
+//   - REFRESH_COOKIE_SAME_SITE: one of strict, lax or none, strict by default
+func GetRefreshCookieConfig() RefreshCookieConfig {
+	config := RefreshCookieConfig{
+		Name:     getEnvOrDefault("REFRESH_COOKIE_NAME", constants.DefaultRefreshCookieName),
+		Path:     getEnvOrDefault("REFRESH_COOKIE_PATH", constants.DefaultRefreshCookiePath),
+		Domain:   os.Getenv("REFRESH_COOKIE_DOMAIN"),
+		Secure:   os.Getenv("API_ENVIRONMENT") != "local",
+		SameSite: http.SameSiteStrictMode,
+	}
+
+	if enabled, parseErr := strconv.ParseBool(os.Getenv("REFRESH_COOKIE_ENABLED")); parseErr == nil {
+		config.Enabled = enabled
+	}
+
+	if secure, parseErr := strconv.ParseBool(os.Getenv("REFRESH_COOKIE_SECURE")); parseErr == nil {
+		config.Secure = secure
+	}
+
+	switch strings.ToLower(os.Getenv("REFRESH_COOKIE_SAME_SITE")) {
+	case "lax":
+		config.SameSite = http.SameSiteLaxMode
+	case "none":
+		// Browsers reject SameSite=None cookies that are not secure.
+		config.SameSite = http.SameSiteNoneMode
+		config.Secure = true
+	}
+
+	return config
+}
+
+// NewRefreshCookie builds the HttpOnly cookie carrying the refresh token, expiring along with the token.
+func NewRefreshCookie(refreshToken string, expiration time.Time) *http.Cookie {
+	config := GetRefreshCookieConfig()
+
+	return &http.Cookie{
+		Name:     config.Name,
+		Value:    refreshToken,
+		Path:     config.Path,
+		Domain:   config.Domain,
+		Expires:  expiration.UTC(),
+		MaxAge:   int(time.Until(expiration).Seconds()),
+		HttpOnly: true,
+		Secure:   config.Secure,
+		SameSite: config.SameSite,
+	}
+}
+
+// NewCsrfCookie builds the cookie carrying the CSRF token. It is readable by scripts, so web clients
+// can send its value back in the CSRF header.
+func NewCsrfCookie(csrfToken string, expiration time.Time) *http.Cookie {
+	config := GetRefreshCookieConfig()
+
+	return &http.Cookie{
+		Name:     constants.CsrfCookieName,
+		Value:    csrfToken,
+		Path:     config.Path,
+		Domain:   config.Domain,
+		Expires:  expiration.UTC(),
+		MaxAge:   int(time.Until(expiration).Seconds()),
+		HttpOnly: false,
+		Secure:   config.Secure,
+		SameSite: config.SameSite,
+	}
+}
+
+// GenerateCsrfToken returns a new random CSRF token.
+func GenerateCsrfToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
+// GetRefreshTokenFromCookie returns the refresh token sent in the refresh cookie.
+// As the cookie is attached by the browser automatically, the request must also pass
+// the double-submit CSRF check: the CSRF header must be equal to the CSRF cookie.
+func GetRefreshTokenFromCookie(req *http.Request) (string, error) {
+	refreshCookie, refreshCookieErr := req.Cookie(GetRefreshCookieConfig().Name)
+
+	if refreshCookieErr != nil || len(refreshCookie.Value) == 0 {
+		return "", errors.New("refresh token must be provided in the refresh cookie")
+	}
+
+	if csrfErr := ValidateCsrfToken(req); csrfErr != nil {
+		return "", csrfErr
+	}
+
+	return refreshCookie.Value, nil
+}
+
+// ValidateCsrfToken checks that the CSRF header of the request matches its CSRF cookie.
+func ValidateCsrfToken(req *http.Request) error {
+	csrfCookie, csrfCookieErr := req.Cookie(constants.CsrfCookieName)
+	csrfHeader := req.Header.Get(constants.CsrfHeaderName)
+
+	if csrfCookieErr != nil || len(csrfCookie.Value) == 0 || len(csrfHeader) == 0 {
+		return errors.New("CSRF token must be provided in both the cookie and the " + constants.CsrfHeaderName + " header")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(csrfCookie.Value), []byte(csrfHeader)) != 1 {
+		return errors.New("CSRF token not valid")
+	}
+
+	return nil
+}
+
+func getEnvOrDefault(key string, defaultValue string) string {
+	if value := os.Getenv(key); len(value) > 0 {
+		return value
+	}
+
+	return defaultValue
+}
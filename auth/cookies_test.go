@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRefreshCookieConfig(t *testing.T) {
+	t.Run("default_config", func(t *testing.T) {
+		t.Setenv("API_ENVIRONMENT", "production")
+		config := GetRefreshCookieConfig()
+		assert.False(t, config.Enabled)
+		assert.Equal(t, constants.DefaultRefreshCookieName, config.Name)
+		assert.Equal(t, constants.DefaultRefreshCookiePath, config.Path)
+		assert.True(t, config.Secure)
+		assert.Equal(t, http.SameSiteStrictMode, config.SameSite)
+	})
+
+	t.Run("config_from_environment", func(t *testing.T) {
+		t.Setenv("REFRESH_COOKIE_ENABLED", "true")
+		t.Setenv("REFRESH_COOKIE_NAME", "rt")
+		t.Setenv("REFRESH_COOKIE_PATH", "/")
+		t.Setenv("REFRESH_COOKIE_DOMAIN", "example.com")
+		t.Setenv("REFRESH_COOKIE_SECURE", "false")
+		t.Setenv("REFRESH_COOKIE_SAME_SITE", "Lax")
+		config := GetRefreshCookieConfig()
+		assert.True(t, config.Enabled)
+		assert.Equal(t, "rt", config.Name)
+		assert.Equal(t, "/", config.Path)
+		assert.Equal(t, "example.com", config.Domain)
+		assert.False(t, config.Secure)
+		assert.Equal(t, http.SameSiteLaxMode, config.SameSite)
+	})
+
+	t.Run("same_site_none_forces_secure", func(t *testing.T) {
+		t.Setenv("REFRESH_COOKIE_SECURE", "false")
+		t.Setenv("REFRESH_COOKIE_SAME_SITE", "none")
+		config := GetRefreshCookieConfig()
+		assert.True(t, config.Secure)
+		assert.Equal(t, http.SameSiteNoneMode, config.SameSite)
+	})
+}
+
+func TestNewRefreshCookie(t *testing.T) {
+	t.Setenv("API_ENVIRONMENT", "production")
+	expiration := time.Now().Add(24 * time.Hour)
+
+	cookie := NewRefreshCookie("refresh.token.value", expiration)
+	assert.NoError(t, cookie.Valid())
+	assert.True(t, cookie.HttpOnly)
+
+	header := cookie.String()
+	assert.Contains(t, header, "refreshToken=refresh.token.value")
+	assert.Contains(t, header, "Expires="+expiration.UTC().Format(http.TimeFormat))
+	assert.Contains(t, header, "Path="+constants.DefaultRefreshCookiePath)
+	assert.Contains(t, header, "Secure")
+	assert.Contains(t, header, "SameSite=Strict")
+
+	csrfCookie := NewCsrfCookie("csrf", expiration)
+	assert.False(t, csrfCookie.HttpOnly)
+	assert.Equal(t, constants.CsrfCookieName, csrfCookie.Name)
+}
+
+func TestGetRefreshTokenFromCookie(t *testing.T) {
+	csrfToken, csrfErr := GenerateCsrfToken()
+	assert.NoError(t, csrfErr)
+
+	newRequest := func(refreshToken string, csrfCookie string, csrfHeader string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refreshToken", nil)
+		if refreshToken != "" {
+			req.AddCookie(&http.Cookie{Name: constants.DefaultRefreshCookieName, Value: refreshToken})
+		}
+		if csrfCookie != "" {
+			req.AddCookie(&http.Cookie{Name: constants.CsrfCookieName, Value: csrfCookie})
+		}
+		if csrfHeader != "" {
+			req.Header.Set(constants.CsrfHeaderName, csrfHeader)
+		}
+		return req
+	}
+
+	t.Run("valid_cookie_and_csrf", func(t *testing.T) {
+		refreshToken, err := GetRefreshTokenFromCookie(newRequest("refresh.token", csrfToken, csrfToken))
+		assert.NoError(t, err)
+		assert.Equal(t, "refresh.token", refreshToken)
+	})
+
+	t.Run("missing_refresh_cookie", func(t *testing.T) {
+		_, err := GetRefreshTokenFromCookie(newRequest("", csrfToken, csrfToken))
+		assert.Error(t, err)
+	})
+
+	t.Run("missing_csrf_header", func(t *testing.T) {
+		_, err := GetRefreshTokenFromCookie(newRequest("refresh.token", csrfToken, ""))
+		assert.Error(t, err)
+	})
+
+	t.Run("csrf_mismatch", func(t *testing.T) {
+		_, err := GetRefreshTokenFromCookie(newRequest("refresh.token", csrfToken, "other"))
+		assert.EqualError(t, err, "CSRF token not valid")
+	})
+}
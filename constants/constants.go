@@ -2,6 +2,7 @@ package constants
 
 const StartDateQueryParam = "start_date"
 const EndDateQueryParam = "end_date"
+const DateQueryParam = "date"
 const QueryParamError = "the query parameter %s is not provided or its format is not correct."
 const ErrorUnauthorizedOperation = "you have no permissions over the resource you are trying to access to"
 const ApiV1UrlRoot = "/api/v1"
@@ -10,6 +11,11 @@ const ApiUrlUserDiaryEntries = "/diaryEntries/user"
 const ApiUrlBookRegistrations = "/activityRegistrations/books"
 const ApiUrlGameRegistrations = "/activityRegistrations/games"
 const ApiGoogleTokenValidationUrl = "https://www.googleapis.com/oauth2/v3/tokeninfo"
+const DefaultRefreshCookieName = "refreshToken"
+const DefaultRefreshCookiePath = "/api/v1/auth"
+const CsrfCookieName = "csrfToken"
+const CsrfHeaderName = "X-CSRF-Token"
+const ShareLinkPassphraseHeaderName = "X-Share-Passphrase"
 
 // TEST CONSTANTS
 const TestAccessTokenValue = "mock_access_jwt_from_manager_v_agnostic"
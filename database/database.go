@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/adfer-dev/analock-api/utils"
 	_ "github.com/tursodatabase/go-libsql"
@@ -11,6 +12,8 @@ import (
 
 const (
 	createUsersTableQuery = "CREATE TABLE IF NOT EXISTS `user` (`id` integer, `email` text, 'username' text, `role` integer" +
+		", `avatar_url` text NOT NULL DEFAULT '', `locale` text NOT NULL DEFAULT '', `time_zone` text NOT NULL DEFAULT ''" +
+		", `search_index_enabled` integer NOT NULL DEFAULT 0, `version` integer NOT NULL DEFAULT 1" +
 		", PRIMARY KEY (`id`), UNIQUE (`email`));"
 	createTokensTableQuery = "CREATE TABLE IF NOT EXISTS `token` (`id` integer, `value` text, `kind` integer, `user_id` text," +
 		" PRIMARY KEY (`id`)," +
@@ -31,6 +34,8 @@ const (
 		"`id` integer PRIMARY KEY, " +
 		"`registration_date` integer, " +
 		"`user_id` integer, " +
+		"`deleted_at` integer NOT NULL DEFAULT 0, " +
+		"`version` integer NOT NULL DEFAULT 1, " +
 		"CONSTRAINT `fk_activity_registration_user` FOREIGN KEY (`user_id`) " +
 		"REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
 	createActivityRegistrationBookTableQuery = "CREATE TABLE IF NOT EXISTS `activity_registration_book` (" +
@@ -45,8 +50,214 @@ const (
 		"`game_name` text, " +
 		"CONSTRAINT `fk_activity_registration` FOREIGN KEY (`registration_id`) " +
 		"REFERENCES `activity_registration` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	createAuditEventTableQuery = "CREATE TABLE IF NOT EXISTS `audit_event` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`event_type` text NOT NULL, " +
+		"`actor_id` integer NOT NULL DEFAULT 0, " +
+		"`target_type` text NOT NULL DEFAULT '', " +
+		"`target_id` integer NOT NULL DEFAULT 0, " +
+		"`ip` text NOT NULL DEFAULT '', " +
+		"`user_agent` text NOT NULL DEFAULT '', " +
+		"`details` text NOT NULL DEFAULT '', " +
+		"`created_at` integer NOT NULL);"
+	createPersonalAccessTokenTableQuery = "CREATE TABLE IF NOT EXISTS `personal_access_token` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`name` text NOT NULL, " +
+		"`prefix` text NOT NULL, " +
+		"`token_hash` text NOT NULL UNIQUE, " +
+		"`scopes` text NOT NULL DEFAULT '', " +
+		"`user_id` integer NOT NULL, " +
+		"`created_at` integer NOT NULL, " +
+		"`last_used_at` integer NOT NULL DEFAULT 0, " +
+		"`expires_at` integer NOT NULL DEFAULT 0, " +
+		"CONSTRAINT `fk_users_personal_access_token` FOREIGN KEY (`user_id`)" +
+		" REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	createTagTableQuery = "CREATE TABLE IF NOT EXISTS `tag` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`name` text NOT NULL COLLATE NOCASE, " +
+		"`user_id` integer NOT NULL, " +
+		"UNIQUE (`user_id`, `name`), " +
+		"CONSTRAINT `fk_users_tag` FOREIGN KEY (`user_id`)" +
+		" REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	createDiaryEntryTagTableQuery = "CREATE TABLE IF NOT EXISTS `diary_entry_tag` (" +
+		"`diary_entry_id` integer NOT NULL, " +
+		"`tag_id` integer NOT NULL, " +
+		"PRIMARY KEY (`diary_entry_id`, `tag_id`), " +
+		"CONSTRAINT `fk_diary_entry_diary_entry_tag` FOREIGN KEY (`diary_entry_id`)" +
+		" REFERENCES `diary_entry` (`id`) ON DELETE CASCADE ON UPDATE CASCADE, " +
+		"CONSTRAINT `fk_tag_diary_entry_tag` FOREIGN KEY (`tag_id`)" +
+		" REFERENCES `tag` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	// previous versions of the diary entries, stored on every update
+	createDiaryEntryRevisionTableQuery = "CREATE TABLE IF NOT EXISTS `diary_entry_revision` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`diary_entry_id` integer NOT NULL, " +
+		"`title` text NOT NULL, " +
+		"`content` text NOT NULL, " +
+		"`publish_date` integer NOT NULL, " +
+		"`mood` integer, " +
+		"`feelings` text NOT NULL DEFAULT '', " +
+		"`created_at` integer NOT NULL, " +
+		"CONSTRAINT `fk_diary_entry_diary_entry_revision` FOREIGN KEY (`diary_entry_id`)" +
+		" REFERENCES `diary_entry` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	// user encryption keys, encrypted on the client and opaque to the server
+	createUserKeyBackupTableQuery = "CREATE TABLE IF NOT EXISTS `user_key_backup` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`key_id` text NOT NULL, " +
+		"`blob` text NOT NULL, " +
+		"`user_id` integer NOT NULL, " +
+		"`created_at` integer NOT NULL, " +
+		"`updated_at` integer NOT NULL, " +
+		"UNIQUE (`user_id`, `key_id`), " +
+		"CONSTRAINT `fk_users_user_key_backup` FOREIGN KEY (`user_id`)" +
+		" REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	// data keys encrypting the diary content of each user at rest, wrapped by the master key with the given id
+	createUserDataKeyTableQuery = "CREATE TABLE IF NOT EXISTS `user_data_key` (" +
+		"`user_id` integer PRIMARY KEY, " +
+		"`wrapped_key` text NOT NULL, " +
+		"`master_key_id` text NOT NULL, " +
+		"`created_at` integer NOT NULL, " +
+		"CONSTRAINT `fk_users_user_data_key` FOREIGN KEY (`user_id`)" +
+		" REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	// files attached to diary entries, whose content is kept in the blob store under blob_key
+	createDiaryEntryAttachmentTableQuery = "CREATE TABLE IF NOT EXISTS `diary_entry_attachment` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`diary_entry_id` integer NOT NULL, " +
+		"`user_id` integer NOT NULL, " +
+		"`file_name` text NOT NULL, " +
+		"`content_type` text NOT NULL, " +
+		"`size` integer NOT NULL, " +
+		"`blob_key` text NOT NULL, " +
+		"`thumbnail_key` text NOT NULL DEFAULT '', " +
+		"`created_at` integer NOT NULL, " +
+		"CONSTRAINT `fk_diary_entry_diary_entry_attachment` FOREIGN KEY (`diary_entry_id`)" +
+		" REFERENCES `diary_entry` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	// journaling suggestions, managed by admins
+	createPromptTableQuery = "CREATE TABLE IF NOT EXISTS `prompt` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`text` text NOT NULL, " +
+		"`category` text NOT NULL, " +
+		"`language` text NOT NULL, " +
+		"`created_at` integer NOT NULL);"
+	// imports of diary entries from other journaling apps. errors holds the JSON list of item errors
+	createImportJobTableQuery = "CREATE TABLE IF NOT EXISTS `import_job` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`user_id` integer NOT NULL, " +
+		"`format` text NOT NULL, " +
+		"`status` text NOT NULL, " +
+		"`total_items` integer NOT NULL DEFAULT 0, " +
+		"`imported_items` integer NOT NULL DEFAULT 0, " +
+		"`skipped_items` integer NOT NULL DEFAULT 0, " +
+		"`failed_items` integer NOT NULL DEFAULT 0, " +
+		"`errors` text NOT NULL DEFAULT '[]', " +
+		"`error` text NOT NULL DEFAULT '', " +
+		"`created_at` integer NOT NULL, " +
+		"`finished_at` integer NOT NULL DEFAULT 0, " +
+		"CONSTRAINT `fk_users_import_job` FOREIGN KEY (`user_id`)" +
+		" REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	// fingerprints of the imported diary entries, so importing the same file again does not duplicate them
+	createDiaryEntryImportTableQuery = "CREATE TABLE IF NOT EXISTS `diary_entry_import` (" +
+		"`user_id` integer NOT NULL, " +
+		"`fingerprint` text NOT NULL, " +
+		"`diary_entry_id` integer NOT NULL, " +
+		"PRIMARY KEY (`user_id`, `fingerprint`), " +
+		"CONSTRAINT `fk_diary_entry_diary_entry_import` FOREIGN KEY (`diary_entry_id`)" +
+		" REFERENCES `diary_entry` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	// links giving unauthenticated read access to a diary entry
+	createShareLinkTableQuery = "CREATE TABLE IF NOT EXISTS `share_link` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`diary_entry_id` integer NOT NULL, " +
+		"`user_id` integer NOT NULL, " +
+		"`prefix` text NOT NULL, " +
+		"`token_hash` text NOT NULL UNIQUE, " +
+		"`passphrase_hash` text NOT NULL DEFAULT '', " +
+		"`max_views` integer NOT NULL DEFAULT 0, " +
+		"`view_count` integer NOT NULL DEFAULT 0, " +
+		"`created_at` integer NOT NULL, " +
+		"`expires_at` integer NOT NULL, " +
+		"CONSTRAINT `fk_diary_entry_share_link` FOREIGN KEY (`diary_entry_id`)" +
+		" REFERENCES `diary_entry` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	// payload of the registrations of the activity types declared in the activity type registry
+	createActivityRegistrationPayloadTableQuery = "CREATE TABLE IF NOT EXISTS `activity_registration_payload` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`registration_id` integer NOT NULL, " +
+		"`activity_type` text NOT NULL, " +
+		"`payload` text NOT NULL, " +
+		"CONSTRAINT `fk_activity_registration_payload` FOREIGN KEY (`registration_id`) " +
+		"REFERENCES `activity_registration` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	createReadingSessionTableQuery = "CREATE TABLE IF NOT EXISTS `reading_session` (" +
+		"`id` integer PRIMARY KEY, " +
+		"`book_registration_id` integer NOT NULL, " +
+		"`user_id` integer NOT NULL, " +
+		"`started_at` integer NOT NULL, " +
+		"`ended_at` integer NOT NULL DEFAULT 0, " +
+		"`page` integer, " +
+		"`percentage` real, " +
+		"`position` text NOT NULL DEFAULT '', " +
+		"CONSTRAINT `fk_reading_session_book_registration` FOREIGN KEY (`book_registration_id`) " +
+		"REFERENCES `activity_registration_book` (`id`) ON DELETE CASCADE ON UPDATE CASCADE, " +
+		"CONSTRAINT `fk_reading_session_user` FOREIGN KEY (`user_id`) " +
+		"REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE);"
+	// full-text index of diary entries, whose rowid is the diary entry id. It is kept in sync by the diary entry storage.
+	createDiaryEntryFtsTableQuery = "CREATE VIRTUAL TABLE IF NOT EXISTS `diary_entry_fts` USING fts5(" +
+		"`title`, `content`, tokenize = 'unicode61 remove_diacritics 2');"
 )
 
+// Columns added to already existing tables. They are applied in order after creating the tables,
+// and the ones already present in the database are skipped.
+var addColumnQueries = []string{
+	"ALTER TABLE `user` ADD COLUMN `avatar_url` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `user` ADD COLUMN `locale` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `user` ADD COLUMN `time_zone` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `diary_entry` ADD COLUMN `mood` integer;",
+	"ALTER TABLE `diary_entry` ADD COLUMN `feelings` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `diary_entry` ADD COLUMN `encryption_algorithm` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `diary_entry` ADD COLUMN `encryption_nonce` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `diary_entry` ADD COLUMN `encryption_key_id` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `diary_entry` ADD COLUMN `encryption_wrapped_key` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `diary_entry_revision` ADD COLUMN `encryption_algorithm` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `diary_entry_revision` ADD COLUMN `encryption_nonce` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `diary_entry_revision` ADD COLUMN `encryption_key_id` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `diary_entry_revision` ADD COLUMN `encryption_wrapped_key` text NOT NULL DEFAULT '';",
+	"ALTER TABLE `user` ADD COLUMN `search_index_enabled` integer NOT NULL DEFAULT 0;",
+	"ALTER TABLE `user` ADD COLUMN `version` integer NOT NULL DEFAULT 1;",
+	"ALTER TABLE `diary_entry` ADD COLUMN `version` integer NOT NULL DEFAULT 1;",
+	"ALTER TABLE `activity_registration` ADD COLUMN `deleted_at` integer NOT NULL DEFAULT 0;",
+	"ALTER TABLE `diary_entry` ADD COLUMN `prompt_id` integer;",
+	"ALTER TABLE `activity_registration` ADD COLUMN `version` integer NOT NULL DEFAULT 1;",
+}
+
+// Indexes created after the tables and columns.
+var createIndexQueries = []string{
+	"CREATE INDEX IF NOT EXISTS `idx_audit_event_created_at` ON `audit_event` (`created_at`);",
+	"CREATE INDEX IF NOT EXISTS `idx_audit_event_actor` ON `audit_event` (`actor_id`, `created_at`);",
+	"CREATE INDEX IF NOT EXISTS `idx_audit_event_target` ON `audit_event` (`target_type`, `target_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_personal_access_token_user` ON `personal_access_token` (`user_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_activity_registration_user_date` ON `activity_registration` (`user_id`, `registration_date`);",
+	"CREATE INDEX IF NOT EXISTS `idx_activity_registration_deleted_at` ON `activity_registration` (`deleted_at`);",
+	"CREATE INDEX IF NOT EXISTS `idx_diary_entry_tag_tag` ON `diary_entry_tag` (`tag_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_diary_entry_revision_entry` ON `diary_entry_revision` (`diary_entry_id`, `created_at`);",
+	"CREATE INDEX IF NOT EXISTS `idx_diary_entry_attachment_entry` ON `diary_entry_attachment` (`diary_entry_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_diary_entry_attachment_user` ON `diary_entry_attachment` (`user_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_diary_entry_prompt` ON `diary_entry` (`prompt_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_prompt_language` ON `prompt` (`language`, `category`);",
+	"CREATE INDEX IF NOT EXISTS `idx_import_job_user` ON `import_job` (`user_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_diary_entry_import_entry` ON `diary_entry_import` (`diary_entry_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_share_link_user` ON `share_link` (`user_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_share_link_entry` ON `share_link` (`diary_entry_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_activity_registration_payload_registration` ON `activity_registration_payload` (`registration_id`);",
+	"CREATE INDEX IF NOT EXISTS `idx_activity_registration_payload_type` ON `activity_registration_payload` (`activity_type`);",
+	"CREATE INDEX IF NOT EXISTS `idx_reading_session_book` ON `reading_session` (`book_registration_id`, `started_at`);",
+	"CREATE INDEX IF NOT EXISTS `idx_reading_session_user` ON `reading_session` (`user_id`, `started_at`);",
+}
+
+// Queries filling derived tables with the rows that existed before they were created.
+// They run after the indexes and must be idempotent.
+var backfillQueries = []string{
+	"INSERT INTO `diary_entry_fts` (`rowid`, `title`, `content`) SELECT `id`," +
+		" CASE WHEN `title` LIKE 'enc:v1:%' THEN '' ELSE `title` END, CASE WHEN `content` LIKE 'enc:v1:%' THEN '' ELSE `content` END" +
+		" FROM `diary_entry` WHERE `id` NOT IN (SELECT `rowid` FROM `diary_entry_fts`);",
+}
+
 type Database struct {
 	dbConnection *sql.DB
 }
@@ -90,6 +301,21 @@ func initDatabase() {
 	createTableQueryMap["activity_registration"] = createActivityRegistrationTableQuery
 	createTableQueryMap["activity_registration_book"] = createActivityRegistrationBookTableQuery
 	createTableQueryMap["activity_registration_game"] = createActivityRegistrationGameTableQuery
+	createTableQueryMap["audit_event"] = createAuditEventTableQuery
+	createTableQueryMap["personal_access_token"] = createPersonalAccessTokenTableQuery
+	createTableQueryMap["diary_entry_fts"] = createDiaryEntryFtsTableQuery
+	createTableQueryMap["tag"] = createTagTableQuery
+	createTableQueryMap["diary_entry_tag"] = createDiaryEntryTagTableQuery
+	createTableQueryMap["diary_entry_revision"] = createDiaryEntryRevisionTableQuery
+	createTableQueryMap["user_key_backup"] = createUserKeyBackupTableQuery
+	createTableQueryMap["user_data_key"] = createUserDataKeyTableQuery
+	createTableQueryMap["diary_entry_attachment"] = createDiaryEntryAttachmentTableQuery
+	createTableQueryMap["prompt"] = createPromptTableQuery
+	createTableQueryMap["import_job"] = createImportJobTableQuery
+	createTableQueryMap["diary_entry_import"] = createDiaryEntryImportTableQuery
+	createTableQueryMap["share_link"] = createShareLinkTableQuery
+	createTableQueryMap["activity_registration_payload"] = createActivityRegistrationPayloadTableQuery
+	createTableQueryMap["reading_session"] = createReadingSessionTableQuery
 
 	for tableName, query := range createTableQueryMap {
 		_, createTableErr := connectionInstance.GetConnection().Exec(query)
@@ -97,4 +323,25 @@ func initDatabase() {
 			logger.ErrorLogger.Printf("Error when creating table %s: %s", tableName, createTableErr.Error())
 		}
 	}
+
+	for _, query := range addColumnQueries {
+		_, addColumnErr := connectionInstance.GetConnection().Exec(query)
+		if addColumnErr != nil && !strings.Contains(addColumnErr.Error(), "duplicate column name") {
+			logger.ErrorLogger.Printf("Error when adding column: %s", addColumnErr.Error())
+		}
+	}
+
+	for _, query := range createIndexQueries {
+		_, createIndexErr := connectionInstance.GetConnection().Exec(query)
+		if createIndexErr != nil {
+			logger.ErrorLogger.Printf("Error when creating index: %s", createIndexErr.Error())
+		}
+	}
+
+	for _, query := range backfillQueries {
+		_, backfillErr := connectionInstance.GetConnection().Exec(query)
+		if backfillErr != nil {
+			logger.ErrorLogger.Printf("Error when backfilling table: %s", backfillErr.Error())
+		}
+	}
 }
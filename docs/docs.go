@@ -76,7 +76,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all book activity registrations for a user, optionally filtered by date range",
+                "description": "Get all book activity registrations for a user, optionally filtered by a date range.\nCalendar dates are days in the time zone of the authenticated user",
                 "consumes": [
                     "application/json"
                 ],
@@ -84,7 +84,7 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "activities"
+                    "activity registrations"
                 ],
                 "summary": "Get user book activity registrations",
                 "parameters": [
@@ -96,15 +96,21 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Start date timestamp",
-                        "name": "startDate",
+                        "type": "string",
+                        "description": "Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "start_date",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "End date timestamp",
-                        "name": "endDate",
+                        "type": "string",
+                        "description": "End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar date like 2026-10-17, instead of start_date and end_date",
+                        "name": "date",
                         "in": "query"
                     }
                 ],
@@ -133,136 +139,133 @@ const docTemplate = `{
                 }
             }
         },
-        "/activityRegistrations/games": {
-            "post": {
+        "/activityRegistrations/books/{id}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new game activity registration",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get a book registration of the authenticated user. The ETag header holds its version, to be sent in the If-Match header of updates",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "activities"
+                    "activity registrations"
                 ],
-                "summary": "Create game activity registration",
+                "summary": "Get book activity registration",
                 "parameters": [
                     {
-                        "description": "Game activity registration information",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/services.AddGameActivityRegistrationBody"
-                        }
+                        "type": "integer",
+                        "description": "Book registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.GameActivityRegistration"
+                            "$ref": "#/definitions/models.BookActivityRegistration"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the book registration"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
                     }
                 }
-            }
-        },
-        "/activityRegistrations/games/user/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all game activity registrations for a user, optionally filtered by date range",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Move a book registration of the authenticated user to the trash, from where it can be restored until the trash is emptied",
                 "tags": [
-                    "activities"
+                    "activity registrations"
                 ],
-                "summary": "Get user game activity registrations",
+                "summary": "Delete book activity registration",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Book registration ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Start date timestamp",
-                        "name": "startDate",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "End date timestamp",
-                        "name": "endDate",
-                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.GameActivityRegistration"
-                            }
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
                     }
                 }
-            }
-        },
-        "/auth/authenticate": {
-            "post": {
-                "description": "Authenticates a user and returns access and refresh tokens",
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update only the fields of a book registration of the authenticated user present in a JSON Merge Patch (RFC 7396) document.\nWhen the If-Match header is sent, updates based on an outdated version respond 412 with the current version of the registration",
                 "consumes": [
-                    "application/json"
+                    "application/merge-patch+json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "activity registrations"
                 ],
-                "summary": "Authenticate user",
+                "summary": "Partially update book activity registration",
                 "parameters": [
                     {
-                        "description": "Authentication request",
+                        "type": "integer",
+                        "description": "Book registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the updated version",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Book registration fields to update",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/services.UserAuthenticateBody"
+                            "$ref": "#/definitions/services.UpdateBookActivityRegistrationBody"
                         }
                     }
                 ],
@@ -270,7 +273,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.TokenResponse"
+                            "$ref": "#/definitions/models.BookActivityRegistration"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the book registration"
+                            }
                         }
                     },
                     "400": {
@@ -279,8 +288,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.HttpError"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.PreconditionFailedHttpError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
@@ -288,39 +315,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/refreshToken": {
-            "post": {
-                "description": "Refreshes the access token using a refresh token",
-                "consumes": [
-                    "application/json"
+        "/activityRegistrations/books/{id}/progress": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Get the point reached in a book registration of the authenticated user, with its active session and the time spent reading it,\nso that the book can be resumed on any device",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "reading sessions"
                 ],
-                "summary": "Refresh access token",
+                "summary": "Get book reading progress",
                 "parameters": [
                     {
-                        "description": "Refresh token request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/services.RefreshTokenRequest"
-                        }
+                        "type": "integer",
+                        "description": "Book activity registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.TokenResponse"
+                            "$ref": "#/definitions/services.ReadingProgress"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
@@ -328,65 +361,61 @@ const docTemplate = `{
                 }
             }
         },
-        "/diaryEntries": {
-            "post": {
+        "/activityRegistrations/books/{id}/readingSessions": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new diary entry for a user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the reading sessions of a book registration of the authenticated user, most recent first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "diary"
+                    "reading sessions"
                 ],
-                "summary": "Create diary entry",
+                "summary": "Get book reading sessions",
                 "parameters": [
                     {
-                        "description": "Diary entry information",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/services.SaveDiaryEntryBody"
-                        }
+                        "type": "integer",
+                        "description": "Book activity registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.DiaryEntry"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ReadingSession"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
                     }
                 }
-            }
-        },
-        "/diaryEntries/user/{id}": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all diary entries for a user, optionally filtered by date range",
+                "description": "Start a reading session of a book registration of the authenticated user, now unless a start is sent.\nA session of the book still active, for example on another device, is stopped when the new one starts",
                 "consumes": [
                     "application/json"
                 ],
@@ -394,38 +423,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "diary"
+                    "reading sessions"
                 ],
-                "summary": "Get user diary entries",
+                "summary": "Start reading session",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Book activity registration ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Start date timestamp",
-                        "name": "startDate",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "End date timestamp",
-                        "name": "endDate",
-                        "in": "query"
+                        "description": "Start of the session",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/services.StartReadingSessionBody"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.DiaryEntry"
-                            }
+                            "$ref": "#/definitions/models.ReadingSession"
                         }
                     },
                     "400": {
@@ -434,8 +456,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.HttpError"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
@@ -443,14 +471,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/diaryEntries/{id}": {
-            "put": {
+        "/activityRegistrations/books/{id}/readingSessions/{sessionId}/stop": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update an existing diary entry",
+                "description": "Stop an active reading session of a book registration of the authenticated user, now unless an end is sent,\nsaving the page, percentage or position (like an EPUB CFI) reached",
                 "consumes": [
                     "application/json"
                 ],
@@ -458,24 +486,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "diary"
+                    "reading sessions"
                 ],
-                "summary": "Update diary entry",
+                "summary": "Stop reading session",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Diary entry ID",
+                        "description": "Book activity registration ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Updated diary entry information",
+                        "type": "integer",
+                        "description": "Reading session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "End of the session and progress reached",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/services.UpdateDiaryEntryBody"
+                            "$ref": "#/definitions/services.StopReadingSessionBody"
                         }
                     }
                 ],
@@ -483,7 +518,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.DiaryEntry"
+                            "$ref": "#/definitions/models.ReadingSession"
                         }
                     },
                     "400": {
@@ -492,8 +527,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.HttpError"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
@@ -501,14 +548,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/activityRegistrations/games": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new user with the provided information",
+                "description": "Create a new game activity registration",
                 "consumes": [
                     "application/json"
                 ],
@@ -516,29 +563,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "activities"
                 ],
-                "summary": "Create new user",
+                "summary": "Create game activity registration",
                 "parameters": [
                     {
-                        "description": "User information",
+                        "description": "Game activity registration information",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/services.UserBody"
+                            "$ref": "#/definitions/services.AddGameActivityRegistrationBody"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.GameActivityRegistration"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
@@ -546,14 +593,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{email}": {
+        "/activityRegistrations/games/user/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get user information by their email",
+                "description": "Get all game activity registrations for a user, optionally filtered by a date range.\nCalendar dates are days in the time zone of the authenticated user",
                 "consumes": [
                     "application/json"
                 ],
@@ -561,27 +608,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "activities"
                 ],
-                "summary": "Get user by email",
+                "summary": "Get user game activity registrations",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User email",
-                        "name": "email",
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar date like 2026-10-17, instead of start_date and end_date",
+                        "name": "date",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.GameActivityRegistration"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/models.HttpError"
                         }
@@ -589,38 +663,306 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
+        "/activityRegistrations/games/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get user information by their ID",
-                "consumes": [
+                "description": "Get a game registration of the authenticated user. The ETag header holds its version, to be sent in the If-Match header of updates",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "activity registrations"
+                ],
+                "summary": "Get game activity registration",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GameActivityRegistration"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the game registration"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a game registration of the authenticated user to the trash, from where it can be restored until the trash is emptied",
+                "tags": [
+                    "activity registrations"
+                ],
+                "summary": "Delete game activity registration",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update only the fields of a game registration of the authenticated user present in a JSON Merge Patch (RFC 7396) document.\nWhen the If-Match header is sent, updates based on an outdated version respond 412 with the current version of the registration",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "activity registrations"
                 ],
-                "summary": "Get user by ID",
+                "summary": "Partially update game activity registration",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Game registration ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the updated version",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Game registration fields to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateGameActivityRegistrationBody"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.GameActivityRegistration"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the game registration"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.PreconditionFailedHttpError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/activityRegistrations/{type}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the registrations of a declared activity type of the authenticated user, oldest first, optionally filtered by a date range.\nCalendar dates are days in the time zone of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "activity registrations"
+                ],
+                "summary": "Get custom activity registrations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Activity type name, like walk",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar date like 2026-10-17, instead of start_date and end_date",
+                        "name": "date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CustomActivityRegistration"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register an activity of a declared type for the authenticated user. The payload must match the schema of the activity type",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "activity registrations"
+                ],
+                "summary": "Create custom activity registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Activity type name, like walk",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Registration date and payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.AddCustomActivityRegistrationBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CustomActivityRegistration"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
                         }
                     },
                     "404": {
@@ -631,176 +973,4741 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.ActivityRegistration": {
+        },
+        "/activityRegistrations/{type}/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a registration of a declared activity type of the authenticated user. The ETag header holds its version, to be sent in the If-Match header of updates",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "activity registrations"
+                ],
+                "summary": "Get custom activity registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Activity type name, like walk",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Custom activity registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CustomActivityRegistration"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the registration"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a registration of a declared activity type of the authenticated user to the trash, from where it can be restored until the trash is emptied",
+                "tags": [
+                    "activity registrations"
+                ],
+                "summary": "Delete custom activity registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Activity type name, like walk",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Custom activity registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update only the fields of a registration of a declared activity type present in a JSON Merge Patch (RFC 7396) document.\nMembers of the payload are merged one by one, and the merged payload must match the schema of the activity type.\nWhen the If-Match header is sent, updates based on an outdated version respond 412 with the current version of the registration",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "activity registrations"
+                ],
+                "summary": "Partially update custom activity registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Activity type name, like walk",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Custom activity registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the updated version",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Registration fields to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateCustomActivityRegistrationBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CustomActivityRegistration"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the registration"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.PreconditionFailedHttpError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/activityTypes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the declared activity types, like walks or meditation, with the JSON schema their registration payloads must match",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "activity registrations"
+                ],
+                "summary": "Get activity types",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ActivityTypeDefinition"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/auditEvents": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the audit events matching the filters, most recent first. Only available to admins",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get audit events",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id of the user who performed the operation",
+                        "name": "actorId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Event type, like diary_entry.updated",
+                        "name": "eventType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of the affected resource, like diary_entry",
+                        "name": "targetType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of the affected resource",
+                        "name": "targetId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar date like 2026-10-17, instead of start_date and end_date",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 200 at most",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.AuditEventPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/prompts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the journaling prompts sorted by id, with the number of diary entries answering each of them. Only available to admins",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get prompts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prompt category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prompt language, like es",
+                        "name": "language",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PromptUsage"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a journaling prompt. Only available to admins",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create prompt",
+                "parameters": [
+                    {
+                        "description": "Prompt information",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.SavePromptBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Prompt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/prompts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a journaling prompt. Only available to admins",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get prompt",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Prompt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Prompt"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace a journaling prompt. Only available to admins",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update prompt",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Prompt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Prompt information",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.SavePromptBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Prompt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a journaling prompt. The diary entries that answered it are kept. Only available to admins",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete prompt",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Prompt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/authenticate": {
+            "post": {
+                "description": "Authenticates a user and returns access and refresh tokens. When the refresh cookie is enabled, the refresh token\nis only sent in the HttpOnly refresh cookie, and the response holds the CSRF token instead",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Authenticate user",
+                "parameters": [
+                    {
+                        "description": "Authentication request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UserAuthenticateBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refreshToken": {
+            "post": {
+                "description": "Refreshes the access token using a refresh token. The refresh token is read from the request body or,\nwhen the refresh cookie is enabled, only from the refresh cookie. Cookie requests must send the CSRF token\nreceived at authentication in the X-CSRF-Token header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token request",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/services.RefreshTokenRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSRF token, required when using the refresh cookie",
+                        "name": "X-CSRF-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.RefreshTokenResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new diary entry for the authenticated user, optionally answering a prompt",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Create diary entry",
+                "parameters": [
+                    {
+                        "description": "Diary entry information",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.SaveDiaryEntryBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.DiaryEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Full-text search over the title and content of the diary entries of the authenticated user, best matches first.\nAll words must match. Words ending with * match as prefixes, and text between double quotes matches as a phrase.\nSnippets highlight the matched terms between \u003cmark\u003e\u003c/mark\u003e.\nWhen diary content is encrypted at rest, users must enable the search index in their profile first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary entries"
+                ],
+                "summary": "Search diary entries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar date like 2026-10-17, instead of start_date and end_date",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 20 by default and 100 at most",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.DiaryEntrySearchPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/user/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of the diary entries of the authenticated user, sorted by registration date and optionally filtered by a date range.\nCalendar dates are days in the time zone of the authenticated user.\nThe nextCursor of the response is passed in the cursor parameter to get the next page, and is not present on the last page",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary entries"
+                ],
+                "summary": "Get user diary entries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar date like 2026-10-17, instead of start_date and end_date",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort by registration date, desc by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries, 50 by default and 200 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page, from the nextCursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only entries with all these tags",
+                        "name": "tag",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.DiaryEntryPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a diary entry of the authenticated user. The ETag header holds its version, to be sent in the If-Match header of updates",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Get diary entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DiaryEntry"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the diary entry"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing diary entry of the authenticated user. The If-Match header must hold the ETag of the version the update is based on, or * to overwrite any version.\nUpdates based on an outdated version respond 412 with the current version of the entry",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Update diary entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the updated version",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated diary entry information",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateDiaryEntryBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DiaryEntry"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the diary entry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.PreconditionFailedHttpError"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a diary entry of the authenticated user to the trash, from where it can be restored until the trash is emptied",
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Delete diary entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update only the fields of a diary entry of the authenticated user present in a JSON Merge Patch (RFC 7396) document.\nFields set to null are removed. The merged entry is validated like the body of full updates.\nWhen the If-Match header is sent, updates based on an outdated version respond 412 with the current version of the entry",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Partially update diary entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the updated version",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Diary entry fields to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateDiaryEntryBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DiaryEntry"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the diary entry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.PreconditionFailedHttpError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/{id}/attachments": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the files attached to a diary entry of the authenticated user, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Get diary entry attachments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DiaryEntryAttachment"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload a JPEG, PNG, GIF or WebP image to a diary entry of the authenticated user, up to 10 MiB.\nThe type is detected from the content. Thumbnails are generated for the images that can be decoded",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Attach file to diary entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Attached file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.DiaryEntryAttachment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/{id}/attachments/{attachmentId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a file attached to a diary entry of the authenticated user",
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Delete diary entry attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/{id}/attachments/{attachmentId}/content": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download a file attached to a diary entry of the authenticated user",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Get diary entry attachment content",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/{id}/attachments/{attachmentId}/thumbnail": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download the JPEG thumbnail of an image attached to a diary entry of the authenticated user.\nAttachments without thumbnail respond 404",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Get diary entry attachment thumbnail",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/{id}/revisions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the previous versions of a diary entry of the authenticated user, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Get diary entry revisions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DiaryEntryRevision"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/{id}/revisions/diff": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Compare line by line the title and content of two versions of a diary entry of the authenticated user.\nEncrypted versions can only be compared by the client",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Diff diary entry revisions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision ID of the old version",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision ID of the new version, the current version by default",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.DiaryEntryRevisionDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/{id}/revisions/{revisionId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a previous version of a diary entry of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Get diary entry revision",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision ID",
+                        "name": "revisionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DiaryEntryRevision"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/{id}/revisions/{revisionId}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace a diary entry of the authenticated user with a previous version.\nThe replaced version is stored as a new revision",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Restore diary entry revision",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision ID",
+                        "name": "revisionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DiaryEntry"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/diaryEntries/{id}/shareLinks": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a link giving read access to a diary entry of the authenticated user without authentication, until it expires.\nThe link can be protected by a passphrase and limited to a number of views.\nThe token is only returned in this response. End-to-end encrypted entries cannot be shared",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Create diary entry share link",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Share link information",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.CreateShareLinkBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/services.CreatedShareLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the profile of the authenticated user. The ETag header holds its version",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user profile"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Partially update the profile of the authenticated user. Only the provided fields are changed.\nWhen the If-Match header is sent, updates based on an outdated version respond 412 with the current profile",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update current user profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the updated version",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Profile fields to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateUserProfileBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user profile"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.PreconditionFailedHttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/activities": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of the diary entries, books, games and declared activities of the authenticated user, most recent first, each tagged with its type.\nCalendar dates are days in the time zone of the user.\nThe nextCursor of the response is passed in the cursor parameter to get the next page, and is not present on the last page",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "activities"
+                ],
+                "summary": "Get current user activity timeline",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only activities of these types: diary_entry, book, game or a declared activity type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar date like 2026-10-17, instead of start_date and end_date",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of activities, 50 by default and 200 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page, from the nextCursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.ActivityPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/attachments/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the bytes taken by the attachments of the authenticated user and their storage quota",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Get current user attachment usage",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.AttachmentUsage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/calendar": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get every day of a month of the authenticated user, with the number of diary entries, books and games registered\nand the first three of each as summaries. Days are in the time zone of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Get current user calendar month",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Month like 2026-10, the current one by default",
+                        "name": "month",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CalendarMonth"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download a zip archive with the profile, diary entries and activity registrations of the authenticated user",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Export user data",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/imports": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Import the diary entries of another journaling app for the authenticated user, up to 50 MiB.\nThe formats are a Day One JSON export (or its zip archive), a zip archive of Markdown files with title and date\nfront matter, or a CSV file with a header line naming its title, content and date columns.\nThe import runs in the background, and its progress and item errors are read from the returned job.\nEntries already imported are skipped, so the same file can be imported again",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Import diary entries",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Imported file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "dayone",
+                            "markdown",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Format of the file",
+                        "name": "format",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportJob"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the import job"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/imports/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the status and progress of an import of the authenticated user, with the errors of the items that could not be imported",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Get import job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Import job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportJob"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/keyBackups": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the backups of the encryption keys of the authenticated user, which are encrypted on the client",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "encryption"
+                ],
+                "summary": "Get current user key backups",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UserKeyBackup"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/keyBackups/{keyId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Store the backup of an encryption key of the authenticated user, replacing the previous backup of the key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "encryption"
+                ],
+                "summary": "Save current user key backup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Encrypted key",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.SaveUserKeyBackupBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserKeyBackup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete the backup of an encryption key of the authenticated user",
+                "tags": [
+                    "encryption"
+                ],
+                "summary": "Delete current user key backup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mood": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the mood and feelings aggregates of the diary entries of the authenticated user for every period of a date range.\nPeriods start at midnight in the time zone of the user, weeks on Monday",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mood"
+                ],
+                "summary": "Get current user mood summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar date like 2026-10-17, instead of start_date and end_date",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Aggregation period, day by default",
+                        "name": "period",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.MoodSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mood/correlation": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Compare the average mood of the authenticated user with the number of book and game activities\nregistered for every period of a date range",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mood"
+                ],
+                "summary": "Get current user mood and activity correlation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar date like 2026-10-17, instead of start_date and end_date",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Aggregation period, day by default",
+                        "name": "period",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.MoodActivityCorrelation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/readingTime": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the time the authenticated user spent reading on every day of a date range.\nDays start at midnight in the time zone of the user, and active sessions count until now",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reading sessions"
+                ],
+                "summary": "Get current user reading time",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar date like 2026-10-17, instead of start_date and end_date",
+                        "name": "date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.ReadingTimeSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/shareLinks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the diary entry share links of the authenticated user, including the expired ones. Tokens are never returned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Get share links",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ShareLink"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/shareLinks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke a diary entry share link of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Revoke share link",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Share link ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/tags": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the tags of the authenticated user sorted by name, with the number of diary entries using each of them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Get current user tags",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TagUsage"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/tags/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename a tag of the authenticated user, renaming it on every tagged diary entry.\nFails if the user already has a tag with the new name, which can be merged instead",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Rename tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New tag name",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.RenameTagBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/tags/{id}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Merge tags of the authenticated user into the tag of the path: their diary entries get tagged with it and they are deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Merge tags",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Target tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tags to merge",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.MergeTagsBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the personal access tokens of the authenticated user. Token values are never returned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "personal access tokens"
+                ],
+                "summary": "Get personal access tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PersonalAccessToken"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a personal access token for the authenticated user, limited to the given scopes.\nThe token is only returned in this response, so it must be stored by the client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "personal access tokens"
+                ],
+                "summary": "Create personal access token",
+                "parameters": [
+                    {
+                        "description": "Personal access token information",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.CreatePersonalAccessTokenBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/services.CreatedPersonalAccessTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/tokens/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke a personal access token of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "personal access tokens"
+                ],
+                "summary": "Revoke personal access token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Personal access token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the diary entries and activity registrations deleted by the authenticated user, most recently deleted first.\nItems are permanently deleted once they have been in the trash for the retention period",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Get current user trash",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.Trash"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Permanently delete every item in the trash of the authenticated user",
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Empty current user trash",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/trash/books/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a book registration out of the trash of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restore book activity registration from trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookActivityRegistration"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/trash/custom/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a registration of a declared activity type, like a walk, out of the trash of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restore custom activity registration from trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Custom activity registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CustomActivityRegistration"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/trash/diaryEntries/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a diary entry out of the trash of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restore diary entry from trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Diary entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DiaryEntry"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/trash/games/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a game registration out of the trash of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restore game activity registration from trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game registration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GameActivityRegistration"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/prompts/today": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the journaling prompt of the day for the authenticated user, in the language of their locale unless another one is given.\nThe prompt changes every day in the time zone of the user, skipping the prompts answered in the last 30 days.\nPrompts in English are returned when there are none in the language",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "prompts"
+                ],
+                "summary": "Get daily prompt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Language of the prompt, like es",
+                        "name": "language",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Prompt"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/shared/{token}": {
+            "get": {
+                "description": "Get the diary entry of a share link. No authentication is needed, and each successful request counts as a view.\nLinks protected by a passphrase need it in the X-Share-Passphrase header",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diary"
+                ],
+                "summary": "Get shared diary entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share link token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Share link passphrase",
+                        "name": "X-Share-Passphrase",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SharedDiaryEntry"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{email}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get user information by their email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get user information by their ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.HttpError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.Activity": {
+            "type": "object",
+            "properties": {
+                "encrypted": {
+                    "type": "boolean"
+                },
+                "gameName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "internetArchiveId": {
+                    "type": "string"
+                },
+                "mood": {
+                    "type": "integer"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "registration": {
+                    "$ref": "#/definitions/models.ActivityRegistration"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.ActivityType"
+                }
+            }
+        },
+        "models.ActivityRegistration": {
+            "type": "object",
+            "properties": {
+                "deletedAt": {
+                    "description": "DeletedAt is the time the registration was moved to the trash, or 0 when it is not in the trash.",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "registrationDate": {
+                    "description": "RegistrationDate is a Unix timestamp in seconds, so it has no time zone. It is the publish date of diary entries.",
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "Version is incremented on every update, and is sent as the ETag of book, game and custom registrations.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ActivityType": {
+            "type": "string",
+            "enum": [
+                "diary_entry",
+                "book",
+                "game"
+            ],
+            "x-enum-varnames": [
+                "ActivityTypeDiaryEntry",
+                "ActivityTypeBook",
+                "ActivityTypeGame"
+            ]
+        },
+        "models.ActivityTypeDefinition": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "object"
+                }
+            }
+        },
+        "models.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "actorId": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "eventType": {
+                    "$ref": "#/definitions/models.AuditEventType"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "targetId": {
+                    "type": "integer"
+                },
+                "targetType": {
+                    "type": "string"
+                },
+                "userAgent": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditEventType": {
+            "type": "string",
+            "enum": [
+                "auth.login",
+                "auth.login_failed",
+                "auth.token_refresh",
+                "auth.token_refresh_failed",
+                "token.revoked",
+                "diary_entry.created",
+                "diary_entry.updated",
+                "diary_entry.deleted",
+                "diary_entry.restored",
+                "diary_entry.attachment_added",
+                "diary_entry.attachment_deleted",
+                "book_registration.created",
+                "book_registration.updated",
+                "book_registration.deleted",
+                "game_registration.created",
+                "game_registration.updated",
+                "game_registration.deleted",
+                "custom_registration.created",
+                "custom_registration.updated",
+                "custom_registration.deleted",
+                "personal_access_token.created",
+                "personal_access_token.revoked",
+                "tag.renamed",
+                "tag.merged",
+                "key_backup.saved",
+                "key_backup.deleted",
+                "trash.item_restored",
+                "trash.emptied",
+                "prompt.created",
+                "prompt.updated",
+                "prompt.deleted",
+                "share_link.created",
+                "share_link.revoked",
+                "reading_session.started",
+                "reading_session.stopped"
+            ],
+            "x-enum-varnames": [
+                "AuditLogin",
+                "AuditLoginFailed",
+                "AuditTokenRefresh",
+                "AuditTokenRefreshFailed",
+                "AuditTokenRevoked",
+                "AuditDiaryEntryCreated",
+                "AuditDiaryEntryUpdated",
+                "AuditDiaryEntryDeleted",
+                "AuditDiaryEntryRestored",
+                "AuditDiaryEntryAttachmentAdded",
+                "AuditDiaryEntryAttachmentDeleted",
+                "AuditBookRegistrationCreated",
+                "AuditBookRegistrationUpdated",
+                "AuditBookRegistrationDeleted",
+                "AuditGameRegistrationCreated",
+                "AuditGameRegistrationUpdated",
+                "AuditGameRegistrationDeleted",
+                "AuditCustomRegistrationCreated",
+                "AuditCustomRegistrationUpdated",
+                "AuditCustomRegistrationDeleted",
+                "AuditPersonalAccessTokenCreated",
+                "AuditPersonalAccessTokenRevoked",
+                "AuditTagRenamed",
+                "AuditTagMerged",
+                "AuditKeyBackupSaved",
+                "AuditKeyBackupDeleted",
+                "AuditTrashItemRestored",
+                "AuditTrashEmptied",
+                "AuditPromptCreated",
+                "AuditPromptUpdated",
+                "AuditPromptDeleted",
+                "AuditShareLinkCreated",
+                "AuditShareLinkRevoked",
+                "AuditReadingSessionStarted",
+                "AuditReadingSessionStopped"
+            ]
+        },
+        "models.BookActivityRegistration": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "internetArchiveId": {
+                    "type": "string"
+                },
+                "registration": {
+                    "$ref": "#/definitions/models.ActivityRegistration"
+                }
+            }
+        },
+        "models.CalendarBook": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "internetArchiveId": {
+                    "type": "string"
+                },
+                "registrationDate": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CalendarDay": {
+            "type": "object",
+            "properties": {
+                "bookCount": {
+                    "type": "integer"
+                },
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CalendarBook"
+                    }
+                },
+                "date": {
+                    "type": "string"
+                },
+                "diaryEntries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CalendarDiaryEntry"
+                    }
+                },
+                "diaryEntryCount": {
+                    "type": "integer"
+                },
+                "gameCount": {
+                    "type": "integer"
+                },
+                "games": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CalendarGame"
+                    }
+                }
+            }
+        },
+        "models.CalendarDiaryEntry": {
+            "type": "object",
+            "properties": {
+                "encrypted": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mood": {
+                    "type": "integer"
+                },
+                "registrationDate": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CalendarGame": {
+            "type": "object",
+            "properties": {
+                "gameName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "registrationDate": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CalendarMonth": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CalendarDay"
+                    }
+                },
+                "month": {
+                    "type": "string"
+                },
+                "timeZone": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CustomActivityRegistration": {
+            "type": "object",
+            "properties": {
+                "activityType": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "registration": {
+                    "$ref": "#/definitions/models.ActivityRegistration"
+                }
+            }
+        },
+        "models.DiaryEntry": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "encryption": {
+                    "$ref": "#/definitions/models.DiaryEntryEncryption"
+                },
+                "feelings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mood": {
+                    "type": "integer"
+                },
+                "promptId": {
+                    "description": "PromptRefer is the id of the prompt answered by the entry, if any.",
+                    "type": "integer"
+                },
+                "registration": {
+                    "$ref": "#/definitions/models.ActivityRegistration"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version is incremented on every update, and is sent as the ETag of the entry.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DiaryEntryAttachment": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "diaryEntryId": {
+                    "type": "integer"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "hasThumbnail": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DiaryEntryEncryption": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string"
+                },
+                "keyId": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "string"
+                },
+                "wrappedKey": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DiaryEntryRevision": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "diaryEntryId": {
+                    "type": "integer"
+                },
+                "encryption": {
+                    "$ref": "#/definitions/models.DiaryEntryEncryption"
+                },
+                "feelings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mood": {
+                    "type": "integer"
+                },
+                "publishDate": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DiaryEntrySearchResult": {
+            "type": "object",
+            "properties": {
+                "contentSnippet": {
+                    "type": "string"
+                },
+                "entry": {
+                    "$ref": "#/definitions/models.DiaryEntry"
+                },
+                "titleSnippet": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GameActivityRegistration": {
+            "type": "object",
+            "properties": {
+                "gameName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "registration": {
+                    "$ref": "#/definitions/models.ActivityRegistration"
+                }
+            }
+        },
+        "models.HttpError": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ImportItemError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "item": {
+                    "type": "integer"
+                },
+                "reference": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImportJob": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportItemError"
+                    }
+                },
+                "failedItems": {
+                    "type": "integer"
+                },
+                "finishedAt": {
+                    "type": "integer"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "importedItems": {
+                    "type": "integer"
+                },
+                "skippedItems": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.ImportJobStatus"
+                },
+                "totalItems": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ImportJobStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "completed",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "ImportJobPending",
+                "ImportJobRunning",
+                "ImportJobCompleted",
+                "ImportJobFailed"
+            ]
+        },
+        "models.PersonalAccessToken": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "integer"
+                },
+                "expiresAt": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PreconditionFailedHttpError": {
+            "type": "object",
+            "properties": {
+                "current": {},
+                "currentVersion": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Prompt": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PromptUsage": {
+            "type": "object",
+            "properties": {
+                "answerCount": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReadingSession": {
+            "type": "object",
+            "properties": {
+                "bookRegistrationId": {
+                    "type": "integer"
+                },
+                "endedAt": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "percentage": {
+                    "type": "number"
+                },
+                "position": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ShareLink": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "integer"
+                },
+                "diaryEntryId": {
+                    "type": "integer"
+                },
+                "expiresAt": {
+                    "type": "integer"
+                },
+                "hasPassphrase": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "maxViews": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "viewCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SharedDiaryEntry": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "integer"
+                },
+                "publishDate": {
+                    "type": "integer"
+                },
+                "remainingViews": {
+                    "description": "RemainingViews is nil when the number of views is not limited.",
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Tag": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TagUsage": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "usageCount": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "avatarUrl": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.UserRole"
+                },
+                "searchIndexEnabled": {
+                    "description": "SearchIndexEnabled opts the user into the full-text index of their diary entries when they are encrypted at rest.",
+                    "type": "boolean"
+                },
+                "timeZone": {
+                    "description": "TimeZone is the IANA name of the time zone of the user, like Europe/Madrid. Calendar dates and day boundaries\nare computed in it, or in UTC when it is empty.",
+                    "type": "string"
+                },
+                "userName": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version is incremented on every profile update, and is sent as the ETag of the profile.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UserKeyBackup": {
+            "type": "object",
+            "properties": {
+                "blob": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "keyId": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UserRole": {
+            "type": "integer",
+            "enum": [
+                1,
+                2
+            ],
+            "x-enum-varnames": [
+                "Admin",
+                "Standard"
+            ]
+        },
+        "services.ActivityPage": {
+            "type": "object",
+            "properties": {
+                "activities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Activity"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.AddBookActivityRegistrationBody": {
+            "type": "object",
+            "required": [
+                "internetArchiveId",
+                "registrationDate",
+                "userId"
+            ],
+            "properties": {
+                "internetArchiveId": {
+                    "type": "string"
+                },
+                "registrationDate": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.AddCustomActivityRegistrationBody": {
+            "type": "object",
+            "required": [
+                "payload",
+                "registrationDate"
+            ],
+            "properties": {
+                "payload": {
+                    "type": "object"
+                },
+                "registrationDate": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.AddGameActivityRegistrationBody": {
+            "type": "object",
+            "required": [
+                "gameName",
+                "registrationDate",
+                "userId"
+            ],
+            "properties": {
+                "gameName": {
+                    "type": "string"
+                },
+                "registrationDate": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.AttachmentUsage": {
+            "type": "object",
+            "properties": {
+                "quotaBytes": {
+                    "type": "integer"
+                },
+                "usedBytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.AuditEventPage": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEvent"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.CreatePersonalAccessTokenBody": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expiresInDays": {
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "services.CreateShareLinkBody": {
+            "type": "object",
+            "required": [
+                "expiresInHours"
+            ],
+            "properties": {
+                "expiresInHours": {
+                    "type": "integer",
+                    "maximum": 720,
+                    "minimum": 1
+                },
+                "maxViews": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1
+                },
+                "passphrase": {
+                    "description": "Passphrase is limited to the 72 bytes hashed by bcrypt.",
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
+                }
+            }
+        },
+        "services.CreatedPersonalAccessTokenResponse": {
+            "type": "object",
+            "properties": {
+                "personalAccessToken": {
+                    "$ref": "#/definitions/models.PersonalAccessToken"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CreatedShareLinkResponse": {
+            "type": "object",
+            "properties": {
+                "shareLink": {
+                    "$ref": "#/definitions/models.ShareLink"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.DiaryEntryEncryptionBody": {
+            "type": "object",
+            "required": [
+                "algorithm",
+                "keyId",
+                "nonce",
+                "wrappedKey"
+            ],
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "enum": [
+                        "AES-256-GCM",
+                        "XChaCha20-Poly1305"
+                    ]
+                },
+                "keyId": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "nonce": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "wrappedKey": {
+                    "type": "string",
+                    "maxLength": 1024
+                }
+            }
+        },
+        "services.DiaryEntryPage": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DiaryEntry"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.DiaryEntryRevisionDiff": {
             "type": "object",
             "properties": {
-                "id": {
-                    "type": "integer"
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.DiffLine"
+                    }
                 },
-                "registrationDate": {
+                "fromRevisionId": {
                     "type": "integer"
                 },
-                "userId": {
+                "title": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.DiffLine"
+                    }
+                },
+                "toRevisionId": {
                     "type": "integer"
                 }
             }
         },
-        "models.BookActivityRegistration": {
+        "services.DiaryEntrySearchPage": {
             "type": "object",
             "properties": {
-                "id": {
+                "page": {
                     "type": "integer"
                 },
-                "internetArchiveId": {
+                "pageSize": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DiaryEntrySearchResult"
+                    }
+                }
+            }
+        },
+        "services.DiffLine": {
+            "type": "object",
+            "properties": {
+                "operation": {
                     "type": "string"
                 },
-                "registration": {
-                    "$ref": "#/definitions/models.ActivityRegistration"
+                "text": {
+                    "type": "string"
                 }
             }
         },
-        "models.DiaryEntry": {
+        "services.MergeTagsBody": {
+            "type": "object",
+            "required": [
+                "sourceTagIds"
+            ],
+            "properties": {
+                "sourceTagIds": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "services.MoodActivityCorrelation": {
             "type": "object",
             "properties": {
-                "content": {
+                "bookActivityCorrelation": {
+                    "type": "number"
+                },
+                "gameActivityCorrelation": {
+                    "type": "number"
+                },
+                "period": {
                     "type": "string"
                 },
-                "id": {
+                "periods": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.MoodActivityPeriod"
+                    }
+                }
+            }
+        },
+        "services.MoodActivityPeriod": {
+            "type": "object",
+            "properties": {
+                "averageMood": {
+                    "type": "number"
+                },
+                "bookActivityCount": {
                     "type": "integer"
                 },
-                "registration": {
-                    "$ref": "#/definitions/models.ActivityRegistration"
+                "gameActivityCount": {
+                    "type": "integer"
                 },
-                "title": {
-                    "type": "string"
+                "periodStart": {
+                    "type": "integer"
                 }
             }
         },
-        "models.GameActivityRegistration": {
+        "services.MoodPeriodSummary": {
             "type": "object",
             "properties": {
-                "gameName": {
-                    "type": "string"
+                "averageMood": {
+                    "type": "number"
                 },
-                "id": {
+                "entryCount": {
                     "type": "integer"
                 },
-                "registration": {
-                    "$ref": "#/definitions/models.ActivityRegistration"
+                "feelings": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "maxMood": {
+                    "type": "integer"
+                },
+                "minMood": {
+                    "type": "integer"
+                },
+                "moodCount": {
+                    "type": "integer"
+                },
+                "periodStart": {
+                    "type": "integer"
                 }
             }
         },
-        "models.HttpError": {
+        "services.MoodSummary": {
             "type": "object",
             "properties": {
-                "description": {
+                "period": {
                     "type": "string"
                 },
-                "status": {
-                    "type": "integer"
+                "periods": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.MoodPeriodSummary"
+                    }
                 }
             }
         },
-        "models.User": {
+        "services.ReadingDay": {
             "type": "object",
             "properties": {
-                "email": {
+                "date": {
                     "type": "string"
                 },
-                "id": {
+                "readingSeconds": {
                     "type": "integer"
                 },
-                "role": {
-                    "$ref": "#/definitions/models.UserRole"
-                },
-                "userName": {
-                    "type": "string"
+                "sessionCount": {
+                    "type": "integer"
                 }
             }
         },
-        "models.UserRole": {
-            "type": "integer",
-            "enum": [
-                1,
-                2
-            ],
-            "x-enum-varnames": [
-                "Admin",
-                "Standard"
-            ]
-        },
-        "services.AddBookActivityRegistrationBody": {
+        "services.ReadingProgress": {
             "type": "object",
-            "required": [
-                "internetArchiveId",
-                "registrationDate",
-                "userId"
-            ],
             "properties": {
-                "internetArchiveId": {
+                "activeSession": {
+                    "$ref": "#/definitions/models.ReadingSession"
+                },
+                "bookRegistrationId": {
+                    "type": "integer"
+                },
+                "lastReadAt": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "percentage": {
+                    "type": "number"
+                },
+                "position": {
                     "type": "string"
                 },
-                "registrationDate": {
+                "sessionCount": {
                     "type": "integer"
                 },
-                "userId": {
+                "totalReadingSeconds": {
                     "type": "integer"
                 }
             }
         },
-        "services.AddGameActivityRegistrationBody": {
+        "services.ReadingTimeSummary": {
             "type": "object",
-            "required": [
-                "gameName",
-                "registrationDate",
-                "userId"
-            ],
             "properties": {
-                "gameName": {
-                    "type": "string"
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.ReadingDay"
+                    }
                 },
-                "registrationDate": {
-                    "type": "integer"
+                "timeZone": {
+                    "type": "string"
                 },
-                "userId": {
+                "totalSeconds": {
                     "type": "integer"
                 }
             }
         },
         "services.RefreshTokenRequest": {
             "type": "object",
-            "required": [
-                "refreshToken"
-            ],
             "properties": {
                 "refreshToken": {
                     "type": "string"
                 }
             }
         },
+        "services.RefreshTokenResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.RenameTagBody": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 32
+                }
+            }
+        },
         "services.SaveDiaryEntryBody": {
             "type": "object",
             "required": [
                 "content",
-                "publishDate",
-                "title",
-                "userId"
+                "publishDate"
             ],
             "properties": {
                 "content": {
                     "type": "string"
                 },
+                "encryption": {
+                    "$ref": "#/definitions/services.DiaryEntryEncryptionBody"
+                },
+                "feelings": {
+                    "type": "array",
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "mood": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                },
+                "promptId": {
+                    "description": "PromptRefer is the id of the prompt answered by the entry, if any.",
+                    "type": "integer",
+                    "minimum": 1
+                },
                 "publishDate": {
                     "type": "integer"
                 },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "title": {
                     "type": "string"
+                }
+            }
+        },
+        "services.SavePromptBody": {
+            "type": "object",
+            "required": [
+                "category",
+                "language",
+                "text"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "language": {
+                    "type": "string"
                 },
-                "userId": {
-                    "type": "integer"
+                "text": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "services.SaveUserKeyBackupBody": {
+            "type": "object",
+            "required": [
+                "blob"
+            ],
+            "properties": {
+                "blob": {
+                    "type": "string",
+                    "maxLength": 16384
+                }
+            }
+        },
+        "services.StartReadingSessionBody": {
+            "type": "object",
+            "properties": {
+                "startedAt": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "services.StopReadingSessionBody": {
+            "type": "object",
+            "properties": {
+                "endedAt": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "page": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "percentage": {
+                    "type": "number",
+                    "maximum": 100,
+                    "minimum": 0
+                },
+                "position": {
+                    "type": "string",
+                    "maxLength": 1024
                 }
             }
         },
@@ -810,64 +5717,176 @@ const docTemplate = `{
                 "accessToken": {
                     "type": "string"
                 },
+                "csrfToken": {
+                    "type": "string"
+                },
                 "refreshToken": {
                     "type": "string"
                 }
             }
         },
+        "services.Trash": {
+            "type": "object",
+            "properties": {
+                "bookRegistrations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BookActivityRegistration"
+                    }
+                },
+                "customRegistrations": {
+                    "description": "CustomRegistrations are the registrations of the activity types declared in the activity type registry.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CustomActivityRegistration"
+                    }
+                },
+                "diaryEntries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DiaryEntry"
+                    }
+                },
+                "gameRegistrations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GameActivityRegistration"
+                    }
+                },
+                "retentionDays": {
+                    "description": "RetentionDays is the number of days items are kept in the trash before being permanently deleted.",
+                    "type": "integer"
+                }
+            }
+        },
+        "services.UpdateBookActivityRegistrationBody": {
+            "type": "object",
+            "required": [
+                "internetArchiveId",
+                "registrationDate"
+            ],
+            "properties": {
+                "internetArchiveId": {
+                    "type": "string"
+                },
+                "registrationDate": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.UpdateCustomActivityRegistrationBody": {
+            "type": "object",
+            "required": [
+                "payload",
+                "registrationDate"
+            ],
+            "properties": {
+                "payload": {
+                    "type": "object"
+                },
+                "registrationDate": {
+                    "type": "integer"
+                }
+            }
+        },
         "services.UpdateDiaryEntryBody": {
             "type": "object",
             "required": [
                 "content",
-                "publishDate",
-                "title"
+                "publishDate"
             ],
             "properties": {
                 "content": {
                     "type": "string"
                 },
+                "encryption": {
+                    "$ref": "#/definitions/services.DiaryEntryEncryptionBody"
+                },
+                "feelings": {
+                    "type": "array",
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "mood": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                },
                 "publishDate": {
                     "type": "integer"
                 },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "title": {
                     "type": "string"
                 }
             }
         },
-        "services.UserAuthenticateBody": {
+        "services.UpdateGameActivityRegistrationBody": {
             "type": "object",
             "required": [
-                "email",
-                "providerId",
-                "providerToken",
-                "userName"
+                "gameName",
+                "registrationDate"
             ],
             "properties": {
-                "email": {
+                "gameName": {
                     "type": "string"
                 },
-                "providerId": {
+                "registrationDate": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.UpdateUserProfileBody": {
+            "type": "object",
+            "properties": {
+                "avatarUrl": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "locale": {
                     "type": "string"
                 },
-                "providerToken": {
+                "searchIndexEnabled": {
+                    "description": "SearchIndexEnabled opts in or out of the full-text index of diary entries encrypted at rest.",
+                    "type": "boolean"
+                },
+                "timeZone": {
                     "type": "string"
                 },
                 "userName": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 1
                 }
             }
         },
-        "services.UserBody": {
+        "services.UserAuthenticateBody": {
             "type": "object",
             "required": [
                 "email",
-                "username"
+                "providerId",
+                "providerToken",
+                "userName"
             ],
             "properties": {
                 "email": {
                     "type": "string"
                 },
-                "username": {
+                "providerId": {
+                    "type": "string"
+                },
+                "providerToken": {
+                    "type": "string"
+                },
+                "userName": {
                     "type": "string"
                 }
             }
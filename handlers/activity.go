@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/adfer-dev/analock-api/constants"
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitActivityRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/me/activities", utils.ParseToHandlerFunc(handleGetCurrentUserActivities)).Methods("GET")
+}
+
+var activityService services.ActivityService = &services.ActivityServiceImpl{}
+
+// @Summary		Get current user activity timeline
+// @Description	Get a page of the diary entries, books, games and declared activities of the authenticated user, most recent first, each tagged with its type.
+// @Description	Calendar dates are days in the time zone of the user.
+// @Description	The nextCursor of the response is passed in the cursor parameter to get the next page, and is not present on the last page
+// @Tags			activities
+// @Produce		json
+// @Param			type		query		[]string	false	"Only activities of these types: diary_entry, book, game or a declared activity type"	collectionFormat(multi)
+// @Param			start_date	query		string		false	"Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			end_date	query		string		false	"End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			date		query		string		false	"Calendar date like 2026-10-17, instead of start_date and end_date"
+// @Param			limit		query		int			false	"Maximum number of activities, 50 by default and 200 at most"
+// @Param			cursor		query		string		false	"Cursor of the page, from the nextCursor of the previous page"
+// @Success		200			{object}	services.ActivityPage
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		500			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/activities [get]
+func handleGetCurrentUserActivities(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	queryParams := req.URL.Query()
+	pageQuery := &services.ActivityPageQuery{
+		Types:  queryParams["type"],
+		Cursor: queryParams.Get("cursor"),
+	}
+
+	startDate, endDate, dateErr := parseDateRangeQueryParams(req)
+
+	if dateErr != nil {
+		return utils.WriteJSON(res, dateErr.Status, dateErr)
+	}
+
+	pageQuery.StartDate = startDate
+	pageQuery.EndDate = endDate
+
+	limit, limitErr := parseOptionalIntQueryParam(queryParams, "limit")
+
+	if limitErr != nil {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, "limit")})
+	}
+
+	pageQuery.Limit = int(limit)
+	activityPage, err := activityService.GetUserActivitiesPage(user.Id, pageQuery)
+
+	if errors.Is(err, services.ErrInvalidActivityType) || errors.Is(err, services.ErrInvalidActivityCursor) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, activityPage)
+}
@@ -1,11 +1,10 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
 
-	"github.com/adfer-dev/analock-api/constants"
 	"github.com/adfer-dev/analock-api/models"
 	"github.com/adfer-dev/analock-api/services"
 	"github.com/adfer-dev/analock-api/utils"
@@ -20,16 +19,24 @@ func InitActivityRegistrationRoutes(router *mux.Router) {
 	router.HandleFunc("/api/v1/activityRegistrations/games/user/{id:[0-9]+}", utils.ParseToHandlerFunc(handleGetUserGameActivityRegistrations)).Methods("GET")
 	router.HandleFunc("/api/v1/activityRegistrations/books", utils.ParseToHandlerFunc(handleCreateBookActivityRegistration)).Methods("POST")
 	router.HandleFunc("/api/v1/activityRegistrations/games", utils.ParseToHandlerFunc(handleCreateGameActivityRegistration)).Methods("POST")
+	router.HandleFunc("/api/v1/activityRegistrations/books/{id:[0-9]+}", utils.ParseToHandlerFunc(handleGetBookActivityRegistration)).Methods("GET")
+	router.HandleFunc("/api/v1/activityRegistrations/games/{id:[0-9]+}", utils.ParseToHandlerFunc(handleGetGameActivityRegistration)).Methods("GET")
+	router.HandleFunc("/api/v1/activityRegistrations/books/{id:[0-9]+}", utils.ParseToHandlerFunc(handlePatchBookActivityRegistration)).Methods("PATCH")
+	router.HandleFunc("/api/v1/activityRegistrations/games/{id:[0-9]+}", utils.ParseToHandlerFunc(handlePatchGameActivityRegistration)).Methods("PATCH")
+	router.HandleFunc("/api/v1/activityRegistrations/books/{id:[0-9]+}", utils.ParseToHandlerFunc(handleDeleteBookActivityRegistration)).Methods("DELETE")
+	router.HandleFunc("/api/v1/activityRegistrations/games/{id:[0-9]+}", utils.ParseToHandlerFunc(handleDeleteGameActivityRegistration)).Methods("DELETE")
 }
 
 // @Summary		Get user book activity registrations
-// @Description	Get all book activity registrations for a user, optionally filtered by date range
+// @Description	Get all book activity registrations for a user, optionally filtered by a date range.
+// @Description	Calendar dates are days in the time zone of the authenticated user
 // @Tags			activity registrations
 // @Accept			json
 // @Produce		json
 // @Param			id			path		int	true	"User ID"
-// @Param			startDate	query		int	false	"Start date timestamp"
-// @Param			endDate		query		int	false	"End date timestamp"
+// @Param			start_date	query		string	false	"Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			end_date	query		string	false	"End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			date		query		string	false	"Calendar date like 2026-10-17, instead of start_date and end_date"
 // @Success		200			{array}		models.BookActivityRegistration
 // @Failure		400			{object}	models.HttpError
 // @Failure		500			{object}	models.HttpError
@@ -37,10 +44,13 @@ func InitActivityRegistrationRoutes(router *mux.Router) {
 // @Router			/activityRegistrations/books/user/{id} [get]
 func handleGetUserBookActivityRegistrations(res http.ResponseWriter, req *http.Request) error {
 	userId, _ := strconv.Atoi(mux.Vars(req)["id"])
-	startDateString := req.URL.Query().Get(constants.StartDateQueryParam)
-	endDateString := req.URL.Query().Get(constants.EndDateQueryParam)
+	startDate, endDate, dateErr := parseDateRangeQueryParams(req)
 
-	if len(startDateString) == 0 || len(endDateString) == 0 {
+	if dateErr != nil {
+		return utils.WriteJSON(res, dateErr.Status, dateErr)
+	}
+
+	if startDate == 0 || endDate == 0 {
 		userBookRegistrations, err := bookRegistrationService.GetUserBookActivityRegistrations(uint(userId))
 
 		if err != nil {
@@ -50,19 +60,7 @@ func handleGetUserBookActivityRegistrations(res http.ResponseWriter, req *http.R
 		return utils.WriteJSON(res, 200, userBookRegistrations)
 	}
 
-	startDate, startTimeErr := strconv.Atoi(req.URL.Query().Get(constants.StartDateQueryParam))
-
-	if startTimeErr != nil {
-		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, constants.StartDateQueryParam)})
-	}
-
-	endDate, endTimeErr := strconv.Atoi(req.URL.Query().Get(constants.EndDateQueryParam))
-
-	if endTimeErr != nil {
-		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, constants.EndDateQueryParam)})
-	}
-
-	userRegistrations, err := bookRegistrationService.GetUserBookActivityRegistrationsTimeRange(uint(userId), int64(startDate), int64(endDate))
+	userRegistrations, err := bookRegistrationService.GetUserBookActivityRegistrationsTimeRange(uint(userId), startDate, endDate)
 
 	if err != nil {
 		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
@@ -72,13 +70,15 @@ func handleGetUserBookActivityRegistrations(res http.ResponseWriter, req *http.R
 }
 
 // @Summary		Get user game activity registrations
-// @Description	Get all game activity registrations for a user, optionally filtered by date range
+// @Description	Get all game activity registrations for a user, optionally filtered by a date range.
+// @Description	Calendar dates are days in the time zone of the authenticated user
 // @Tags			activities
 // @Accept			json
 // @Produce		json
 // @Param			id			path		int	true	"User ID"
-// @Param			startDate	query		int	false	"Start date timestamp"
-// @Param			endDate		query		int	false	"End date timestamp"
+// @Param			start_date	query		string	false	"Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			end_date	query		string	false	"End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			date		query		string	false	"Calendar date like 2026-10-17, instead of start_date and end_date"
 // @Success		200			{array}		models.GameActivityRegistration
 // @Failure		400			{object}	models.HttpError
 // @Failure		500			{object}	models.HttpError
@@ -86,10 +86,13 @@ func handleGetUserBookActivityRegistrations(res http.ResponseWriter, req *http.R
 // @Router			/activityRegistrations/games/user/{id} [get]
 func handleGetUserGameActivityRegistrations(res http.ResponseWriter, req *http.Request) error {
 	userId, _ := strconv.Atoi(mux.Vars(req)["id"])
-	startDateString := req.URL.Query().Get(constants.StartDateQueryParam)
-	endDateString := req.URL.Query().Get(constants.EndDateQueryParam)
+	startDate, endDate, dateErr := parseDateRangeQueryParams(req)
+
+	if dateErr != nil {
+		return utils.WriteJSON(res, dateErr.Status, dateErr)
+	}
 
-	if len(startDateString) == 0 || len(endDateString) == 0 {
+	if startDate == 0 || endDate == 0 {
 		userGameRegistrations, err := gameRegistrationService.GetUserGameActivityRegistrations(uint(userId))
 
 		if err != nil {
@@ -99,18 +102,7 @@ func handleGetUserGameActivityRegistrations(res http.ResponseWriter, req *http.R
 		return utils.WriteJSON(res, 200, userGameRegistrations)
 	}
 
-	startDate, startTimeErr := strconv.Atoi(req.URL.Query().Get(constants.StartDateQueryParam))
-
-	if startTimeErr != nil {
-		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, constants.StartDateQueryParam)})
-	}
-
-	endDate, endTimeErr := strconv.Atoi(req.URL.Query().Get(constants.EndDateQueryParam))
-
-	if endTimeErr != nil {
-		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, constants.EndDateQueryParam)})
-	}
-	userRegistrations, err := gameRegistrationService.GetUserGameActivityRegistrationsTimeRange(uint(userId), int64(startDate), int64(endDate))
+	userRegistrations, err := gameRegistrationService.GetUserGameActivityRegistrationsTimeRange(uint(userId), startDate, endDate)
 
 	if err != nil {
 		return utils.WriteJSON(res, 400, err.Error())
@@ -138,7 +130,7 @@ func handleCreateBookActivityRegistration(res http.ResponseWriter, req *http.Req
 		return utils.WriteJSON(res, 400, validationErrs)
 	}
 
-	savedBookRegistration, saveBookRegistrationErr := bookRegistrationService.CreateBookActivityRegistration(&entryBody)
+	savedBookRegistration, saveBookRegistrationErr := bookRegistrationService.CreateBookActivityRegistration(&entryBody, getAuditMetadata(req))
 
 	if saveBookRegistrationErr != nil {
 		return utils.WriteJSON(res, 400, saveBookRegistrationErr.Error())
@@ -166,7 +158,7 @@ func handleCreateGameActivityRegistration(res http.ResponseWriter, req *http.Req
 		return utils.WriteJSON(res, 400, validationErrs)
 	}
 
-	savedGameRegistration, saveGameRegistrationErr := gameRegistrationService.CreateGameActivityRegistration(&entryBody)
+	savedGameRegistration, saveGameRegistrationErr := gameRegistrationService.CreateGameActivityRegistration(&entryBody, getAuditMetadata(req))
 
 	if saveGameRegistrationErr != nil {
 		return utils.WriteJSON(res, 400, saveGameRegistrationErr.Error())
@@ -174,3 +166,233 @@ func handleCreateGameActivityRegistration(res http.ResponseWriter, req *http.Req
 
 	return utils.WriteJSON(res, 200, savedGameRegistration)
 }
+
+// @Summary		Get book activity registration
+// @Description	Get a book registration of the authenticated user. The ETag header holds its version, to be sent in the If-Match header of updates
+// @Tags			activity registrations
+// @Produce		json
+// @Param			id	path		int	true	"Book registration ID"
+// @Success		200	{object}	models.BookActivityRegistration
+// @Header			200	{string}	ETag	"Version of the book registration"
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/books/{id} [get]
+func handleGetBookActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	registration, err := bookRegistrationService.GetBookActivityRegistration(user.Id, uint(registrationId))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.Header().Set("ETag", utils.FormatVersionETag(registration.Registration.Version))
+	return utils.WriteJSON(res, 200, registration)
+}
+
+// @Summary		Get game activity registration
+// @Description	Get a game registration of the authenticated user. The ETag header holds its version, to be sent in the If-Match header of updates
+// @Tags			activity registrations
+// @Produce		json
+// @Param			id	path		int	true	"Game registration ID"
+// @Success		200	{object}	models.GameActivityRegistration
+// @Header			200	{string}	ETag	"Version of the game registration"
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/games/{id} [get]
+func handleGetGameActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	registration, err := gameRegistrationService.GetGameActivityRegistration(user.Id, uint(registrationId))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.Header().Set("ETag", utils.FormatVersionETag(registration.Registration.Version))
+	return utils.WriteJSON(res, 200, registration)
+}
+
+// @Summary		Partially update book activity registration
+// @Description	Update only the fields of a book registration of the authenticated user present in a JSON Merge Patch (RFC 7396) document.
+// @Description	When the If-Match header is sent, updates based on an outdated version respond 412 with the current version of the registration
+// @Tags			activity registrations
+// @Accept			application/merge-patch+json
+// @Produce		json
+// @Param			id			path		int											true	"Book registration ID"
+// @Param			If-Match	header		string										false	"ETag of the updated version"
+// @Param			body		body		services.UpdateBookActivityRegistrationBody	true	"Book registration fields to update"
+// @Success		200			{object}	models.BookActivityRegistration
+// @Header			200			{string}	ETag	"Version of the book registration"
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Failure		412			{object}	models.PreconditionFailedHttpError
+// @Failure		415			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/books/{id} [patch]
+func handlePatchBookActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	expectedVersion, _, ifMatchErr := utils.ParseIfMatchVersion(req)
+
+	if ifMatchErr != nil {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: ifMatchErr.Error()})
+	}
+
+	patch, patchRead, readErr := readMergePatch(res, req)
+
+	if !patchRead {
+		return readErr
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	patchedRegistration, patchErr := bookRegistrationService.PatchBookActivityRegistration(user.Id, uint(registrationId), patch, expectedVersion, getAuditMetadata(req))
+
+	var conflictErr *models.DbVersionConflictError
+	if errors.As(patchErr, &conflictErr) {
+		var current interface{}
+		if currentRegistration, getErr := bookRegistrationService.GetBookActivityRegistration(user.Id, uint(registrationId)); getErr == nil {
+			current = currentRegistration
+		}
+		return writePreconditionFailed(res, conflictErr, current)
+	}
+
+	if patchErr != nil {
+		return writeMergePatchError(res, patchErr)
+	}
+
+	res.Header().Set("ETag", utils.FormatVersionETag(patchedRegistration.Registration.Version))
+	return utils.WriteJSON(res, 200, patchedRegistration)
+}
+
+// @Summary		Partially update game activity registration
+// @Description	Update only the fields of a game registration of the authenticated user present in a JSON Merge Patch (RFC 7396) document.
+// @Description	When the If-Match header is sent, updates based on an outdated version respond 412 with the current version of the registration
+// @Tags			activity registrations
+// @Accept			application/merge-patch+json
+// @Produce		json
+// @Param			id			path		int											true	"Game registration ID"
+// @Param			If-Match	header		string										false	"ETag of the updated version"
+// @Param			body		body		services.UpdateGameActivityRegistrationBody	true	"Game registration fields to update"
+// @Success		200			{object}	models.GameActivityRegistration
+// @Header			200			{string}	ETag	"Version of the game registration"
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Failure		412			{object}	models.PreconditionFailedHttpError
+// @Failure		415			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/games/{id} [patch]
+func handlePatchGameActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	expectedVersion, _, ifMatchErr := utils.ParseIfMatchVersion(req)
+
+	if ifMatchErr != nil {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: ifMatchErr.Error()})
+	}
+
+	patch, patchRead, readErr := readMergePatch(res, req)
+
+	if !patchRead {
+		return readErr
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	patchedRegistration, patchErr := gameRegistrationService.PatchGameActivityRegistration(user.Id, uint(registrationId), patch, expectedVersion, getAuditMetadata(req))
+
+	var conflictErr *models.DbVersionConflictError
+	if errors.As(patchErr, &conflictErr) {
+		var current interface{}
+		if currentRegistration, getErr := gameRegistrationService.GetGameActivityRegistration(user.Id, uint(registrationId)); getErr == nil {
+			current = currentRegistration
+		}
+		return writePreconditionFailed(res, conflictErr, current)
+	}
+
+	if patchErr != nil {
+		return writeMergePatchError(res, patchErr)
+	}
+
+	res.Header().Set("ETag", utils.FormatVersionETag(patchedRegistration.Registration.Version))
+	return utils.WriteJSON(res, 200, patchedRegistration)
+}
+
+// @Summary		Delete book activity registration
+// @Description	Move a book registration of the authenticated user to the trash, from where it can be restored until the trash is emptied
+// @Tags			activity registrations
+// @Param			id	path	int	true	"Book registration ID"
+// @Success		204
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/books/{id} [delete]
+func handleDeleteBookActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	deleteErr := bookRegistrationService.DeleteBookActivityRegistration(user.Id, uint(registrationId), getAuditMetadata(req))
+
+	if deleteErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(deleteErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary		Delete game activity registration
+// @Description	Move a game registration of the authenticated user to the trash, from where it can be restored until the trash is emptied
+// @Tags			activity registrations
+// @Param			id	path	int	true	"Game registration ID"
+// @Success		204
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/games/{id} [delete]
+func handleDeleteGameActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	deleteErr := gameRegistrationService.DeleteGameActivityRegistration(user.Id, uint(registrationId), getAuditMetadata(req))
+
+	if deleteErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(deleteErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
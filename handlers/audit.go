@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/constants"
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitAuditRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/admin/auditEvents", utils.ParseToHandlerFunc(handleGetAuditEvents)).Methods("GET")
+}
+
+var auditService services.AuditService = &services.AuditServiceImpl{}
+
+// @Summary		Get audit events
+// @Description	Get the audit events matching the filters, most recent first. Only available to admins
+// @Tags			admin
+// @Accept			json
+// @Produce		json
+// @Param			actorId		query		int		false	"Id of the user who performed the operation"
+// @Param			eventType	query		string	false	"Event type, like diary_entry.updated"
+// @Param			targetType	query		string	false	"Type of the affected resource, like diary_entry"
+// @Param			targetId	query		int		false	"Id of the affected resource"
+// @Param			start_date	query		string	false	"Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			end_date	query		string	false	"End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			date		query		string	false	"Calendar date like 2026-10-17, instead of start_date and end_date"
+// @Param			page		query		int		false	"Page number, starting at 1"
+// @Param			pageSize	query		int		false	"Page size, 200 at most"
+// @Success		200			{object}	services.AuditEventPage
+// @Failure		400			{object}	models.HttpError
+// @Failure		500			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/admin/auditEvents [get]
+func handleGetAuditEvents(res http.ResponseWriter, req *http.Request) error {
+	queryParams := req.URL.Query()
+	auditEventQuery := &services.AuditEventQuery{
+		EventType:  models.AuditEventType(queryParams.Get("eventType")),
+		TargetType: queryParams.Get("targetType"),
+	}
+
+	startDate, endDate, dateErr := parseDateRangeQueryParams(req)
+
+	if dateErr != nil {
+		return utils.WriteJSON(res, dateErr.Status, dateErr)
+	}
+
+	auditEventQuery.StartDate = startDate
+	auditEventQuery.EndDate = endDate
+
+	intQueryParams := map[string]func(value int64){
+		"actorId":  func(value int64) { auditEventQuery.ActorId = uint(value) },
+		"targetId": func(value int64) { auditEventQuery.TargetId = uint(value) },
+		"page":     func(value int64) { auditEventQuery.Page = int(value) },
+		"pageSize": func(value int64) { auditEventQuery.PageSize = int(value) },
+	}
+
+	for queryParam, setValue := range intQueryParams {
+		value, parseErr := parseOptionalIntQueryParam(queryParams, queryParam)
+
+		if parseErr != nil {
+			return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, queryParam)})
+		}
+		setValue(value)
+	}
+
+	auditEventPage, err := auditService.GetAuditEvents(auditEventQuery)
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, auditEventPage)
+}
+
+// parseOptionalIntQueryParam returns the non-negative integer value of a query parameter, or 0 if it is not provided.
+func parseOptionalIntQueryParam(queryParams url.Values, queryParam string) (int64, error) {
+	valueString := queryParams.Get(queryParam)
+
+	if len(valueString) == 0 {
+		return 0, nil
+	}
+
+	value, parseErr := strconv.ParseInt(valueString, 10, 64)
+
+	if parseErr != nil || value < 0 {
+		return 0, fmt.Errorf("invalid value for query parameter %s", queryParam)
+	}
+
+	return value, nil
+}
@@ -1,9 +1,9 @@
 package handlers
 
 import (
-	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/adfer-dev/analock-api/auth"
 	"github.com/adfer-dev/analock-api/models"
@@ -26,7 +26,8 @@ var authService *services.AuthService = services.NewAuthService(
 )
 
 // @Summary		Authenticate user
-// @Description	Authenticates a user and returns access and refresh tokens
+// @Description	Authenticates a user and returns access and refresh tokens. When the refresh cookie is enabled, the refresh token
+// @Description	is only sent in the HttpOnly refresh cookie, and the response holds the CSRF token instead
 // @Tags			auth
 // @Accept			json
 // @Produce		json
@@ -44,46 +45,77 @@ func handleAuthenticateUser(res http.ResponseWriter, req *http.Request) error {
 		return utils.WriteJSON(res, 400, validationErrs)
 	}
 
-	accessToken, refreshToken, authErr := authService.AuthenticateUser(authenticateBody)
+	accessToken, refreshToken, authErr := authService.AuthenticateUser(authenticateBody, getAuditMetadata(req))
 
 	if authErr != nil {
 		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: "Error happenned when authenticating user. Please, try again."})
 	}
 
+	if !auth.GetRefreshCookieConfig().Enabled {
+		return utils.WriteJSON(res, 200, services.TokenResponse{AccessToken: accessToken.TokenValue, RefreshToken: refreshToken.TokenValue})
+	}
+
 	claims, claimsErr := authService.AppTokenManager.GetClaims(refreshToken.TokenValue)
 
 	if claimsErr != nil {
 		return claimsErr
 	}
-	res.Header().Add("Set-Cookie", fmt.Sprintf("refreshToken=%s; Expires=%d; HttpOnly", refreshToken.TokenValue, int64(claims["exp"].(float64))))
-	return utils.WriteJSON(res, 200,
-		services.TokenResponse{AccessToken: accessToken.TokenValue, RefreshToken: refreshToken.TokenValue})
+
+	csrfToken, csrfErr := auth.GenerateCsrfToken()
+
+	if csrfErr != nil {
+		return csrfErr
+	}
+
+	// the refresh token is only readable by the browser, not by page scripts
+	refreshTokenExpiration := time.Unix(int64(claims["exp"].(float64)), 0)
+	http.SetCookie(res, auth.NewRefreshCookie(refreshToken.TokenValue, refreshTokenExpiration))
+	http.SetCookie(res, auth.NewCsrfCookie(csrfToken, refreshTokenExpiration))
+
+	return utils.WriteJSON(res, 200, services.TokenResponse{AccessToken: accessToken.TokenValue, CsrfToken: csrfToken})
 }
 
 // @Summary		Refresh access token
-// @Description	Refreshes the access token using a refresh token
+// @Description	Refreshes the access token using a refresh token. The refresh token is read from the request body or,
+// @Description	when the refresh cookie is enabled, only from the refresh cookie. Cookie requests must send the CSRF token
+// @Description	received at authentication in the X-CSRF-Token header.
 // @Tags			auth
 // @Accept			json
 // @Produce		json
-// @Param			body	body		services.RefreshTokenRequest	true	"Refresh token request"
-// @Success		200		{object}	services.TokenResponse
-// @Failure		403		{object}	models.HttpError
+// @Param			body			body		services.RefreshTokenRequest	false	"Refresh token request"
+// @Param			X-CSRF-Token	header		string							false	"CSRF token, required when using the refresh cookie"
+// @Success		200				{object}	services.RefreshTokenResponse
+// @Failure		403				{object}	models.HttpError
 // @Router			/auth/refreshToken [post]
 func handleRefreshToken(res http.ResponseWriter, req *http.Request) error {
-	authenticateBody := services.RefreshTokenRequest{}
+	refreshTokenBody := services.RefreshTokenRequest{}
 
-	validationErrs := utils.HandleValidation(req, &authenticateBody)
+	if auth.GetRefreshCookieConfig().Enabled {
+		cookieRefreshToken, cookieErr := auth.GetRefreshTokenFromCookie(req)
 
-	if len(validationErrs) > 0 {
-		return utils.WriteJSON(res, 403, validationErrs)
+		if cookieErr != nil {
+			return utils.WriteJSON(res, 403, models.HttpError{Status: http.StatusForbidden, Description: cookieErr.Error()})
+		}
+
+		refreshTokenBody.RefreshToken = cookieRefreshToken
+	} else {
+		validationErrs := utils.HandleValidation(req, &refreshTokenBody)
+
+		if len(validationErrs) > 0 {
+			return utils.WriteJSON(res, 403, validationErrs)
+		}
+
+		if len(refreshTokenBody.RefreshToken) == 0 {
+			return utils.WriteJSON(res, 403, models.HttpError{Status: http.StatusForbidden, Description: "refresh token must be provided in the request body"})
+		}
 	}
 
-	newAccessToken, refreshTokenErr := authService.RefreshToken(authenticateBody)
+	newAccessToken, refreshTokenErr := authService.RefreshToken(refreshTokenBody, getAuditMetadata(req))
 
 	log.Println(refreshTokenErr)
 
 	if refreshTokenErr != nil {
-		return utils.WriteJSON(res, 403, refreshTokenErr)
+		return utils.WriteJSON(res, 403, models.HttpError{Status: http.StatusForbidden, Description: refreshTokenErr.Error()})
 	}
 
 	return utils.WriteJSON(res, 200, newAccessToken)
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitCalendarRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/me/calendar", utils.ParseToHandlerFunc(handleGetCurrentUserCalendar)).Methods("GET")
+}
+
+var calendarService services.CalendarService = &services.CalendarServiceImpl{}
+
+// @Summary		Get current user calendar month
+// @Description	Get every day of a month of the authenticated user, with the number of diary entries, books and games registered
+// @Description	and the first three of each as summaries. Days are in the time zone of the user
+// @Tags			calendar
+// @Produce		json
+// @Param			month	query		string	false	"Month like 2026-10, the current one by default"
+// @Success		200		{object}	models.CalendarMonth
+// @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		500		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/calendar [get]
+func handleGetCurrentUserCalendar(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	calendarMonth, err := calendarService.GetCalendarMonth(user, req.URL.Query().Get("month"))
+
+	if errors.Is(err, services.ErrInvalidCalendarMonth) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, calendarMonth)
+}
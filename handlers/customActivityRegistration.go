@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+var customRegistrationService services.CustomActivityRegistrationService = &services.CustomActivityRegistrationServiceImpl{}
+
+// InitCustomActivityRegistrationRoutes registers the generic endpoints of the declared activity types.
+// They must be registered after the book and game endpoints, whose paths they would also match.
+func InitCustomActivityRegistrationRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/activityTypes", utils.ParseToHandlerFunc(handleGetActivityTypes)).Methods("GET")
+	router.HandleFunc("/api/v1/activityRegistrations/{type:[a-z][a-z0-9_]*}", utils.ParseToHandlerFunc(handleGetUserCustomActivityRegistrations)).Methods("GET")
+	router.HandleFunc("/api/v1/activityRegistrations/{type:[a-z][a-z0-9_]*}", utils.ParseToHandlerFunc(handleCreateCustomActivityRegistration)).Methods("POST")
+	router.HandleFunc("/api/v1/activityRegistrations/{type:[a-z][a-z0-9_]*}/{id:[0-9]+}", utils.ParseToHandlerFunc(handleGetCustomActivityRegistration)).Methods("GET")
+	router.HandleFunc("/api/v1/activityRegistrations/{type:[a-z][a-z0-9_]*}/{id:[0-9]+}", utils.ParseToHandlerFunc(handlePatchCustomActivityRegistration)).Methods("PATCH")
+	router.HandleFunc("/api/v1/activityRegistrations/{type:[a-z][a-z0-9_]*}/{id:[0-9]+}", utils.ParseToHandlerFunc(handleDeleteCustomActivityRegistration)).Methods("DELETE")
+}
+
+// @Summary		Get activity types
+// @Description	Get the declared activity types, like walks or meditation, with the JSON schema their registration payloads must match
+// @Tags			activity registrations
+// @Produce		json
+// @Success		200	{array}	models.ActivityTypeDefinition
+// @Security		BearerAuth
+// @Router			/activityTypes [get]
+func handleGetActivityTypes(res http.ResponseWriter, req *http.Request) error {
+	return utils.WriteJSON(res, 200, services.GetActivityTypes())
+}
+
+// @Summary		Get custom activity registrations
+// @Description	Get the registrations of a declared activity type of the authenticated user, oldest first, optionally filtered by a date range.
+// @Description	Calendar dates are days in the time zone of the authenticated user
+// @Tags			activity registrations
+// @Produce		json
+// @Param			type		path		string	true	"Activity type name, like walk"
+// @Param			start_date	query		string	false	"Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			end_date	query		string	false	"End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			date		query		string	false	"Calendar date like 2026-10-17, instead of start_date and end_date"
+// @Success		200			{array}		models.CustomActivityRegistration
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/{type} [get]
+func handleGetUserCustomActivityRegistrations(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	startDate, endDate, dateErr := parseDateRangeQueryParams(req)
+
+	if dateErr != nil {
+		return utils.WriteJSON(res, dateErr.Status, dateErr)
+	}
+
+	userRegistrations, err := customRegistrationService.GetUserCustomActivityRegistrations(user.Id, mux.Vars(req)["type"], startDate, endDate)
+
+	if err != nil {
+		return writeCustomActivityRegistrationError(res, err)
+	}
+
+	return utils.WriteJSON(res, 200, userRegistrations)
+}
+
+// @Summary		Create custom activity registration
+// @Description	Register an activity of a declared type for the authenticated user. The payload must match the schema of the activity type
+// @Tags			activity registrations
+// @Accept			json
+// @Produce		json
+// @Param			type	path		string										true	"Activity type name, like walk"
+// @Param			body	body		services.AddCustomActivityRegistrationBody	true	"Registration date and payload"
+// @Success		201		{object}	models.CustomActivityRegistration
+// @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		404		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/{type} [post]
+func handleCreateCustomActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	registrationBody := services.AddCustomActivityRegistrationBody{}
+
+	validationErrs := utils.HandleValidation(req, &registrationBody)
+
+	if len(validationErrs) > 0 {
+		return utils.WriteJSON(res, 400, validationErrs)
+	}
+
+	savedRegistration, err := customRegistrationService.CreateCustomActivityRegistration(user.Id, mux.Vars(req)["type"], &registrationBody, getAuditMetadata(req))
+
+	if err != nil {
+		return writeCustomActivityRegistrationError(res, err)
+	}
+
+	return utils.WriteJSON(res, 201, savedRegistration)
+}
+
+// @Summary		Get custom activity registration
+// @Description	Get a registration of a declared activity type of the authenticated user. The ETag header holds its version, to be sent in the If-Match header of updates
+// @Tags			activity registrations
+// @Produce		json
+// @Param			type	path		string	true	"Activity type name, like walk"
+// @Param			id		path		int		true	"Custom activity registration ID"
+// @Success		200		{object}	models.CustomActivityRegistration
+// @Header			200		{string}	ETag	"Version of the registration"
+// @Failure		401		{object}	models.HttpError
+// @Failure		404		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/{type}/{id} [get]
+func handleGetCustomActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	registration, err := customRegistrationService.GetCustomActivityRegistration(user.Id, mux.Vars(req)["type"], uint(registrationId))
+
+	if err != nil {
+		return writeCustomActivityRegistrationError(res, err)
+	}
+
+	res.Header().Set("ETag", utils.FormatVersionETag(registration.Registration.Version))
+	return utils.WriteJSON(res, 200, registration)
+}
+
+// @Summary		Partially update custom activity registration
+// @Description	Update only the fields of a registration of a declared activity type present in a JSON Merge Patch (RFC 7396) document.
+// @Description	Members of the payload are merged one by one, and the merged payload must match the schema of the activity type.
+// @Description	When the If-Match header is sent, updates based on an outdated version respond 412 with the current version of the registration
+// @Tags			activity registrations
+// @Accept			application/merge-patch+json
+// @Produce		json
+// @Param			type		path		string											true	"Activity type name, like walk"
+// @Param			id			path		int												true	"Custom activity registration ID"
+// @Param			If-Match	header		string											false	"ETag of the updated version"
+// @Param			body		body		services.UpdateCustomActivityRegistrationBody	true	"Registration fields to update"
+// @Success		200			{object}	models.CustomActivityRegistration
+// @Header			200			{string}	ETag	"Version of the registration"
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Failure		412			{object}	models.PreconditionFailedHttpError
+// @Failure		415			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/{type}/{id} [patch]
+func handlePatchCustomActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	expectedVersion, _, ifMatchErr := utils.ParseIfMatchVersion(req)
+
+	if ifMatchErr != nil {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: ifMatchErr.Error()})
+	}
+
+	patch, patchRead, readErr := readMergePatch(res, req)
+
+	if !patchRead {
+		return readErr
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	patchedRegistration, patchErr := customRegistrationService.PatchCustomActivityRegistration(user.Id, mux.Vars(req)["type"], uint(registrationId),
+		patch, expectedVersion, getAuditMetadata(req))
+
+	var conflictErr *models.DbVersionConflictError
+	if errors.As(patchErr, &conflictErr) {
+		var current interface{}
+		if currentRegistration, getErr := customRegistrationService.GetCustomActivityRegistration(user.Id, mux.Vars(req)["type"], uint(registrationId)); getErr == nil {
+			current = currentRegistration
+		}
+		return writePreconditionFailed(res, conflictErr, current)
+	}
+
+	if patchErr != nil {
+		return writeCustomActivityRegistrationError(res, patchErr)
+	}
+
+	res.Header().Set("ETag", utils.FormatVersionETag(patchedRegistration.Registration.Version))
+	return utils.WriteJSON(res, 200, patchedRegistration)
+}
+
+// @Summary		Delete custom activity registration
+// @Description	Move a registration of a declared activity type of the authenticated user to the trash, from where it can be restored until the trash is emptied
+// @Tags			activity registrations
+// @Param			type	path	string	true	"Activity type name, like walk"
+// @Param			id		path	int		true	"Custom activity registration ID"
+// @Success		204
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/{type}/{id} [delete]
+func handleDeleteCustomActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	deleteErr := customRegistrationService.DeleteCustomActivityRegistration(user.Id, mux.Vars(req)["type"], uint(registrationId), getAuditMetadata(req))
+
+	if deleteErr != nil {
+		return writeCustomActivityRegistrationError(res, deleteErr)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// writeCustomActivityRegistrationError responds 404 to undeclared activity types and 400 to payloads not matching their schema.
+func writeCustomActivityRegistrationError(res http.ResponseWriter, err error) error {
+	if errors.Is(err, services.ErrUnknownActivityType) {
+		return utils.WriteJSON(res, 404, models.HttpError{Status: http.StatusNotFound, Description: err.Error()})
+	}
+
+	var schemaErr *utils.JSONSchemaValidationError
+	if errors.As(err, &schemaErr) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: "invalid payload: " + schemaErr.Error()})
+	}
+
+	return writeMergePatchError(res, err)
+}
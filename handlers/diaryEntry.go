@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -16,69 +17,218 @@ var diaryEntryService services.DiaryEntryService = &services.DefaultDiaryEntrySe
 
 func InitDiaryEntryRoutes(router *mux.Router) {
 	router.HandleFunc("/api/v1/diaryEntries/user/{id:[0-9]+}", utils.ParseToHandlerFunc(handleGetUserEntries)).Methods("GET")
+	router.HandleFunc("/api/v1/diaryEntries/search", utils.ParseToHandlerFunc(handleSearchDiaryEntries)).Methods("GET")
 	router.HandleFunc("/api/v1/diaryEntries", utils.ParseToHandlerFunc(handleCreateDiaryEntry)).Methods("POST")
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}", utils.ParseToHandlerFunc(handleGetDiaryEntry)).Methods("GET")
 	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}", utils.ParseToHandlerFunc(handleUpdateDiaryEntry)).Methods("PUT")
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}", utils.ParseToHandlerFunc(handlePatchDiaryEntry)).Methods("PATCH")
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}", utils.ParseToHandlerFunc(handleDeleteDiaryEntry)).Methods("DELETE")
 }
 
 // @Summary		Get user diary entries
-// @Description	Get all diary entries for a user, optionally filtered by date range
+// @Description	Get a page of the diary entries of the authenticated user, sorted by registration date and optionally filtered by a date range.
+// @Description	Calendar dates are days in the time zone of the authenticated user.
+// @Description	The nextCursor of the response is passed in the cursor parameter to get the next page, and is not present on the last page
 // @Tags			diary entries
 // @Accept			json
 // @Produce		json
-// @Param			id			path		int	true	"User ID"
-// @Param			startDate	query		int	false	"Start date timestamp"
-// @Param			endDate		query		int	false	"End date timestamp"
-// @Success		200			{array}		models.DiaryEntry
+// @Param			id			path		int		true	"User ID"
+// @Param			start_date	query		string	false	"Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			end_date	query		string	false	"End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			date		query		string	false	"Calendar date like 2026-10-17, instead of start_date and end_date"
+// @Param			sort		query		string	false	"Sort by registration date, desc by default"	Enums(asc, desc)
+// @Param			limit		query		int		false	"Maximum number of entries, 50 by default and 200 at most"
+// @Param			cursor		query		string	false	"Cursor of the page, from the nextCursor of the previous page"
+// @Param			tag			query		[]string	false	"Only entries with all these tags"	collectionFormat(multi)
+// @Success		200			{object}	services.DiaryEntryPage
 // @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
 // @Failure		500			{object}	models.HttpError
 // @Security		BearerAuth
 // @Router			/diaryEntries/user/{id} [get]
 func handleGetUserEntries(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
 	userId, _ := strconv.Atoi(mux.Vars(req)["id"])
 
-	startDateString := req.URL.Query().Get(constants.StartDateQueryParam)
-	endDateString := req.URL.Query().Get(constants.EndDateQueryParam)
+	// the entries of other users are not disclosed
+	if uint(userId) != user.Id {
+		httpErr := utils.TranslateDbErrorToHttpError(&models.DbNotFoundError{DbItem: &models.DiaryEntry{}})
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
 
-	if len(startDateString) == 0 || len(endDateString) == 0 {
-		userDiaryEntries, err := diaryEntryService.GetUserEntries(uint(userId))
+	queryParams := req.URL.Query()
 
-		if err != nil {
-			return utils.WriteJSON(res, 500, err.Error())
-		}
+	pageQuery := &services.DiaryEntryPageQuery{
+		Sort:   queryParams.Get("sort"),
+		Cursor: queryParams.Get("cursor"),
+		Tags:   queryParams["tag"],
+	}
 
-		return utils.WriteJSON(res, 200, userDiaryEntries)
+	if len(pageQuery.Sort) > 0 && pageQuery.Sort != services.DiaryEntriesSortAscending && pageQuery.Sort != services.DiaryEntriesSortDescending {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, "sort")})
 	}
 
-	startDate, startDateErr := strconv.Atoi(startDateString)
+	startDate, endDate, dateErr := parseDateRangeQueryParams(req)
 
-	if startDateErr != nil {
-		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, constants.StartDateQueryParam)})
+	if dateErr != nil {
+		return utils.WriteJSON(res, dateErr.Status, dateErr)
 	}
-	endDate, endDateErr := strconv.Atoi(endDateString)
 
-	if endDateErr != nil {
-		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, constants.EndDateQueryParam)})
+	pageQuery.StartDate = startDate
+	pageQuery.EndDate = endDate
+
+	intQueryParams := map[string]func(value int64){
+		"limit": func(value int64) { pageQuery.Limit = int(value) },
 	}
-	dateIntervalUserDiaryEntries, err := diaryEntryService.GetUserEntriesTimeRange(uint(userId), int64(startDate), int64(endDate))
+
+	for queryParam, setValue := range intQueryParams {
+		value, parseErr := parseOptionalIntQueryParam(queryParams, queryParam)
+
+		if parseErr != nil {
+			return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, queryParam)})
+		}
+		setValue(value)
+	}
+
+	diaryEntryPage, err := diaryEntryService.GetUserEntriesPage(user.Id, pageQuery)
+
+	if errors.Is(err, services.ErrInvalidDiaryEntryCursor) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
 	if err != nil {
 		return utils.WriteJSON(res, 500, err.Error())
 	}
 
-	return utils.WriteJSON(res, 200, dateIntervalUserDiaryEntries)
+	return utils.WriteJSON(res, 200, diaryEntryPage)
+}
+
+// @Summary		Search diary entries
+// @Description	Full-text search over the title and content of the diary entries of the authenticated user, best matches first.
+// @Description	All words must match. Words ending with * match as prefixes, and text between double quotes matches as a phrase.
+// @Description	Snippets highlight the matched terms between <mark></mark>.
+// @Description	When diary content is encrypted at rest, users must enable the search index in their profile first
+// @Tags			diary entries
+// @Produce		json
+// @Param			q			query		string	true	"Search query"
+// @Param			start_date	query		string	false	"Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			end_date	query		string	false	"End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date"
+// @Param			date		query		string	false	"Calendar date like 2026-10-17, instead of start_date and end_date"
+// @Param			page		query		int		false	"Page number, starting at 1"
+// @Param			pageSize	query		int		false	"Page size, 20 by default and 100 at most"
+// @Success		200			{object}	services.DiaryEntrySearchPage
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		500			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/search [get]
+func handleSearchDiaryEntries(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	queryParams := req.URL.Query()
+	searchQuery := &services.DiaryEntrySearchQuery{Query: queryParams.Get("q")}
+
+	startDate, endDate, dateErr := parseDateRangeQueryParams(req)
+
+	if dateErr != nil {
+		return utils.WriteJSON(res, dateErr.Status, dateErr)
+	}
+
+	searchQuery.StartDate = startDate
+	searchQuery.EndDate = endDate
+
+	intQueryParams := map[string]func(value int64){
+		"page":     func(value int64) { searchQuery.Page = int(value) },
+		"pageSize": func(value int64) { searchQuery.PageSize = int(value) },
+	}
+
+	for queryParam, setValue := range intQueryParams {
+		value, parseErr := parseOptionalIntQueryParam(queryParams, queryParam)
+
+		if parseErr != nil {
+			return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, queryParam)})
+		}
+		setValue(value)
+	}
+
+	searchPage, err := diaryEntryService.SearchUserEntries(user.Id, searchQuery)
+
+	if errors.Is(err, services.ErrInvalidDiaryEntrySearchQuery) || errors.Is(err, services.ErrDiaryEntrySearchIndexDisabled) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, searchPage)
+}
+
+// @Summary		Get diary entry
+// @Description	Get a diary entry of the authenticated user. The ETag header holds its version, to be sent in the If-Match header of updates
+// @Tags			diary
+// @Produce		json
+// @Param			id	path		int	true	"Diary entry ID"
+// @Success		200	{object}	models.DiaryEntry
+// @Header			200	{string}	ETag	"Version of the diary entry"
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id} [get]
+func handleGetDiaryEntry(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	entry, err := diaryEntryService.GetDiaryEntryById(uint(entryId))
+
+	// the entries of other users are not disclosed
+	if err == nil && entry.Registration.UserRefer != user.Id {
+		err = &models.DbNotFoundError{DbItem: &models.DiaryEntry{}}
+	}
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.Header().Set("ETag", utils.FormatVersionETag(entry.Version))
+	return utils.WriteJSON(res, 200, entry)
 }
 
 // @Summary		Create diary entry
-// @Description	Create a new diary entry for a user
+// @Description	Create a new diary entry for the authenticated user, optionally answering a prompt
 // @Tags			diary
 // @Accept			json
 // @Produce		json
 // @Param			body	body		services.SaveDiaryEntryBody	true	"Diary entry information"
 // @Success		201		{object}	models.DiaryEntry
 // @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		404		{object}	models.HttpError
 // @Failure		500		{object}	models.HttpError
 // @Security		BearerAuth
 // @Router			/diaryEntries [post]
 func handleCreateDiaryEntry(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
 	entryBody := services.SaveDiaryEntryBody{}
 
 	validationErrs := utils.HandleValidation(req, &entryBody)
@@ -87,29 +237,65 @@ func handleCreateDiaryEntry(res http.ResponseWriter, req *http.Request) error {
 		return utils.WriteJSON(res, 400, validationErrs)
 	}
 
-	savedEntry, saveEntryErr := diaryEntryService.SaveDiaryEntry(&entryBody)
+	savedEntry, saveEntryErr := diaryEntryService.SaveDiaryEntry(user.Id, &entryBody, getAuditMetadata(req))
 
 	if saveEntryErr != nil {
-		return utils.WriteJSON(res, 500, saveEntryErr.Error())
+		httpErr := utils.TranslateDbErrorToHttpError(saveEntryErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
 	}
 
 	return utils.WriteJSON(res, 201, savedEntry)
 }
 
 // @Summary		Update diary entry
-// @Description	Update an existing diary entry
+// @Description	Update an existing diary entry of the authenticated user. The If-Match header must hold the ETag of the version the update is based on, or * to overwrite any version.
+// @Description	Updates based on an outdated version respond 412 with the current version of the entry
 // @Tags			diary
 // @Accept			json
 // @Produce		json
-// @Param			id		path		int								true	"Diary entry ID"
-// @Param			body	body		services.UpdateDiaryEntryBody	true	"Updated diary entry information"
-// @Success		200		{object}	models.DiaryEntry
-// @Failure		400		{object}	models.HttpError
-// @Failure		500		{object}	models.HttpError
+// @Param			id			path		int								true	"Diary entry ID"
+// @Param			If-Match	header		string							true	"ETag of the updated version"
+// @Param			body		body		services.UpdateDiaryEntryBody	true	"Updated diary entry information"
+// @Success		200			{object}	models.DiaryEntry
+// @Header			200			{string}	ETag	"Version of the diary entry"
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Failure		412			{object}	models.PreconditionFailedHttpError
+// @Failure		428			{object}	models.HttpError
+// @Failure		500			{object}	models.HttpError
 // @Security		BearerAuth
 // @Router			/diaryEntries/{id} [put]
 func handleUpdateDiaryEntry(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
 	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	expectedVersion, ifMatchPresent, ifMatchErr := utils.ParseIfMatchVersion(req)
+
+	if !ifMatchPresent {
+		return utils.WriteJSON(res, 428, models.HttpError{Status: http.StatusPreconditionRequired, Description: "the If-Match header is required"})
+	}
+
+	if ifMatchErr != nil {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: ifMatchErr.Error()})
+	}
+
+	entry, getEntryErr := diaryEntryService.GetDiaryEntryById(uint(entryId))
+
+	// the entries of other users are not disclosed, nor overwritten
+	if getEntryErr == nil && entry.Registration.UserRefer != user.Id {
+		getEntryErr = &models.DbNotFoundError{DbItem: &models.DiaryEntry{}}
+	}
+
+	if getEntryErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(getEntryErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
 	updateEntryBody := services.UpdateDiaryEntryBody{}
 
 	validationErrs := utils.HandleValidation(req, &updateEntryBody)
@@ -118,11 +304,123 @@ func handleUpdateDiaryEntry(res http.ResponseWriter, req *http.Request) error {
 		return utils.WriteJSON(res, 400, validationErrs)
 	}
 
-	updatedEntry, updateEntryErr := diaryEntryService.UpdateDiaryEntry(uint(entryId), &updateEntryBody)
+	updateEntryBody.ExpectedVersion = expectedVersion
+	updatedEntry, updateEntryErr := diaryEntryService.UpdateDiaryEntry(uint(entryId), &updateEntryBody, getAuditMetadata(req))
+
+	var conflictErr *models.DbVersionConflictError
+	if errors.As(updateEntryErr, &conflictErr) {
+		var current interface{}
+		if currentEntry, getErr := diaryEntryService.GetDiaryEntryById(uint(entryId)); getErr == nil {
+			current = currentEntry
+		}
+		return writePreconditionFailed(res, conflictErr, current)
+	}
+
+	var notFoundErr *models.DbNotFoundError
+	if errors.As(updateEntryErr, &notFoundErr) {
+		httpErr := utils.TranslateDbErrorToHttpError(updateEntryErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
 
 	if updateEntryErr != nil {
 		return utils.WriteJSON(res, 500, updateEntryErr.Error())
 	}
 
+	res.Header().Set("ETag", utils.FormatVersionETag(updatedEntry.Version))
 	return utils.WriteJSON(res, 200, updatedEntry)
 }
+
+// @Summary		Partially update diary entry
+// @Description	Update only the fields of a diary entry of the authenticated user present in a JSON Merge Patch (RFC 7396) document.
+// @Description	Fields set to null are removed. The merged entry is validated like the body of full updates.
+// @Description	When the If-Match header is sent, updates based on an outdated version respond 412 with the current version of the entry
+// @Tags			diary
+// @Accept			application/merge-patch+json
+// @Produce		json
+// @Param			id			path		int								true	"Diary entry ID"
+// @Param			If-Match	header		string							false	"ETag of the updated version"
+// @Param			body		body		services.UpdateDiaryEntryBody	true	"Diary entry fields to update"
+// @Success		200			{object}	models.DiaryEntry
+// @Header			200			{string}	ETag	"Version of the diary entry"
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Failure		412			{object}	models.PreconditionFailedHttpError
+// @Failure		415			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id} [patch]
+func handlePatchDiaryEntry(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	expectedVersion, _, ifMatchErr := utils.ParseIfMatchVersion(req)
+
+	if ifMatchErr != nil {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: ifMatchErr.Error()})
+	}
+
+	patch, patchRead, readErr := readMergePatch(res, req)
+
+	if !patchRead {
+		return readErr
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	patchedEntry, patchErr := diaryEntryService.PatchDiaryEntry(user.Id, uint(entryId), patch, expectedVersion, getAuditMetadata(req))
+
+	var conflictErr *models.DbVersionConflictError
+	if errors.As(patchErr, &conflictErr) {
+		var current interface{}
+		if currentEntry, getErr := diaryEntryService.GetDiaryEntryById(uint(entryId)); getErr == nil {
+			current = currentEntry
+		}
+		return writePreconditionFailed(res, conflictErr, current)
+	}
+
+	if patchErr != nil {
+		return writeMergePatchError(res, patchErr)
+	}
+
+	res.Header().Set("ETag", utils.FormatVersionETag(patchedEntry.Version))
+	return utils.WriteJSON(res, 200, patchedEntry)
+}
+
+// @Summary		Delete diary entry
+// @Description	Move a diary entry of the authenticated user to the trash, from where it can be restored until the trash is emptied
+// @Tags			diary
+// @Param			id	path	int	true	"Diary entry ID"
+// @Success		204
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id} [delete]
+func handleDeleteDiaryEntry(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	entry, err := diaryEntryService.GetDiaryEntryById(uint(entryId))
+
+	// the entries of other users are not disclosed
+	if err == nil && entry.Registration.UserRefer != user.Id {
+		err = &models.DbNotFoundError{DbItem: &models.DiaryEntry{}}
+	}
+
+	if err == nil {
+		err = diaryEntryService.DeleteDiaryEntry(uint(entryId), getAuditMetadata(req))
+	}
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+// maxAttachmentFormOverhead is the room left in upload requests for the multipart boundaries and headers.
+const maxAttachmentFormOverhead = 1 << 20
+
+func InitDiaryEntryAttachmentRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}/attachments", utils.ParseToHandlerFunc(handleGetDiaryEntryAttachments)).Methods("GET")
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}/attachments", utils.ParseToHandlerFunc(handleSaveDiaryEntryAttachment)).Methods("POST")
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}/attachments/{attachmentId:[0-9]+}/content", utils.ParseToHandlerFunc(handleGetDiaryEntryAttachmentContent)).Methods("GET")
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}/attachments/{attachmentId:[0-9]+}/thumbnail", utils.ParseToHandlerFunc(handleGetDiaryEntryAttachmentThumbnail)).Methods("GET")
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}/attachments/{attachmentId:[0-9]+}", utils.ParseToHandlerFunc(handleDeleteDiaryEntryAttachment)).Methods("DELETE")
+	router.HandleFunc("/api/v1/me/attachments/usage", utils.ParseToHandlerFunc(handleGetCurrentUserAttachmentUsage)).Methods("GET")
+}
+
+var diaryEntryAttachmentService services.DiaryEntryAttachmentService = services.NewDiaryEntryAttachmentServiceImpl(&services.DefaultDiaryEntryService{})
+
+// @Summary		Get diary entry attachments
+// @Description	Get the files attached to a diary entry of the authenticated user, oldest first
+// @Tags			diary
+// @Produce		json
+// @Param			id	path		int	true	"Diary entry ID"
+// @Success		200	{array}		models.DiaryEntryAttachment
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id}/attachments [get]
+func handleGetDiaryEntryAttachments(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	attachments, err := diaryEntryAttachmentService.GetDiaryEntryAttachments(user.Id, uint(entryId))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, attachments)
+}
+
+// @Summary		Attach file to diary entry
+// @Description	Upload a JPEG, PNG, GIF or WebP image to a diary entry of the authenticated user, up to 10 MiB.
+// @Description	The type is detected from the content. Thumbnails are generated for the images that can be decoded
+// @Tags			diary
+// @Accept			multipart/form-data
+// @Produce		json
+// @Param			id		path		int		true	"Diary entry ID"
+// @Param			file	formData	file	true	"Attached file"
+// @Success		201		{object}	models.DiaryEntryAttachment
+// @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		404		{object}	models.HttpError
+// @Failure		413		{object}	models.HttpError
+// @Failure		415		{object}	models.HttpError
+// @Failure		507		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id}/attachments [post]
+func handleSaveDiaryEntryAttachment(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	req.Body = http.MaxBytesReader(res, req.Body, services.MaxAttachmentSize+maxAttachmentFormOverhead)
+	file, fileHeader, formErr := req.FormFile("file")
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(formErr, &maxBytesErr) {
+		return utils.WriteJSON(res, 413, models.HttpError{Status: http.StatusRequestEntityTooLarge, Description: services.ErrAttachmentTooLarge.Error()})
+	}
+
+	if formErr != nil {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: "the multipart form field file is not provided"})
+	}
+
+	defer file.Close()
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	attachment, err := diaryEntryAttachmentService.SaveDiaryEntryAttachment(user.Id, uint(entryId), fileHeader.Filename, file, getAuditMetadata(req))
+
+	if err != nil {
+		return writeDiaryEntryAttachmentError(res, err)
+	}
+
+	return utils.WriteJSON(res, 201, attachment)
+}
+
+// @Summary		Get diary entry attachment content
+// @Description	Download a file attached to a diary entry of the authenticated user
+// @Tags			diary
+// @Produce		octet-stream
+// @Param			id				path		int	true	"Diary entry ID"
+// @Param			attachmentId	path		int	true	"Attachment ID"
+// @Success		200				{file}		file
+// @Failure		401				{object}	models.HttpError
+// @Failure		404				{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id}/attachments/{attachmentId}/content [get]
+func handleGetDiaryEntryAttachmentContent(res http.ResponseWriter, req *http.Request) error {
+	return writeDiaryEntryAttachmentContent(res, req, false)
+}
+
+// @Summary		Get diary entry attachment thumbnail
+// @Description	Download the JPEG thumbnail of an image attached to a diary entry of the authenticated user.
+// @Description	Attachments without thumbnail respond 404
+// @Tags			diary
+// @Produce		jpeg
+// @Param			id				path		int	true	"Diary entry ID"
+// @Param			attachmentId	path		int	true	"Attachment ID"
+// @Success		200				{file}		file
+// @Failure		401				{object}	models.HttpError
+// @Failure		404				{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id}/attachments/{attachmentId}/thumbnail [get]
+func handleGetDiaryEntryAttachmentThumbnail(res http.ResponseWriter, req *http.Request) error {
+	return writeDiaryEntryAttachmentContent(res, req, true)
+}
+
+// @Summary		Delete diary entry attachment
+// @Description	Delete a file attached to a diary entry of the authenticated user
+// @Tags			diary
+// @Param			id				path	int	true	"Diary entry ID"
+// @Param			attachmentId	path	int	true	"Attachment ID"
+// @Success		204
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id}/attachments/{attachmentId} [delete]
+func handleDeleteDiaryEntryAttachment(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	attachmentId, _ := strconv.Atoi(mux.Vars(req)["attachmentId"])
+	deleteErr := diaryEntryAttachmentService.DeleteDiaryEntryAttachment(user.Id, uint(entryId), uint(attachmentId), getAuditMetadata(req))
+
+	if deleteErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(deleteErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary		Get current user attachment usage
+// @Description	Get the bytes taken by the attachments of the authenticated user and their storage quota
+// @Tags			diary
+// @Produce		json
+// @Success		200	{object}	services.AttachmentUsage
+// @Failure		401	{object}	models.HttpError
+// @Failure		500	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/attachments/usage [get]
+func handleGetCurrentUserAttachmentUsage(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	attachmentUsage, err := diaryEntryAttachmentService.GetUserAttachmentUsage(user.Id)
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, attachmentUsage)
+}
+
+// writeDiaryEntryAttachmentContent streams the content or the thumbnail of an attachment.
+func writeDiaryEntryAttachmentContent(res http.ResponseWriter, req *http.Request, thumbnail bool) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	attachmentId, _ := strconv.Atoi(mux.Vars(req)["attachmentId"])
+	attachment, content, err := diaryEntryAttachmentService.GetDiaryEntryAttachmentContent(user.Id, uint(entryId), uint(attachmentId), thumbnail)
+
+	if err != nil {
+		return writeDiaryEntryAttachmentError(res, err)
+	}
+
+	defer content.Close()
+
+	contentType := attachment.ContentType
+
+	if thumbnail {
+		contentType = services.AttachmentThumbnailContentType
+	}
+
+	res.Header().Set("Content-Type", contentType)
+	res.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName}))
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	res.Header().Set("Cache-Control", "private, max-age=86400")
+	res.WriteHeader(200)
+
+	// once the body has started an error can only be logged
+	if _, copyErr := io.Copy(res, content); copyErr != nil {
+		handlersLogger.ErrorLogger.Printf("error when sending attachment %d: %s", attachment.Id, copyErr.Error())
+	}
+
+	return nil
+}
+
+func writeDiaryEntryAttachmentError(res http.ResponseWriter, err error) error {
+	switch {
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		return utils.WriteJSON(res, 413, models.HttpError{Status: http.StatusRequestEntityTooLarge, Description: err.Error()})
+	case errors.Is(err, services.ErrUnsupportedAttachmentType):
+		return utils.WriteJSON(res, 415, models.HttpError{Status: http.StatusUnsupportedMediaType, Description: err.Error()})
+	case errors.Is(err, services.ErrAttachmentQuotaExceeded):
+		return utils.WriteJSON(res, 507, models.HttpError{Status: http.StatusInsufficientStorage, Description: err.Error()})
+	}
+
+	httpErr := utils.TranslateDbErrorToHttpError(err)
+	return utils.WriteJSON(res, httpErr.Status, httpErr)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/constants"
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitDiaryEntryRevisionRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}/revisions", utils.ParseToHandlerFunc(handleGetDiaryEntryRevisions)).Methods("GET")
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}/revisions/diff", utils.ParseToHandlerFunc(handleDiffDiaryEntryRevisions)).Methods("GET")
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}/revisions/{revisionId:[0-9]+}", utils.ParseToHandlerFunc(handleGetDiaryEntryRevision)).Methods("GET")
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}/revisions/{revisionId:[0-9]+}/restore", utils.ParseToHandlerFunc(handleRestoreDiaryEntryRevision)).Methods("POST")
+}
+
+var diaryEntryRevisionService services.DiaryEntryRevisionService = services.NewDiaryEntryRevisionServiceImpl(&services.DefaultDiaryEntryService{})
+
+// @Summary		Get diary entry revisions
+// @Description	Get the previous versions of a diary entry of the authenticated user, newest first
+// @Tags			diary
+// @Produce		json
+// @Param			id	path		int	true	"Diary entry ID"
+// @Success		200	{array}		models.DiaryEntryRevision
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id}/revisions [get]
+func handleGetDiaryEntryRevisions(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	revisions, err := diaryEntryRevisionService.GetDiaryEntryRevisions(user.Id, uint(entryId))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, revisions)
+}
+
+// @Summary		Get diary entry revision
+// @Description	Get a previous version of a diary entry of the authenticated user
+// @Tags			diary
+// @Produce		json
+// @Param			id			path		int	true	"Diary entry ID"
+// @Param			revisionId	path		int	true	"Revision ID"
+// @Success		200			{object}	models.DiaryEntryRevision
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id}/revisions/{revisionId} [get]
+func handleGetDiaryEntryRevision(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	revisionId, _ := strconv.Atoi(mux.Vars(req)["revisionId"])
+	revision, err := diaryEntryRevisionService.GetDiaryEntryRevision(user.Id, uint(entryId), uint(revisionId))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, revision)
+}
+
+// @Summary		Diff diary entry revisions
+// @Description	Compare line by line the title and content of two versions of a diary entry of the authenticated user.
+// @Description	Encrypted versions can only be compared by the client
+// @Tags			diary
+// @Produce		json
+// @Param			id		path		int	true	"Diary entry ID"
+// @Param			from	query		int	true	"Revision ID of the old version"
+// @Param			to		query		int	false	"Revision ID of the new version, the current version by default"
+// @Success		200		{object}	services.DiaryEntryRevisionDiff
+// @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		404		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id}/revisions/diff [get]
+func handleDiffDiaryEntryRevisions(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	queryParams := req.URL.Query()
+	fromRevisionId, fromErr := parseOptionalIntQueryParam(queryParams, "from")
+
+	if fromErr != nil || fromRevisionId <= 0 {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, "from")})
+	}
+
+	toRevisionId, toErr := parseOptionalIntQueryParam(queryParams, "to")
+
+	if toErr != nil || toRevisionId < 0 {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, "to")})
+	}
+
+	revisionDiff, err := diaryEntryRevisionService.DiffDiaryEntryRevisions(user.Id, uint(entryId), uint(fromRevisionId), uint(toRevisionId))
+
+	if errors.Is(err, services.ErrEncryptedDiaryEntryDiff) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, revisionDiff)
+}
+
+// @Summary		Restore diary entry revision
+// @Description	Replace a diary entry of the authenticated user with a previous version.
+// @Description	The replaced version is stored as a new revision
+// @Tags			diary
+// @Produce		json
+// @Param			id			path		int	true	"Diary entry ID"
+// @Param			revisionId	path		int	true	"Revision ID"
+// @Success		200			{object}	models.DiaryEntry
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id}/revisions/{revisionId}/restore [post]
+func handleRestoreDiaryEntryRevision(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	revisionId, _ := strconv.Atoi(mux.Vars(req)["revisionId"])
+	restoredEntry, err := diaryEntryRevisionService.RestoreDiaryEntryRevision(user.Id, uint(entryId), uint(revisionId), getAuditMetadata(req))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, restoredEntry)
+}
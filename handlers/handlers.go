@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/adfer-dev/analock-api/constants"
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+)
+
+var handlersLogger *utils.CustomLogger = utils.GetCustomLogger()
+
+// getAuditMetadata returns the audit metadata of the request: the authenticated user, if any, and the client.
+func getAuditMetadata(req *http.Request) *services.AuditMetadata {
+	auditMetadata := &services.AuditMetadata{IP: utils.GetClientIP(req), UserAgent: req.UserAgent()}
+
+	if user, userPresent := utils.GetRequestUser(req); userPresent {
+		auditMetadata.ActorId = user.Id
+	}
+
+	return auditMetadata
+}
+
+// parseDateRangeQueryParams reads the inclusive date range of the start_date and end_date query parameters,
+// or the whole calendar day of the date parameter. Calendar dates are read in the time zone of the authenticated user.
+// Bounds not provided are 0.
+func parseDateRangeQueryParams(req *http.Request) (int64, int64, *models.HttpError) {
+	queryParams := req.URL.Query()
+	location := time.UTC
+
+	if user, userPresent := utils.GetRequestUser(req); userPresent {
+		location = services.GetUserLocation(user)
+	}
+
+	if date := queryParams.Get(constants.DateQueryParam); len(date) > 0 {
+		if queryParams.Has(constants.StartDateQueryParam) || queryParams.Has(constants.EndDateQueryParam) {
+			return 0, 0, &models.HttpError{Status: http.StatusBadRequest, Description: "the date query parameter cannot be combined with start_date or end_date"}
+		}
+
+		startDate, endDate, err := services.GetLocalDayRange(date, location)
+
+		if err != nil {
+			return 0, 0, &models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, constants.DateQueryParam)}
+		}
+
+		return startDate, endDate, nil
+	}
+
+	startDate, startDateErr := parseOptionalDateQueryParam(queryParams, constants.StartDateQueryParam, location, false)
+
+	if startDateErr != nil {
+		return 0, 0, startDateErr
+	}
+
+	endDate, endDateErr := parseOptionalDateQueryParam(queryParams, constants.EndDateQueryParam, location, true)
+
+	if endDateErr != nil {
+		return 0, 0, endDateErr
+	}
+
+	return startDate, endDate, nil
+}
+
+// parseRequiredDateRangeQueryParams reads the date range like parseDateRangeQueryParams, but both bounds
+// must be provided unless the date parameter is.
+func parseRequiredDateRangeQueryParams(req *http.Request) (int64, int64, *models.HttpError) {
+	queryParams := req.URL.Query()
+	startDate, endDate, dateErr := parseDateRangeQueryParams(req)
+
+	if dateErr != nil {
+		return 0, 0, dateErr
+	}
+
+	if !queryParams.Has(constants.DateQueryParam) {
+		for _, queryParam := range []string{constants.StartDateQueryParam, constants.EndDateQueryParam} {
+			if len(queryParams.Get(queryParam)) == 0 {
+				return 0, 0, &models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, queryParam)}
+			}
+		}
+	}
+
+	return startDate, endDate, nil
+}
+
+// parseOptionalDateQueryParam returns the timestamp of a date query parameter, or 0 if it is not provided.
+func parseOptionalDateQueryParam(queryParams url.Values, queryParam string, location *time.Location, endOfDay bool) (int64, *models.HttpError) {
+	value := queryParams.Get(queryParam)
+
+	if len(value) == 0 {
+		return 0, nil
+	}
+
+	date, err := services.ParseDate(value, location, endOfDay)
+
+	if err != nil {
+		return 0, &models.HttpError{Status: http.StatusBadRequest, Description: fmt.Sprintf(constants.QueryParamError, queryParam)}
+	}
+
+	return date, nil
+}
+
+// writePreconditionFailed responds 412 to an update made on an outdated version, with the current version of the resource.
+func writePreconditionFailed(res http.ResponseWriter, conflictErr *models.DbVersionConflictError, current interface{}) error {
+	res.Header().Set("ETag", utils.FormatVersionETag(conflictErr.CurrentVersion))
+
+	return utils.WriteJSON(res, 412, models.PreconditionFailedHttpError{
+		Status:         http.StatusPreconditionFailed,
+		Description:    conflictErr.Error(),
+		CurrentVersion: conflictErr.CurrentVersion,
+		Current:        current,
+	})
+}
+
+// readMergePatch reads the JSON Merge Patch document of a PATCH request, responding 415 to other content types.
+// The returned bool is false when the response was already written.
+func readMergePatch(res http.ResponseWriter, req *http.Request) ([]byte, bool, error) {
+	patch, err := utils.ReadMergePatch(req)
+
+	if errors.Is(err, utils.ErrUnsupportedMergePatchType) {
+		return nil, false, utils.WriteJSON(res, 415, models.HttpError{Status: http.StatusUnsupportedMediaType, Description: err.Error()})
+	}
+
+	if err != nil {
+		return nil, false, utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
+	return patch, true, nil
+}
+
+// writeMergePatchError responds to a failed partial update: invalid patches and merged bodies respond 400.
+func writeMergePatchError(res http.ResponseWriter, err error) error {
+	var invalidBodyErr *utils.InvalidBodyError
+	if errors.As(err, &invalidBodyErr) {
+		return utils.WriteJSON(res, 400, invalidBodyErr.HttpErrors())
+	}
+
+	httpErr := utils.TranslateDbErrorToHttpError(err)
+	return utils.WriteJSON(res, httpErr.Status, httpErr)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+// maxImportFormOverhead is the room left in import requests for the multipart boundaries, headers and format field.
+const maxImportFormOverhead = 1 << 20
+
+func InitImportRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/me/imports", utils.ParseToHandlerFunc(handleStartImport)).Methods("POST")
+	router.HandleFunc("/api/v1/me/imports/{id:[0-9]+}", utils.ParseToHandlerFunc(handleGetImportJob)).Methods("GET")
+}
+
+var importService services.ImportService = services.NewImportServiceImpl(&services.DefaultDiaryEntryService{})
+
+// @Summary		Import diary entries
+// @Description	Import the diary entries of another journaling app for the authenticated user, up to 50 MiB.
+// @Description	The formats are a Day One JSON export (or its zip archive), a zip archive of Markdown files with title and date
+// @Description	front matter, or a CSV file with a header line naming its title, content and date columns.
+// @Description	The import runs in the background, and its progress and item errors are read from the returned job.
+// @Description	Entries already imported are skipped, so the same file can be imported again
+// @Tags			diary
+// @Accept			multipart/form-data
+// @Produce		json
+// @Param			file	formData	file	true	"Imported file"
+// @Param			format	formData	string	true	"Format of the file"	Enums(dayone, markdown, csv)
+// @Success		202		{object}	models.ImportJob
+// @Header			202		{string}	Location	"URL of the import job"
+// @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		413		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/imports [post]
+func handleStartImport(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	req.Body = http.MaxBytesReader(res, req.Body, services.MaxImportSize+maxImportFormOverhead)
+	file, _, formErr := req.FormFile("file")
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(formErr, &maxBytesErr) {
+		return utils.WriteJSON(res, 413, models.HttpError{Status: http.StatusRequestEntityTooLarge, Description: services.ErrImportTooLarge.Error()})
+	}
+
+	if formErr != nil {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: "the multipart form field file is not provided"})
+	}
+
+	defer file.Close()
+
+	importJob, err := importService.StartImport(user, req.FormValue("format"), file, getAuditMetadata(req))
+
+	if errors.Is(err, services.ErrUnsupportedImportFormat) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
+	if errors.Is(err, services.ErrImportTooLarge) {
+		return utils.WriteJSON(res, 413, models.HttpError{Status: http.StatusRequestEntityTooLarge, Description: err.Error()})
+	}
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	res.Header().Set("Location", fmt.Sprintf("/api/v1/me/imports/%d", importJob.Id))
+	return utils.WriteJSON(res, 202, importJob)
+}
+
+// @Summary		Get import job
+// @Description	Get the status and progress of an import of the authenticated user, with the errors of the items that could not be imported
+// @Tags			diary
+// @Produce		json
+// @Param			id	path		int	true	"Import job ID"
+// @Success		200	{object}	models.ImportJob
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/imports/{id} [get]
+func handleGetImportJob(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	importJobId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	importJob, err := importService.GetImportJob(user.Id, uint(importJobId))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, importJob)
+}
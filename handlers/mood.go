@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitMoodRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/me/mood", utils.ParseToHandlerFunc(handleGetCurrentUserMoodSummary)).Methods("GET")
+	router.HandleFunc("/api/v1/me/mood/correlation", utils.ParseToHandlerFunc(handleGetCurrentUserMoodActivityCorrelation)).Methods("GET")
+}
+
+var moodService services.MoodService = services.NewMoodServiceImpl(
+	&services.DefaultDiaryEntryService{},
+	&services.BookActivityRegistrationServiceImpl{},
+	&services.GameActivityRegistrationServiceImpl{},
+)
+
+// @Summary		Get current user mood summary
+// @Description	Get the mood and feelings aggregates of the diary entries of the authenticated user for every period of a date range.
+// @Description	Periods start at midnight in the time zone of the user, weeks on Monday
+// @Tags			mood
+// @Produce		json
+// @Param			start_date	query		string	false	"Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date"
+// @Param			end_date	query		string	false	"End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date"
+// @Param			date		query		string	false	"Calendar date like 2026-10-17, instead of start_date and end_date"
+// @Param			period		query		string	false	"Aggregation period, day by default"	Enums(day, week, month)
+// @Success		200			{object}	services.MoodSummary
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		500			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/mood [get]
+func handleGetCurrentUserMoodSummary(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	moodQuery, queryErr := parseMoodQuery(req)
+
+	if queryErr != nil {
+		return utils.WriteJSON(res, queryErr.Status, queryErr)
+	}
+
+	moodSummary, err := moodService.GetMoodSummary(user, moodQuery)
+
+	if errors.Is(err, services.ErrInvalidMoodPeriod) || errors.Is(err, services.ErrInvalidMoodDateRange) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, moodSummary)
+}
+
+// @Summary		Get current user mood and activity correlation
+// @Description	Compare the average mood of the authenticated user with the number of book and game activities
+// @Description	registered for every period of a date range
+// @Tags			mood
+// @Produce		json
+// @Param			start_date	query		string	false	"Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date"
+// @Param			end_date	query		string	false	"End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date"
+// @Param			date		query		string	false	"Calendar date like 2026-10-17, instead of start_date and end_date"
+// @Param			period		query		string	false	"Aggregation period, day by default"	Enums(day, week, month)
+// @Success		200			{object}	services.MoodActivityCorrelation
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		500			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/mood/correlation [get]
+func handleGetCurrentUserMoodActivityCorrelation(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	moodQuery, queryErr := parseMoodQuery(req)
+
+	if queryErr != nil {
+		return utils.WriteJSON(res, queryErr.Status, queryErr)
+	}
+
+	moodActivityCorrelation, err := moodService.GetMoodActivityCorrelation(user, moodQuery)
+
+	if errors.Is(err, services.ErrInvalidMoodPeriod) || errors.Is(err, services.ErrInvalidMoodDateRange) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, moodActivityCorrelation)
+}
+
+// parseMoodQuery reads the required date range and the optional period of the mood endpoints.
+func parseMoodQuery(req *http.Request) (*services.MoodQuery, *models.HttpError) {
+	queryParams := req.URL.Query()
+	moodQuery := &services.MoodQuery{Period: queryParams.Get("period")}
+
+	if len(moodQuery.Period) == 0 {
+		moodQuery.Period = services.MoodPeriodDay
+	}
+
+	startDate, endDate, dateErr := parseRequiredDateRangeQueryParams(req)
+
+	if dateErr != nil {
+		return nil, dateErr
+	}
+
+	moodQuery.StartDate = startDate
+	moodQuery.EndDate = endDate
+
+	return moodQuery, nil
+}
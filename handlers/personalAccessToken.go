@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitPersonalAccessTokenRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/me/tokens", utils.ParseToHandlerFunc(handleGetPersonalAccessTokens)).Methods("GET")
+	router.HandleFunc("/api/v1/me/tokens", utils.ParseToHandlerFunc(handleCreatePersonalAccessToken)).Methods("POST")
+	router.HandleFunc("/api/v1/me/tokens/{id:[0-9]+}", utils.ParseToHandlerFunc(handleDeletePersonalAccessToken)).Methods("DELETE")
+}
+
+var personalAccessTokenService services.PersonalAccessTokenService = &services.PersonalAccessTokenServiceImpl{}
+
+// @Summary		Get personal access tokens
+// @Description	Get the personal access tokens of the authenticated user. Token values are never returned
+// @Tags			personal access tokens
+// @Produce		json
+// @Success		200	{array}		models.PersonalAccessToken
+// @Failure		401	{object}	models.HttpError
+// @Failure		500	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/tokens [get]
+func handleGetPersonalAccessTokens(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	personalAccessTokens, err := personalAccessTokenService.GetUserPersonalAccessTokens(user.Id)
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, personalAccessTokens)
+}
+
+// @Summary		Create personal access token
+// @Description	Create a personal access token for the authenticated user, limited to the given scopes.
+// @Description	The token is only returned in this response, so it must be stored by the client
+// @Tags			personal access tokens
+// @Accept			json
+// @Produce		json
+// @Param			body	body		services.CreatePersonalAccessTokenBody	true	"Personal access token information"
+// @Success		201		{object}	services.CreatedPersonalAccessTokenResponse
+// @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		500		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/tokens [post]
+func handleCreatePersonalAccessToken(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	createBody := services.CreatePersonalAccessTokenBody{}
+
+	validationErrs := utils.HandleValidation(req, &createBody)
+
+	if len(validationErrs) > 0 {
+		return utils.WriteJSON(res, 400, validationErrs)
+	}
+
+	createdToken, createErr := personalAccessTokenService.CreatePersonalAccessToken(user.Id, &createBody, getAuditMetadata(req))
+
+	if createErr != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: createErr.Error()})
+	}
+
+	return utils.WriteJSON(res, 201, createdToken)
+}
+
+// @Summary		Revoke personal access token
+// @Description	Revoke a personal access token of the authenticated user
+// @Tags			personal access tokens
+// @Produce		json
+// @Param			id	path	int	true	"Personal access token ID"
+// @Success		204
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/tokens/{id} [delete]
+func handleDeletePersonalAccessToken(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	tokenId, _ := strconv.Atoi(mux.Vars(req)["id"])
+
+	deleteErr := personalAccessTokenService.DeletePersonalAccessToken(user.Id, uint(tokenId), getAuditMetadata(req))
+
+	if deleteErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(deleteErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitPromptRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/prompts/today", utils.ParseToHandlerFunc(handleGetDailyPrompt)).Methods("GET")
+	router.HandleFunc("/api/v1/admin/prompts", utils.ParseToHandlerFunc(handleGetPrompts)).Methods("GET")
+	router.HandleFunc("/api/v1/admin/prompts", utils.ParseToHandlerFunc(handleCreatePrompt)).Methods("POST")
+	router.HandleFunc("/api/v1/admin/prompts/{id:[0-9]+}", utils.ParseToHandlerFunc(handleGetPrompt)).Methods("GET")
+	router.HandleFunc("/api/v1/admin/prompts/{id:[0-9]+}", utils.ParseToHandlerFunc(handleUpdatePrompt)).Methods("PUT")
+	router.HandleFunc("/api/v1/admin/prompts/{id:[0-9]+}", utils.ParseToHandlerFunc(handleDeletePrompt)).Methods("DELETE")
+}
+
+var promptService services.PromptService = &services.PromptServiceImpl{}
+
+// @Summary		Get daily prompt
+// @Description	Get the journaling prompt of the day for the authenticated user, in the language of their locale unless another one is given.
+// @Description	The prompt changes every day in the time zone of the user, skipping the prompts answered in the last 30 days.
+// @Description	Prompts in English are returned when there are none in the language
+// @Tags			prompts
+// @Produce		json
+// @Param			language	query		string	false	"Language of the prompt, like es"
+// @Success		200			{object}	models.Prompt
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/prompts/today [get]
+func handleGetDailyPrompt(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	prompt, err := promptService.GetDailyPrompt(user, req.URL.Query().Get("language"))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, prompt)
+}
+
+// @Summary		Get prompts
+// @Description	Get the journaling prompts sorted by id, with the number of diary entries answering each of them. Only available to admins
+// @Tags			admin
+// @Produce		json
+// @Param			category	query		string	false	"Prompt category"
+// @Param			language	query		string	false	"Prompt language, like es"
+// @Success		200			{array}		models.PromptUsage
+// @Failure		500			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/admin/prompts [get]
+func handleGetPrompts(res http.ResponseWriter, req *http.Request) error {
+	queryParams := req.URL.Query()
+	promptUsages, err := promptService.GetPrompts(&services.PromptQuery{
+		Category: queryParams.Get("category"),
+		Language: queryParams.Get("language"),
+	})
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, promptUsages)
+}
+
+// @Summary		Get prompt
+// @Description	Get a journaling prompt. Only available to admins
+// @Tags			admin
+// @Produce		json
+// @Param			id	path		int	true	"Prompt ID"
+// @Success		200	{object}	models.Prompt
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/admin/prompts/{id} [get]
+func handleGetPrompt(res http.ResponseWriter, req *http.Request) error {
+	promptId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	prompt, err := promptService.GetPromptById(uint(promptId))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, prompt)
+}
+
+// @Summary		Create prompt
+// @Description	Create a journaling prompt. Only available to admins
+// @Tags			admin
+// @Accept			json
+// @Produce		json
+// @Param			body	body		services.SavePromptBody	true	"Prompt information"
+// @Success		201		{object}	models.Prompt
+// @Failure		400		{object}	models.HttpError
+// @Failure		500		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/admin/prompts [post]
+func handleCreatePrompt(res http.ResponseWriter, req *http.Request) error {
+	promptBody := services.SavePromptBody{}
+
+	validationErrs := utils.HandleValidation(req, &promptBody)
+
+	if len(validationErrs) > 0 {
+		return utils.WriteJSON(res, 400, validationErrs)
+	}
+
+	savedPrompt, err := promptService.SavePrompt(&promptBody, getAuditMetadata(req))
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 201, savedPrompt)
+}
+
+// @Summary		Update prompt
+// @Description	Replace a journaling prompt. Only available to admins
+// @Tags			admin
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int						true	"Prompt ID"
+// @Param			body	body		services.SavePromptBody	true	"Prompt information"
+// @Success		200		{object}	models.Prompt
+// @Failure		400		{object}	models.HttpError
+// @Failure		404		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/admin/prompts/{id} [put]
+func handleUpdatePrompt(res http.ResponseWriter, req *http.Request) error {
+	promptId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	promptBody := services.SavePromptBody{}
+
+	validationErrs := utils.HandleValidation(req, &promptBody)
+
+	if len(validationErrs) > 0 {
+		return utils.WriteJSON(res, 400, validationErrs)
+	}
+
+	updatedPrompt, err := promptService.UpdatePrompt(uint(promptId), &promptBody, getAuditMetadata(req))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, updatedPrompt)
+}
+
+// @Summary		Delete prompt
+// @Description	Delete a journaling prompt. The diary entries that answered it are kept. Only available to admins
+// @Tags			admin
+// @Param			id	path	int	true	"Prompt ID"
+// @Success		204
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/admin/prompts/{id} [delete]
+func handleDeletePrompt(res http.ResponseWriter, req *http.Request) error {
+	promptId, _ := strconv.Atoi(mux.Vars(req)["id"])
+
+	if err := promptService.DeletePrompt(uint(promptId), getAuditMetadata(req)); err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
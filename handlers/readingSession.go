@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+var readingSessionService services.ReadingSessionService = &services.ReadingSessionServiceImpl{}
+
+func InitReadingSessionRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/activityRegistrations/books/{id:[0-9]+}/readingSessions", utils.ParseToHandlerFunc(handleGetBookReadingSessions)).Methods("GET")
+	router.HandleFunc("/api/v1/activityRegistrations/books/{id:[0-9]+}/readingSessions", utils.ParseToHandlerFunc(handleStartReadingSession)).Methods("POST")
+	router.HandleFunc("/api/v1/activityRegistrations/books/{id:[0-9]+}/readingSessions/{sessionId:[0-9]+}/stop", utils.ParseToHandlerFunc(handleStopReadingSession)).Methods("POST")
+	router.HandleFunc("/api/v1/activityRegistrations/books/{id:[0-9]+}/progress", utils.ParseToHandlerFunc(handleGetBookReadingProgress)).Methods("GET")
+	router.HandleFunc("/api/v1/me/readingTime", utils.ParseToHandlerFunc(handleGetCurrentUserReadingTime)).Methods("GET")
+}
+
+// @Summary		Get book reading sessions
+// @Description	Get the reading sessions of a book registration of the authenticated user, most recent first
+// @Tags			reading sessions
+// @Produce		json
+// @Param			id	path		int	true	"Book activity registration ID"
+// @Success		200	{array}		models.ReadingSession
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/books/{id}/readingSessions [get]
+func handleGetBookReadingSessions(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	bookRegistrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	bookSessions, err := readingSessionService.GetBookReadingSessions(user.Id, uint(bookRegistrationId))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, bookSessions)
+}
+
+// @Summary		Start reading session
+// @Description	Start a reading session of a book registration of the authenticated user, now unless a start is sent.
+// @Description	A session of the book still active, for example on another device, is stopped when the new one starts
+// @Tags			reading sessions
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int								true	"Book activity registration ID"
+// @Param			body	body		services.StartReadingSessionBody	false	"Start of the session"
+// @Success		201		{object}	models.ReadingSession
+// @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		404		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/books/{id}/readingSessions [post]
+func handleStartReadingSession(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	startBody := services.StartReadingSessionBody{}
+
+	if req.ContentLength != 0 {
+		if validationErrs := utils.HandleValidation(req, &startBody); len(validationErrs) > 0 {
+			return utils.WriteJSON(res, 400, validationErrs)
+		}
+	}
+
+	bookRegistrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	readingSession, err := readingSessionService.StartReadingSession(user.Id, uint(bookRegistrationId), &startBody, getAuditMetadata(req))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 201, readingSession)
+}
+
+// @Summary		Stop reading session
+// @Description	Stop an active reading session of a book registration of the authenticated user, now unless an end is sent,
+// @Description	saving the page, percentage or position (like an EPUB CFI) reached
+// @Tags			reading sessions
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int								true	"Book activity registration ID"
+// @Param			sessionId	path		int								true	"Reading session ID"
+// @Param			body		body		services.StopReadingSessionBody	true	"End of the session and progress reached"
+// @Success		200			{object}	models.ReadingSession
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Failure		409			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/books/{id}/readingSessions/{sessionId}/stop [post]
+func handleStopReadingSession(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	stopBody := services.StopReadingSessionBody{}
+
+	if req.ContentLength != 0 {
+		if validationErrs := utils.HandleValidation(req, &stopBody); len(validationErrs) > 0 {
+			return utils.WriteJSON(res, 400, validationErrs)
+		}
+	}
+
+	bookRegistrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	sessionId, _ := strconv.Atoi(mux.Vars(req)["sessionId"])
+	readingSession, err := readingSessionService.StopReadingSession(user.Id, uint(bookRegistrationId), uint(sessionId), &stopBody, getAuditMetadata(req))
+
+	if errors.Is(err, services.ErrInvalidReadingSessionEnd) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
+	if errors.Is(err, services.ErrReadingSessionAlreadyStopped) {
+		return utils.WriteJSON(res, 409, models.HttpError{Status: http.StatusConflict, Description: err.Error()})
+	}
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, readingSession)
+}
+
+// @Summary		Get book reading progress
+// @Description	Get the point reached in a book registration of the authenticated user, with its active session and the time spent reading it,
+// @Description	so that the book can be resumed on any device
+// @Tags			reading sessions
+// @Produce		json
+// @Param			id	path		int	true	"Book activity registration ID"
+// @Success		200	{object}	services.ReadingProgress
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/activityRegistrations/books/{id}/progress [get]
+func handleGetBookReadingProgress(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	bookRegistrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	readingProgress, err := readingSessionService.GetBookReadingProgress(user.Id, uint(bookRegistrationId))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, readingProgress)
+}
+
+// @Summary		Get current user reading time
+// @Description	Get the time the authenticated user spent reading on every day of a date range.
+// @Description	Days start at midnight in the time zone of the user, and active sessions count until now
+// @Tags			reading sessions
+// @Produce		json
+// @Param			start_date	query		string	false	"Start of the range: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date"
+// @Param			end_date	query		string	false	"End of the range, included: Unix timestamp in seconds, RFC 3339 timestamp or calendar date. Required without date"
+// @Param			date		query		string	false	"Calendar date like 2026-10-17, instead of start_date and end_date"
+// @Success		200			{object}	services.ReadingTimeSummary
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		500			{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/readingTime [get]
+func handleGetCurrentUserReadingTime(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	startDate, endDate, dateErr := parseRequiredDateRangeQueryParams(req)
+
+	if dateErr != nil {
+		return utils.WriteJSON(res, dateErr.Status, dateErr)
+	}
+
+	readingTime, err := readingSessionService.GetReadingTime(user, startDate, endDate)
+
+	if errors.Is(err, services.ErrInvalidReadingDateRange) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: err.Error()})
+	}
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, readingTime)
+}
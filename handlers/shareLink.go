@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/constants"
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitShareLinkRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/diaryEntries/{id:[0-9]+}/shareLinks", utils.ParseToHandlerFunc(handleCreateShareLink)).Methods("POST")
+	router.HandleFunc("/api/v1/me/shareLinks", utils.ParseToHandlerFunc(handleGetShareLinks)).Methods("GET")
+	router.HandleFunc("/api/v1/me/shareLinks/{id:[0-9]+}", utils.ParseToHandlerFunc(handleDeleteShareLink)).Methods("DELETE")
+	router.HandleFunc("/api/v1/shared/{token}", utils.ParseToHandlerFunc(handleGetSharedDiaryEntry)).Methods("GET")
+}
+
+var shareLinkService services.ShareLinkService = services.NewShareLinkServiceImpl(&services.DefaultDiaryEntryService{})
+
+// @Summary		Create diary entry share link
+// @Description	Create a link giving read access to a diary entry of the authenticated user without authentication, until it expires.
+// @Description	The link can be protected by a passphrase and limited to a number of views.
+// @Description	The token is only returned in this response. End-to-end encrypted entries cannot be shared
+// @Tags			diary
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int							true	"Diary entry ID"
+// @Param			body	body		services.CreateShareLinkBody	true	"Share link information"
+// @Success		201		{object}	services.CreatedShareLinkResponse
+// @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		404		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/diaryEntries/{id}/shareLinks [post]
+func handleCreateShareLink(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	createBody := services.CreateShareLinkBody{}
+
+	validationErrs := utils.HandleValidation(req, &createBody)
+
+	if len(validationErrs) > 0 {
+		return utils.WriteJSON(res, 400, validationErrs)
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	createdShareLink, createErr := shareLinkService.CreateShareLink(user.Id, uint(entryId), &createBody, getAuditMetadata(req))
+
+	if errors.Is(createErr, services.ErrEncryptedDiaryEntryShare) {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: createErr.Error()})
+	}
+
+	if createErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(createErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 201, createdShareLink)
+}
+
+// @Summary		Get share links
+// @Description	Get the diary entry share links of the authenticated user, including the expired ones. Tokens are never returned
+// @Tags			diary
+// @Produce		json
+// @Success		200	{array}		models.ShareLink
+// @Failure		401	{object}	models.HttpError
+// @Failure		500	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/shareLinks [get]
+func handleGetShareLinks(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	shareLinks, err := shareLinkService.GetUserShareLinks(user.Id)
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, shareLinks)
+}
+
+// @Summary		Revoke share link
+// @Description	Revoke a diary entry share link of the authenticated user
+// @Tags			diary
+// @Produce		json
+// @Param			id	path	int	true	"Share link ID"
+// @Success		204
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/shareLinks/{id} [delete]
+func handleDeleteShareLink(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	shareLinkId, _ := strconv.Atoi(mux.Vars(req)["id"])
+
+	deleteErr := shareLinkService.DeleteShareLink(user.Id, uint(shareLinkId), getAuditMetadata(req))
+
+	if deleteErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(deleteErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary		Get shared diary entry
+// @Description	Get the diary entry of a share link. No authentication is needed, and each successful request counts as a view.
+// @Description	Links protected by a passphrase need it in the X-Share-Passphrase header
+// @Tags			diary
+// @Produce		json
+// @Param			token				path		string	true	"Share link token"
+// @Param			X-Share-Passphrase	header		string	false	"Share link passphrase"
+// @Success		200					{object}	models.SharedDiaryEntry
+// @Failure		401					{object}	models.HttpError
+// @Failure		404					{object}	models.HttpError
+// @Failure		410					{object}	models.HttpError
+// @Router			/shared/{token} [get]
+func handleGetSharedDiaryEntry(res http.ResponseWriter, req *http.Request) error {
+	// shared entries are private content reached without authentication, so they are kept out of caches and referrers
+	res.Header().Set("Cache-Control", "no-store")
+	res.Header().Set("Referrer-Policy", "no-referrer")
+
+	sharedDiaryEntry, err := shareLinkService.GetSharedDiaryEntry(mux.Vars(req)["token"], req.Header.Get(constants.ShareLinkPassphraseHeaderName))
+
+	if errors.Is(err, services.ErrShareLinkPassphraseRequired) || errors.Is(err, services.ErrInvalidShareLinkPassphrase) {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: err.Error()})
+	}
+
+	if errors.Is(err, services.ErrShareLinkExpired) || errors.Is(err, services.ErrShareLinkViewLimitReached) {
+		return utils.WriteJSON(res, 410, models.HttpError{Status: http.StatusGone, Description: err.Error()})
+	}
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, sharedDiaryEntry)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitTagRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/me/tags", utils.ParseToHandlerFunc(handleGetCurrentUserTags)).Methods("GET")
+	router.HandleFunc("/api/v1/me/tags/{id:[0-9]+}", utils.ParseToHandlerFunc(handleRenameTag)).Methods("PATCH")
+	router.HandleFunc("/api/v1/me/tags/{id:[0-9]+}/merge", utils.ParseToHandlerFunc(handleMergeTags)).Methods("POST")
+}
+
+var tagService services.TagService = &services.TagServiceImpl{}
+
+// @Summary		Get current user tags
+// @Description	Get the tags of the authenticated user sorted by name, with the number of diary entries using each of them
+// @Tags			tags
+// @Produce		json
+// @Success		200	{array}		models.TagUsage
+// @Failure		401	{object}	models.HttpError
+// @Failure		500	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/tags [get]
+func handleGetCurrentUserTags(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	tagUsages, err := tagService.GetUserTags(user.Id)
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, tagUsages)
+}
+
+// @Summary		Rename tag
+// @Description	Rename a tag of the authenticated user, renaming it on every tagged diary entry.
+// @Description	Fails if the user already has a tag with the new name, which can be merged instead
+// @Tags			tags
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int						true	"Tag ID"
+// @Param			body	body		services.RenameTagBody	true	"New tag name"
+// @Success		200		{object}	models.Tag
+// @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		404		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/tags/{id} [patch]
+func handleRenameTag(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	tagId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	renameBody := services.RenameTagBody{}
+
+	validationErrs := utils.HandleValidation(req, &renameBody)
+
+	if len(validationErrs) > 0 {
+		return utils.WriteJSON(res, 400, validationErrs)
+	}
+
+	renamedTag, renameErr := tagService.RenameTag(user.Id, uint(tagId), &renameBody, getAuditMetadata(req))
+
+	if renameErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(renameErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, renamedTag)
+}
+
+// @Summary		Merge tags
+// @Description	Merge tags of the authenticated user into the tag of the path: their diary entries get tagged with it and they are deleted
+// @Tags			tags
+// @Accept			json
+// @Produce		json
+// @Param			id		path	int						true	"Target tag ID"
+// @Param			body	body	services.MergeTagsBody	true	"Tags to merge"
+// @Success		204
+// @Failure		400	{object}	models.HttpError
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/tags/{id}/merge [post]
+func handleMergeTags(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	tagId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	mergeBody := services.MergeTagsBody{}
+
+	validationErrs := utils.HandleValidation(req, &mergeBody)
+
+	if len(validationErrs) > 0 {
+		return utils.WriteJSON(res, 400, validationErrs)
+	}
+
+	mergeErr := tagService.MergeTags(user.Id, uint(tagId), &mergeBody, getAuditMetadata(req))
+
+	if mergeErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(mergeErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
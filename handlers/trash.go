@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitTrashRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/me/trash", utils.ParseToHandlerFunc(handleGetCurrentUserTrash)).Methods("GET")
+	router.HandleFunc("/api/v1/me/trash", utils.ParseToHandlerFunc(handleEmptyCurrentUserTrash)).Methods("DELETE")
+	router.HandleFunc("/api/v1/me/trash/diaryEntries/{id:[0-9]+}/restore", utils.ParseToHandlerFunc(handleRestoreTrashedDiaryEntry)).Methods("POST")
+	router.HandleFunc("/api/v1/me/trash/books/{id:[0-9]+}/restore", utils.ParseToHandlerFunc(handleRestoreTrashedBookActivityRegistration)).Methods("POST")
+	router.HandleFunc("/api/v1/me/trash/games/{id:[0-9]+}/restore", utils.ParseToHandlerFunc(handleRestoreTrashedGameActivityRegistration)).Methods("POST")
+	router.HandleFunc("/api/v1/me/trash/custom/{id:[0-9]+}/restore", utils.ParseToHandlerFunc(handleRestoreTrashedCustomActivityRegistration)).Methods("POST")
+}
+
+var trashService services.TrashService = &services.TrashServiceImpl{}
+
+// @Summary		Get current user trash
+// @Description	Get the diary entries and activity registrations deleted by the authenticated user, most recently deleted first.
+// @Description	Items are permanently deleted once they have been in the trash for the retention period
+// @Tags			trash
+// @Produce		json
+// @Success		200	{object}	services.Trash
+// @Failure		401	{object}	models.HttpError
+// @Failure		500	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/trash [get]
+func handleGetCurrentUserTrash(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	trash, err := trashService.GetUserTrash(user.Id)
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, trash)
+}
+
+// @Summary		Empty current user trash
+// @Description	Permanently delete every item in the trash of the authenticated user
+// @Tags			trash
+// @Success		204
+// @Failure		401	{object}	models.HttpError
+// @Failure		500	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/trash [delete]
+func handleEmptyCurrentUserTrash(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	if err := trashService.EmptyUserTrash(user.Id, getAuditMetadata(req)); err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary		Restore diary entry from trash
+// @Description	Move a diary entry out of the trash of the authenticated user
+// @Tags			trash
+// @Produce		json
+// @Param			id	path		int	true	"Diary entry ID"
+// @Success		200	{object}	models.DiaryEntry
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/trash/diaryEntries/{id}/restore [post]
+func handleRestoreTrashedDiaryEntry(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	entryId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	restoredEntry, err := trashService.RestoreDiaryEntry(user.Id, uint(entryId), getAuditMetadata(req))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, restoredEntry)
+}
+
+// @Summary		Restore book activity registration from trash
+// @Description	Move a book registration out of the trash of the authenticated user
+// @Tags			trash
+// @Produce		json
+// @Param			id	path		int	true	"Book registration ID"
+// @Success		200	{object}	models.BookActivityRegistration
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/trash/books/{id}/restore [post]
+func handleRestoreTrashedBookActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	restoredRegistration, err := trashService.RestoreBookActivityRegistration(user.Id, uint(registrationId), getAuditMetadata(req))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, restoredRegistration)
+}
+
+// @Summary		Restore game activity registration from trash
+// @Description	Move a game registration out of the trash of the authenticated user
+// @Tags			trash
+// @Produce		json
+// @Param			id	path		int	true	"Game registration ID"
+// @Success		200	{object}	models.GameActivityRegistration
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/trash/games/{id}/restore [post]
+func handleRestoreTrashedGameActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	restoredRegistration, err := trashService.RestoreGameActivityRegistration(user.Id, uint(registrationId), getAuditMetadata(req))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, restoredRegistration)
+}
+
+// @Summary		Restore custom activity registration from trash
+// @Description	Move a registration of a declared activity type, like a walk, out of the trash of the authenticated user
+// @Tags			trash
+// @Produce		json
+// @Param			id	path		int	true	"Custom activity registration ID"
+// @Success		200	{object}	models.CustomActivityRegistration
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/trash/custom/{id}/restore [post]
+func handleRestoreTrashedCustomActivityRegistration(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	registrationId, _ := strconv.Atoi(mux.Vars(req)["id"])
+	restoredRegistration, err := trashService.RestoreCustomActivityRegistration(user.Id, uint(registrationId), getAuditMetadata(req))
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, restoredRegistration)
+}
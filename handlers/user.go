@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/adfer-dev/analock-api/models"
 	"github.com/adfer-dev/analock-api/services"
 	"github.com/adfer-dev/analock-api/utils"
 	"github.com/gorilla/mux"
@@ -12,9 +16,18 @@ import (
 func InitUserRoutes(router *mux.Router) {
 	router.HandleFunc("/api/v1/users/{id:[0-9]+}", utils.ParseToHandlerFunc(handleGetUser)).Methods("GET")
 	router.HandleFunc("/api/v1/users/{email}", utils.ParseToHandlerFunc(handleGetUserByEmail)).Methods("GET")
+	router.HandleFunc("/api/v1/me", utils.ParseToHandlerFunc(handleGetCurrentUser)).Methods("GET")
+	router.HandleFunc("/api/v1/me", utils.ParseToHandlerFunc(handleUpdateCurrentUser)).Methods("PATCH")
+	router.HandleFunc("/api/v1/me/export", utils.ParseToHandlerFunc(handleExportUserData)).Methods("GET")
 }
 
 var userService services.UserService = &services.UserServiceImpl{}
+var userDataExportService services.UserDataExportService = services.NewUserDataExportServiceImpl(
+	&services.DefaultDiaryEntryService{},
+	&services.BookActivityRegistrationServiceImpl{},
+	&services.GameActivityRegistrationServiceImpl{},
+	&services.CustomActivityRegistrationServiceImpl{},
+)
 
 // @Summary		Get user by ID
 // @Description	Get user information by their ID
@@ -61,3 +74,125 @@ func handleGetUserByEmail(res http.ResponseWriter, req *http.Request) error {
 
 	return utils.WriteJSON(res, 200, user)
 }
+
+// @Summary		Get current user
+// @Description	Get the profile of the authenticated user. The ETag header holds its version
+// @Tags			users
+// @Produce		json
+// @Success		200	{object}	models.User
+// @Header			200	{string}	ETag	"Version of the user profile"
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me [get]
+func handleGetCurrentUser(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	currentUser, err := userService.GetUserById(user.Id)
+
+	if err != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(err)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.Header().Set("ETag", utils.FormatVersionETag(currentUser.Version))
+	return utils.WriteJSON(res, 200, currentUser)
+}
+
+// @Summary		Update current user profile
+// @Description	Partially update the profile of the authenticated user. Only the provided fields are changed.
+// @Description	When the If-Match header is sent, updates based on an outdated version respond 412 with the current profile
+// @Tags			users
+// @Accept			json
+// @Produce		json
+// @Param			If-Match	header		string							false	"ETag of the updated version"
+// @Param			body		body		services.UpdateUserProfileBody	true	"Profile fields to update"
+// @Success		200			{object}	models.User
+// @Header			200			{string}	ETag	"Version of the user profile"
+// @Failure		400			{object}	models.HttpError
+// @Failure		401			{object}	models.HttpError
+// @Failure		404			{object}	models.HttpError
+// @Failure		412			{object}	models.PreconditionFailedHttpError
+// @Security		BearerAuth
+// @Router			/me [patch]
+func handleUpdateCurrentUser(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	expectedVersion, _, ifMatchErr := utils.ParseIfMatchVersion(req)
+
+	if ifMatchErr != nil {
+		return utils.WriteJSON(res, 400, models.HttpError{Status: http.StatusBadRequest, Description: ifMatchErr.Error()})
+	}
+
+	profileBody := services.UpdateUserProfileBody{}
+
+	validationErrs := utils.HandleValidation(req, &profileBody)
+
+	if len(validationErrs) > 0 {
+		return utils.WriteJSON(res, 400, validationErrs)
+	}
+
+	profileBody.ExpectedVersion = expectedVersion
+	updatedUser, updateErr := userService.UpdateUserProfile(user.Id, &profileBody)
+
+	var conflictErr *models.DbVersionConflictError
+	if errors.As(updateErr, &conflictErr) {
+		var current interface{}
+		if currentUser, getErr := userService.GetUserById(user.Id); getErr == nil {
+			current = currentUser
+		}
+		return writePreconditionFailed(res, conflictErr, current)
+	}
+
+	if updateErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(updateErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.Header().Set("ETag", utils.FormatVersionETag(updatedUser.Version))
+	return utils.WriteJSON(res, 200, updatedUser)
+}
+
+// @Summary		Export user data
+// @Description	Download a zip archive with the profile, diary entries and activity registrations of the authenticated user
+// @Tags			users
+// @Produce		application/zip
+// @Success		200	{file}		file
+// @Failure		401	{object}	models.HttpError
+// @Failure		500	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/export [get]
+func handleExportUserData(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	userDataExport, getExportErr := userDataExportService.GetUserDataExport(user)
+
+	if getExportErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(getExportErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.Header().Set("Content-Type", "application/zip")
+	res.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"analock-export-%s.zip\"", time.Now().UTC().Format("2006-01-02")))
+	res.WriteHeader(200)
+
+	// The archive is streamed, so once the body has started an error can only be logged.
+	if exportErr := userDataExportService.WriteUserDataExport(userDataExport, res); exportErr != nil {
+		handlersLogger.ErrorLogger.Printf("error when exporting data of user %d: %s", user.Id, exportErr.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/gorilla/mux"
+)
+
+func InitUserKeyBackupRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/me/keyBackups", utils.ParseToHandlerFunc(handleGetCurrentUserKeyBackups)).Methods("GET")
+	router.HandleFunc("/api/v1/me/keyBackups/{keyId:[A-Za-z0-9_-]{1,64}}", utils.ParseToHandlerFunc(handleSaveCurrentUserKeyBackup)).Methods("PUT")
+	router.HandleFunc("/api/v1/me/keyBackups/{keyId:[A-Za-z0-9_-]{1,64}}", utils.ParseToHandlerFunc(handleDeleteCurrentUserKeyBackup)).Methods("DELETE")
+}
+
+var userKeyBackupService services.UserKeyBackupService = &services.UserKeyBackupServiceImpl{}
+
+// @Summary		Get current user key backups
+// @Description	Get the backups of the encryption keys of the authenticated user, which are encrypted on the client
+// @Tags			encryption
+// @Produce		json
+// @Success		200	{array}		models.UserKeyBackup
+// @Failure		401	{object}	models.HttpError
+// @Failure		500	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/keyBackups [get]
+func handleGetCurrentUserKeyBackups(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	keyBackups, err := userKeyBackupService.GetUserKeyBackups(user.Id)
+
+	if err != nil {
+		return utils.WriteJSON(res, 500, models.HttpError{Status: http.StatusInternalServerError, Description: err.Error()})
+	}
+
+	return utils.WriteJSON(res, 200, keyBackups)
+}
+
+// @Summary		Save current user key backup
+// @Description	Store the backup of an encryption key of the authenticated user, replacing the previous backup of the key
+// @Tags			encryption
+// @Accept			json
+// @Produce		json
+// @Param			keyId	path		string							true	"Key ID"
+// @Param			body	body		services.SaveUserKeyBackupBody	true	"Encrypted key"
+// @Success		200		{object}	models.UserKeyBackup
+// @Failure		400		{object}	models.HttpError
+// @Failure		401		{object}	models.HttpError
+// @Failure		500		{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/keyBackups/{keyId} [put]
+func handleSaveCurrentUserKeyBackup(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	saveBody := services.SaveUserKeyBackupBody{}
+
+	validationErrs := utils.HandleValidation(req, &saveBody)
+
+	if len(validationErrs) > 0 {
+		return utils.WriteJSON(res, 400, validationErrs)
+	}
+
+	keyBackup, saveErr := userKeyBackupService.SaveUserKeyBackup(user.Id, mux.Vars(req)["keyId"], &saveBody, getAuditMetadata(req))
+
+	if saveErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(saveErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	return utils.WriteJSON(res, 200, keyBackup)
+}
+
+// @Summary		Delete current user key backup
+// @Description	Delete the backup of an encryption key of the authenticated user
+// @Tags			encryption
+// @Param			keyId	path	string	true	"Key ID"
+// @Success		204
+// @Failure		401	{object}	models.HttpError
+// @Failure		404	{object}	models.HttpError
+// @Security		BearerAuth
+// @Router			/me/keyBackups/{keyId} [delete]
+func handleDeleteCurrentUserKeyBackup(res http.ResponseWriter, req *http.Request) error {
+	user, userPresent := utils.GetRequestUser(req)
+
+	if !userPresent {
+		return utils.WriteJSON(res, 401, models.HttpError{Status: http.StatusUnauthorized, Description: "authenticated user not found"})
+	}
+
+	deleteErr := userKeyBackupService.DeleteUserKeyBackup(user.Id, mux.Vars(req)["keyId"], getAuditMetadata(req))
+
+	if deleteErr != nil {
+		httpErr := utils.TranslateDbErrorToHttpError(deleteErr)
+		return utils.WriteJSON(res, httpErr.Status, httpErr)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+	return nil
+}
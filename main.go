@@ -2,8 +2,11 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/adfer-dev/analock-api/api"
+	"github.com/adfer-dev/analock-api/services"
+	"github.com/adfer-dev/analock-api/storage"
 	"github.com/adfer-dev/analock-api/utils"
 	"github.com/joho/godotenv"
 )
@@ -35,8 +38,55 @@ func main() {
 		log.Fatal("No env file is present")
 	}
 
-	server := api.APIServer{Port: 3000}
+	if keysErr := storage.LoadContentEncryptionKeys(); keysErr != nil {
+		log.Fatalf("Could not load the data encryption master keys: %s", keysErr.Error())
+	}
+
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1])
+		return
+	}
+
+	rateLimitRules, rateLimitErr := api.LoadRateLimitRules()
+	if rateLimitErr != nil {
+		log.Fatalf("Could not load the rate limit rules: %s", rateLimitErr.Error())
+	}
+
+	services.StartTrashPurge(&services.TrashServiceImpl{})
+	services.FailInterruptedImportJobs()
+
+	server := api.APIServer{Port: 3000, RateLimitRules: rateLimitRules}
 
 	logger.InfoLogger.Printf("Server listening at port %d...\n", server.Port)
 	logger.ErrorLogger.Println(server.Run().Error())
 }
+
+// runCommand runs a maintenance command instead of the server.
+//
+//	rewrap-data-keys:      wrap the user data keys with the active master key, after rotating it
+//	encrypt-diary-content: encrypt the diary content stored before encryption at rest was enabled
+//	purge-trash:           permanently delete the items kept in the trash longer than the retention period
+func runCommand(command string) {
+	switch command {
+	case "rewrap-data-keys":
+		rewrappedKeys, err := storage.RewrapUserDataKeys()
+		if err != nil {
+			log.Fatalf("Could not re-wrap data keys: %s", err.Error())
+		}
+		logger.InfoLogger.Printf("Re-wrapped %d data keys\n", rewrappedKeys)
+	case "encrypt-diary-content":
+		encryptedRows, err := storage.EncryptDiaryContent()
+		if err != nil {
+			log.Fatalf("Could not encrypt diary content: %s", err.Error())
+		}
+		logger.InfoLogger.Printf("Encrypted %d diary entries and revisions\n", encryptedRows)
+	case "purge-trash":
+		purgedItems, err := (&services.TrashServiceImpl{}).PurgeExpiredTrash()
+		if err != nil {
+			log.Fatalf("Could not purge the trash: %s", err.Error())
+		}
+		logger.InfoLogger.Printf("Purged %d items from the trash\n", purgedItems)
+	default:
+		log.Fatalf("Unknown command %s", command)
+	}
+}
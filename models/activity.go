@@ -0,0 +1,28 @@
+package models
+
+import "encoding/json"
+
+// ActivityType tells the kind of an activity of the timeline.
+type ActivityType string
+
+const (
+	ActivityTypeDiaryEntry ActivityType = "diary_entry"
+	ActivityTypeBook       ActivityType = "book"
+	ActivityTypeGame       ActivityType = "game"
+)
+
+// Activity is an item of the activity timeline of a user: a diary entry, a book or a game registration, or a
+// registration of a declared activity type, whose type is the name of the activity type.
+// Id is the id of the diary entry or registration, and only the fields of its type are present.
+// End-to-end encrypted diary entries have no title.
+type Activity struct {
+	Type                      ActivityType         `json:"type"`
+	Id                        uint                 `json:"id"`
+	Registration              ActivityRegistration `json:"registration"`
+	Title                     string               `json:"title,omitempty"`
+	Mood                      *int                 `json:"mood,omitempty"`
+	Encrypted                 bool                 `json:"encrypted,omitempty"`
+	InternetArchiveIdentifier string               `json:"internetArchiveId,omitempty"`
+	GameName                  string               `json:"gameName,omitempty"`
+	Payload                   json.RawMessage      `json:"payload,omitempty" swaggertype:"object"`
+}
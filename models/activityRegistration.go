@@ -1,7 +1,12 @@
 package models
 
 type ActivityRegistration struct {
-	Id               uint  `json:"id"`
+	Id uint `json:"id"`
+	// RegistrationDate is a Unix timestamp in seconds, so it has no time zone. It is the publish date of diary entries.
 	RegistrationDate int64 `json:"registrationDate"`
 	UserRefer        uint  `json:"userId"`
+	// DeletedAt is the time the registration was moved to the trash, or 0 when it is not in the trash.
+	DeletedAt int64 `json:"deletedAt,omitempty"`
+	// Version is incremented on every update, and is sent as the ETag of book, game and custom registrations.
+	Version int64 `json:"version"`
 }
@@ -0,0 +1,12 @@
+package models
+
+import "encoding/json"
+
+// ActivityTypeDefinition declares a kind of offline activity, like a walk, whose registrations are stored
+// in the generic activity registration payload table. The payload of its registrations must match Schema,
+// a JSON Schema document.
+type ActivityTypeDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Schema      json.RawMessage `json:"schema" swaggertype:"object"`
+}
@@ -0,0 +1,70 @@
+package models
+
+type AuditEventType string
+
+const (
+	AuditLogin                       AuditEventType = "auth.login"
+	AuditLoginFailed                 AuditEventType = "auth.login_failed"
+	AuditTokenRefresh                AuditEventType = "auth.token_refresh"
+	AuditTokenRefreshFailed          AuditEventType = "auth.token_refresh_failed"
+	AuditTokenRevoked                AuditEventType = "token.revoked"
+	AuditDiaryEntryCreated           AuditEventType = "diary_entry.created"
+	AuditDiaryEntryUpdated           AuditEventType = "diary_entry.updated"
+	AuditDiaryEntryDeleted           AuditEventType = "diary_entry.deleted"
+	AuditDiaryEntryRestored          AuditEventType = "diary_entry.restored"
+	AuditDiaryEntryAttachmentAdded   AuditEventType = "diary_entry.attachment_added"
+	AuditDiaryEntryAttachmentDeleted AuditEventType = "diary_entry.attachment_deleted"
+	AuditBookRegistrationCreated     AuditEventType = "book_registration.created"
+	AuditBookRegistrationUpdated     AuditEventType = "book_registration.updated"
+	AuditBookRegistrationDeleted     AuditEventType = "book_registration.deleted"
+	AuditGameRegistrationCreated     AuditEventType = "game_registration.created"
+	AuditGameRegistrationUpdated     AuditEventType = "game_registration.updated"
+	AuditGameRegistrationDeleted     AuditEventType = "game_registration.deleted"
+	AuditCustomRegistrationCreated   AuditEventType = "custom_registration.created"
+	AuditCustomRegistrationUpdated   AuditEventType = "custom_registration.updated"
+	AuditCustomRegistrationDeleted   AuditEventType = "custom_registration.deleted"
+	AuditPersonalAccessTokenCreated  AuditEventType = "personal_access_token.created"
+	AuditPersonalAccessTokenRevoked  AuditEventType = "personal_access_token.revoked"
+	AuditTagRenamed                  AuditEventType = "tag.renamed"
+	AuditTagMerged                   AuditEventType = "tag.merged"
+	AuditKeyBackupSaved              AuditEventType = "key_backup.saved"
+	AuditKeyBackupDeleted            AuditEventType = "key_backup.deleted"
+	AuditTrashItemRestored           AuditEventType = "trash.item_restored"
+	AuditTrashEmptied                AuditEventType = "trash.emptied"
+	AuditPromptCreated               AuditEventType = "prompt.created"
+	AuditPromptUpdated               AuditEventType = "prompt.updated"
+	AuditPromptDeleted               AuditEventType = "prompt.deleted"
+	AuditShareLinkCreated            AuditEventType = "share_link.created"
+	AuditShareLinkRevoked            AuditEventType = "share_link.revoked"
+	AuditReadingSessionStarted       AuditEventType = "reading_session.started"
+	AuditReadingSessionStopped       AuditEventType = "reading_session.stopped"
+)
+
+const (
+	AuditTargetUser                = "user"
+	AuditTargetToken               = "token"
+	AuditTargetDiaryEntry          = "diary_entry"
+	AuditTargetBookRegistration    = "book_registration"
+	AuditTargetGameRegistration    = "game_registration"
+	AuditTargetCustomRegistration  = "custom_registration"
+	AuditTargetPersonalAccessToken = "personal_access_token"
+	AuditTargetTag                 = "tag"
+	AuditTargetKeyBackup           = "key_backup"
+	AuditTargetPrompt              = "prompt"
+	AuditTargetShareLink           = "share_link"
+	AuditTargetReadingSession      = "reading_session"
+)
+
+// AuditEvent records a security-relevant or data-changing operation.
+// ActorId is 0 when the actor is unknown, e.g. on a failed login.
+type AuditEvent struct {
+	Id         uint           `json:"id"`
+	EventType  AuditEventType `json:"eventType"`
+	ActorId    uint           `json:"actorId"`
+	TargetType string         `json:"targetType"`
+	TargetId   uint           `json:"targetId"`
+	IP         string         `json:"ip"`
+	UserAgent  string         `json:"userAgent"`
+	Details    string         `json:"details"`
+	CreatedAt  int64          `json:"createdAt"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// CalendarMonth summarizes the activity of a user on every day of a month. Days are in the time zone of the user.
+type CalendarMonth struct {
+	Month    string         `json:"month"`
+	TimeZone string         `json:"timeZone"`
+	Days     []*CalendarDay `json:"days"`
+}
+
+// CalendarDay counts the diary entries, books and games registered on a day, with the first of each of them as summaries.
+type CalendarDay struct {
+	Date            string                `json:"date"`
+	DiaryEntryCount int                   `json:"diaryEntryCount"`
+	BookCount       int                   `json:"bookCount"`
+	GameCount       int                   `json:"gameCount"`
+	DiaryEntries    []*CalendarDiaryEntry `json:"diaryEntries"`
+	Books           []*CalendarBook       `json:"books"`
+	Games           []*CalendarGame       `json:"games"`
+}
+
+// CalendarDiaryEntry summarizes a diary entry. End-to-end encrypted entries have no title.
+type CalendarDiaryEntry struct {
+	Id               uint   `json:"id"`
+	Title            string `json:"title"`
+	Mood             *int   `json:"mood"`
+	Encrypted        bool   `json:"encrypted"`
+	RegistrationDate int64  `json:"registrationDate"`
+}
+
+type CalendarBook struct {
+	Id                        uint   `json:"id"`
+	InternetArchiveIdentifier string `json:"internetArchiveId"`
+	RegistrationDate          int64  `json:"registrationDate"`
+}
+
+type CalendarGame struct {
+	Id               uint   `json:"id"`
+	GameName         string `json:"gameName"`
+	RegistrationDate int64  `json:"registrationDate"`
+}
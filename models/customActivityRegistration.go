@@ -0,0 +1,12 @@
+package models
+
+import "encoding/json"
+
+// CustomActivityRegistration is a registration of an activity type declared in the activity type registry.
+// Payload is the JSON document of the registration, matching the schema of its type.
+type CustomActivityRegistration struct {
+	Id           uint                 `json:"id"`
+	Registration ActivityRegistration `json:"registration"`
+	ActivityType string               `json:"activityType"`
+	Payload      json.RawMessage      `json:"payload" swaggertype:"object"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// DbVersionConflictError is returned when updating an item that was modified since the version the update was based on.
+type DbVersionConflictError struct {
+	DbItem         interface{}
+	CurrentVersion int64
+}
+
+func (err *DbVersionConflictError) Error() string {
+	itemType := reflect.TypeOf(err.DbItem)
+
+	if itemType.Kind() == reflect.Pointer {
+		itemType = itemType.Elem()
+	}
+
+	return fmt.Sprintf("%s was modified, its current version is %d", itemType.Name(), err.CurrentVersion)
+}
@@ -1,8 +1,16 @@
 package models
 
 type DiaryEntry struct {
-	Id           uint                 `json:"id"`
-	Title        string               `json:"title"`
-	Content      string               `json:"content"`
-	Registration ActivityRegistration `json:"registration"`
+	Id           uint                  `json:"id"`
+	Title        string                `json:"title"`
+	Content      string                `json:"content"`
+	Tags         []string              `json:"tags"`
+	Mood         *int                  `json:"mood"`
+	Feelings     []string              `json:"feelings"`
+	Encryption   *DiaryEntryEncryption `json:"encryption"`
+	Registration ActivityRegistration  `json:"registration"`
+	// PromptRefer is the id of the prompt answered by the entry, if any.
+	PromptRefer *uint `json:"promptId"`
+	// Version is incremented on every update, and is sent as the ETag of the entry.
+	Version int64 `json:"version"`
 }
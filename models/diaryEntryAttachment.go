@@ -0,0 +1,15 @@
+package models
+
+// DiaryEntryAttachment is a file attached to a diary entry. Its content is kept in the blob store.
+type DiaryEntryAttachment struct {
+	Id              uint   `json:"id"`
+	DiaryEntryRefer uint   `json:"diaryEntryId"`
+	UserRefer       uint   `json:"userId"`
+	FileName        string `json:"fileName"`
+	ContentType     string `json:"contentType"`
+	Size            int64  `json:"size"`
+	BlobKey         string `json:"-"`
+	ThumbnailKey    string `json:"-"`
+	HasThumbnail    bool   `json:"hasThumbnail"`
+	CreatedAt       int64  `json:"createdAt"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// Algorithms supported for the client-side encryption of diary entries.
+const (
+	EncryptionAlgorithmAesGcm            = "AES-256-GCM"
+	EncryptionAlgorithmXChaCha20Poly1305 = "XChaCha20-Poly1305"
+)
+
+// DiaryEntryEncryption is the metadata a client needs to decrypt an end-to-end encrypted diary entry.
+// The server stores it opaquely: the content of the entry is encrypted with a random key,
+// which is wrapped with the user key identified by KeyId.
+type DiaryEntryEncryption struct {
+	Algorithm  string `json:"algorithm"`
+	Nonce      string `json:"nonce"`
+	KeyId      string `json:"keyId"`
+	WrappedKey string `json:"wrappedKey"`
+}
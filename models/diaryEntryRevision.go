@@ -0,0 +1,14 @@
+package models
+
+// DiaryEntryRevision is a previous version of a diary entry, stored when the entry is updated.
+type DiaryEntryRevision struct {
+	Id              uint                  `json:"id"`
+	DiaryEntryRefer uint                  `json:"diaryEntryId"`
+	Title           string                `json:"title"`
+	Content         string                `json:"content"`
+	PublishDate     int64                 `json:"publishDate"`
+	Mood            *int                  `json:"mood"`
+	Feelings        []string              `json:"feelings"`
+	Encryption      *DiaryEntryEncryption `json:"encryption"`
+	CreatedAt       int64                 `json:"createdAt"`
+}
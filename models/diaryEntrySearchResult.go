@@ -0,0 +1,9 @@
+package models
+
+// DiaryEntrySearchResult is a diary entry matching a full-text search, with snippets of the
+// title and content around the matched terms. Matched terms are wrapped in <mark></mark>.
+type DiaryEntrySearchResult struct {
+	Entry          *DiaryEntry `json:"entry"`
+	TitleSnippet   string      `json:"titleSnippet"`
+	ContentSnippet string      `json:"contentSnippet"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// Mood scores go from very bad to very good.
+const (
+	MinMoodScore = 1
+	MaxMoodScore = 5
+)
+
+// Feelings that can be attached to a diary entry.
+const (
+	FeelingCalm      = "calm"
+	FeelingHappy     = "happy"
+	FeelingGrateful  = "grateful"
+	FeelingEnergetic = "energetic"
+	FeelingFocused   = "focused"
+	FeelingBored     = "bored"
+	FeelingTired     = "tired"
+	FeelingAnxious   = "anxious"
+	FeelingStressed  = "stressed"
+	FeelingSad       = "sad"
+	FeelingLonely    = "lonely"
+	FeelingAngry     = "angry"
+)
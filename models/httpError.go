@@ -4,3 +4,12 @@ type HttpError struct {
 	Status      int    `json:"status"`
 	Description string `json:"description"`
 }
+
+// PreconditionFailedHttpError is the error of a conditional request made on an outdated version of a resource.
+// It holds the current version of the resource, so clients can merge their changes and retry.
+type PreconditionFailedHttpError struct {
+	Status         int         `json:"status"`
+	Description    string      `json:"description"`
+	CurrentVersion int64       `json:"currentVersion"`
+	Current        interface{} `json:"current,omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+type ImportJobStatus string
+
+const (
+	ImportJobPending   ImportJobStatus = "pending"
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobCompleted ImportJobStatus = "completed"
+	ImportJobFailed    ImportJobStatus = "failed"
+)
+
+// ImportJob tracks the import of the diary entries of another journaling app.
+// Skipped items were already imported, and Error explains why a failed job could not read the file.
+type ImportJob struct {
+	Id            uint              `json:"id"`
+	UserRefer     uint              `json:"userId"`
+	Format        string            `json:"format"`
+	Status        ImportJobStatus   `json:"status"`
+	TotalItems    int               `json:"totalItems"`
+	ImportedItems int               `json:"importedItems"`
+	SkippedItems  int               `json:"skippedItems"`
+	FailedItems   int               `json:"failedItems"`
+	Errors        []ImportItemError `json:"errors"`
+	Error         string            `json:"error,omitempty"`
+	CreatedAt     int64             `json:"createdAt"`
+	FinishedAt    int64             `json:"finishedAt,omitempty"`
+}
+
+// ImportItemError is the reason an item of an import could not be imported.
+// Item is the position of the item in the file, starting at 1, and Reference identifies it in the source app,
+// like a file name or a CSV line.
+type ImportItemError struct {
+	Item      int    `json:"item"`
+	Reference string `json:"reference"`
+	Error     string `json:"error"`
+}
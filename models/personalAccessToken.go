@@ -0,0 +1,25 @@
+package models
+
+const (
+	ScopeDiaryRead       = "diary:read"
+	ScopeDiaryWrite      = "diary:write"
+	ScopeActivitiesRead  = "activities:read"
+	ScopeActivitiesWrite = "activities:write"
+	ScopeProfileRead     = "profile:read"
+	ScopeProfileWrite    = "profile:write"
+)
+
+// PersonalAccessToken is a long-lived credential a user creates for scripts and integrations.
+// Only the hash of the token is stored; the token itself is shown once, when it is created.
+// ExpiresAt and LastUsedAt are 0 when the token never expires or has never been used.
+type PersonalAccessToken struct {
+	Id         uint     `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	TokenHash  string   `json:"-"`
+	Scopes     []string `json:"scopes"`
+	UserRefer  uint     `json:"userId"`
+	CreatedAt  int64    `json:"createdAt"`
+	LastUsedAt int64    `json:"lastUsedAt"`
+	ExpiresAt  int64    `json:"expiresAt"`
+}
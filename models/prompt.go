@@ -0,0 +1,17 @@
+package models
+
+// Prompt is a journaling suggestion shown to users who do not know what to write about.
+// Language is an ISO 639-1 code, like en.
+type Prompt struct {
+	Id        uint   `json:"id"`
+	Text      string `json:"text"`
+	Category  string `json:"category"`
+	Language  string `json:"language"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// PromptUsage is a prompt with the number of diary entries answering it.
+type PromptUsage struct {
+	Prompt
+	AnswerCount int `json:"answerCount"`
+}
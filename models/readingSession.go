@@ -0,0 +1,15 @@
+package models
+
+// ReadingSession is a period of reading of a registered book. EndedAt is 0 while the session is active.
+// The progress fields hold the point of the book reached at the end of the session. Each one is optional,
+// as readers track their progress by page, by percentage or by a position like an EPUB CFI.
+type ReadingSession struct {
+	Id                    uint     `json:"id"`
+	BookRegistrationRefer uint     `json:"bookRegistrationId"`
+	UserRefer             uint     `json:"userId"`
+	StartedAt             int64    `json:"startedAt"`
+	EndedAt               int64    `json:"endedAt"`
+	Page                  *int     `json:"page,omitempty"`
+	Percentage            *float64 `json:"percentage,omitempty"`
+	Position              string   `json:"position,omitempty"`
+}
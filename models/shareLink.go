@@ -0,0 +1,28 @@
+package models
+
+// ShareLink gives read access to a diary entry to anyone holding its token, without authentication.
+// Only the hashes of the token and of the optional passphrase are stored; the token is shown once, when the link is created.
+// MaxViews is 0 when the number of views is not limited.
+type ShareLink struct {
+	Id              uint   `json:"id"`
+	DiaryEntryRefer uint   `json:"diaryEntryId"`
+	UserRefer       uint   `json:"userId"`
+	Prefix          string `json:"prefix"`
+	TokenHash       string `json:"-"`
+	PassphraseHash  string `json:"-"`
+	HasPassphrase   bool   `json:"hasPassphrase"`
+	MaxViews        int    `json:"maxViews"`
+	ViewCount       int    `json:"viewCount"`
+	CreatedAt       int64  `json:"createdAt"`
+	ExpiresAt       int64  `json:"expiresAt"`
+}
+
+// SharedDiaryEntry is the part of a diary entry shown through a share link.
+type SharedDiaryEntry struct {
+	Title       string `json:"title"`
+	Content     string `json:"content"`
+	PublishDate int64  `json:"publishDate"`
+	ExpiresAt   int64  `json:"expiresAt"`
+	// RemainingViews is nil when the number of views is not limited.
+	RemainingViews *int `json:"remainingViews"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// Tag is a user-defined label of diary entries. Tag names are unique per user, ignoring case.
+type Tag struct {
+	Id        uint   `json:"id"`
+	Name      string `json:"name"`
+	UserRefer uint   `json:"userId"`
+}
+
+// TagUsage is a tag with the number of diary entries tagged with it.
+type TagUsage struct {
+	Tag
+	UsageCount int `json:"usageCount"`
+}
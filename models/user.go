@@ -8,8 +8,17 @@ const (
 )
 
 type User struct {
-	Id       uint     `json:"id"`
-	Email    string   `json:"email"`
-	UserName string   `json:"userName"`
-	Role     UserRole `json:"role"`
+	Id        uint     `json:"id"`
+	Email     string   `json:"email"`
+	UserName  string   `json:"userName"`
+	Role      UserRole `json:"role"`
+	AvatarUrl string   `json:"avatarUrl"`
+	Locale    string   `json:"locale"`
+	// TimeZone is the IANA name of the time zone of the user, like Europe/Madrid. Calendar dates and day boundaries
+	// are computed in it, or in UTC when it is empty.
+	TimeZone string `json:"timeZone"`
+	// SearchIndexEnabled opts the user into the full-text index of their diary entries when they are encrypted at rest.
+	SearchIndexEnabled bool `json:"searchIndexEnabled"`
+	// Version is incremented on every profile update, and is sent as the ETag of the profile.
+	Version int64 `json:"version"`
 }
@@ -0,0 +1,9 @@
+package models
+
+// UserDataKey is the key encrypting the diary content of a user at rest, wrapped by a master key of the server.
+type UserDataKey struct {
+	UserRefer   uint   `json:"userId"`
+	WrappedKey  string `json:"-"`
+	MasterKeyId string `json:"masterKeyId"`
+	CreatedAt   int64  `json:"createdAt"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// UserKeyBackup is a user encryption key, encrypted on the client, so it can be recovered on other devices.
+type UserKeyBackup struct {
+	Id        uint   `json:"id"`
+	KeyId     string `json:"keyId"`
+	Blob      string `json:"blob"`
+	UserRefer uint   `json:"userId"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+)
+
+const (
+	defaultActivitiesPageLimit = 50
+	maxActivitiesPageLimit     = 200
+)
+
+var (
+	ErrInvalidActivityType   = errors.New("the activity types must be diary_entry, book, game or a declared activity type")
+	ErrInvalidActivityCursor = errors.New("invalid activities cursor")
+)
+
+// ActivityPageQuery holds the filters and position of a page of the activity timeline of a user.
+// Zero dates are not applied, and an empty cursor returns the first page. When types are given,
+// only the activities of those types are returned.
+type ActivityPageQuery struct {
+	StartDate int64
+	EndDate   int64
+	Types     []string
+	Limit     int
+	Cursor    string
+}
+
+// ActivityPage is a page of the activity timeline of a user, most recent first. NextCursor is empty on the last page.
+type ActivityPage struct {
+	Activities []*models.Activity `json:"activities"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
+
+// activityCursor is the JSON payload of the opaque cursors handed to clients.
+type activityCursor struct {
+	RegistrationDate int64 `json:"d"`
+	RegistrationId   uint  `json:"r"`
+}
+
+var activityStorage storage.ActivityStorageInterface = &storage.ActivityStorage{}
+
+// ActivityService defines all operations for the activity timeline service.
+type ActivityService interface {
+	GetUserActivitiesPage(userId uint, pageQuery *ActivityPageQuery) (*ActivityPage, error)
+}
+
+// ActivityServiceImpl is the concrete implementation of ActivityService.
+type ActivityServiceImpl struct{}
+
+// GetUserActivitiesPage returns a page of the diary entries, books, games and declared activities of the user, most recent first.
+func (activityService *ActivityServiceImpl) GetUserActivitiesPage(userId uint, pageQuery *ActivityPageQuery) (*ActivityPage, error) {
+	limit := pageQuery.Limit
+	if limit <= 0 {
+		limit = defaultActivitiesPageLimit
+	}
+	limit = min(limit, maxActivitiesPageLimit)
+
+	activityTypes := []models.ActivityType{}
+
+	for _, activityType := range pageQuery.Types {
+		switch models.ActivityType(activityType) {
+		case models.ActivityTypeDiaryEntry, models.ActivityTypeBook, models.ActivityTypeGame:
+		default:
+			if !IsDeclaredActivityType(activityType) {
+				return nil, ErrInvalidActivityType
+			}
+		}
+
+		if !slices.Contains(activityTypes, models.ActivityType(activityType)) {
+			activityTypes = append(activityTypes, models.ActivityType(activityType))
+		}
+	}
+
+	filter := &storage.ActivityPageFilter{
+		UserId:    userId,
+		StartDate: pageQuery.StartDate,
+		EndDate:   pageQuery.EndDate,
+		Types:     activityTypes,
+		// one more activity than needed is requested to know if there is a next page
+		Limit: limit + 1,
+	}
+
+	if len(pageQuery.Cursor) > 0 {
+		cursor, cursorErr := decodeActivityCursor(pageQuery.Cursor)
+
+		if cursorErr != nil {
+			return nil, cursorErr
+		}
+		filter.After = cursor
+	}
+
+	storedActivities, err := activityStorage.GetUserPage(filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	activities := storedActivities.([]*models.Activity)
+	activityPage := &ActivityPage{Activities: activities}
+
+	if len(activities) > limit {
+		activityPage.Activities = activities[:limit]
+		activityPage.NextCursor = encodeActivityCursor(activities[limit-1])
+	}
+
+	return activityPage, nil
+}
+
+func encodeActivityCursor(activity *models.Activity) string {
+	cursorJSON, _ := json.Marshal(activityCursor{RegistrationDate: activity.Registration.RegistrationDate, RegistrationId: activity.Registration.Id})
+
+	return base64.RawURLEncoding.EncodeToString(cursorJSON)
+}
+
+func decodeActivityCursor(encodedCursor string) (*storage.ActivityCursor, error) {
+	cursorJSON, decodeErr := base64.RawURLEncoding.DecodeString(encodedCursor)
+
+	if decodeErr != nil {
+		return nil, ErrInvalidActivityCursor
+	}
+
+	cursor := activityCursor{}
+
+	if err := json.Unmarshal(cursorJSON, &cursor); err != nil || cursor.RegistrationId == 0 {
+		return nil, ErrInvalidActivityCursor
+	}
+
+	return &storage.ActivityCursor{RegistrationDate: cursor.RegistrationDate, RegistrationId: cursor.RegistrationId}, nil
+}
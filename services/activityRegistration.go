@@ -1,15 +1,21 @@
 package services
 
 import (
+	"time"
+
 	"github.com/adfer-dev/analock-api/models"
 	"github.com/adfer-dev/analock-api/storage"
+	"github.com/adfer-dev/analock-api/utils"
 )
 
 // BookActicityRegistrationService interface and implementation
 type BookActivityRegistrationService interface {
 	GetUserBookActivityRegistrations(userId uint) ([]*models.BookActivityRegistration, error)
 	GetUserBookActivityRegistrationsTimeRange(userId uint, startTime int64, endTime int64) ([]*models.BookActivityRegistration, error)
-	CreateBookActivityRegistration(addRegistrationBody *AddBookActivityRegistrationBody) (*models.BookActivityRegistration, error)
+	GetBookActivityRegistration(userId uint, registrationId uint) (*models.BookActivityRegistration, error)
+	CreateBookActivityRegistration(addRegistrationBody *AddBookActivityRegistrationBody, auditMetadata *AuditMetadata) (*models.BookActivityRegistration, error)
+	PatchBookActivityRegistration(userId uint, registrationId uint, patch []byte, expectedVersion int64, auditMetadata *AuditMetadata) (*models.BookActivityRegistration, error)
+	DeleteBookActivityRegistration(userId uint, registrationId uint, auditMetadata *AuditMetadata) error
 }
 type BookActivityRegistrationServiceImpl struct{}
 
@@ -17,7 +23,10 @@ type BookActivityRegistrationServiceImpl struct{}
 type GameActivityRegistrationService interface {
 	GetUserGameActivityRegistrations(userId uint) ([]*models.GameActivityRegistration, error)
 	GetUserGameActivityRegistrationsTimeRange(userId uint, startDate int64, endDate int64) ([]*models.GameActivityRegistration, error)
-	CreateGameActivityRegistration(addRegistrationBody *AddGameActivityRegistrationBody) (*models.GameActivityRegistration, error)
+	GetGameActivityRegistration(userId uint, registrationId uint) (*models.GameActivityRegistration, error)
+	CreateGameActivityRegistration(addRegistrationBody *AddGameActivityRegistrationBody, auditMetadata *AuditMetadata) (*models.GameActivityRegistration, error)
+	PatchGameActivityRegistration(userId uint, registrationId uint, patch []byte, expectedVersion int64, auditMetadata *AuditMetadata) (*models.GameActivityRegistration, error)
+	DeleteGameActivityRegistration(userId uint, registrationId uint, auditMetadata *AuditMetadata) error
 }
 type GameActivityRegistrationServiceImpl struct{}
 
@@ -34,6 +43,18 @@ type AddGameActivityRegistrationBody struct {
 	UserRefer        uint   `json:"userId" validate:"required"`
 }
 
+// UpdateBookActivityRegistrationBody holds the updatable fields of a book registration, which partial updates are merged into.
+type UpdateBookActivityRegistrationBody struct {
+	InternetArchiveId string `json:"internetArchiveId" validate:"required"`
+	RegistrationDate  int64  `json:"registrationDate" validate:"required"`
+}
+
+// UpdateGameActivityRegistrationBody holds the updatable fields of a game registration, which partial updates are merged into.
+type UpdateGameActivityRegistrationBody struct {
+	GameName         string `json:"gameName" validate:"required"`
+	RegistrationDate int64  `json:"registrationDate" validate:"required"`
+}
+
 var bookActivityRegistrationStorage storage.BookActivityRegistrationStorageInterface = &storage.BookActivityRegistrationStorage{}
 var gameActivityRegistrationStorage storage.GameActivityRegistrationStorageInterface = &storage.GameActivityRegistrationStorage{}
 var activityRegistrationStorage storage.ActivityRegistrationStorageInterface = &storage.ActivityRegistrationStorage{}
@@ -78,7 +99,41 @@ func (gameActivityRegistrationService *GameActivityRegistrationServiceImpl) GetU
 	return dbUserRegistrations.([]*models.GameActivityRegistration), nil
 }
 
-func (bookActivityRegistrationService *BookActivityRegistrationServiceImpl) CreateBookActivityRegistration(addRegistrationBody *AddBookActivityRegistrationBody) (*models.BookActivityRegistration, error) {
+// GetBookActivityRegistration returns a book registration of the user. The registrations of other users are reported as not found.
+func (bookActivityRegistrationService *BookActivityRegistrationServiceImpl) GetBookActivityRegistration(userId uint, registrationId uint) (*models.BookActivityRegistration, error) {
+	storedRegistration, getErr := bookActivityRegistrationStorage.Get(registrationId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	bookRegistration := storedRegistration.(*models.BookActivityRegistration)
+
+	if bookRegistration.Registration.UserRefer != userId {
+		return nil, &models.DbNotFoundError{DbItem: &models.BookActivityRegistration{}}
+	}
+
+	return bookRegistration, nil
+}
+
+// GetGameActivityRegistration returns a game registration of the user. The registrations of other users are reported as not found.
+func (gameActivityRegistrationService *GameActivityRegistrationServiceImpl) GetGameActivityRegistration(userId uint, registrationId uint) (*models.GameActivityRegistration, error) {
+	storedRegistration, getErr := gameActivityRegistrationStorage.Get(registrationId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	gameRegistration := storedRegistration.(*models.GameActivityRegistration)
+
+	if gameRegistration.Registration.UserRefer != userId {
+		return nil, &models.DbNotFoundError{DbItem: &models.GameActivityRegistration{}}
+	}
+
+	return gameRegistration, nil
+}
+
+func (bookActivityRegistrationService *BookActivityRegistrationServiceImpl) CreateBookActivityRegistration(addRegistrationBody *AddBookActivityRegistrationBody, auditMetadata *AuditMetadata) (*models.BookActivityRegistration, error) {
 	dbActivityRegistration := &models.ActivityRegistration{
 		RegistrationDate: addRegistrationBody.RegistrationDate,
 		UserRefer:        addRegistrationBody.UserRefer,
@@ -100,10 +155,12 @@ func (bookActivityRegistrationService *BookActivityRegistrationServiceImpl) Crea
 		return nil, createBookActivityRegistrationErr
 	}
 
+	auditService.RecordEvent(models.AuditBookRegistrationCreated, models.AuditTargetBookRegistration, dbBookActivityRegistration.Id, auditMetadata, "")
+
 	return dbBookActivityRegistration, nil
 }
 
-func (gameActivityRegistrationService *GameActivityRegistrationServiceImpl) CreateGameActivityRegistration(addRegistrationBody *AddGameActivityRegistrationBody) (*models.GameActivityRegistration, error) {
+func (gameActivityRegistrationService *GameActivityRegistrationServiceImpl) CreateGameActivityRegistration(addRegistrationBody *AddGameActivityRegistrationBody, auditMetadata *AuditMetadata) (*models.GameActivityRegistration, error) {
 
 	dbActivityRegistration := &models.ActivityRegistration{
 		RegistrationDate: addRegistrationBody.RegistrationDate,
@@ -126,5 +183,111 @@ func (gameActivityRegistrationService *GameActivityRegistrationServiceImpl) Crea
 		return nil, createGameActivityRegistrationErr
 	}
 
+	auditService.RecordEvent(models.AuditGameRegistrationCreated, models.AuditTargetGameRegistration, dbGameActivityRegistration.Id, auditMetadata, "")
+
 	return dbGameActivityRegistration, nil
 }
+
+// PatchBookActivityRegistration applies a JSON Merge Patch to a book registration of the user.
+// The registrations of other users are reported as not found. A non zero expected version must be the stored one.
+func (bookActivityRegistrationService *BookActivityRegistrationServiceImpl) PatchBookActivityRegistration(userId uint, registrationId uint, patch []byte, expectedVersion int64, auditMetadata *AuditMetadata) (*models.BookActivityRegistration, error) {
+	bookRegistration, getErr := bookActivityRegistrationService.GetBookActivityRegistration(userId, registrationId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if expectedVersion != 0 && expectedVersion != bookRegistration.Registration.Version {
+		return nil, &models.DbVersionConflictError{DbItem: &models.BookActivityRegistration{}, CurrentVersion: bookRegistration.Registration.Version}
+	}
+
+	updateBody := &UpdateBookActivityRegistrationBody{
+		InternetArchiveId: bookRegistration.InternetArchiveIdentifier,
+		RegistrationDate:  bookRegistration.Registration.RegistrationDate,
+	}
+
+	if patchErr := utils.ApplyMergePatch(updateBody, patch); patchErr != nil {
+		return nil, patchErr
+	}
+
+	bookRegistration.Registration.RegistrationDate = updateBody.RegistrationDate
+	bookRegistration.InternetArchiveIdentifier = updateBody.InternetArchiveId
+
+	if updateErr := bookActivityRegistrationStorage.Update(bookRegistration); updateErr != nil {
+		return nil, updateErr
+	}
+
+	auditService.RecordEvent(models.AuditBookRegistrationUpdated, models.AuditTargetBookRegistration, bookRegistration.Id, auditMetadata, "")
+
+	return bookRegistration, nil
+}
+
+// PatchGameActivityRegistration applies a JSON Merge Patch to a game registration of the user.
+// The registrations of other users are reported as not found. A non zero expected version must be the stored one.
+func (gameActivityRegistrationService *GameActivityRegistrationServiceImpl) PatchGameActivityRegistration(userId uint, registrationId uint, patch []byte, expectedVersion int64, auditMetadata *AuditMetadata) (*models.GameActivityRegistration, error) {
+	gameRegistration, getErr := gameActivityRegistrationService.GetGameActivityRegistration(userId, registrationId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if expectedVersion != 0 && expectedVersion != gameRegistration.Registration.Version {
+		return nil, &models.DbVersionConflictError{DbItem: &models.GameActivityRegistration{}, CurrentVersion: gameRegistration.Registration.Version}
+	}
+
+	updateBody := &UpdateGameActivityRegistrationBody{
+		GameName:         gameRegistration.GameName,
+		RegistrationDate: gameRegistration.Registration.RegistrationDate,
+	}
+
+	if patchErr := utils.ApplyMergePatch(updateBody, patch); patchErr != nil {
+		return nil, patchErr
+	}
+
+	gameRegistration.Registration.RegistrationDate = updateBody.RegistrationDate
+	gameRegistration.GameName = updateBody.GameName
+
+	if updateErr := gameActivityRegistrationStorage.Update(gameRegistration); updateErr != nil {
+		return nil, updateErr
+	}
+
+	auditService.RecordEvent(models.AuditGameRegistrationUpdated, models.AuditTargetGameRegistration, gameRegistration.Id, auditMetadata, "")
+
+	return gameRegistration, nil
+}
+
+// DeleteBookActivityRegistration moves a book registration of the user to the trash.
+// The registrations of other users are reported as not found.
+func (bookActivityRegistrationService *BookActivityRegistrationServiceImpl) DeleteBookActivityRegistration(userId uint, registrationId uint, auditMetadata *AuditMetadata) error {
+	bookRegistration, getErr := bookActivityRegistrationService.GetBookActivityRegistration(userId, registrationId)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	if trashErr := activityRegistrationStorage.Trash(bookRegistration.Registration.Id, time.Now().Unix()); trashErr != nil {
+		return trashErr
+	}
+
+	auditService.RecordEvent(models.AuditBookRegistrationDeleted, models.AuditTargetBookRegistration, bookRegistration.Id, auditMetadata, "")
+
+	return nil
+}
+
+// DeleteGameActivityRegistration moves a game registration of the user to the trash.
+// The registrations of other users are reported as not found.
+func (gameActivityRegistrationService *GameActivityRegistrationServiceImpl) DeleteGameActivityRegistration(userId uint, registrationId uint, auditMetadata *AuditMetadata) error {
+	gameRegistration, getErr := gameActivityRegistrationService.GetGameActivityRegistration(userId, registrationId)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	if trashErr := activityRegistrationStorage.Trash(gameRegistration.Registration.Id, time.Now().Unix()); trashErr != nil {
+		return trashErr
+	}
+
+	auditService.RecordEvent(models.AuditGameRegistrationDeleted, models.AuditTargetGameRegistration, gameRegistration.Id, auditMetadata, "")
+
+	return nil
+}
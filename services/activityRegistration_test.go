@@ -6,12 +6,14 @@ import (
 	"time"
 
 	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/utils"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockBookActivityRegistrationStorage struct {
 	Registrations map[uint][]*models.BookActivityRegistration
 	Err           error
+	UpdateErr     error
 }
 
 func (m *mockBookActivityRegistrationStorage) GetByUserId(userId uint) (interface{}, error) {
@@ -56,6 +58,53 @@ func (m *mockBookActivityRegistrationStorage) Create(data interface{}) error {
 	return nil
 }
 
+func (m *mockBookActivityRegistrationStorage) Get(id uint) (interface{}, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	for _, userRegs := range m.Registrations {
+		for _, reg := range userRegs {
+			if reg.Id == id {
+				storedReg := *reg
+				return &storedReg, nil
+			}
+		}
+	}
+	return nil, &models.DbNotFoundError{DbItem: &models.BookActivityRegistration{}}
+}
+
+func (m *mockBookActivityRegistrationStorage) Update(data interface{}) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	if m.UpdateErr != nil {
+		return m.UpdateErr
+	}
+	reg, ok := data.(*models.BookActivityRegistration)
+	if !ok {
+		return &models.DbCouldNotParseItemError{DbItem: &models.BookActivityRegistration{}}
+	}
+	userRegs := m.Registrations[reg.Registration.UserRefer]
+	for i, storedReg := range userRegs {
+		if storedReg.Id == reg.Id {
+			reg.Registration.Version++
+			userRegs[i] = reg
+			return nil
+		}
+	}
+	return &models.DbNotFoundError{DbItem: &models.BookActivityRegistration{}}
+}
+
+func (m *mockBookActivityRegistrationStorage) GetTrashedByUserId(userId uint) (interface{}, error) {
+	trashedRegs := []*models.BookActivityRegistration{}
+	for _, reg := range m.Registrations[userId] {
+		if reg.Registration.DeletedAt != 0 {
+			trashedRegs = append(trashedRegs, reg)
+		}
+	}
+	return trashedRegs, nil
+}
+
 type mockGameActivityRegistrationStorage struct {
 	Registrations map[uint][]*models.GameActivityRegistration
 	Err           error
@@ -102,13 +151,61 @@ func (m *mockGameActivityRegistrationStorage) Create(data interface{}) error {
 	return nil
 }
 
+func (m *mockGameActivityRegistrationStorage) Get(id uint) (interface{}, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	for _, userRegs := range m.Registrations {
+		for _, reg := range userRegs {
+			if reg.Id == id {
+				storedReg := *reg
+				return &storedReg, nil
+			}
+		}
+	}
+	return nil, &models.DbNotFoundError{DbItem: &models.GameActivityRegistration{}}
+}
+
+func (m *mockGameActivityRegistrationStorage) Update(data interface{}) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	reg, ok := data.(*models.GameActivityRegistration)
+	if !ok {
+		return &models.DbCouldNotParseItemError{DbItem: &models.GameActivityRegistration{}}
+	}
+	userRegs := m.Registrations[reg.Registration.UserRefer]
+	for i, storedReg := range userRegs {
+		if storedReg.Id == reg.Id {
+			reg.Registration.Version++
+			userRegs[i] = reg
+			return nil
+		}
+	}
+	return &models.DbNotFoundError{DbItem: &models.GameActivityRegistration{}}
+}
+
+func (m *mockGameActivityRegistrationStorage) GetTrashedByUserId(userId uint) (interface{}, error) {
+	trashedRegs := []*models.GameActivityRegistration{}
+	for _, reg := range m.Registrations[userId] {
+		if reg.Registration.DeletedAt != 0 {
+			trashedRegs = append(trashedRegs, reg)
+		}
+	}
+	return trashedRegs, nil
+}
+
 type mockActivityRegistrationStorage struct {
-	CreatedActivity *models.ActivityRegistration
-	UpdatedActivity *models.ActivityRegistration
-	DeletedId       uint
-	Err             error
-	UpdateErr       error
-	DeleteErr       error
+	CreatedActivity    *models.ActivityRegistration
+	DeletedId          uint
+	DeletedIds         []uint
+	TrashedId          uint
+	RestoredId         uint
+	EmptiedUserId      uint
+	PurgedBefore       int64
+	PurgedRegistration int64
+	Err                error
+	DeleteErr          error
 }
 
 func (m *mockActivityRegistrationStorage) Create(data interface{}) error {
@@ -123,26 +220,47 @@ func (m *mockActivityRegistrationStorage) Create(data interface{}) error {
 	return nil
 }
 
-func (m *mockActivityRegistrationStorage) Update(data interface{}) error {
-	if m.UpdateErr != nil {
-		return m.UpdateErr
-	}
-	actReg, ok := data.(*models.ActivityRegistration)
-	if !ok {
-		return errors.New("Update: invalid type for ActivityRegistration")
+func (m *mockActivityRegistrationStorage) Delete(id uint) error {
+	if m.DeleteErr != nil {
+		return m.DeleteErr
 	}
-	m.UpdatedActivity = actReg
+	m.DeletedId = id
+	m.DeletedIds = append(m.DeletedIds, id)
 	return nil
 }
 
-func (m *mockActivityRegistrationStorage) Delete(id uint) error {
+func (m *mockActivityRegistrationStorage) Trash(id uint, deletedAt int64) error {
 	if m.DeleteErr != nil {
 		return m.DeleteErr
 	}
-	m.DeletedId = id
+	m.TrashedId = id
 	return nil
 }
 
+func (m *mockActivityRegistrationStorage) Restore(id uint) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.RestoredId = id
+	return nil
+}
+
+func (m *mockActivityRegistrationStorage) DeleteTrashedByUserId(userId uint) (int64, error) {
+	if m.DeleteErr != nil {
+		return 0, m.DeleteErr
+	}
+	m.EmptiedUserId = userId
+	return m.PurgedRegistration, nil
+}
+
+func (m *mockActivityRegistrationStorage) DeleteTrashedBefore(deletedBefore int64) (int64, error) {
+	if m.DeleteErr != nil {
+		return 0, m.DeleteErr
+	}
+	m.PurgedBefore = deletedBefore
+	return m.PurgedRegistration, nil
+}
+
 var bookRegistrationService BookActivityRegistrationService = &BookActivityRegistrationServiceImpl{}
 var gameRegistrationService GameActivityRegistrationService = &GameActivityRegistrationServiceImpl{}
 
@@ -281,7 +399,7 @@ func TestCreateBookActivityRegistration(t *testing.T) {
 		UserRefer:         1,
 	}
 
-	createdReg, err := bookRegistrationService.CreateBookActivityRegistration(addRegBody)
+	createdReg, err := bookRegistrationService.CreateBookActivityRegistration(addRegBody, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, createdReg)
@@ -296,13 +414,13 @@ func TestCreateBookActivityRegistration(t *testing.T) {
 
 	// Test case: Error during activity registration creation
 	mockActivityStore.Err = assert.AnError
-	_, err = bookRegistrationService.CreateBookActivityRegistration(addRegBody)
+	_, err = bookRegistrationService.CreateBookActivityRegistration(addRegBody, nil)
 	assert.Error(t, err)
 	mockActivityStore.Err = nil // Reset error
 
 	// Test case: Error during book activity registration creation
 	mockBookStore.Err = assert.AnError
-	_, err = bookRegistrationService.CreateBookActivityRegistration(addRegBody)
+	_, err = bookRegistrationService.CreateBookActivityRegistration(addRegBody, nil)
 	assert.Error(t, err)
 	mockBookStore.Err = nil // Reset error
 }
@@ -330,7 +448,7 @@ func TestCreateGameActivityRegistration(t *testing.T) {
 		UserRefer:        1,
 	}
 
-	createdReg, err := gameRegistrationService.CreateGameActivityRegistration(addRegBody)
+	createdReg, err := gameRegistrationService.CreateGameActivityRegistration(addRegBody, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, createdReg)
@@ -344,13 +462,105 @@ func TestCreateGameActivityRegistration(t *testing.T) {
 
 	// Test case: Error during activity registration creation
 	mockActivityStore.Err = assert.AnError
-	_, err = gameRegistrationService.CreateGameActivityRegistration(addRegBody)
+	_, err = gameRegistrationService.CreateGameActivityRegistration(addRegBody, nil)
 	assert.Error(t, err)
 	mockActivityStore.Err = nil
 
 	// Test case: Error during game activity registration creation
 	mockGameStore.Err = assert.AnError
-	_, err = gameRegistrationService.CreateGameActivityRegistration(addRegBody)
+	_, err = gameRegistrationService.CreateGameActivityRegistration(addRegBody, nil)
 	assert.Error(t, err)
 	mockGameStore.Err = nil
 }
+
+func TestPatchBookActivityRegistration(t *testing.T) {
+	originalBookStorage := bookActivityRegistrationStorage
+
+	storedReg := &models.BookActivityRegistration{
+		Id:                        5,
+		InternetArchiveIdentifier: "old_book",
+		Registration:              models.ActivityRegistration{Id: 50, RegistrationDate: 1000, UserRefer: 1, Version: 1},
+	}
+	mockBookStore := &mockBookActivityRegistrationStorage{
+		Registrations: map[uint][]*models.BookActivityRegistration{1: {storedReg}},
+	}
+
+	bookActivityRegistrationStorage = mockBookStore
+
+	defer func() {
+		bookActivityRegistrationStorage = originalBookStorage
+	}()
+
+	// Test case: only the patched fields change
+	patchedReg, err := bookRegistrationService.PatchBookActivityRegistration(1, 5, []byte(`{"internetArchiveId":"new_book"}`), 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "new_book", patchedReg.InternetArchiveIdentifier)
+	assert.Equal(t, int64(1000), patchedReg.Registration.RegistrationDate)
+	assert.Equal(t, "new_book", mockBookStore.Registrations[1][0].InternetArchiveIdentifier)
+	assert.Equal(t, int64(1000), mockBookStore.Registrations[1][0].Registration.RegistrationDate)
+	assert.Equal(t, int64(2), patchedReg.Registration.Version)
+
+	// Test case: patches based on an outdated version are rejected
+	_, err = bookRegistrationService.PatchBookActivityRegistration(1, 5, []byte(`{"internetArchiveId":"outdated_book"}`), 1, nil)
+	var conflictErr *models.DbVersionConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, int64(2), conflictErr.CurrentVersion)
+	assert.Equal(t, "new_book", mockBookStore.Registrations[1][0].InternetArchiveIdentifier)
+
+	// Test case: the book is not updated when the registration changed concurrently
+	mockBookStore.UpdateErr = &models.DbVersionConflictError{DbItem: &models.ActivityRegistration{}, CurrentVersion: 3}
+	_, err = bookRegistrationService.PatchBookActivityRegistration(1, 5, []byte(`{"internetArchiveId":"concurrent_book"}`), 2, nil)
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, "new_book", mockBookStore.Registrations[1][0].InternetArchiveIdentifier)
+	mockBookStore.UpdateErr = nil
+
+	// Test case: removing a required field fails validation
+	_, err = bookRegistrationService.PatchBookActivityRegistration(1, 5, []byte(`{"internetArchiveId":null}`), 0, nil)
+	var invalidBodyErr *utils.InvalidBodyError
+	assert.ErrorAs(t, err, &invalidBodyErr)
+	assert.Equal(t, "new_book", mockBookStore.Registrations[1][0].InternetArchiveIdentifier)
+
+	// Test case: patches that are not objects are rejected
+	_, err = bookRegistrationService.PatchBookActivityRegistration(1, 5, []byte(`["new_book"]`), 0, nil)
+	assert.ErrorIs(t, err, utils.ErrInvalidMergePatch)
+
+	// Test case: registrations of other users are not found
+	_, err = bookRegistrationService.PatchBookActivityRegistration(2, 5, []byte(`{"internetArchiveId":"other_book"}`), 0, nil)
+	var notFoundErr *models.DbNotFoundError
+	assert.ErrorAs(t, err, &notFoundErr)
+}
+
+func TestPatchGameActivityRegistration(t *testing.T) {
+	originalGameStorage := gameActivityRegistrationStorage
+
+	storedReg := &models.GameActivityRegistration{
+		Id:           6,
+		GameName:     "sudoku",
+		Registration: models.ActivityRegistration{Id: 60, RegistrationDate: 1000, UserRefer: 1, Version: 4},
+	}
+	mockGameStore := &mockGameActivityRegistrationStorage{
+		Registrations: map[uint][]*models.GameActivityRegistration{1: {storedReg}},
+	}
+
+	gameActivityRegistrationStorage = mockGameStore
+
+	defer func() {
+		gameActivityRegistrationStorage = originalGameStorage
+	}()
+
+	patchedReg, err := gameRegistrationService.PatchGameActivityRegistration(1, 6, []byte(`{"registrationDate":2000}`), 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "sudoku", patchedReg.GameName)
+	assert.Equal(t, int64(2000), patchedReg.Registration.RegistrationDate)
+	assert.Equal(t, int64(2000), mockGameStore.Registrations[1][0].Registration.RegistrationDate)
+	assert.Equal(t, int64(5), patchedReg.Registration.Version)
+
+	_, err = gameRegistrationService.PatchGameActivityRegistration(1, 6, []byte(`{"gameName":"chess"}`), 4, nil)
+	var conflictErr *models.DbVersionConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, "sudoku", mockGameStore.Registrations[1][0].GameName)
+
+	_, err = gameRegistrationService.PatchGameActivityRegistration(1, 7, []byte(`{"gameName":"chess"}`), 0, nil)
+	var notFoundErr *models.DbNotFoundError
+	assert.ErrorAs(t, err, &notFoundErr)
+}
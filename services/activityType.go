@@ -0,0 +1,144 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/utils"
+)
+
+// activityTypeNamePattern matches the names of declared activity types, which are used in URLs.
+var activityTypeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// reservedActivityTypeNames are the names of the activity kinds with their own tables, and the path segments
+// of their endpoints, which declared activity types cannot use.
+var reservedActivityTypeNames = []string{
+	string(models.ActivityTypeDiaryEntry), string(models.ActivityTypeBook), string(models.ActivityTypeGame), "books", "games", "user",
+}
+
+var ErrUnknownActivityType = errors.New("the activity type is not declared")
+
+// builtInActivityTypes are the offline activity types available to every user.
+// A new kind of activity only needs to be declared here to get its endpoints.
+var builtInActivityTypes = []*models.ActivityTypeDefinition{
+	{
+		Name:        "walk",
+		Description: "A walk, with its duration and optionally the distance in kilometres",
+		Schema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"durationMinutes": {"type": "integer", "minimum": 1, "maximum": 1440},
+				"distanceKm": {"type": "number", "minimum": 0, "maximum": 200},
+				"place": {"type": "string", "maxLength": 100}
+			},
+			"required": ["durationMinutes"],
+			"additionalProperties": false
+		}`),
+	},
+	{
+		Name:        "meditation",
+		Description: "A meditation session, with its duration and optionally the technique practised",
+		Schema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"durationMinutes": {"type": "integer", "minimum": 1, "maximum": 1440},
+				"technique": {"type": "string", "maxLength": 100}
+			},
+			"required": ["durationMinutes"],
+			"additionalProperties": false
+		}`),
+	},
+	{
+		Name:        "music_practice",
+		Description: "A music practice session, with the instrument, its duration and optionally the pieces played",
+		Schema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"instrument": {"type": "string", "minLength": 1, "maxLength": 50},
+				"durationMinutes": {"type": "integer", "minimum": 1, "maximum": 1440},
+				"pieces": {"type": "array", "items": {"type": "string", "maxLength": 100}, "maxItems": 20}
+			},
+			"required": ["instrument", "durationMinutes"],
+			"additionalProperties": false
+		}`),
+	},
+}
+
+// registeredActivityType is a declared activity type along with its parsed schema.
+type registeredActivityType struct {
+	definition *models.ActivityTypeDefinition
+	schema     *utils.JSONSchema
+}
+
+var activityTypeRegistry = map[string]*registeredActivityType{}
+
+func init() {
+	for _, activityType := range builtInActivityTypes {
+		if err := RegisterActivityType(activityType); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterActivityType declares a new kind of activity, whose registrations are then validated against its schema
+// and exposed through the generic activity registration endpoints. The payload schema must describe an object.
+func RegisterActivityType(definition *models.ActivityTypeDefinition) error {
+	if !activityTypeNamePattern.MatchString(definition.Name) || slices.Contains(reservedActivityTypeNames, definition.Name) {
+		return fmt.Errorf("the activity type name %q is not valid or is reserved", definition.Name)
+	}
+
+	if _, found := activityTypeRegistry[definition.Name]; found {
+		return fmt.Errorf("the activity type %q is already declared", definition.Name)
+	}
+
+	schema, err := utils.ParseJSONSchema(definition.Schema)
+
+	if err != nil {
+		return fmt.Errorf("the schema of the activity type %q is not valid: %w", definition.Name, err)
+	}
+
+	if schema.Type != "object" {
+		return fmt.Errorf("the schema of the activity type %q must describe an object", definition.Name)
+	}
+
+	activityTypeRegistry[definition.Name] = &registeredActivityType{definition: definition, schema: schema}
+
+	return nil
+}
+
+// GetActivityTypes returns the declared activity types, sorted by name.
+func GetActivityTypes() []*models.ActivityTypeDefinition {
+	activityTypes := make([]*models.ActivityTypeDefinition, 0, len(activityTypeRegistry))
+
+	for _, activityType := range activityTypeRegistry {
+		activityTypes = append(activityTypes, activityType.definition)
+	}
+
+	slices.SortFunc(activityTypes, func(a, b *models.ActivityTypeDefinition) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return activityTypes
+}
+
+// IsDeclaredActivityType tells if the activity type is declared in the registry.
+func IsDeclaredActivityType(name string) bool {
+	_, found := activityTypeRegistry[name]
+	return found
+}
+
+// validateActivityPayload checks the payload of a registration against the schema of its activity type.
+func validateActivityPayload(activityTypeName string, payload []byte) error {
+	activityType, found := activityTypeRegistry[activityTypeName]
+
+	if !found {
+		return ErrUnknownActivityType
+	}
+
+	return activityType.schema.Validate(payload)
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterActivityType(t *testing.T) {
+	t.Cleanup(func() {
+		delete(activityTypeRegistry, "yoga")
+	})
+
+	yoga := &models.ActivityTypeDefinition{
+		Name:   "yoga",
+		Schema: json.RawMessage(`{"type":"object","properties":{"durationMinutes":{"type":"integer"}},"required":["durationMinutes"]}`),
+	}
+	assert.NoError(t, RegisterActivityType(yoga))
+	assert.True(t, IsDeclaredActivityType("yoga"))
+	assert.NoError(t, validateActivityPayload("yoga", []byte(`{"durationMinutes":60}`)))
+	assert.Error(t, validateActivityPayload("yoga", []byte(`{}`)))
+
+	// Test duplicated, reserved and invalid declarations are rejected
+	invalidDefinitions := []*models.ActivityTypeDefinition{
+		yoga,
+		{Name: "book", Schema: json.RawMessage(`{"type":"object"}`)},
+		{Name: "games", Schema: json.RawMessage(`{"type":"object"}`)},
+		{Name: "Tai Chi", Schema: json.RawMessage(`{"type":"object"}`)},
+		{Name: "swim", Schema: json.RawMessage(`{"type":"string"}`)},
+		{Name: "swim", Schema: json.RawMessage(`{"type":"object","properties":{"laps":{"type":"int"}}}`)},
+	}
+
+	for _, invalidDefinition := range invalidDefinitions {
+		assert.Error(t, RegisterActivityType(invalidDefinition), invalidDefinition.Name)
+	}
+	assert.False(t, IsDeclaredActivityType("swim"))
+}
+
+func TestGetActivityTypes(t *testing.T) {
+	activityTypes := GetActivityTypes()
+	activityTypeNames := []string{}
+
+	for _, activityType := range activityTypes {
+		activityTypeNames = append(activityTypeNames, activityType.Name)
+	}
+
+	assert.Equal(t, []string{"meditation", "music_practice", "walk"}, activityTypeNames)
+	assert.ErrorIs(t, validateActivityPayload("skydiving", []byte(`{}`)), ErrUnknownActivityType)
+}
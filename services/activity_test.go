@@ -0,0 +1,100 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockActivityStorage implements ActivityStorageInterface
+type mockActivityStorage struct {
+	Activities []*models.Activity
+	Filter     *storage.ActivityPageFilter
+}
+
+func (m *mockActivityStorage) GetUserPage(filter *storage.ActivityPageFilter) (interface{}, error) {
+	m.Filter = filter
+	activities := []*models.Activity{}
+
+	for _, activity := range m.Activities {
+		if filter.After != nil && (activity.Registration.RegistrationDate > filter.After.RegistrationDate ||
+			(activity.Registration.RegistrationDate == filter.After.RegistrationDate && activity.Registration.Id >= filter.After.RegistrationId)) {
+			continue
+		}
+		if len(activities) == filter.Limit {
+			break
+		}
+		activities = append(activities, activity)
+	}
+
+	return activities, nil
+}
+
+func setUpActivityMocks(t *testing.T) *mockActivityStorage {
+	originalActivityStorage := activityStorage
+	activityStorageMock := &mockActivityStorage{Activities: []*models.Activity{}}
+	activityStorage = activityStorageMock
+	t.Cleanup(func() {
+		activityStorage = originalActivityStorage
+	})
+
+	return activityStorageMock
+}
+
+func TestGetUserActivitiesPage(t *testing.T) {
+	activityStorageMock := setUpActivityMocks(t)
+	activityService := &ActivityServiceImpl{}
+	activityStorageMock.Activities = []*models.Activity{
+		{Type: models.ActivityTypeGame, Id: 3, Registration: models.ActivityRegistration{Id: 5, RegistrationDate: 300}, GameName: "Sudoku"},
+		{Type: models.ActivityTypeDiaryEntry, Id: 2, Registration: models.ActivityRegistration{Id: 4, RegistrationDate: 200}, Title: "Monday"},
+		{Type: models.ActivityTypeBook, Id: 1, Registration: models.ActivityRegistration{Id: 3, RegistrationDate: 200}, InternetArchiveIdentifier: "book"},
+	}
+
+	// Test the first page has a cursor to the next one
+	firstPage, err := activityService.GetUserActivitiesPage(1, &ActivityPageQuery{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, firstPage.Activities, 2)
+	assert.Equal(t, 3, activityStorageMock.Filter.Limit)
+	assert.Nil(t, activityStorageMock.Filter.After)
+	assert.NotEmpty(t, firstPage.NextCursor)
+
+	// Test the cursor continues after the last activity, even with the same registration date
+	lastPage, err := activityService.GetUserActivitiesPage(1, &ActivityPageQuery{Limit: 2, Cursor: firstPage.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, &storage.ActivityCursor{RegistrationDate: 200, RegistrationId: 4}, activityStorageMock.Filter.After)
+	assert.Len(t, lastPage.Activities, 1)
+	assert.Equal(t, models.ActivityTypeBook, lastPage.Activities[0].Type)
+	assert.Empty(t, lastPage.NextCursor)
+
+	// Test default and maximum limits
+	_, err = activityService.GetUserActivitiesPage(1, &ActivityPageQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultActivitiesPageLimit+1, activityStorageMock.Filter.Limit)
+	_, err = activityService.GetUserActivitiesPage(1, &ActivityPageQuery{Limit: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, maxActivitiesPageLimit+1, activityStorageMock.Filter.Limit)
+
+	// Test type filters and dates are passed to storage without duplicates
+	_, err = activityService.GetUserActivitiesPage(1, &ActivityPageQuery{StartDate: 100, EndDate: 400, Types: []string{"book", "game", "book"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ActivityType{models.ActivityTypeBook, models.ActivityTypeGame}, activityStorageMock.Filter.Types)
+	assert.Equal(t, int64(100), activityStorageMock.Filter.StartDate)
+	assert.Equal(t, int64(400), activityStorageMock.Filter.EndDate)
+
+	// Test declared activity types can be filtered too
+	_, err = activityService.GetUserActivitiesPage(1, &ActivityPageQuery{Types: []string{"walk", "diary_entry"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ActivityType{"walk", models.ActivityTypeDiaryEntry}, activityStorageMock.Filter.Types)
+
+	// Test invalid type
+	_, err = activityService.GetUserActivitiesPage(1, &ActivityPageQuery{Types: []string{"movie"}})
+	assert.ErrorIs(t, err, ErrInvalidActivityType)
+
+	// Test invalid cursors
+	_, err = activityService.GetUserActivitiesPage(1, &ActivityPageQuery{Cursor: "not a cursor!"})
+	assert.ErrorIs(t, err, ErrInvalidActivityCursor)
+	_, err = activityService.GetUserActivitiesPage(1, &ActivityPageQuery{Cursor: "e30"})
+	assert.ErrorIs(t, err, ErrInvalidActivityCursor)
+}
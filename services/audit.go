@@ -0,0 +1,126 @@
+package services
+
+import (
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"github.com/adfer-dev/analock-api/utils"
+)
+
+const (
+	defaultAuditEventsPageSize = 50
+	maxAuditEventsPageSize     = 200
+)
+
+// AuditMetadata identifies who performed an operation and where the request came from.
+// ActorId is 0 when the request is not authenticated.
+type AuditMetadata struct {
+	ActorId   uint
+	IP        string
+	UserAgent string
+}
+
+// AuditEventQuery holds the filters and pagination of an audit event listing.
+type AuditEventQuery struct {
+	ActorId    uint
+	EventType  models.AuditEventType
+	TargetType string
+	TargetId   uint
+	StartDate  int64
+	EndDate    int64
+	Page       int
+	PageSize   int
+}
+
+type AuditEventPage struct {
+	Events   []*models.AuditEvent `json:"events"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"pageSize"`
+	Total    int                  `json:"total"`
+}
+
+var auditEventStorage storage.AuditEventStorageInterface = &storage.AuditEventStorage{}
+var auditService AuditService = &AuditServiceImpl{}
+var servicesLogger *utils.CustomLogger = utils.GetCustomLogger()
+
+// AuditService defines all operations for the audit service.
+type AuditService interface {
+	RecordEvent(eventType models.AuditEventType, targetType string, targetId uint, auditMetadata *AuditMetadata, details string)
+	GetAuditEvents(query *AuditEventQuery) (*AuditEventPage, error)
+}
+
+// AuditServiceImpl is the concrete implementation of AuditService.
+type AuditServiceImpl struct{}
+
+// RecordEvent stores an audit event. Failing to store it is logged but does not
+// fail the audited operation, which has already been performed.
+func (auditServiceImpl *AuditServiceImpl) RecordEvent(eventType models.AuditEventType, targetType string, targetId uint, auditMetadata *AuditMetadata, details string) {
+	auditEvent := &models.AuditEvent{
+		EventType:  eventType,
+		TargetType: targetType,
+		TargetId:   targetId,
+		Details:    details,
+		CreatedAt:  time.Now().Unix(),
+	}
+
+	if auditMetadata != nil {
+		auditEvent.ActorId = auditMetadata.ActorId
+		auditEvent.IP = auditMetadata.IP
+		auditEvent.UserAgent = auditMetadata.UserAgent
+	}
+
+	if err := auditEventStorage.Create(auditEvent); err != nil {
+		servicesLogger.ErrorLogger.Printf("error when recording audit event %s: %s", eventType, err.Error())
+	}
+}
+
+func (auditServiceImpl *AuditServiceImpl) GetAuditEvents(query *AuditEventQuery) (*AuditEventPage, error) {
+	page := max(query.Page, 1)
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultAuditEventsPageSize
+	}
+	pageSize = min(pageSize, maxAuditEventsPageSize)
+
+	filter := &storage.AuditEventFilter{
+		ActorId:    query.ActorId,
+		EventType:  query.EventType,
+		TargetType: query.TargetType,
+		TargetId:   query.TargetId,
+		StartDate:  query.StartDate,
+		EndDate:    query.EndDate,
+		Limit:      pageSize,
+		Offset:     (page - 1) * pageSize,
+	}
+
+	auditEvents, err := auditEventStorage.GetFiltered(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, countErr := auditEventStorage.CountFiltered(filter)
+	if countErr != nil {
+		return nil, countErr
+	}
+
+	return &AuditEventPage{
+		Events:   auditEvents.([]*models.AuditEvent),
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// withActor returns a copy of the metadata with the given actor, for operations
+// that identify the actor themselves, like a login.
+func (auditMetadata *AuditMetadata) withActor(actorId uint) *AuditMetadata {
+	actorMetadata := &AuditMetadata{ActorId: actorId}
+
+	if auditMetadata != nil {
+		actorMetadata.IP = auditMetadata.IP
+		actorMetadata.UserAgent = auditMetadata.UserAgent
+	}
+
+	return actorMetadata
+}
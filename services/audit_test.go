@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAuditEventStorage implements AuditEventStorageInterface
+type mockAuditEventStorage struct {
+	Events     []*models.AuditEvent
+	LastFilter *storage.AuditEventFilter
+
+	GetErr    error
+	CountErr  error
+	CreateErr error
+}
+
+func (m *mockAuditEventStorage) GetFiltered(filter *storage.AuditEventFilter) (interface{}, error) {
+	m.LastFilter = filter
+	if m.GetErr != nil {
+		return nil, m.GetErr
+	}
+	return m.Events, nil
+}
+
+func (m *mockAuditEventStorage) CountFiltered(filter *storage.AuditEventFilter) (int, error) {
+	if m.CountErr != nil {
+		return 0, m.CountErr
+	}
+	return len(m.Events), nil
+}
+
+func (m *mockAuditEventStorage) Create(data interface{}) error {
+	if m.CreateErr != nil {
+		return m.CreateErr
+	}
+	auditEvent := data.(*models.AuditEvent)
+	auditEvent.Id = uint(len(m.Events) + 1)
+	m.Events = append(m.Events, auditEvent)
+	return nil
+}
+
+// TestMain keeps the audit events, diary entry revisions and attachments of every test from reaching the database.
+func TestMain(m *testing.M) {
+	auditEventStorage = &mockAuditEventStorage{}
+	diaryEntryRevisionStorage = &mockDiaryEntryRevisionStorage{}
+	diaryEntryAttachmentStorage = &mockDiaryEntryAttachmentStorage{}
+	attachmentBlobStore = newMockBlobStore()
+	os.Exit(m.Run())
+}
+
+func TestRecordEvent(t *testing.T) {
+	originalStorage := auditEventStorage
+	mockStorage := &mockAuditEventStorage{}
+	auditEventStorage = mockStorage
+	defer func() { auditEventStorage = originalStorage }()
+
+	auditMetadata := &AuditMetadata{ActorId: 1, IP: "10.0.0.1", UserAgent: "test-agent"}
+	auditService.RecordEvent(models.AuditDiaryEntryCreated, models.AuditTargetDiaryEntry, 5, auditMetadata, "")
+
+	assert.Len(t, mockStorage.Events, 1)
+	recordedEvent := mockStorage.Events[0]
+	assert.Equal(t, models.AuditDiaryEntryCreated, recordedEvent.EventType)
+	assert.Equal(t, uint(1), recordedEvent.ActorId)
+	assert.Equal(t, models.AuditTargetDiaryEntry, recordedEvent.TargetType)
+	assert.Equal(t, uint(5), recordedEvent.TargetId)
+	assert.Equal(t, "10.0.0.1", recordedEvent.IP)
+	assert.Equal(t, "test-agent", recordedEvent.UserAgent)
+	assert.NotZero(t, recordedEvent.CreatedAt)
+
+	// events without metadata are recorded without actor
+	auditService.RecordEvent(models.AuditLoginFailed, models.AuditTargetUser, 0, nil, "")
+	assert.Len(t, mockStorage.Events, 2)
+	assert.Zero(t, mockStorage.Events[1].ActorId)
+
+	// storage errors do not panic nor fail the caller
+	mockStorage.CreateErr = errors.New("db error")
+	auditService.RecordEvent(models.AuditLogin, models.AuditTargetUser, 1, auditMetadata, "")
+	assert.Len(t, mockStorage.Events, 2)
+}
+
+func TestGetAuditEvents(t *testing.T) {
+	originalStorage := auditEventStorage
+	mockStorage := &mockAuditEventStorage{Events: []*models.AuditEvent{{Id: 1, EventType: models.AuditLogin}}}
+	auditEventStorage = mockStorage
+	defer func() { auditEventStorage = originalStorage }()
+
+	// Test default pagination
+	auditEventPage, err := auditService.GetAuditEvents(&AuditEventQuery{ActorId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, auditEventPage.Page)
+	assert.Equal(t, defaultAuditEventsPageSize, auditEventPage.PageSize)
+	assert.Equal(t, 1, auditEventPage.Total)
+	assert.Len(t, auditEventPage.Events, 1)
+	assert.Equal(t, uint(1), mockStorage.LastFilter.ActorId)
+	assert.Equal(t, 0, mockStorage.LastFilter.Offset)
+
+	// Test page size is capped and offset computed
+	auditEventPage, err = auditService.GetAuditEvents(&AuditEventQuery{Page: 3, PageSize: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, maxAuditEventsPageSize, auditEventPage.PageSize)
+	assert.Equal(t, maxAuditEventsPageSize, mockStorage.LastFilter.Limit)
+	assert.Equal(t, 2*maxAuditEventsPageSize, mockStorage.LastFilter.Offset)
+
+	// Test storage error
+	mockStorage.GetErr = errors.New("db error")
+	_, err = auditService.GetAuditEvents(&AuditEventQuery{})
+	assert.Error(t, err)
+}
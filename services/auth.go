@@ -45,13 +45,18 @@ type UserAuthenticateBody struct {
 	ProviderToken string `json:"providerToken" validate:"required,jwt"`
 }
 
+// TokenResponse holds the tokens of an authenticated user. When the refresh cookie is enabled, the refresh token
+// is only sent in the cookie, and the CSRF token is sent instead.
 type TokenResponse struct {
 	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	CsrfToken    string `json:"csrfToken,omitempty"`
 }
 
+// RefreshTokenRequest holds the refresh token sent in the body.
+// It is not sent when the refresh cookie is enabled, as the refresh token is read from the cookie instead.
 type RefreshTokenRequest struct {
-	RefreshToken string `json:"refreshToken" validate:"required,jwt"`
+	RefreshToken string `json:"refreshToken" validate:"omitempty,jwt"`
 }
 
 type RefreshTokenResponse struct {
@@ -59,9 +64,11 @@ type RefreshTokenResponse struct {
 }
 
 // AuthService methods
-func (authService *AuthService) AuthenticateUser(authBody UserAuthenticateBody) (*models.Token, *models.Token, error) {
+func (authService *AuthService) AuthenticateUser(authBody UserAuthenticateBody, auditMetadata *AuditMetadata) (*models.Token, *models.Token, error) {
 	googleValidateErr := authService.validateGoogleToken(authBody.ProviderToken)
 	if googleValidateErr != nil {
+		auditService.RecordEvent(models.AuditLoginFailed, models.AuditTargetUser, 0, auditMetadata,
+			fmt.Sprintf("email: %s", authBody.Email))
 		return nil, nil, googleValidateErr
 	}
 
@@ -75,7 +82,11 @@ func (authService *AuthService) AuthenticateUser(authBody UserAuthenticateBody)
 		if saveExternalLoginError != nil {
 			return nil, nil, saveExternalLoginError
 		}
-		return authService.updateTokenPair(user)
+		accessToken, refreshToken, updateErr := authService.updateTokenPair(user, auditMetadata.withActor(user.Id))
+		if updateErr == nil {
+			auditService.RecordEvent(models.AuditLogin, models.AuditTargetUser, user.Id, auditMetadata.withActor(user.Id), "")
+		}
+		return accessToken, refreshToken, updateErr
 	} else {
 		userBody := UserBody{
 			Email:    authBody.Email,
@@ -97,39 +108,57 @@ func (authService *AuthService) AuthenticateUser(authBody UserAuthenticateBody)
 			// Consider rolling back user creation or logging, for now, return error
 			return nil, nil, saveExternalLoginError
 		}
-		return authService.generateAndSaveTokenPair(savedUser)
+		accessToken, refreshToken, generateErr := authService.generateAndSaveTokenPair(savedUser)
+		if generateErr == nil {
+			auditService.RecordEvent(models.AuditLogin, models.AuditTargetUser, savedUser.Id, auditMetadata.withActor(savedUser.Id), "")
+		}
+		return accessToken, refreshToken, generateErr
 	}
 }
 
-func (authService *AuthService) RefreshToken(request RefreshTokenRequest) (*RefreshTokenResponse, error) {
+func (authService *AuthService) RefreshToken(request RefreshTokenRequest, auditMetadata *AuditMetadata) (*RefreshTokenResponse, error) {
+	refreshTokenResponse, user, err := authService.refreshAccessToken(request, auditMetadata)
+
+	if err != nil {
+		auditService.RecordEvent(models.AuditTokenRefreshFailed, models.AuditTargetToken, 0, auditMetadata, err.Error())
+		return nil, err
+	}
+
+	auditService.RecordEvent(models.AuditTokenRefresh, models.AuditTargetUser, user.Id, auditMetadata.withActor(user.Id), "")
+	return refreshTokenResponse, nil
+}
+
+// refreshAccessToken generates a new access token from a refresh token, returning the owner of the token too.
+// The access token it replaces is revoked.
+func (authService *AuthService) refreshAccessToken(request RefreshTokenRequest, auditMetadata *AuditMetadata) (*RefreshTokenResponse, *models.User, error) {
 	validationErr := authService.AppTokenManager.ValidateToken(request.RefreshToken)
 	if validationErr != nil {
-		return nil, validationErr
+		return nil, nil, validationErr
 	}
 
 	claims, claimsErr := authService.AppTokenManager.GetClaims(request.RefreshToken)
 	if claimsErr != nil {
-		return nil, claimsErr
+		return nil, nil, claimsErr
 	}
 
 	email, ok := claims["email"].(string)
 	if !ok {
-		return nil, errors.New("email claim is not a string or not found")
+		return nil, nil, errors.New("email claim is not a string or not found")
 	}
 
 	user, getUserErr := authService.userService.GetUserByEmail(email)
 	if getUserErr != nil {
-		return nil, getUserErr
+		return nil, nil, getUserErr
 	}
 
 	accessTokenString, accessTokenErr := authService.AppTokenManager.GenerateToken(*user, models.Access)
 	if accessTokenErr != nil {
-		return nil, accessTokenErr
+		return nil, nil, accessTokenErr
 	}
 
 	dbAccessToken, getDbAccessTokenErr := authService.tokenService.GetUserTokenByKind(user.Id, models.Access)
 	if getDbAccessTokenErr != nil {
-		return nil, getDbAccessTokenErr
+		return nil, nil, getDbAccessTokenErr
 	}
 
 	accessToken := &models.Token{
@@ -141,10 +170,13 @@ func (authService *AuthService) RefreshToken(request RefreshTokenRequest) (*Refr
 
 	_, saveAccessTokenErr := authService.tokenService.UpdateToken(accessToken)
 	if saveAccessTokenErr != nil {
-		return nil, saveAccessTokenErr
+		return nil, nil, saveAccessTokenErr
 	}
 
-	return &RefreshTokenResponse{Token: accessToken.TokenValue}, nil
+	auditService.RecordEvent(models.AuditTokenRevoked, models.AuditTargetToken, accessToken.Id, auditMetadata.withActor(user.Id),
+		"replaced on token refresh")
+
+	return &RefreshTokenResponse{Token: accessToken.TokenValue}, user, nil
 }
 
 func (authService *AuthService) generateAndSaveTokenPair(user *models.User) (accessToken *models.Token, refreshToken *models.Token, err error) {
@@ -181,7 +213,8 @@ func (authService *AuthService) generateAndSaveTokenPair(user *models.User) (acc
 	return accessToken, refreshToken, nil
 }
 
-func (authService *AuthService) updateTokenPair(user *models.User) (accessToken *models.Token, refreshToken *models.Token, err error) {
+// updateTokenPair replaces the token pair of the user on a new login, revoking the previous tokens.
+func (authService *AuthService) updateTokenPair(user *models.User, auditMetadata *AuditMetadata) (accessToken *models.Token, refreshToken *models.Token, err error) {
 	tokenPair, getTokenPairErr := authService.tokenService.GetUserTokenPair(user.Id)
 	if getTokenPairErr != nil {
 		return nil, nil, getTokenPairErr
@@ -216,6 +249,8 @@ func (authService *AuthService) updateTokenPair(user *models.User) (accessToken
 			if updateErr != nil {
 				return nil, nil, updateErr // return early on first error
 			}
+
+			auditService.RecordEvent(models.AuditTokenRevoked, models.AuditTargetToken, currentTokenToUpdate.Id, auditMetadata, "replaced on login")
 		}
 	}
 
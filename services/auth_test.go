@@ -69,11 +69,11 @@ func (m *mockTokenManager) GetClaims(tokenString string) (jwt.MapClaims, error)
 
 // Mock implementation for UserService
 type mockUserService struct {
-	GetUserByIdFunc    func(id uint) (*models.User, error)
-	GetUserByEmailFunc func(email string) (*models.User, error)
-	SaveUserFunc       func(userBody UserBody) (*models.User, error)
-	UpdateUserFunc     func(userBody UserBody) (*models.User, error)
-	DeleteUserFunc     func(id uint) error
+	GetUserByIdFunc       func(id uint) (*models.User, error)
+	GetUserByEmailFunc    func(email string) (*models.User, error)
+	SaveUserFunc          func(userBody UserBody) (*models.User, error)
+	UpdateUserProfileFunc func(userId uint, profileBody *UpdateUserProfileBody) (*models.User, error)
+	DeleteUserFunc        func(id uint) error
 }
 
 func (m *mockUserService) GetUserById(id uint) (*models.User, error) {
@@ -103,11 +103,11 @@ func (m *mockUserService) SaveUser(userBody UserBody) (*models.User, error) {
 	return &models.User{Id: 2, Email: userBody.Email, UserName: userBody.UserName, Role: models.Standard}, nil
 }
 
-func (m *mockUserService) UpdateUser(userBody UserBody) (*models.User, error) {
-	if m.UpdateUserFunc != nil {
-		return m.UpdateUserFunc(userBody)
+func (m *mockUserService) UpdateUserProfile(userId uint, profileBody *UpdateUserProfileBody) (*models.User, error) {
+	if m.UpdateUserProfileFunc != nil {
+		return m.UpdateUserProfileFunc(userId, profileBody)
 	}
-	return &models.User{Email: userBody.Email, UserName: userBody.UserName, Role: models.Standard}, nil
+	return nil, nil
 }
 
 func (m *mockUserService) DeleteUser(id uint) error {
@@ -176,7 +176,7 @@ func (m *mockTokenService) GetUserTokenPair(userId uint) ([2]*models.Token, erro
 	}, nil
 }
 
-func (m *mockTokenService) DeleteToken(id uint) error {
+func (m *mockTokenService) DeleteToken(id uint, auditMetadata *AuditMetadata) error {
 	if m.DeleteTokenFunc != nil {
 		return m.DeleteTokenFunc(id)
 	}
@@ -264,7 +264,7 @@ func TestAuthenticateUser_ExistingUser(t *testing.T) {
 		ProviderToken: "valid_google_token",
 	}
 
-	accessToken, refreshToken, err := authService.AuthenticateUser(authBody)
+	accessToken, refreshToken, err := authService.AuthenticateUser(authBody, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, accessToken)
@@ -300,7 +300,7 @@ func TestAuthenticateUser_NewUser(t *testing.T) {
 		ProviderToken: "valid_google_token",
 	}
 
-	accessToken, refreshToken, err := authService.AuthenticateUser(authBody)
+	accessToken, refreshToken, err := authService.AuthenticateUser(authBody, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, accessToken)
@@ -333,7 +333,7 @@ func TestAuthenticateUser_GoogleTokenInvalid(t *testing.T) {
 		ProviderToken: "invalid_google_token",
 	}
 
-	_, _, err := authService.AuthenticateUser(authBody)
+	_, _, err := authService.AuthenticateUser(authBody, nil)
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "google token not valid")
@@ -359,11 +359,23 @@ func TestRefreshToken_Valid(t *testing.T) {
 		RefreshToken: "valid_refresh_token",
 	}
 
-	res, err := authService.RefreshToken(req)
+	auditStorageMock := &mockAuditEventStorage{}
+	originalAuditStorage := auditEventStorage
+	auditEventStorage = auditStorageMock
+	defer func() { auditEventStorage = originalAuditStorage }()
+
+	res, err := authService.RefreshToken(req, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 	assert.Equal(t, constants.TestAccessTokenValue, res.Token)
+
+	// the replaced access token is revoked
+	assert.Len(t, auditStorageMock.Events, 2)
+	assert.Equal(t, models.AuditTokenRevoked, auditStorageMock.Events[0].EventType)
+	assert.Equal(t, uint(1), auditStorageMock.Events[0].TargetId)
+	assert.Equal(t, uint(1), auditStorageMock.Events[0].ActorId)
+	assert.Equal(t, models.AuditTokenRefresh, auditStorageMock.Events[1].EventType)
 }
 
 func TestRefreshToken_InvalidToken(t *testing.T) {
@@ -378,7 +390,7 @@ func TestRefreshToken_InvalidToken(t *testing.T) {
 		RefreshToken: "invalid_token_for_refresh",
 	}
 
-	res, err := authService.RefreshToken(req)
+	res, err := authService.RefreshToken(req, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, res)
@@ -406,7 +418,7 @@ func TestRefreshToken_UserNotFound(t *testing.T) {
 		RefreshToken: "valid_refresh_token_unknown_user",
 	}
 
-	res, err := authService.RefreshToken(req)
+	res, err := authService.RefreshToken(req, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, res)
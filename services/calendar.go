@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+)
+
+const (
+	// CalendarMonthLayout is the layout of the months of the calendar.
+	CalendarMonthLayout = "2006-01"
+	// maxCalendarDaySummaries is the number of diary entries, books and games summarized per day. The rest are only counted.
+	maxCalendarDaySummaries = 3
+)
+
+var ErrInvalidCalendarMonth = errors.New("the month must be a calendar month like 2006-01")
+
+var calendarStorage storage.CalendarStorageInterface = &storage.CalendarStorage{}
+
+// CalendarService defines all operations for the calendar service.
+type CalendarService interface {
+	GetCalendarMonth(user *models.User, month string) (*models.CalendarMonth, error)
+}
+
+// CalendarServiceImpl is the concrete implementation of CalendarService.
+type CalendarServiceImpl struct{}
+
+// GetCalendarMonth summarizes the activity of the user on every day of a month, the current one when it is empty.
+// Months and days are in the time zone of the user.
+func (calendarService *CalendarServiceImpl) GetCalendarMonth(user *models.User, month string) (*models.CalendarMonth, error) {
+	location := GetUserLocation(user)
+	monthStart, parseErr := getCalendarMonthStart(month, location)
+
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	dayRanges := []storage.CalendarDayRange{}
+
+	for dayStart := monthStart; dayStart.Month() == monthStart.Month(); dayStart = getNextDayStart(dayStart) {
+		dayRanges = append(dayRanges, storage.CalendarDayRange{
+			Date:      dayStart.Format(LocalDateLayout),
+			StartDate: dayStart.Unix(),
+			EndDate:   getNextDayStart(dayStart).Unix() - 1,
+		})
+	}
+
+	activeDays, err := calendarStorage.GetUserDays(user.Id, dayRanges)
+
+	if err != nil {
+		return nil, err
+	}
+
+	activeDaysByDate := map[string]*models.CalendarDay{}
+
+	for _, activeDay := range activeDays.([]*models.CalendarDay) {
+		activeDaysByDate[activeDay.Date] = activeDay
+	}
+
+	calendarMonth := &models.CalendarMonth{
+		Month:    monthStart.Format(CalendarMonthLayout),
+		TimeZone: location.String(),
+		Days:     make([]*models.CalendarDay, len(dayRanges)),
+	}
+
+	for i, dayRange := range dayRanges {
+		calendarDay, active := activeDaysByDate[dayRange.Date]
+
+		if !active {
+			calendarDay = &models.CalendarDay{
+				Date:         dayRange.Date,
+				DiaryEntries: []*models.CalendarDiaryEntry{},
+				Books:        []*models.CalendarBook{},
+				Games:        []*models.CalendarGame{},
+			}
+		}
+
+		calendarDay.DiaryEntries = calendarDay.DiaryEntries[:min(len(calendarDay.DiaryEntries), maxCalendarDaySummaries)]
+		calendarDay.Books = calendarDay.Books[:min(len(calendarDay.Books), maxCalendarDaySummaries)]
+		calendarDay.Games = calendarDay.Games[:min(len(calendarDay.Games), maxCalendarDaySummaries)]
+		calendarMonth.Days[i] = calendarDay
+	}
+
+	return calendarMonth, nil
+}
+
+// getCalendarMonthStart returns the midnight starting a month like 2006-01 in the location, or the current month.
+func getCalendarMonthStart(month string, location *time.Location) (time.Time, error) {
+	if len(month) == 0 {
+		now := time.Now().In(location)
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location), nil
+	}
+
+	monthStart, err := time.ParseInLocation(CalendarMonthLayout, month, location)
+
+	if err != nil {
+		return time.Time{}, ErrInvalidCalendarMonth
+	}
+
+	return monthStart, nil
+}
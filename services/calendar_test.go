@@ -0,0 +1,80 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockCalendarStorage implements CalendarStorageInterface
+type mockCalendarStorage struct {
+	Days      []*models.CalendarDay
+	DayRanges []storage.CalendarDayRange
+}
+
+func (m *mockCalendarStorage) GetUserDays(userId uint, dayRanges []storage.CalendarDayRange) (interface{}, error) {
+	m.DayRanges = dayRanges
+	return m.Days, nil
+}
+
+func setUpCalendarMocks(t *testing.T) *mockCalendarStorage {
+	originalCalendarStorage := calendarStorage
+	calendarStorageMock := &mockCalendarStorage{Days: []*models.CalendarDay{}}
+	calendarStorage = calendarStorageMock
+	t.Cleanup(func() {
+		calendarStorage = originalCalendarStorage
+	})
+
+	return calendarStorageMock
+}
+
+func TestGetCalendarMonth(t *testing.T) {
+	calendarStorageMock := setUpCalendarMocks(t)
+	calendarService := &CalendarServiceImpl{}
+	user := &models.User{Id: 1, TimeZone: "Europe/Madrid"}
+	calendarStorageMock.Days = []*models.CalendarDay{{
+		Date:            "2026-10-17",
+		DiaryEntryCount: 1,
+		GameCount:       5,
+		DiaryEntries:    []*models.CalendarDiaryEntry{{Id: 1, Title: "Saturday"}},
+		Books:           []*models.CalendarBook{},
+		Games:           []*models.CalendarGame{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 4}, {Id: 5}},
+	}}
+
+	calendarMonth, err := calendarService.GetCalendarMonth(user, "2026-10")
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-10", calendarMonth.Month)
+	assert.Equal(t, "Europe/Madrid", calendarMonth.TimeZone)
+	assert.Len(t, calendarMonth.Days, 31)
+	assert.Equal(t, "2026-10-01", calendarMonth.Days[0].Date)
+	assert.Zero(t, calendarMonth.Days[0].DiaryEntryCount)
+	assert.NotNil(t, calendarMonth.Days[0].DiaryEntries)
+
+	// Test the summaries are limited, while counts are kept
+	activeDay := calendarMonth.Days[16]
+	assert.Equal(t, "2026-10-17", activeDay.Date)
+	assert.Equal(t, 5, activeDay.GameCount)
+	assert.Len(t, activeDay.Games, maxCalendarDaySummaries)
+	assert.Equal(t, "Saturday", activeDay.DiaryEntries[0].Title)
+
+	// Test days are in the time zone of the user, including the 25 hour day of the change to winter time
+	madridLocation, _ := time.LoadLocation("Europe/Madrid")
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, madridLocation).Unix(), calendarStorageMock.DayRanges[0].StartDate)
+	assert.Equal(t, int64(25*60*60-1), calendarStorageMock.DayRanges[24].EndDate-calendarStorageMock.DayRanges[24].StartDate)
+	assert.Equal(t, calendarStorageMock.DayRanges[0].EndDate+1, calendarStorageMock.DayRanges[1].StartDate)
+
+	calendarMonth, err = calendarService.GetCalendarMonth(user, "2026-02")
+	assert.NoError(t, err)
+	assert.Len(t, calendarMonth.Days, 28)
+
+	// Test the current month is the default
+	calendarMonth, err = calendarService.GetCalendarMonth(user, "")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Now().In(madridLocation).Format(CalendarMonthLayout), calendarMonth.Month)
+
+	_, err = calendarService.GetCalendarMonth(user, "2026-10-17")
+	assert.ErrorIs(t, err, ErrInvalidCalendarMonth)
+}
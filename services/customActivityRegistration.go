@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"github.com/adfer-dev/analock-api/utils"
+)
+
+// CustomActivityRegistrationService defines the operations on the registrations of the declared activity types.
+// Registrations of other users, or of another activity type than the requested one, are reported as not found.
+type CustomActivityRegistrationService interface {
+	GetUserCustomActivityRegistrations(userId uint, activityType string, startDate int64, endDate int64) ([]*models.CustomActivityRegistration, error)
+	GetAllUserCustomActivityRegistrations(userId uint) ([]*models.CustomActivityRegistration, error)
+	GetCustomActivityRegistration(userId uint, activityType string, registrationId uint) (*models.CustomActivityRegistration, error)
+	CreateCustomActivityRegistration(userId uint, activityType string, addRegistrationBody *AddCustomActivityRegistrationBody, auditMetadata *AuditMetadata) (*models.CustomActivityRegistration, error)
+	PatchCustomActivityRegistration(userId uint, activityType string, registrationId uint, patch []byte, expectedVersion int64, auditMetadata *AuditMetadata) (*models.CustomActivityRegistration, error)
+	DeleteCustomActivityRegistration(userId uint, activityType string, registrationId uint, auditMetadata *AuditMetadata) error
+}
+
+type CustomActivityRegistrationServiceImpl struct{}
+
+// AddCustomActivityRegistrationBody holds a new registration of a declared activity type.
+// The payload must match the schema of the activity type.
+type AddCustomActivityRegistrationBody struct {
+	RegistrationDate int64           `json:"registrationDate" validate:"required"`
+	Payload          json.RawMessage `json:"payload" validate:"required" swaggertype:"object"`
+}
+
+// UpdateCustomActivityRegistrationBody holds the updatable fields of a registration of a declared activity type,
+// which partial updates are merged into. Members of the payload are merged one by one.
+type UpdateCustomActivityRegistrationBody struct {
+	RegistrationDate int64           `json:"registrationDate" validate:"required"`
+	Payload          json.RawMessage `json:"payload" validate:"required" swaggertype:"object"`
+}
+
+var customActivityRegistrationStorage storage.CustomActivityRegistrationStorageInterface = &storage.CustomActivityRegistrationStorage{}
+
+// GetUserCustomActivityRegistrations returns the registrations of an activity type of the user, oldest first.
+// Zero dates are not applied.
+func (customActivityRegistrationService *CustomActivityRegistrationServiceImpl) GetUserCustomActivityRegistrations(userId uint, activityType string, startDate int64, endDate int64) ([]*models.CustomActivityRegistration, error) {
+	if !IsDeclaredActivityType(activityType) {
+		return nil, ErrUnknownActivityType
+	}
+
+	dbUserRegistrations, err := customActivityRegistrationStorage.GetByUserIdAndType(userId, activityType, startDate, endDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dbUserRegistrations.([]*models.CustomActivityRegistration), nil
+}
+
+// GetAllUserCustomActivityRegistrations returns the registrations of every declared activity type of the user, oldest first.
+func (customActivityRegistrationService *CustomActivityRegistrationServiceImpl) GetAllUserCustomActivityRegistrations(userId uint) ([]*models.CustomActivityRegistration, error) {
+	dbUserRegistrations, err := customActivityRegistrationStorage.GetByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dbUserRegistrations.([]*models.CustomActivityRegistration), nil
+}
+
+func (customActivityRegistrationService *CustomActivityRegistrationServiceImpl) GetCustomActivityRegistration(userId uint, activityType string, registrationId uint) (*models.CustomActivityRegistration, error) {
+	if !IsDeclaredActivityType(activityType) {
+		return nil, ErrUnknownActivityType
+	}
+
+	storedRegistration, getErr := customActivityRegistrationStorage.Get(registrationId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	customRegistration := storedRegistration.(*models.CustomActivityRegistration)
+
+	if customRegistration.Registration.UserRefer != userId || customRegistration.ActivityType != activityType {
+		return nil, &models.DbNotFoundError{DbItem: &models.CustomActivityRegistration{}}
+	}
+
+	return customRegistration, nil
+}
+
+// CreateCustomActivityRegistration registers an activity of a declared type for the user, once its payload matches the schema of the type.
+func (customActivityRegistrationService *CustomActivityRegistrationServiceImpl) CreateCustomActivityRegistration(userId uint, activityType string, addRegistrationBody *AddCustomActivityRegistrationBody, auditMetadata *AuditMetadata) (*models.CustomActivityRegistration, error) {
+	if validationErr := validateActivityPayload(activityType, addRegistrationBody.Payload); validationErr != nil {
+		return nil, validationErr
+	}
+
+	payload := &bytes.Buffer{}
+
+	if compactErr := json.Compact(payload, addRegistrationBody.Payload); compactErr != nil {
+		return nil, compactErr
+	}
+
+	dbActivityRegistration := &models.ActivityRegistration{
+		RegistrationDate: addRegistrationBody.RegistrationDate,
+		UserRefer:        userId,
+	}
+
+	if createErr := activityRegistrationStorage.Create(dbActivityRegistration); createErr != nil {
+		return nil, createErr
+	}
+
+	dbCustomActivityRegistration := &models.CustomActivityRegistration{
+		Registration: *dbActivityRegistration,
+		ActivityType: activityType,
+		Payload:      payload.Bytes(),
+	}
+
+	if createErr := customActivityRegistrationStorage.Create(dbCustomActivityRegistration); createErr != nil {
+		return nil, createErr
+	}
+
+	auditService.RecordEvent(models.AuditCustomRegistrationCreated, models.AuditTargetCustomRegistration, dbCustomActivityRegistration.Id, auditMetadata, "activity type: "+activityType)
+
+	return dbCustomActivityRegistration, nil
+}
+
+// PatchCustomActivityRegistration applies a JSON Merge Patch to a registration of a declared activity type of the user.
+// The merged payload must still match the schema of the type. A non zero expected version must be the stored one.
+func (customActivityRegistrationService *CustomActivityRegistrationServiceImpl) PatchCustomActivityRegistration(userId uint, activityType string, registrationId uint, patch []byte, expectedVersion int64, auditMetadata *AuditMetadata) (*models.CustomActivityRegistration, error) {
+	customRegistration, getErr := customActivityRegistrationService.GetCustomActivityRegistration(userId, activityType, registrationId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if expectedVersion != 0 && expectedVersion != customRegistration.Registration.Version {
+		return nil, &models.DbVersionConflictError{DbItem: &models.CustomActivityRegistration{}, CurrentVersion: customRegistration.Registration.Version}
+	}
+
+	updateBody := &UpdateCustomActivityRegistrationBody{
+		RegistrationDate: customRegistration.Registration.RegistrationDate,
+		Payload:          customRegistration.Payload,
+	}
+
+	if patchErr := utils.ApplyMergePatch(updateBody, patch); patchErr != nil {
+		return nil, patchErr
+	}
+
+	if validationErr := validateActivityPayload(activityType, updateBody.Payload); validationErr != nil {
+		return nil, validationErr
+	}
+
+	customRegistration.Registration.RegistrationDate = updateBody.RegistrationDate
+	customRegistration.Payload = updateBody.Payload
+
+	if updateErr := customActivityRegistrationStorage.Update(customRegistration); updateErr != nil {
+		return nil, updateErr
+	}
+
+	auditService.RecordEvent(models.AuditCustomRegistrationUpdated, models.AuditTargetCustomRegistration, customRegistration.Id, auditMetadata, "activity type: "+activityType)
+
+	return customRegistration, nil
+}
+
+// DeleteCustomActivityRegistration moves a registration of a declared activity type of the user to the trash.
+func (customActivityRegistrationService *CustomActivityRegistrationServiceImpl) DeleteCustomActivityRegistration(userId uint, activityType string, registrationId uint, auditMetadata *AuditMetadata) error {
+	customRegistration, getErr := customActivityRegistrationService.GetCustomActivityRegistration(userId, activityType, registrationId)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	if trashErr := activityRegistrationStorage.Trash(customRegistration.Registration.Id, time.Now().Unix()); trashErr != nil {
+		return trashErr
+	}
+
+	auditService.RecordEvent(models.AuditCustomRegistrationDeleted, models.AuditTargetCustomRegistration, customRegistration.Id, auditMetadata, "activity type: "+activityType)
+
+	return nil
+}
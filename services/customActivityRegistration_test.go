@@ -0,0 +1,217 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockCustomActivityRegistrationStorage implements CustomActivityRegistrationStorageInterface
+type mockCustomActivityRegistrationStorage struct {
+	Registrations       map[uint]*models.CustomActivityRegistration
+	CreatedRegistration *models.CustomActivityRegistration
+	UpdatedRegistration *models.CustomActivityRegistration
+}
+
+func (m *mockCustomActivityRegistrationStorage) Get(id uint) (interface{}, error) {
+	registration, found := m.Registrations[id]
+
+	if !found || registration.Registration.DeletedAt != 0 {
+		return nil, &models.DbNotFoundError{DbItem: &models.CustomActivityRegistration{}}
+	}
+
+	return registration, nil
+}
+
+func (m *mockCustomActivityRegistrationStorage) GetByUserId(userId uint) (interface{}, error) {
+	return m.filter(func(registration *models.CustomActivityRegistration) bool {
+		return registration.Registration.UserRefer == userId && registration.Registration.DeletedAt == 0
+	}), nil
+}
+
+func (m *mockCustomActivityRegistrationStorage) GetByUserIdAndType(userId uint, activityType string, startDate int64, endDate int64) (interface{}, error) {
+	return m.filter(func(registration *models.CustomActivityRegistration) bool {
+		return registration.Registration.UserRefer == userId && registration.Registration.DeletedAt == 0 &&
+			registration.ActivityType == activityType &&
+			(startDate == 0 || registration.Registration.RegistrationDate >= startDate) &&
+			(endDate == 0 || registration.Registration.RegistrationDate <= endDate)
+	}), nil
+}
+
+func (m *mockCustomActivityRegistrationStorage) GetTrashedByUserId(userId uint) (interface{}, error) {
+	return m.filter(func(registration *models.CustomActivityRegistration) bool {
+		return registration.Registration.UserRefer == userId && registration.Registration.DeletedAt != 0
+	}), nil
+}
+
+func (m *mockCustomActivityRegistrationStorage) Create(data interface{}) error {
+	registration := data.(*models.CustomActivityRegistration)
+	registration.Id = uint(len(m.Registrations) + 1)
+	m.Registrations[registration.Id] = registration
+	m.CreatedRegistration = registration
+	return nil
+}
+
+func (m *mockCustomActivityRegistrationStorage) Update(data interface{}) error {
+	m.UpdatedRegistration = data.(*models.CustomActivityRegistration)
+	m.UpdatedRegistration.Registration.Version++
+	return nil
+}
+
+func (m *mockCustomActivityRegistrationStorage) filter(matches func(*models.CustomActivityRegistration) bool) []*models.CustomActivityRegistration {
+	registrations := []*models.CustomActivityRegistration{}
+
+	for id := uint(1); id <= uint(len(m.Registrations)); id++ {
+		if registration, found := m.Registrations[id]; found && matches(registration) {
+			registrations = append(registrations, registration)
+		}
+	}
+
+	return registrations
+}
+
+func setUpCustomActivityRegistrationMocks(t *testing.T) (*mockCustomActivityRegistrationStorage, *mockActivityRegistrationStorage) {
+	originalCustomStorage := customActivityRegistrationStorage
+	originalActivityStorage := activityRegistrationStorage
+	customStorageMock := &mockCustomActivityRegistrationStorage{Registrations: make(map[uint]*models.CustomActivityRegistration)}
+	activityStorageMock := &mockActivityRegistrationStorage{}
+	customActivityRegistrationStorage = customStorageMock
+	activityRegistrationStorage = activityStorageMock
+	t.Cleanup(func() {
+		customActivityRegistrationStorage = originalCustomStorage
+		activityRegistrationStorage = originalActivityStorage
+	})
+
+	return customStorageMock, activityStorageMock
+}
+
+var customRegistrationService CustomActivityRegistrationService = &CustomActivityRegistrationServiceImpl{}
+
+// Ensure the mock satisfies the storage interface
+var _ storage.CustomActivityRegistrationStorageInterface = &mockCustomActivityRegistrationStorage{}
+
+func TestCreateCustomActivityRegistration(t *testing.T) {
+	customStorageMock, activityStorageMock := setUpCustomActivityRegistrationMocks(t)
+
+	body := &AddCustomActivityRegistrationBody{
+		RegistrationDate: 1000,
+		Payload:          json.RawMessage(`{ "durationMinutes": 45, "place": "beach" }`),
+	}
+	registration, err := customRegistrationService.CreateCustomActivityRegistration(1, "walk", body, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "walk", registration.ActivityType)
+	assert.JSONEq(t, `{"durationMinutes":45,"place":"beach"}`, string(registration.Payload))
+	assert.Equal(t, `{"durationMinutes":45,"place":"beach"}`, string(customStorageMock.CreatedRegistration.Payload))
+	assert.Equal(t, uint(1), activityStorageMock.CreatedActivity.UserRefer)
+	assert.Equal(t, int64(1000), activityStorageMock.CreatedActivity.RegistrationDate)
+
+	// Test payloads not matching the schema of the type are rejected
+	invalidPayloads := []string{`{"place":"beach"}`, `{"durationMinutes":0}`, `{"durationMinutes":45,"steps":3000}`, `null`, `[45]`}
+
+	for _, invalidPayload := range invalidPayloads {
+		body.Payload = json.RawMessage(invalidPayload)
+		_, err = customRegistrationService.CreateCustomActivityRegistration(1, "walk", body, nil)
+		var schemaErr *utils.JSONSchemaValidationError
+		assert.ErrorAs(t, err, &schemaErr, invalidPayload)
+	}
+	assert.Len(t, customStorageMock.Registrations, 1)
+
+	// Test undeclared activity types
+	body.Payload = json.RawMessage(`{"durationMinutes":45}`)
+	_, err = customRegistrationService.CreateCustomActivityRegistration(1, "skydiving", body, nil)
+	assert.ErrorIs(t, err, ErrUnknownActivityType)
+}
+
+func TestGetUserCustomActivityRegistrations(t *testing.T) {
+	customStorageMock, _ := setUpCustomActivityRegistrationMocks(t)
+	customStorageMock.Registrations[1] = &models.CustomActivityRegistration{Id: 1, ActivityType: "walk", Registration: models.ActivityRegistration{UserRefer: 1, RegistrationDate: 100}}
+	customStorageMock.Registrations[2] = &models.CustomActivityRegistration{Id: 2, ActivityType: "meditation", Registration: models.ActivityRegistration{UserRefer: 1, RegistrationDate: 200}}
+	customStorageMock.Registrations[3] = &models.CustomActivityRegistration{Id: 3, ActivityType: "walk", Registration: models.ActivityRegistration{UserRefer: 2, RegistrationDate: 300}}
+	customStorageMock.Registrations[4] = &models.CustomActivityRegistration{Id: 4, ActivityType: "walk", Registration: models.ActivityRegistration{UserRefer: 1, RegistrationDate: 400}}
+
+	walks, err := customRegistrationService.GetUserCustomActivityRegistrations(1, "walk", 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, walks, 2)
+
+	walks, err = customRegistrationService.GetUserCustomActivityRegistrations(1, "walk", 300, 500)
+	assert.NoError(t, err)
+	assert.Len(t, walks, 1)
+	assert.Equal(t, uint(4), walks[0].Id)
+
+	allRegistrations, err := customRegistrationService.GetAllUserCustomActivityRegistrations(1)
+	assert.NoError(t, err)
+	assert.Len(t, allRegistrations, 3)
+
+	_, err = customRegistrationService.GetUserCustomActivityRegistrations(1, "skydiving", 0, 0)
+	assert.ErrorIs(t, err, ErrUnknownActivityType)
+
+	// Test registrations of other users or types are not found
+	registration, err := customRegistrationService.GetCustomActivityRegistration(1, "walk", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), registration.Id)
+
+	var notFoundErr *models.DbNotFoundError
+	_, err = customRegistrationService.GetCustomActivityRegistration(1, "walk", 3)
+	assert.ErrorAs(t, err, &notFoundErr)
+	_, err = customRegistrationService.GetCustomActivityRegistration(1, "walk", 2)
+	assert.ErrorAs(t, err, &notFoundErr)
+}
+
+func TestPatchCustomActivityRegistration(t *testing.T) {
+	customStorageMock, _ := setUpCustomActivityRegistrationMocks(t)
+	customStorageMock.Registrations[1] = &models.CustomActivityRegistration{
+		Id:           1,
+		ActivityType: "music_practice",
+		Payload:      json.RawMessage(`{"instrument":"piano","durationMinutes":30,"pieces":["Clair de lune"]}`),
+		Registration: models.ActivityRegistration{Id: 10, UserRefer: 1, RegistrationDate: 1000, Version: 1},
+	}
+
+	// Test payload members are merged one by one
+	patchedRegistration, err := customRegistrationService.PatchCustomActivityRegistration(1, "music_practice", 1,
+		[]byte(`{"payload":{"durationMinutes":45,"pieces":null}}`), 0, nil)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"instrument":"piano","durationMinutes":45}`, string(patchedRegistration.Payload))
+	assert.JSONEq(t, `{"instrument":"piano","durationMinutes":45}`, string(customStorageMock.UpdatedRegistration.Payload))
+	assert.Equal(t, int64(1000), customStorageMock.UpdatedRegistration.Registration.RegistrationDate)
+	assert.Equal(t, int64(2), patchedRegistration.Registration.Version)
+
+	// Test patches based on an outdated version are rejected
+	customStorageMock.UpdatedRegistration = nil
+	_, err = customRegistrationService.PatchCustomActivityRegistration(1, "music_practice", 1, []byte(`{"registrationDate":2000}`), 1, nil)
+	var conflictErr *models.DbVersionConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, int64(2), conflictErr.CurrentVersion)
+	assert.Nil(t, customStorageMock.UpdatedRegistration)
+
+	// Test merged payloads not matching the schema are rejected
+	customStorageMock.UpdatedRegistration = nil
+	_, err = customRegistrationService.PatchCustomActivityRegistration(1, "music_practice", 1, []byte(`{"payload":{"instrument":null}}`), 0, nil)
+	var schemaErr *utils.JSONSchemaValidationError
+	assert.ErrorAs(t, err, &schemaErr)
+	assert.Nil(t, customStorageMock.UpdatedRegistration)
+
+	_, err = customRegistrationService.PatchCustomActivityRegistration(1, "music_practice", 1, []byte(`{"payload":null}`), 0, nil)
+	var invalidBodyErr *utils.InvalidBodyError
+	assert.ErrorAs(t, err, &invalidBodyErr)
+
+	// Test registrations of other users are not found
+	_, err = customRegistrationService.PatchCustomActivityRegistration(2, "music_practice", 1, []byte(`{"registrationDate":2000}`), 0, nil)
+	var notFoundErr *models.DbNotFoundError
+	assert.ErrorAs(t, err, &notFoundErr)
+}
+
+func TestDeleteCustomActivityRegistration(t *testing.T) {
+	customStorageMock, activityStorageMock := setUpCustomActivityRegistrationMocks(t)
+	customStorageMock.Registrations[1] = &models.CustomActivityRegistration{Id: 1, ActivityType: "meditation", Registration: models.ActivityRegistration{Id: 10, UserRefer: 1}}
+
+	var notFoundErr *models.DbNotFoundError
+	assert.ErrorAs(t, customRegistrationService.DeleteCustomActivityRegistration(2, "meditation", 1, nil), &notFoundErr)
+	assert.Zero(t, activityStorageMock.TrashedId)
+
+	assert.NoError(t, customRegistrationService.DeleteCustomActivityRegistration(1, "meditation", 1, nil))
+	assert.Equal(t, uint(10), activityStorageMock.TrashedId)
+}
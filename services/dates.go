@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+)
+
+// Dates are stored and returned as Unix timestamps in seconds, which have no time zone.
+// Calendar dates sent by clients are read in the time zone of the user, so that day boundaries
+// match the days the user lives in rather than UTC days.
+
+// LocalDateLayout is the layout of the calendar dates accepted by the date query parameters.
+const LocalDateLayout = time.DateOnly
+
+var ErrInvalidDate = errors.New("dates must be Unix timestamps in seconds, RFC 3339 timestamps or calendar dates like 2006-01-02")
+
+// GetUserLocation returns the time zone of the user, defaulting to UTC.
+func GetUserLocation(user *models.User) *time.Location {
+	if location, err := time.LoadLocation(user.TimeZone); err == nil && len(user.TimeZone) > 0 {
+		return location
+	}
+
+	return time.UTC
+}
+
+// ParseDate reads a date sent by a client as a Unix timestamp in seconds, an RFC 3339 timestamp or a calendar date.
+// Calendar dates are read in the location and resolve to the first second of the day, or to the last one when
+// endOfDay is set, so that they can be used as inclusive range bounds.
+func ParseDate(value string, location *time.Location, endOfDay bool) (int64, error) {
+	if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil && timestamp >= 0 {
+		return timestamp, nil
+	}
+
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date.Unix(), nil
+	}
+
+	localDate, err := time.ParseInLocation(LocalDateLayout, value, location)
+
+	if err != nil {
+		return 0, ErrInvalidDate
+	}
+
+	if endOfDay {
+		return getNextDayStart(localDate).Unix() - 1, nil
+	}
+
+	return localDate.Unix(), nil
+}
+
+// GetLocalDayRange returns the first and last second of a calendar date in the location.
+func GetLocalDayRange(value string, location *time.Location) (int64, int64, error) {
+	localDate, err := time.ParseInLocation(LocalDateLayout, value, location)
+
+	if err != nil {
+		return 0, 0, ErrInvalidDate
+	}
+
+	return localDate.Unix(), getNextDayStart(localDate).Unix() - 1, nil
+}
+
+// getDayStart returns the midnight starting the day of the date, in the location of the date.
+func getDayStart(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+}
+
+// getNextDayStart returns the midnight ending the day of the date. Days are not always 24 hours long,
+// because of daylight saving time changes.
+func getNextDayStart(date time.Time) time.Time {
+	return getDayStart(date).AddDate(0, 0, 1)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUserLocation(t *testing.T) {
+	assert.Equal(t, "Europe/Madrid", GetUserLocation(&models.User{TimeZone: "Europe/Madrid"}).String())
+	assert.Equal(t, time.UTC, GetUserLocation(&models.User{}))
+	assert.Equal(t, time.UTC, GetUserLocation(&models.User{TimeZone: "Mars/Olympus"}))
+}
+
+func TestParseDate(t *testing.T) {
+	madridLocation, _ := time.LoadLocation("Europe/Madrid")
+
+	date, err := ParseDate("1700000000", madridLocation, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1700000000), date)
+
+	date, err = ParseDate("2026-10-17T10:00:00+02:00", time.UTC, false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC).Unix(), date)
+
+	// Test calendar dates are days in the location
+	date, err = ParseDate("2026-10-17", madridLocation, false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC).Unix(), date)
+
+	date, err = ParseDate("2026-10-17", madridLocation, true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 17, 21, 59, 59, 0, time.UTC).Unix(), date)
+
+	for _, invalidDate := range []string{"", "-5", "17/10/2026", "2026-13-01", "yesterday"} {
+		_, err = ParseDate(invalidDate, time.UTC, false)
+		assert.ErrorIs(t, err, ErrInvalidDate, invalidDate)
+	}
+}
+
+func TestGetLocalDayRange(t *testing.T) {
+	madridLocation, _ := time.LoadLocation("Europe/Madrid")
+
+	startDate, endDate, err := GetLocalDayRange("2026-10-17", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC).Unix(), startDate)
+	assert.Equal(t, startDate+24*60*60-1, endDate)
+
+	// Test days changing to winter time last 25 hours
+	startDate, endDate, err = GetLocalDayRange("2026-10-25", madridLocation)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 24, 22, 0, 0, 0, time.UTC).Unix(), startDate)
+	assert.Equal(t, startDate+25*60*60-1, endDate)
+
+	_, _, err = GetLocalDayRange("1700000000", time.UTC)
+	assert.ErrorIs(t, err, ErrInvalidDate)
+}
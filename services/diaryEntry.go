@@ -1,21 +1,116 @@
 package services
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
 	"github.com/adfer-dev/analock-api/models"
 	"github.com/adfer-dev/analock-api/storage"
+	"github.com/adfer-dev/analock-api/utils"
 )
 
+// SaveDiaryEntryBody creates a diary entry, published at a Unix timestamp in seconds. End-to-end encrypted entries
+// carry the ciphertext as content and no plaintext title, tags, mood or feelings.
 type SaveDiaryEntryBody struct {
-	Title       string `json:"title" validate:"required"`
-	Content     string `json:"content" validate:"required"`
-	PublishDate int64  `json:"publishDate" validate:"required"`
-	UserRefer   uint   `json:"userId" validate:"required"`
+	Title       string                    `json:"title" validate:"required_without=Encryption,excluded_with=Encryption"`
+	Content     string                    `json:"content" validate:"required"`
+	PublishDate int64                     `json:"publishDate" validate:"required"`
+	Tags        []string                  `json:"tags" validate:"omitempty,excluded_with=Encryption,max=20,dive,max=32,excludesall=0x2C"`
+	Mood        *int                      `json:"mood" validate:"omitempty,excluded_with=Encryption,min=1,max=5"`
+	Feelings    []string                  `json:"feelings" validate:"omitempty,excluded_with=Encryption,unique,dive,oneof=calm happy grateful energetic focused bored tired anxious stressed sad lonely angry"`
+	Encryption  *DiaryEntryEncryptionBody `json:"encryption"`
+	// PromptRefer is the id of the prompt answered by the entry, if any.
+	PromptRefer *uint `json:"promptId" validate:"omitempty,min=1"`
 }
 
+// UpdateDiaryEntryBody replaces a diary entry. When tags, mood or feelings are not provided the entry keeps them,
+// while an empty list of tags or feelings and a zero mood remove them.
+// Encrypting an entry removes its plaintext tags, mood, feelings and revisions.
 type UpdateDiaryEntryBody struct {
-	Title       string `json:"title" validate:"required"`
-	Content     string `json:"content" validate:"required"`
-	PublishDate int64  `json:"publishDate" validate:"required"`
+	Title       string                    `json:"title" validate:"required_without=Encryption,excluded_with=Encryption"`
+	Content     string                    `json:"content" validate:"required"`
+	PublishDate int64                     `json:"publishDate" validate:"required"`
+	Tags        []string                  `json:"tags" validate:"omitempty,excluded_with=Encryption,max=20,dive,max=32,excludesall=0x2C"`
+	Mood        *int                      `json:"mood" validate:"omitempty,excluded_with=Encryption,min=1,max=5"`
+	Feelings    []string                  `json:"feelings" validate:"omitempty,excluded_with=Encryption,unique,dive,oneof=calm happy grateful energetic focused bored tired anxious stressed sad lonely angry"`
+	Encryption  *DiaryEntryEncryptionBody `json:"encryption"`
+	// ExpectedVersion is the version the client based the update on, from the If-Match header. Zero skips the check.
+	ExpectedVersion int64 `json:"-"`
+}
+
+// DiaryEntryEncryptionBody is the metadata of an end-to-end encrypted diary entry, stored opaquely.
+type DiaryEntryEncryptionBody struct {
+	Algorithm  string `json:"algorithm" validate:"required,oneof=AES-256-GCM XChaCha20-Poly1305"`
+	Nonce      string `json:"nonce" validate:"required,base64,max=64"`
+	KeyId      string `json:"keyId" validate:"required,max=64"`
+	WrappedKey string `json:"wrappedKey" validate:"required,base64,max=1024"`
+}
+
+const (
+	DiaryEntriesSortAscending  = "asc"
+	DiaryEntriesSortDescending = "desc"
+
+	defaultDiaryEntriesPageLimit = 50
+	maxDiaryEntriesPageLimit     = 200
+
+	defaultDiaryEntrySearchPageSize = 20
+	maxDiaryEntrySearchPageSize     = 100
+)
+
+// ErrInvalidDiaryEntryCursor is returned when a page cursor was not issued by the API.
+var ErrInvalidDiaryEntryCursor = errors.New("invalid diary entries cursor")
+
+// ErrInvalidDiaryEntrySearchQuery is returned when a search query has no searchable terms.
+var ErrInvalidDiaryEntrySearchQuery = errors.New("the search query must contain at least one word")
+
+// ErrDiaryEntrySearchIndexDisabled is returned when searching the entries of a user who did not opt into the search index.
+var ErrDiaryEntrySearchIndexDisabled = errors.New("diary entries are encrypted at rest, enable the search index to search them")
+
+// isContentEncryptionEnabled reports whether diary content is encrypted at rest, and the search index is opt-in.
+var isContentEncryptionEnabled = storage.IsContentEncryptionEnabled
+
+// DiaryEntryPageQuery holds the filters, sort and position of a page of user diary entries.
+// Zero dates are not applied, and an empty cursor returns the first page.
+// When tags are given, only the entries having all of them are returned.
+type DiaryEntryPageQuery struct {
+	StartDate int64
+	EndDate   int64
+	Tags      []string
+	Sort      string
+	Limit     int
+	Cursor    string
+}
+
+// DiaryEntryPage is a page of user diary entries. NextCursor is empty on the last page.
+type DiaryEntryPage struct {
+	Entries    []*models.DiaryEntry `json:"entries"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+}
+
+// DiaryEntrySearchQuery holds a full-text search over the diary entries of a user.
+// Words are matched as prefixes when they end with *, and text between double quotes is matched as a phrase.
+type DiaryEntrySearchQuery struct {
+	Query     string
+	StartDate int64
+	EndDate   int64
+	Page      int
+	PageSize  int
+}
+
+type DiaryEntrySearchPage struct {
+	Results  []*models.DiaryEntrySearchResult `json:"results"`
+	Page     int                              `json:"page"`
+	PageSize int                              `json:"pageSize"`
+}
+
+// diaryEntryCursor is the JSON payload of the opaque cursors handed to clients.
+type diaryEntryCursor struct {
+	RegistrationDate int64 `json:"d"`
+	Id               uint  `json:"i"`
 }
 
 var diaryEntryStorage storage.DiaryEntryStorageInterface = &storage.DiaryEntryStorage{}
@@ -24,9 +119,12 @@ type DiaryEntryService interface {
 	GetDiaryEntryById(id uint) (*models.DiaryEntry, error)
 	GetUserEntries(userId uint) ([]*models.DiaryEntry, error)
 	GetUserEntriesTimeRange(userId uint, startDate int64, endDate int64) ([]*models.DiaryEntry, error)
-	SaveDiaryEntry(diaryEntryBody *SaveDiaryEntryBody) (*models.DiaryEntry, error)
-	UpdateDiaryEntry(diaryEntryId uint, diaryEntryBody *UpdateDiaryEntryBody) (*models.DiaryEntry, error)
-	DeleteDiaryEntry(id uint) error
+	GetUserEntriesPage(userId uint, pageQuery *DiaryEntryPageQuery) (*DiaryEntryPage, error)
+	SearchUserEntries(userId uint, searchQuery *DiaryEntrySearchQuery) (*DiaryEntrySearchPage, error)
+	SaveDiaryEntry(userId uint, diaryEntryBody *SaveDiaryEntryBody, auditMetadata *AuditMetadata) (*models.DiaryEntry, error)
+	UpdateDiaryEntry(diaryEntryId uint, diaryEntryBody *UpdateDiaryEntryBody, auditMetadata *AuditMetadata) (*models.DiaryEntry, error)
+	PatchDiaryEntry(userId uint, diaryEntryId uint, patch []byte, expectedVersion int64, auditMetadata *AuditMetadata) (*models.DiaryEntry, error)
+	DeleteDiaryEntry(id uint, auditMetadata *AuditMetadata) error
 }
 
 type DefaultDiaryEntryService struct{}
@@ -61,10 +159,109 @@ func (defaultDiaryEntryService *DefaultDiaryEntryService) GetUserEntriesTimeRang
 	return diaryEntry.([]*models.DiaryEntry), nil
 }
 
-func (defaultDiaryEntryService *DefaultDiaryEntryService) SaveDiaryEntry(diaryEntryBody *SaveDiaryEntryBody) (*models.DiaryEntry, error) {
+// GetUserEntriesPage returns a page of user diary entries, sorted by registration date.
+// Entries are sorted most recent first unless the query asks for ascending order.
+func (defaultDiaryEntryService *DefaultDiaryEntryService) GetUserEntriesPage(userId uint, pageQuery *DiaryEntryPageQuery) (*DiaryEntryPage, error) {
+	limit := pageQuery.Limit
+	if limit <= 0 {
+		limit = defaultDiaryEntriesPageLimit
+	}
+	limit = min(limit, maxDiaryEntriesPageLimit)
+
+	filter := &storage.DiaryEntryPageFilter{
+		UserId:     userId,
+		StartDate:  pageQuery.StartDate,
+		EndDate:    pageQuery.EndDate,
+		Tags:       normalizeTagNames(pageQuery.Tags),
+		Descending: pageQuery.Sort != DiaryEntriesSortAscending,
+		// one more entry than needed is requested to know if there is a next page
+		Limit: limit + 1,
+	}
+
+	if len(pageQuery.Cursor) > 0 {
+		cursor, cursorErr := decodeDiaryEntryCursor(pageQuery.Cursor)
+
+		if cursorErr != nil {
+			return nil, cursorErr
+		}
+		filter.After = cursor
+	}
+
+	storedEntries, err := diaryEntryStorage.GetUserPage(filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := storedEntries.([]*models.DiaryEntry)
+	diaryEntryPage := &DiaryEntryPage{Entries: entries}
+
+	if len(entries) > limit {
+		diaryEntryPage.Entries = entries[:limit]
+		diaryEntryPage.NextCursor = encodeDiaryEntryCursor(entries[limit-1])
+	}
+
+	return diaryEntryPage, nil
+}
+
+// SearchUserEntries returns the user diary entries matching a full-text search, best matches first.
+func (defaultDiaryEntryService *DefaultDiaryEntryService) SearchUserEntries(userId uint, searchQuery *DiaryEntrySearchQuery) (*DiaryEntrySearchPage, error) {
+	matchExpression := buildFtsMatchExpression(searchQuery.Query)
+
+	if len(matchExpression) == 0 {
+		return nil, ErrInvalidDiaryEntrySearchQuery
+	}
+
+	if isContentEncryptionEnabled() {
+		user, err := userStorage.Get(userId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !user.(*models.User).SearchIndexEnabled {
+			return nil, ErrDiaryEntrySearchIndexDisabled
+		}
+	}
+
+	page := max(searchQuery.Page, 1)
+	pageSize := searchQuery.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultDiaryEntrySearchPageSize
+	}
+	pageSize = min(pageSize, maxDiaryEntrySearchPageSize)
+
+	searchResults, err := diaryEntryStorage.Search(&storage.DiaryEntrySearchFilter{
+		UserId:    userId,
+		Query:     matchExpression,
+		StartDate: searchQuery.StartDate,
+		EndDate:   searchQuery.EndDate,
+		Limit:     pageSize,
+		Offset:    (page - 1) * pageSize,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiaryEntrySearchPage{
+		Results:  searchResults.([]*models.DiaryEntrySearchResult),
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// SaveDiaryEntry creates a diary entry of the user.
+func (defaultDiaryEntryService *DefaultDiaryEntryService) SaveDiaryEntry(userId uint, diaryEntryBody *SaveDiaryEntryBody, auditMetadata *AuditMetadata) (*models.DiaryEntry, error) {
+	if diaryEntryBody.PromptRefer != nil {
+		if _, getPromptErr := promptStorage.Get(*diaryEntryBody.PromptRefer); getPromptErr != nil {
+			return nil, getPromptErr
+		}
+	}
+
 	dbActivityRegistration := &models.ActivityRegistration{
 		RegistrationDate: diaryEntryBody.PublishDate,
-		UserRefer:        diaryEntryBody.UserRefer,
+		UserRefer:        userId,
 	}
 
 	saveRegistrationErr := activityRegistrationStorage.Create(dbActivityRegistration)
@@ -76,7 +273,12 @@ func (defaultDiaryEntryService *DefaultDiaryEntryService) SaveDiaryEntry(diaryEn
 	dbEntry := &models.DiaryEntry{
 		Title:        diaryEntryBody.Title,
 		Content:      diaryEntryBody.Content,
+		Tags:         normalizeTagNames(diaryEntryBody.Tags),
+		Mood:         normalizeMood(diaryEntryBody.Mood),
+		Feelings:     normalizeFeelings(diaryEntryBody.Feelings),
+		Encryption:   newDiaryEntryEncryption(diaryEntryBody.Encryption),
 		Registration: *dbActivityRegistration,
+		PromptRefer:  diaryEntryBody.PromptRefer,
 	}
 	err := diaryEntryStorage.Create(dbEntry)
 
@@ -84,48 +286,240 @@ func (defaultDiaryEntryService *DefaultDiaryEntryService) SaveDiaryEntry(diaryEn
 		return nil, err
 	}
 
+	auditService.RecordEvent(models.AuditDiaryEntryCreated, models.AuditTargetDiaryEntry, dbEntry.Id, auditMetadata, "")
+
 	return dbEntry, nil
 }
 
-func (defaultDiaryEntryService *DefaultDiaryEntryService) UpdateDiaryEntry(diaryEntryId uint, diaryEntryBody *UpdateDiaryEntryBody) (*models.DiaryEntry, error) {
+func (defaultDiaryEntryService *DefaultDiaryEntryService) UpdateDiaryEntry(diaryEntryId uint, diaryEntryBody *UpdateDiaryEntryBody, auditMetadata *AuditMetadata) (*models.DiaryEntry, error) {
 	storedDiaryEntry, getDiaryEntryError := defaultDiaryEntryService.GetDiaryEntryById(diaryEntryId)
 
 	if getDiaryEntryError != nil {
 		return nil, getDiaryEntryError
 	}
 
+	if diaryEntryBody.ExpectedVersion != 0 && diaryEntryBody.ExpectedVersion != storedDiaryEntry.Version {
+		return nil, &models.DbVersionConflictError{DbItem: &models.DiaryEntry{}, CurrentVersion: storedDiaryEntry.Version}
+	}
+
+	encryption := newDiaryEntryEncryption(diaryEntryBody.Encryption)
+	diaryEntryUpdate := &storage.DiaryEntryUpdate{}
+
+	if encryption != nil && storedDiaryEntry.Encryption == nil {
+		// the plaintext versions are not kept once the entry is encrypted
+		diaryEntryUpdate.DeleteRevisions = true
+	} else {
+		// keep the replaced version, so it can be restored
+		diaryEntryUpdate.Revision = &models.DiaryEntryRevision{
+			DiaryEntryRefer: diaryEntryId,
+			Title:           storedDiaryEntry.Title,
+			Content:         storedDiaryEntry.Content,
+			PublishDate:     storedDiaryEntry.Registration.RegistrationDate,
+			Mood:            storedDiaryEntry.Mood,
+			Feelings:        storedDiaryEntry.Feelings,
+			Encryption:      storedDiaryEntry.Encryption,
+			CreatedAt:       time.Now().Unix(),
+		}
+	}
+
 	dbRegistration := &models.ActivityRegistration{
 		Id:               storedDiaryEntry.Registration.Id,
 		RegistrationDate: diaryEntryBody.PublishDate,
 		UserRefer:        storedDiaryEntry.Registration.UserRefer,
-	}
-	updateRegistrationErr := activityRegistrationStorage.Update(dbRegistration)
-
-	if updateRegistrationErr != nil {
-		return nil, updateRegistrationErr
+		Version:          storedDiaryEntry.Registration.Version,
 	}
 
 	updatedDiaryEntry := &models.DiaryEntry{
 		Id:           diaryEntryId,
 		Title:        diaryEntryBody.Title,
 		Content:      diaryEntryBody.Content,
+		Tags:         storedDiaryEntry.Tags,
+		Mood:         storedDiaryEntry.Mood,
+		Feelings:     storedDiaryEntry.Feelings,
+		Encryption:   encryption,
 		Registration: *dbRegistration,
+		PromptRefer:  storedDiaryEntry.PromptRefer,
+		Version:      storedDiaryEntry.Version,
+	}
+
+	if diaryEntryBody.Tags != nil {
+		updatedDiaryEntry.Tags = normalizeTagNames(diaryEntryBody.Tags)
+	}
+	if diaryEntryBody.Mood != nil {
+		updatedDiaryEntry.Mood = normalizeMood(diaryEntryBody.Mood)
+	}
+	if diaryEntryBody.Feelings != nil {
+		updatedDiaryEntry.Feelings = normalizeFeelings(diaryEntryBody.Feelings)
+	}
+	if encryption != nil {
+		updatedDiaryEntry.Mood = nil
+		updatedDiaryEntry.Feelings = []string{}
+		updatedDiaryEntry.Tags = []string{}
 	}
-	err := diaryEntryStorage.Update(updatedDiaryEntry)
+	diaryEntryUpdate.Entry = updatedDiaryEntry
+	err := diaryEntryStorage.Update(diaryEntryUpdate)
 
 	if err != nil {
 		return nil, err
 	}
 
+	auditService.RecordEvent(models.AuditDiaryEntryUpdated, models.AuditTargetDiaryEntry, diaryEntryId, auditMetadata, "")
+
 	return updatedDiaryEntry, nil
 }
 
-func (defaultDiaryEntryService *DefaultDiaryEntryService) DeleteDiaryEntry(id uint) error {
+// PatchDiaryEntry applies a JSON Merge Patch to a diary entry of the user, and updates it with the merged body.
+// The entries of other users are reported as not found.
+func (defaultDiaryEntryService *DefaultDiaryEntryService) PatchDiaryEntry(userId uint, diaryEntryId uint, patch []byte, expectedVersion int64, auditMetadata *AuditMetadata) (*models.DiaryEntry, error) {
+	storedDiaryEntry, getDiaryEntryError := defaultDiaryEntryService.GetDiaryEntryById(diaryEntryId)
+
+	if getDiaryEntryError != nil {
+		return nil, getDiaryEntryError
+	}
+
+	if storedDiaryEntry.Registration.UserRefer != userId {
+		return nil, &models.DbNotFoundError{DbItem: &models.DiaryEntry{}}
+	}
+
+	updateBody := &UpdateDiaryEntryBody{
+		Title:       storedDiaryEntry.Title,
+		Content:     storedDiaryEntry.Content,
+		PublishDate: storedDiaryEntry.Registration.RegistrationDate,
+		Mood:        storedDiaryEntry.Mood,
+	}
+
+	if storedDiaryEntry.Encryption != nil {
+		updateBody.Encryption = &DiaryEntryEncryptionBody{
+			Algorithm:  storedDiaryEntry.Encryption.Algorithm,
+			Nonce:      storedDiaryEntry.Encryption.Nonce,
+			KeyId:      storedDiaryEntry.Encryption.KeyId,
+			WrappedKey: storedDiaryEntry.Encryption.WrappedKey,
+		}
+	} else {
+		updateBody.Tags = storedDiaryEntry.Tags
+		updateBody.Feelings = storedDiaryEntry.Feelings
+	}
+
+	if patchErr := utils.ApplyMergePatch(updateBody, patch); patchErr != nil {
+		return nil, patchErr
+	}
+
+	// the body holds every stored value, so missing tags, mood or feelings were removed by the patch
+	if updateBody.Tags == nil {
+		updateBody.Tags = []string{}
+	}
+	if updateBody.Feelings == nil {
+		updateBody.Feelings = []string{}
+	}
+	if updateBody.Mood == nil {
+		updateBody.Mood = new(int)
+	}
+
+	updateBody.ExpectedVersion = expectedVersion
+
+	return defaultDiaryEntryService.UpdateDiaryEntry(diaryEntryId, updateBody, auditMetadata)
+}
+
+// DeleteDiaryEntry moves the diary entry to the trash, where it is kept until the trash is emptied or its retention expires.
+func (defaultDiaryEntryService *DefaultDiaryEntryService) DeleteDiaryEntry(id uint, auditMetadata *AuditMetadata) error {
 	diaryEntry, err := defaultDiaryEntryService.GetDiaryEntryById(id)
 
 	if err != nil {
 		return err
 	}
 
-	return activityRegistrationStorage.Delete(diaryEntry.Registration.Id)
+	deleteErr := activityRegistrationStorage.Trash(diaryEntry.Registration.Id, time.Now().Unix())
+
+	if deleteErr != nil {
+		return deleteErr
+	}
+
+	auditService.RecordEvent(models.AuditDiaryEntryDeleted, models.AuditTargetDiaryEntry, id, auditMetadata, "")
+
+	return nil
+}
+
+// newDiaryEntryEncryption returns the encryption metadata of a diary entry body, or nil for plaintext entries.
+func newDiaryEntryEncryption(encryptionBody *DiaryEntryEncryptionBody) *models.DiaryEntryEncryption {
+	if encryptionBody == nil {
+		return nil
+	}
+
+	return &models.DiaryEntryEncryption{
+		Algorithm:  encryptionBody.Algorithm,
+		Nonce:      encryptionBody.Nonce,
+		KeyId:      encryptionBody.KeyId,
+		WrappedKey: encryptionBody.WrappedKey,
+	}
+}
+
+func encodeDiaryEntryCursor(diaryEntry *models.DiaryEntry) string {
+	cursorJSON, _ := json.Marshal(diaryEntryCursor{RegistrationDate: diaryEntry.Registration.RegistrationDate, Id: diaryEntry.Id})
+
+	return base64.RawURLEncoding.EncodeToString(cursorJSON)
+}
+
+func decodeDiaryEntryCursor(encodedCursor string) (*storage.DiaryEntryCursor, error) {
+	cursorJSON, decodeErr := base64.RawURLEncoding.DecodeString(encodedCursor)
+
+	if decodeErr != nil {
+		return nil, ErrInvalidDiaryEntryCursor
+	}
+
+	cursor := diaryEntryCursor{}
+
+	if err := json.Unmarshal(cursorJSON, &cursor); err != nil || cursor.Id == 0 {
+		return nil, ErrInvalidDiaryEntryCursor
+	}
+
+	return &storage.DiaryEntryCursor{RegistrationDate: cursor.RegistrationDate, Id: cursor.Id}, nil
+}
+
+// buildFtsMatchExpression translates a user search query into an FTS5 match expression.
+// Every word and phrase is quoted, so the FTS5 query syntax of the user input is never interpreted,
+// and all of them must match. Returns an empty string if the query has no searchable terms.
+func buildFtsMatchExpression(query string) string {
+	terms := make([]string, 0)
+	remaining := query
+
+	for len(remaining) > 0 {
+		remaining = strings.TrimLeftFunc(remaining, unicode.IsSpace)
+
+		if len(remaining) == 0 {
+			break
+		}
+
+		var term string
+		if remaining[0] == '"' {
+			// phrase, until the closing quote or the end of the query
+			closingQuote := strings.IndexByte(remaining[1:], '"')
+			if closingQuote < 0 {
+				term, remaining = remaining[1:], ""
+			} else {
+				term, remaining = remaining[1:closingQuote+1], remaining[closingQuote+2:]
+			}
+		} else {
+			termEnd := strings.IndexFunc(remaining, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+			if termEnd < 0 {
+				termEnd = len(remaining)
+			}
+			term, remaining = remaining[:termEnd], remaining[termEnd:]
+		}
+
+		prefix := strings.HasSuffix(term, "*") || strings.HasPrefix(remaining, "*")
+		remaining = strings.TrimPrefix(remaining, "*")
+		term = strings.Join(strings.Fields(strings.ReplaceAll(term, "*", " ")), " ")
+
+		if !strings.ContainsFunc(term, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			continue
+		}
+
+		quotedTerm := `"` + term + `"`
+		if prefix {
+			quotedTerm += "*"
+		}
+		terms = append(terms, quotedTerm)
+	}
+
+	return strings.Join(terms, " ")
 }
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+)
+
+const (
+	// MaxAttachmentSize is the maximum size in bytes of an attachment.
+	MaxAttachmentSize = 10 << 20
+	// AttachmentThumbnailContentType is the content type of the attachment thumbnails.
+	AttachmentThumbnailContentType = "image/jpeg"
+
+	defaultAttachmentUserQuota = 100 << 20
+	attachmentUserQuotaEnv     = "ATTACHMENTS_USER_QUOTA_BYTES"
+	attachmentThumbnailSize    = 256
+	// maxThumbnailSourcePixels keeps huge images from being decoded into memory. They are stored without thumbnail.
+	maxThumbnailSourcePixels    = 50_000_000
+	maxAttachmentFileNameLength = 255
+)
+
+// allowedAttachmentContentTypes are the content types accepted as attachments, detected from the content itself.
+var allowedAttachmentContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+var (
+	ErrAttachmentTooLarge          = fmt.Errorf("attachments cannot be larger than %d bytes", MaxAttachmentSize)
+	ErrUnsupportedAttachmentType   = errors.New("attachments must be JPEG, PNG, GIF or WebP images")
+	ErrAttachmentQuotaExceeded     = errors.New("the attachment does not fit in the storage quota of the user")
+	ErrAttachmentThumbnailNotFound = &models.DbNotFoundError{DbItem: &models.DiaryEntryAttachment{}}
+	errAttachmentThumbnailTooLarge = errors.New("image too large to generate its thumbnail")
+)
+
+var diaryEntryAttachmentStorage storage.DiaryEntryAttachmentStorageInterface = &storage.DiaryEntryAttachmentStorage{}
+var attachmentBlobStore storage.BlobStore = &storage.LocalBlobStore{}
+
+// AttachmentUsage holds the bytes taken by the attachments of a user, and the bytes they can take.
+type AttachmentUsage struct {
+	UsedBytes  int64 `json:"usedBytes"`
+	QuotaBytes int64 `json:"quotaBytes"`
+}
+
+// DiaryEntryAttachmentService defines all operations for the diary entry attachment service.
+// Diary entries of other users are reported as not found.
+type DiaryEntryAttachmentService interface {
+	GetDiaryEntryAttachments(userId uint, diaryEntryId uint) ([]*models.DiaryEntryAttachment, error)
+	GetDiaryEntryAttachmentContent(userId uint, diaryEntryId uint, attachmentId uint, thumbnail bool) (*models.DiaryEntryAttachment, io.ReadCloser, error)
+	SaveDiaryEntryAttachment(userId uint, diaryEntryId uint, fileName string, content io.Reader, auditMetadata *AuditMetadata) (*models.DiaryEntryAttachment, error)
+	DeleteDiaryEntryAttachment(userId uint, diaryEntryId uint, attachmentId uint, auditMetadata *AuditMetadata) error
+	GetUserAttachmentUsage(userId uint) (*AttachmentUsage, error)
+}
+
+// DiaryEntryAttachmentServiceImpl is the concrete implementation of DiaryEntryAttachmentService.
+type DiaryEntryAttachmentServiceImpl struct {
+	diaryEntryService DiaryEntryService
+}
+
+// NewDiaryEntryAttachmentServiceImpl creates a new DiaryEntryAttachmentServiceImpl.
+func NewDiaryEntryAttachmentServiceImpl(diaryEntryService DiaryEntryService) *DiaryEntryAttachmentServiceImpl {
+	return &DiaryEntryAttachmentServiceImpl{diaryEntryService: diaryEntryService}
+}
+
+func (attachmentService *DiaryEntryAttachmentServiceImpl) GetDiaryEntryAttachments(userId uint, diaryEntryId uint) ([]*models.DiaryEntryAttachment, error) {
+	if getErr := attachmentService.checkUserDiaryEntry(userId, diaryEntryId); getErr != nil {
+		return nil, getErr
+	}
+
+	attachments, err := diaryEntryAttachmentStorage.GetByDiaryEntryId(diaryEntryId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments.([]*models.DiaryEntryAttachment), nil
+}
+
+// GetDiaryEntryAttachmentContent returns the attachment and its content, or the content of its thumbnail.
+// Callers must close the content.
+func (attachmentService *DiaryEntryAttachmentServiceImpl) GetDiaryEntryAttachmentContent(userId uint, diaryEntryId uint, attachmentId uint, thumbnail bool) (*models.DiaryEntryAttachment, io.ReadCloser, error) {
+	attachment, err := attachmentService.getUserDiaryEntryAttachment(userId, diaryEntryId, attachmentId)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blobKey := attachment.BlobKey
+
+	if thumbnail {
+		if !attachment.HasThumbnail {
+			return nil, nil, ErrAttachmentThumbnailNotFound
+		}
+		blobKey = attachment.ThumbnailKey
+	}
+
+	content, getErr := attachmentBlobStore.Get(blobKey)
+
+	if errors.Is(getErr, storage.ErrBlobNotFound) {
+		return nil, nil, &models.DbNotFoundError{DbItem: &models.DiaryEntryAttachment{}}
+	}
+
+	if getErr != nil {
+		return nil, nil, getErr
+	}
+
+	return attachment, content, nil
+}
+
+// SaveDiaryEntryAttachment stores the content in the blob store, with a thumbnail when it is an image that can be decoded,
+// and attaches it to the diary entry.
+func (attachmentService *DiaryEntryAttachmentServiceImpl) SaveDiaryEntryAttachment(userId uint, diaryEntryId uint, fileName string, content io.Reader, auditMetadata *AuditMetadata) (*models.DiaryEntryAttachment, error) {
+	if getErr := attachmentService.checkUserDiaryEntry(userId, diaryEntryId); getErr != nil {
+		return nil, getErr
+	}
+
+	attachmentContent, readErr := io.ReadAll(io.LimitReader(content, MaxAttachmentSize+1))
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if len(attachmentContent) > MaxAttachmentSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	contentType := http.DetectContentType(attachmentContent)
+
+	if !allowedAttachmentContentTypes[contentType] {
+		return nil, ErrUnsupportedAttachmentType
+	}
+
+	usedBytes, sizeErr := diaryEntryAttachmentStorage.GetUserTotalSize(userId)
+
+	if sizeErr != nil {
+		return nil, sizeErr
+	}
+
+	if usedBytes+int64(len(attachmentContent)) > getAttachmentUserQuota() {
+		return nil, ErrAttachmentQuotaExceeded
+	}
+
+	attachment := &models.DiaryEntryAttachment{
+		DiaryEntryRefer: diaryEntryId,
+		UserRefer:       userId,
+		FileName:        normalizeAttachmentFileName(fileName),
+		ContentType:     contentType,
+		Size:            int64(len(attachmentContent)),
+		BlobKey:         newAttachmentBlobKey(userId),
+		CreatedAt:       time.Now().Unix(),
+	}
+
+	if putErr := attachmentBlobStore.Put(attachment.BlobKey, bytes.NewReader(attachmentContent)); putErr != nil {
+		return nil, putErr
+	}
+
+	// attachments are still saved when their thumbnail cannot be generated, e.g. for WebP images
+	if thumbnail, thumbnailErr := generateThumbnail(attachmentContent); thumbnailErr == nil {
+		thumbnailKey := attachment.BlobKey + "-thumbnail"
+
+		if putErr := attachmentBlobStore.Put(thumbnailKey, bytes.NewReader(thumbnail)); putErr == nil {
+			attachment.ThumbnailKey = thumbnailKey
+			attachment.HasThumbnail = true
+		} else {
+			servicesLogger.ErrorLogger.Printf("error when storing attachment thumbnail: %s", putErr.Error())
+		}
+	}
+
+	if createErr := diaryEntryAttachmentStorage.Create(attachment); createErr != nil {
+		deleteAttachmentBlobs(attachment)
+		return nil, createErr
+	}
+
+	auditService.RecordEvent(models.AuditDiaryEntryAttachmentAdded, models.AuditTargetDiaryEntry, diaryEntryId, auditMetadata,
+		"attachment id: "+strconv.FormatUint(uint64(attachment.Id), 10))
+
+	return attachment, nil
+}
+
+func (attachmentService *DiaryEntryAttachmentServiceImpl) DeleteDiaryEntryAttachment(userId uint, diaryEntryId uint, attachmentId uint, auditMetadata *AuditMetadata) error {
+	attachment, err := attachmentService.getUserDiaryEntryAttachment(userId, diaryEntryId, attachmentId)
+
+	if err != nil {
+		return err
+	}
+
+	if deleteErr := diaryEntryAttachmentStorage.Delete(attachmentId); deleteErr != nil {
+		return deleteErr
+	}
+
+	deleteAttachmentBlobs(attachment)
+
+	auditService.RecordEvent(models.AuditDiaryEntryAttachmentDeleted, models.AuditTargetDiaryEntry, diaryEntryId, auditMetadata,
+		"attachment id: "+strconv.FormatUint(uint64(attachmentId), 10))
+
+	return nil
+}
+
+func (attachmentService *DiaryEntryAttachmentServiceImpl) GetUserAttachmentUsage(userId uint) (*AttachmentUsage, error) {
+	usedBytes, err := diaryEntryAttachmentStorage.GetUserTotalSize(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &AttachmentUsage{UsedBytes: usedBytes, QuotaBytes: getAttachmentUserQuota()}, nil
+}
+
+// checkUserDiaryEntry reports diary entries of other users as not found.
+func (attachmentService *DiaryEntryAttachmentServiceImpl) checkUserDiaryEntry(userId uint, diaryEntryId uint) error {
+	diaryEntry, err := attachmentService.diaryEntryService.GetDiaryEntryById(diaryEntryId)
+
+	if err != nil {
+		return err
+	}
+
+	if diaryEntry.Registration.UserRefer != userId {
+		return &models.DbNotFoundError{DbItem: &models.DiaryEntry{}}
+	}
+
+	return nil
+}
+
+// getUserDiaryEntryAttachment returns an attachment of the diary entry. Attachments of other entries are reported as not found.
+func (attachmentService *DiaryEntryAttachmentServiceImpl) getUserDiaryEntryAttachment(userId uint, diaryEntryId uint, attachmentId uint) (*models.DiaryEntryAttachment, error) {
+	if getErr := attachmentService.checkUserDiaryEntry(userId, diaryEntryId); getErr != nil {
+		return nil, getErr
+	}
+
+	storedAttachment, err := diaryEntryAttachmentStorage.Get(attachmentId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := storedAttachment.(*models.DiaryEntryAttachment)
+
+	if attachment.DiaryEntryRefer != diaryEntryId {
+		return nil, &models.DbNotFoundError{DbItem: &models.DiaryEntryAttachment{}}
+	}
+
+	return attachment, nil
+}
+
+// deleteDiaryEntryAttachments deletes the attachments of a deleted diary entry and their blobs.
+func deleteDiaryEntryAttachments(diaryEntryId uint, attachments []*models.DiaryEntryAttachment) error {
+	if deleteErr := diaryEntryAttachmentStorage.DeleteByDiaryEntryId(diaryEntryId); deleteErr != nil {
+		return deleteErr
+	}
+
+	for _, attachment := range attachments {
+		deleteAttachmentBlobs(attachment)
+	}
+
+	return nil
+}
+
+// deleteAttachmentBlobs deletes the content and thumbnail of an attachment. The attachment is already gone,
+// so errors are only logged.
+func deleteAttachmentBlobs(attachment *models.DiaryEntryAttachment) {
+	blobKeys := []string{attachment.BlobKey}
+
+	if len(attachment.ThumbnailKey) > 0 {
+		blobKeys = append(blobKeys, attachment.ThumbnailKey)
+	}
+
+	for _, blobKey := range blobKeys {
+		if err := attachmentBlobStore.Delete(blobKey); err != nil {
+			servicesLogger.ErrorLogger.Printf("error when deleting attachment blob %s: %s", blobKey, err.Error())
+		}
+	}
+}
+
+// getAttachmentUserQuota returns the bytes each user can store in attachments, configured by ATTACHMENTS_USER_QUOTA_BYTES.
+func getAttachmentUserQuota() int64 {
+	quota, err := strconv.ParseInt(os.Getenv(attachmentUserQuotaEnv), 10, 64)
+
+	if err != nil || quota < 0 {
+		return defaultAttachmentUserQuota
+	}
+
+	return quota
+}
+
+// newAttachmentBlobKey returns a random blob key under the prefix of the user.
+func newAttachmentBlobKey(userId uint) string {
+	randomBytes := make([]byte, 16)
+	rand.Read(randomBytes)
+
+	return fmt.Sprintf("attachments/%d/%s", userId, hex.EncodeToString(randomBytes))
+}
+
+// normalizeAttachmentFileName keeps the base name of the uploaded file, as clients may send full paths.
+func normalizeAttachmentFileName(fileName string) string {
+	fileName = filepath.Base(strings.ReplaceAll(fileName, "\\", "/"))
+
+	if fileName == "." || fileName == "/" {
+		return "attachment"
+	}
+
+	for len(fileName) > maxAttachmentFileNameLength {
+		_, lastRuneSize := utf8.DecodeLastRuneInString(fileName)
+		fileName = fileName[:len(fileName)-lastRuneSize]
+	}
+
+	return fileName
+}
+
+// generateThumbnail decodes the image and scales it down to fit in a square of attachmentThumbnailSize pixels,
+// averaging the pixels each thumbnail pixel covers. Smaller images are not scaled up.
+func generateThumbnail(content []byte) ([]byte, error) {
+	imageConfig, _, configErr := image.DecodeConfig(bytes.NewReader(content))
+
+	if configErr != nil {
+		return nil, configErr
+	}
+
+	if imageConfig.Width*imageConfig.Height > maxThumbnailSourcePixels {
+		return nil, errAttachmentThumbnailTooLarge
+	}
+
+	sourceImage, _, decodeErr := image.Decode(bytes.NewReader(content))
+
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	sourceBounds := sourceImage.Bounds()
+	sourceWidth, sourceHeight := sourceBounds.Dx(), sourceBounds.Dy()
+	thumbnailWidth, thumbnailHeight := sourceWidth, sourceHeight
+
+	if sourceWidth > attachmentThumbnailSize || sourceHeight > attachmentThumbnailSize {
+		if sourceWidth >= sourceHeight {
+			thumbnailWidth = attachmentThumbnailSize
+			thumbnailHeight = max(1, sourceHeight*attachmentThumbnailSize/sourceWidth)
+		} else {
+			thumbnailHeight = attachmentThumbnailSize
+			thumbnailWidth = max(1, sourceWidth*attachmentThumbnailSize/sourceHeight)
+		}
+	}
+
+	thumbnailImage := image.NewRGBA(image.Rect(0, 0, thumbnailWidth, thumbnailHeight))
+
+	for y := 0; y < thumbnailHeight; y++ {
+		startY := sourceBounds.Min.Y + y*sourceHeight/thumbnailHeight
+		endY := max(startY+1, sourceBounds.Min.Y+(y+1)*sourceHeight/thumbnailHeight)
+
+		for x := 0; x < thumbnailWidth; x++ {
+			startX := sourceBounds.Min.X + x*sourceWidth/thumbnailWidth
+			endX := max(startX+1, sourceBounds.Min.X+(x+1)*sourceWidth/thumbnailWidth)
+			var red, green, blue, alpha, pixels uint64
+
+			for sourceY := startY; sourceY < endY; sourceY++ {
+				for sourceX := startX; sourceX < endX; sourceX++ {
+					r, g, b, a := sourceImage.At(sourceX, sourceY).RGBA()
+					red, green, blue, alpha = red+uint64(r), green+uint64(g), blue+uint64(b), alpha+uint64(a)
+					pixels++
+				}
+			}
+
+			// JPEG has no transparency, so transparent pixels are blended over white
+			transparency := 0xffff*pixels - alpha
+			thumbnailImage.SetRGBA(x, y, color.RGBA{
+				R: uint8((red + transparency) / pixels >> 8),
+				G: uint8((green + transparency) / pixels >> 8),
+				B: uint8((blue + transparency) / pixels >> 8),
+				A: 0xff,
+			})
+		}
+	}
+
+	thumbnail := &bytes.Buffer{}
+
+	if encodeErr := jpeg.Encode(thumbnail, thumbnailImage, &jpeg.Options{Quality: 80}); encodeErr != nil {
+		return nil, encodeErr
+	}
+
+	return thumbnail.Bytes(), nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDiaryEntryAttachmentStorage implements DiaryEntryAttachmentStorageInterface
+type mockDiaryEntryAttachmentStorage struct {
+	Attachments []*models.DiaryEntryAttachment
+
+	CreateErr error
+}
+
+func (m *mockDiaryEntryAttachmentStorage) Get(id uint) (interface{}, error) {
+	for _, attachment := range m.Attachments {
+		if attachment.Id == id {
+			return attachment, nil
+		}
+	}
+	return nil, &models.DbNotFoundError{DbItem: &models.DiaryEntryAttachment{}}
+}
+
+func (m *mockDiaryEntryAttachmentStorage) GetByDiaryEntryId(diaryEntryId uint) (interface{}, error) {
+	attachments := []*models.DiaryEntryAttachment{}
+	for _, attachment := range m.Attachments {
+		if attachment.DiaryEntryRefer == diaryEntryId {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+func (m *mockDiaryEntryAttachmentStorage) GetUserTotalSize(userId uint) (int64, error) {
+	var totalSize int64
+	for _, attachment := range m.Attachments {
+		if attachment.UserRefer == userId {
+			totalSize += attachment.Size
+		}
+	}
+	return totalSize, nil
+}
+
+func (m *mockDiaryEntryAttachmentStorage) Create(data interface{}) error {
+	if m.CreateErr != nil {
+		return m.CreateErr
+	}
+	attachment := data.(*models.DiaryEntryAttachment)
+	attachment.Id = uint(len(m.Attachments) + 1)
+	m.Attachments = append(m.Attachments, attachment)
+	return nil
+}
+
+func (m *mockDiaryEntryAttachmentStorage) Delete(id uint) error {
+	for i, attachment := range m.Attachments {
+		if attachment.Id == id {
+			m.Attachments = append(m.Attachments[:i], m.Attachments[i+1:]...)
+			return nil
+		}
+	}
+	return &models.DbNotFoundError{DbItem: &models.DiaryEntryAttachment{}}
+}
+
+func (m *mockDiaryEntryAttachmentStorage) DeleteByDiaryEntryId(diaryEntryId uint) error {
+	remainingAttachments := []*models.DiaryEntryAttachment{}
+	for _, attachment := range m.Attachments {
+		if attachment.DiaryEntryRefer != diaryEntryId {
+			remainingAttachments = append(remainingAttachments, attachment)
+		}
+	}
+	m.Attachments = remainingAttachments
+	return nil
+}
+
+// mockBlobStore implements storage.BlobStore in memory
+type mockBlobStore struct {
+	Blobs map[string][]byte
+}
+
+func newMockBlobStore() *mockBlobStore {
+	return &mockBlobStore{Blobs: make(map[string][]byte)}
+}
+
+func (m *mockBlobStore) Put(key string, content io.Reader) error {
+	blob, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	m.Blobs[key] = blob
+	return nil
+}
+
+func (m *mockBlobStore) Get(key string) (io.ReadCloser, error) {
+	blob, found := m.Blobs[key]
+	if !found {
+		return nil, storage.ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(blob)), nil
+}
+
+func (m *mockBlobStore) Delete(key string) error {
+	delete(m.Blobs, key)
+	return nil
+}
+
+func setUpDiaryEntryAttachmentMocks(t *testing.T) (*mockDiaryEntryAttachmentStorage, *mockBlobStore) {
+	setUpDiaryEntryRevisionMocks(t)
+	originalAttachmentStorage := diaryEntryAttachmentStorage
+	originalBlobStore := attachmentBlobStore
+	attachmentStorageMock := &mockDiaryEntryAttachmentStorage{}
+	blobStoreMock := newMockBlobStore()
+	diaryEntryAttachmentStorage = attachmentStorageMock
+	attachmentBlobStore = blobStoreMock
+	t.Cleanup(func() {
+		diaryEntryAttachmentStorage = originalAttachmentStorage
+		attachmentBlobStore = originalBlobStore
+	})
+
+	return attachmentStorageMock, blobStoreMock
+}
+
+func newTestPng(t *testing.T, width int, height int) []byte {
+	testImage := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			testImage.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	content := &bytes.Buffer{}
+	assert.NoError(t, png.Encode(content, testImage))
+	return content.Bytes()
+}
+
+func TestSaveDiaryEntryAttachment(t *testing.T) {
+	attachmentStorageMock, blobStoreMock := setUpDiaryEntryAttachmentMocks(t)
+	attachmentService := NewDiaryEntryAttachmentServiceImpl(diaryEntryService)
+	createdEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Title", Content: "Content", PublishDate: time.Now().Unix(),
+	}, nil)
+	pngContent := newTestPng(t, 600, 300)
+
+	attachment, err := attachmentService.SaveDiaryEntryAttachment(1, createdEntry.Id, `C:\photos\page.png`, bytes.NewReader(pngContent), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "page.png", attachment.FileName)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, int64(len(pngContent)), attachment.Size)
+	assert.Equal(t, uint(1), attachment.UserRefer)
+	assert.True(t, attachment.HasThumbnail)
+	assert.Equal(t, pngContent, blobStoreMock.Blobs[attachment.BlobKey])
+	assert.Len(t, attachmentStorageMock.Attachments, 1)
+
+	// Test the thumbnail keeps the aspect ratio
+	_, thumbnail, err := attachmentService.GetDiaryEntryAttachmentContent(1, createdEntry.Id, attachment.Id, true)
+	assert.NoError(t, err)
+	thumbnailConfig, err := jpeg.DecodeConfig(thumbnail)
+	assert.NoError(t, err)
+	assert.Equal(t, attachmentThumbnailSize, thumbnailConfig.Width)
+	assert.Equal(t, attachmentThumbnailSize/2, thumbnailConfig.Height)
+
+	// Test images that cannot be decoded are saved without thumbnail
+	webpContent := append([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), make([]byte, 32)...)
+	attachment, err = attachmentService.SaveDiaryEntryAttachment(1, createdEntry.Id, "drawing.webp", bytes.NewReader(webpContent), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/webp", attachment.ContentType)
+	assert.False(t, attachment.HasThumbnail)
+	_, _, err = attachmentService.GetDiaryEntryAttachmentContent(1, createdEntry.Id, attachment.Id, true)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+
+	// Test unsupported content, whatever its file name
+	_, err = attachmentService.SaveDiaryEntryAttachment(1, createdEntry.Id, "page.png", strings.NewReader("plain text"), nil)
+	assert.ErrorIs(t, err, ErrUnsupportedAttachmentType)
+
+	// Test size limit
+	_, err = attachmentService.SaveDiaryEntryAttachment(1, createdEntry.Id, "big.png", io.MultiReader(bytes.NewReader(pngContent), bytes.NewReader(make([]byte, MaxAttachmentSize))), nil)
+	assert.ErrorIs(t, err, ErrAttachmentTooLarge)
+
+	// Test user quota
+	t.Setenv(attachmentUserQuotaEnv, "1000")
+	_, err = attachmentService.SaveDiaryEntryAttachment(1, createdEntry.Id, "page.png", bytes.NewReader(pngContent), nil)
+	assert.ErrorIs(t, err, ErrAttachmentQuotaExceeded)
+	attachmentUsage, _ := attachmentService.GetUserAttachmentUsage(1)
+	assert.Equal(t, int64(len(pngContent)+len(webpContent)), attachmentUsage.UsedBytes)
+	assert.Equal(t, int64(1000), attachmentUsage.QuotaBytes)
+
+	// Test entries of other users are not found
+	_, err = attachmentService.SaveDiaryEntryAttachment(2, createdEntry.Id, "page.png", bytes.NewReader(pngContent), nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	assert.Len(t, attachmentStorageMock.Attachments, 2)
+}
+
+func TestDeleteDiaryEntryAttachment(t *testing.T) {
+	attachmentStorageMock, blobStoreMock := setUpDiaryEntryAttachmentMocks(t)
+	attachmentService := NewDiaryEntryAttachmentServiceImpl(diaryEntryService)
+	createdEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Title", Content: "Content", PublishDate: time.Now().Unix(),
+	}, nil)
+	otherEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Other", Content: "Other", PublishDate: time.Now().Unix(),
+	}, nil)
+	attachment, _ := attachmentService.SaveDiaryEntryAttachment(1, createdEntry.Id, "page.png", bytes.NewReader(newTestPng(t, 10, 10)), nil)
+
+	// Test attachments of other entries and users are not found
+	err := attachmentService.DeleteDiaryEntryAttachment(1, otherEntry.Id, attachment.Id, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	err = attachmentService.DeleteDiaryEntryAttachment(2, createdEntry.Id, attachment.Id, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+
+	err = attachmentService.DeleteDiaryEntryAttachment(1, createdEntry.Id, attachment.Id, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, attachmentStorageMock.Attachments)
+	assert.Empty(t, blobStoreMock.Blobs)
+}
+
+func TestEmptyUserTrashDeletesAttachments(t *testing.T) {
+	attachmentStorageMock, blobStoreMock := setUpDiaryEntryAttachmentMocks(t)
+	attachmentService := NewDiaryEntryAttachmentServiceImpl(diaryEntryService)
+	createdEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Title", Content: "Content", PublishDate: time.Now().Unix(),
+	}, nil)
+	otherEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Other", Content: "Other", PublishDate: time.Now().Unix(),
+	}, nil)
+	attachmentService.SaveDiaryEntryAttachment(1, createdEntry.Id, "page.png", bytes.NewReader(newTestPng(t, 10, 10)), nil)
+	otherAttachment, _ := attachmentService.SaveDiaryEntryAttachment(1, otherEntry.Id, "other.png", bytes.NewReader(newTestPng(t, 10, 10)), nil)
+
+	// Test the attachments are kept while the entry is in the trash
+	err := diaryEntryService.DeleteDiaryEntry(createdEntry.Id, nil)
+	assert.NoError(t, err)
+	assert.Len(t, attachmentStorageMock.Attachments, 2)
+	assert.Len(t, blobStoreMock.Blobs, 4)
+
+	createdEntry.Registration.DeletedAt = time.Now().Unix()
+	diaryEntryStorage.(*mockDiaryEntryStorage).Entries[createdEntry.Id] = createdEntry
+
+	err = (&TrashServiceImpl{}).EmptyUserTrash(1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.DiaryEntryAttachment{otherAttachment}, attachmentStorageMock.Attachments)
+	assert.Len(t, blobStoreMock.Blobs, 2)
+	assert.Contains(t, blobStoreMock.Blobs, otherAttachment.BlobKey)
+}
+
+func TestNormalizeAttachmentFileName(t *testing.T) {
+	assert.Equal(t, "page.png", normalizeAttachmentFileName("page.png"))
+	assert.Equal(t, "page.png", normalizeAttachmentFileName("../../page.png"))
+	assert.Equal(t, "attachment", normalizeAttachmentFileName(""))
+	assert.Equal(t, strings.Repeat("a", maxAttachmentFileNameLength), normalizeAttachmentFileName(strings.Repeat("a", 300)))
+	// Test long names are not cut in the middle of a character
+	assert.Equal(t, strings.Repeat("é", maxAttachmentFileNameLength/2), normalizeAttachmentFileName(strings.Repeat("é", 200)))
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+)
+
+const (
+	DiffOperationEqual  = "equal"
+	DiffOperationInsert = "insert"
+	DiffOperationDelete = "delete"
+
+	// maxDiffCells bounds the size of the line matching table. Bigger texts are diffed as fully replaced.
+	maxDiffCells = 4_000_000
+)
+
+// ErrEncryptedDiaryEntryDiff is returned when diffing end-to-end encrypted versions, which only clients can read.
+var ErrEncryptedDiaryEntryDiff = errors.New("encrypted diary entry versions can only be compared by the client")
+
+var diaryEntryRevisionStorage storage.DiaryEntryRevisionStorageInterface = &storage.DiaryEntryRevisionStorage{}
+
+// DiffLine is a line of a diff, which is kept, inserted or deleted.
+type DiffLine struct {
+	Operation string `json:"operation"`
+	Text      string `json:"text"`
+}
+
+// DiaryEntryRevisionDiff holds the line differences of the title and the content of two versions of a diary entry.
+// A zero revision id stands for the current version of the entry.
+type DiaryEntryRevisionDiff struct {
+	FromRevisionId uint       `json:"fromRevisionId"`
+	ToRevisionId   uint       `json:"toRevisionId"`
+	Title          []DiffLine `json:"title"`
+	Content        []DiffLine `json:"content"`
+}
+
+// DiaryEntryRevisionService defines all operations for the diary entry revision service.
+// Diary entries of other users are reported as not found.
+type DiaryEntryRevisionService interface {
+	GetDiaryEntryRevisions(userId uint, diaryEntryId uint) ([]*models.DiaryEntryRevision, error)
+	GetDiaryEntryRevision(userId uint, diaryEntryId uint, revisionId uint) (*models.DiaryEntryRevision, error)
+	DiffDiaryEntryRevisions(userId uint, diaryEntryId uint, fromRevisionId uint, toRevisionId uint) (*DiaryEntryRevisionDiff, error)
+	RestoreDiaryEntryRevision(userId uint, diaryEntryId uint, revisionId uint, auditMetadata *AuditMetadata) (*models.DiaryEntry, error)
+}
+
+// DiaryEntryRevisionServiceImpl is the concrete implementation of DiaryEntryRevisionService.
+type DiaryEntryRevisionServiceImpl struct {
+	diaryEntryService DiaryEntryService
+}
+
+// NewDiaryEntryRevisionServiceImpl creates a new DiaryEntryRevisionServiceImpl.
+func NewDiaryEntryRevisionServiceImpl(diaryEntryService DiaryEntryService) *DiaryEntryRevisionServiceImpl {
+	return &DiaryEntryRevisionServiceImpl{diaryEntryService: diaryEntryService}
+}
+
+func (revisionService *DiaryEntryRevisionServiceImpl) GetDiaryEntryRevisions(userId uint, diaryEntryId uint) ([]*models.DiaryEntryRevision, error) {
+	if _, getErr := revisionService.getUserDiaryEntry(userId, diaryEntryId); getErr != nil {
+		return nil, getErr
+	}
+
+	revisions, err := diaryEntryRevisionStorage.GetByDiaryEntryId(diaryEntryId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions.([]*models.DiaryEntryRevision), nil
+}
+
+func (revisionService *DiaryEntryRevisionServiceImpl) GetDiaryEntryRevision(userId uint, diaryEntryId uint, revisionId uint) (*models.DiaryEntryRevision, error) {
+	if _, getErr := revisionService.getUserDiaryEntry(userId, diaryEntryId); getErr != nil {
+		return nil, getErr
+	}
+
+	return getDiaryEntryRevision(diaryEntryId, revisionId)
+}
+
+// DiffDiaryEntryRevisions compares two versions of a diary entry line by line.
+func (revisionService *DiaryEntryRevisionServiceImpl) DiffDiaryEntryRevisions(userId uint, diaryEntryId uint, fromRevisionId uint, toRevisionId uint) (*DiaryEntryRevisionDiff, error) {
+	diaryEntry, getErr := revisionService.getUserDiaryEntry(userId, diaryEntryId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	versions := make([]*models.DiaryEntryRevision, 2)
+
+	for i, revisionId := range []uint{fromRevisionId, toRevisionId} {
+		if revisionId == 0 {
+			versions[i] = &models.DiaryEntryRevision{Title: diaryEntry.Title, Content: diaryEntry.Content, Encryption: diaryEntry.Encryption}
+			continue
+		}
+
+		revision, err := getDiaryEntryRevision(diaryEntryId, revisionId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		versions[i] = revision
+	}
+
+	if versions[0].Encryption != nil || versions[1].Encryption != nil {
+		return nil, ErrEncryptedDiaryEntryDiff
+	}
+
+	return &DiaryEntryRevisionDiff{
+		FromRevisionId: fromRevisionId,
+		ToRevisionId:   toRevisionId,
+		Title:          diffLines(versions[0].Title, versions[1].Title),
+		Content:        diffLines(versions[0].Content, versions[1].Content),
+	}, nil
+}
+
+// RestoreDiaryEntryRevision updates the diary entry with the revision values.
+// As any other update, the replaced version is stored as a new revision, so restores can be undone.
+func (revisionService *DiaryEntryRevisionServiceImpl) RestoreDiaryEntryRevision(userId uint, diaryEntryId uint, revisionId uint, auditMetadata *AuditMetadata) (*models.DiaryEntry, error) {
+	if _, getErr := revisionService.getUserDiaryEntry(userId, diaryEntryId); getErr != nil {
+		return nil, getErr
+	}
+
+	revision, getRevisionErr := getDiaryEntryRevision(diaryEntryId, revisionId)
+
+	if getRevisionErr != nil {
+		return nil, getRevisionErr
+	}
+
+	// a zero mood and an empty list of feelings remove the current ones
+	mood := 0
+	if revision.Mood != nil {
+		mood = *revision.Mood
+	}
+	feelings := revision.Feelings
+	if feelings == nil {
+		feelings = []string{}
+	}
+
+	updateBody := &UpdateDiaryEntryBody{
+		Title:       revision.Title,
+		Content:     revision.Content,
+		PublishDate: revision.PublishDate,
+		Mood:        &mood,
+		Feelings:    feelings,
+	}
+
+	if revision.Encryption != nil {
+		updateBody.Encryption = &DiaryEntryEncryptionBody{
+			Algorithm:  revision.Encryption.Algorithm,
+			Nonce:      revision.Encryption.Nonce,
+			KeyId:      revision.Encryption.KeyId,
+			WrappedKey: revision.Encryption.WrappedKey,
+		}
+	}
+
+	restoredDiaryEntry, updateErr := revisionService.diaryEntryService.UpdateDiaryEntry(diaryEntryId, updateBody, auditMetadata)
+
+	if updateErr != nil {
+		return nil, updateErr
+	}
+
+	auditService.RecordEvent(models.AuditDiaryEntryRestored, models.AuditTargetDiaryEntry, diaryEntryId, auditMetadata, "revision "+strconv.FormatUint(uint64(revisionId), 10))
+
+	return restoredDiaryEntry, nil
+}
+
+// getUserDiaryEntry returns a diary entry of the user. Entries of other users are reported as not found.
+func (revisionService *DiaryEntryRevisionServiceImpl) getUserDiaryEntry(userId uint, diaryEntryId uint) (*models.DiaryEntry, error) {
+	diaryEntry, err := revisionService.diaryEntryService.GetDiaryEntryById(diaryEntryId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if diaryEntry.Registration.UserRefer != userId {
+		return nil, &models.DbNotFoundError{DbItem: &models.DiaryEntry{}}
+	}
+
+	return diaryEntry, nil
+}
+
+// getDiaryEntryRevision returns a revision of the diary entry. Revisions of other entries are reported as not found.
+func getDiaryEntryRevision(diaryEntryId uint, revisionId uint) (*models.DiaryEntryRevision, error) {
+	storedRevision, err := diaryEntryRevisionStorage.Get(revisionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	revision := storedRevision.(*models.DiaryEntryRevision)
+
+	if revision.DiaryEntryRefer != diaryEntryId {
+		return nil, &models.DbNotFoundError{DbItem: &models.DiaryEntryRevision{}}
+	}
+
+	return revision, nil
+}
+
+// diffLines returns the line differences between two texts, based on their longest common subsequence of lines.
+func diffLines(from string, to string) []DiffLine {
+	fromLines := strings.Split(from, "\n")
+	toLines := strings.Split(to, "\n")
+	diff := make([]DiffLine, 0, len(fromLines)+len(toLines))
+
+	if len(fromLines)*len(toLines) > maxDiffCells {
+		for _, line := range fromLines {
+			diff = append(diff, DiffLine{Operation: DiffOperationDelete, Text: line})
+		}
+		for _, line := range toLines {
+			diff = append(diff, DiffLine{Operation: DiffOperationInsert, Text: line})
+		}
+		return diff
+	}
+
+	// commonLengths[i][j] is the length of the longest common subsequence of fromLines[i:] and toLines[j:]
+	commonLengths := make([][]int, len(fromLines)+1)
+	for i := range commonLengths {
+		commonLengths[i] = make([]int, len(toLines)+1)
+	}
+
+	for i := len(fromLines) - 1; i >= 0; i-- {
+		for j := len(toLines) - 1; j >= 0; j-- {
+			if fromLines[i] == toLines[j] {
+				commonLengths[i][j] = commonLengths[i+1][j+1] + 1
+			} else {
+				commonLengths[i][j] = max(commonLengths[i+1][j], commonLengths[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+
+	for i < len(fromLines) || j < len(toLines) {
+		switch {
+		case i < len(fromLines) && j < len(toLines) && fromLines[i] == toLines[j]:
+			diff = append(diff, DiffLine{Operation: DiffOperationEqual, Text: fromLines[i]})
+			i++
+			j++
+		case i < len(fromLines) && (j == len(toLines) || commonLengths[i+1][j] >= commonLengths[i][j+1]):
+			diff = append(diff, DiffLine{Operation: DiffOperationDelete, Text: fromLines[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Operation: DiffOperationInsert, Text: toLines[j]})
+			j++
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDiaryEntryRevisionStorage implements DiaryEntryRevisionStorageInterface
+type mockDiaryEntryRevisionStorage struct {
+	Revisions []*models.DiaryEntryRevision
+
+	CreateErr error
+}
+
+func (m *mockDiaryEntryRevisionStorage) Get(id uint) (interface{}, error) {
+	for _, revision := range m.Revisions {
+		if revision.Id == id {
+			return revision, nil
+		}
+	}
+	return nil, &models.DbNotFoundError{DbItem: &models.DiaryEntryRevision{}}
+}
+
+func (m *mockDiaryEntryRevisionStorage) GetByDiaryEntryId(diaryEntryId uint) (interface{}, error) {
+	revisions := []*models.DiaryEntryRevision{}
+	for i := len(m.Revisions) - 1; i >= 0; i-- {
+		if m.Revisions[i].DiaryEntryRefer == diaryEntryId {
+			revisions = append(revisions, m.Revisions[i])
+		}
+	}
+	return revisions, nil
+}
+
+func (m *mockDiaryEntryRevisionStorage) Create(data interface{}) error {
+	if m.CreateErr != nil {
+		return m.CreateErr
+	}
+	revision := data.(*models.DiaryEntryRevision)
+	revision.Id = uint(len(m.Revisions) + 1)
+	m.Revisions = append(m.Revisions, revision)
+	return nil
+}
+
+func (m *mockDiaryEntryRevisionStorage) DeleteByDiaryEntryId(diaryEntryId uint) error {
+	remainingRevisions := []*models.DiaryEntryRevision{}
+	for _, revision := range m.Revisions {
+		if revision.DiaryEntryRefer != diaryEntryId {
+			remainingRevisions = append(remainingRevisions, revision)
+		}
+	}
+	m.Revisions = remainingRevisions
+	return nil
+}
+
+func setUpDiaryEntryRevisionMocks(t *testing.T) (*mockDiaryEntryStorage, *mockDiaryEntryRevisionStorage) {
+	originalDiaryEntryStorage := diaryEntryStorage
+	originalActivityRegistrationStorage := activityRegistrationStorage
+	originalRevisionStorage := diaryEntryRevisionStorage
+	diaryEntryStorageMock := &mockDiaryEntryStorage{
+		Entries:     make(map[uint]*models.DiaryEntry),
+		UserEntries: make(map[uint][]*models.DiaryEntry),
+	}
+	revisionStorageMock := &mockDiaryEntryRevisionStorage{}
+	diaryEntryStorageMock.RevisionStorage = revisionStorageMock
+	diaryEntryStorage = diaryEntryStorageMock
+	activityRegistrationStorage = &mockActivityRegistrationStorage{}
+	diaryEntryRevisionStorage = revisionStorageMock
+	t.Cleanup(func() {
+		diaryEntryStorage = originalDiaryEntryStorage
+		activityRegistrationStorage = originalActivityRegistrationStorage
+		diaryEntryRevisionStorage = originalRevisionStorage
+	})
+
+	return diaryEntryStorageMock, revisionStorageMock
+}
+
+func TestUpdateDiaryEntryStoresRevision(t *testing.T) {
+	diaryEntryStorageMock, revisionStorageMock := setUpDiaryEntryRevisionMocks(t)
+
+	mood := 3
+	publishDate := time.Now().Unix()
+	createdEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Original", Content: "First line", PublishDate: publishDate, Mood: &mood,
+	}, nil)
+
+	_, err := diaryEntryService.UpdateDiaryEntry(createdEntry.Id, &UpdateDiaryEntryBody{Title: "Updated", Content: "Second line", PublishDate: publishDate}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, revisionStorageMock.Revisions, 1)
+	revision := revisionStorageMock.Revisions[0]
+	assert.Equal(t, createdEntry.Id, revision.DiaryEntryRefer)
+	assert.Equal(t, "Original", revision.Title)
+	assert.Equal(t, "First line", revision.Content)
+	assert.Equal(t, publishDate, revision.PublishDate)
+	assert.Equal(t, 3, *revision.Mood)
+
+	// Test the entry is not updated when the revision cannot be stored
+	revisionStorageMock.CreateErr = errors.New("revision storage failed")
+	_, err = diaryEntryService.UpdateDiaryEntry(createdEntry.Id, &UpdateDiaryEntryBody{Title: "Lost", Content: "Lost", PublishDate: publishDate}, nil)
+	assert.EqualError(t, err, "revision storage failed")
+	storedEntry, _ := diaryEntryService.GetDiaryEntryById(createdEntry.Id)
+	assert.Equal(t, "Updated", storedEntry.Title)
+
+	// Test the revision is not stored when the entry cannot be updated
+	revisionStorageMock.CreateErr = nil
+	diaryEntryStorageMock.UpdateErr = &models.DbVersionConflictError{DbItem: &models.DiaryEntry{}, CurrentVersion: 5}
+	_, err = diaryEntryService.UpdateDiaryEntry(createdEntry.Id, &UpdateDiaryEntryBody{Title: "Lost", Content: "Lost", PublishDate: publishDate}, nil)
+	assert.IsType(t, &models.DbVersionConflictError{}, err)
+	assert.Len(t, revisionStorageMock.Revisions, 1)
+}
+
+func TestDiaryEntryRevisionOwnership(t *testing.T) {
+	setUpDiaryEntryRevisionMocks(t)
+	revisionService := NewDiaryEntryRevisionServiceImpl(diaryEntryService)
+
+	createdEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Title", Content: "Content", PublishDate: time.Now().Unix(),
+	}, nil)
+	otherEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Other", Content: "Other", PublishDate: time.Now().Unix(),
+	}, nil)
+	diaryEntryService.UpdateDiaryEntry(createdEntry.Id, &UpdateDiaryEntryBody{Title: "Title", Content: "Updated", PublishDate: time.Now().Unix()}, nil)
+	diaryEntryService.UpdateDiaryEntry(otherEntry.Id, &UpdateDiaryEntryBody{Title: "Other", Content: "Updated", PublishDate: time.Now().Unix()}, nil)
+
+	revisions, err := revisionService.GetDiaryEntryRevisions(1, createdEntry.Id)
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 1)
+
+	// Test entries of other users are not found
+	_, err = revisionService.GetDiaryEntryRevisions(2, createdEntry.Id)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	_, err = revisionService.GetDiaryEntryRevision(2, createdEntry.Id, revisions[0].Id)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	_, err = revisionService.RestoreDiaryEntryRevision(2, createdEntry.Id, revisions[0].Id, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+
+	// Test revisions of other entries are not found
+	otherRevisions, _ := revisionService.GetDiaryEntryRevisions(1, otherEntry.Id)
+	_, err = revisionService.GetDiaryEntryRevision(1, createdEntry.Id, otherRevisions[0].Id)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
+
+func TestRestoreDiaryEntryRevision(t *testing.T) {
+	_, revisionStorageMock := setUpDiaryEntryRevisionMocks(t)
+	revisionService := NewDiaryEntryRevisionServiceImpl(diaryEntryService)
+
+	mood := 2
+	createdEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Original", Content: "Original content", PublishDate: 100,
+		Feelings: []string{models.FeelingSad},
+	}, nil)
+	diaryEntryService.UpdateDiaryEntry(createdEntry.Id, &UpdateDiaryEntryBody{
+		Title: "Overwritten", Content: "Overwritten content", PublishDate: 200, Mood: &mood, Feelings: []string{models.FeelingHappy},
+	}, nil)
+
+	restoredEntry, err := revisionService.RestoreDiaryEntryRevision(1, createdEntry.Id, revisionStorageMock.Revisions[0].Id, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Original", restoredEntry.Title)
+	assert.Equal(t, "Original content", restoredEntry.Content)
+	assert.Equal(t, int64(100), restoredEntry.Registration.RegistrationDate)
+	assert.Nil(t, restoredEntry.Mood)
+	assert.Equal(t, []string{models.FeelingSad}, restoredEntry.Feelings)
+
+	// the overwritten version is kept, so the restore can be undone
+	assert.Len(t, revisionStorageMock.Revisions, 2)
+	assert.Equal(t, "Overwritten", revisionStorageMock.Revisions[1].Title)
+
+	// Test missing revision
+	_, err = revisionService.RestoreDiaryEntryRevision(1, createdEntry.Id, 99, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
+
+func TestDiffDiaryEntryRevisions(t *testing.T) {
+	setUpDiaryEntryRevisionMocks(t)
+	revisionService := NewDiaryEntryRevisionServiceImpl(diaryEntryService)
+
+	createdEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Title", Content: "one\ntwo\nthree", PublishDate: time.Now().Unix(),
+	}, nil)
+	diaryEntryService.UpdateDiaryEntry(createdEntry.Id, &UpdateDiaryEntryBody{Title: "Title", Content: "one\n2\nthree\nfour", PublishDate: time.Now().Unix()}, nil)
+	revisions, _ := revisionService.GetDiaryEntryRevisions(1, createdEntry.Id)
+
+	// Test diff against the current version
+	revisionDiff, err := revisionService.DiffDiaryEntryRevisions(1, createdEntry.Id, revisions[0].Id, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []DiffLine{{Operation: DiffOperationEqual, Text: "Title"}}, revisionDiff.Title)
+	assert.Equal(t, []DiffLine{
+		{Operation: DiffOperationEqual, Text: "one"},
+		{Operation: DiffOperationDelete, Text: "two"},
+		{Operation: DiffOperationInsert, Text: "2"},
+		{Operation: DiffOperationEqual, Text: "three"},
+		{Operation: DiffOperationInsert, Text: "four"},
+	}, revisionDiff.Content)
+
+	// Test missing revision
+	_, err = revisionService.DiffDiaryEntryRevisions(1, createdEntry.Id, revisions[0].Id, 99)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
@@ -1,11 +1,16 @@
 package services
 
 import (
+	"cmp"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"github.com/adfer-dev/analock-api/utils"
+	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,8 +21,17 @@ type mockDiaryEntryStorage struct {
 	GetErr       error
 	GetByUIDErr  error
 	GetByDateErr error
+	GetPageErr   error
+	LastFilter   *storage.DiaryEntryPageFilter
+	SearchErr    error
+	LastSearch   *storage.DiaryEntrySearchFilter
 	CreateErr    error
 	UpdateErr    error
+	DeleteErr    error
+	DeletedId    uint
+	IndexedUsers []uint
+	// RevisionStorage receives the revision changes written along with the updates, when set
+	RevisionStorage *mockDiaryEntryRevisionStorage
 }
 
 func (m *mockDiaryEntryStorage) Get(id uint) (interface{}, error) {
@@ -59,6 +73,48 @@ func (m *mockDiaryEntryStorage) GetByUserIdAndDateInterval(userId uint, startDat
 	return filteredEntries, nil
 }
 
+func (m *mockDiaryEntryStorage) GetUserPage(filter *storage.DiaryEntryPageFilter) (interface{}, error) {
+	m.LastFilter = filter
+	if m.GetPageErr != nil {
+		return nil, m.GetPageErr
+	}
+	compareEntries := func(a, b *models.DiaryEntry) int {
+		return cmp.Or(cmp.Compare(a.Registration.RegistrationDate, b.Registration.RegistrationDate), cmp.Compare(a.Id, b.Id))
+	}
+	userEntries := slices.SortedFunc(slices.Values(m.UserEntries[filter.UserId]), func(a, b *models.DiaryEntry) int {
+		if filter.Descending {
+			return compareEntries(b, a)
+		}
+		return compareEntries(a, b)
+	})
+	pageEntries := []*models.DiaryEntry{}
+	for _, entry := range userEntries {
+		if filter.After != nil {
+			comparison := compareEntries(entry, &models.DiaryEntry{Id: filter.After.Id,
+				Registration: models.ActivityRegistration{RegistrationDate: filter.After.RegistrationDate}})
+			if (filter.Descending && comparison >= 0) || (!filter.Descending && comparison <= 0) {
+				continue
+			}
+		}
+		if len(pageEntries) < filter.Limit {
+			pageEntries = append(pageEntries, entry)
+		}
+	}
+	return pageEntries, nil
+}
+
+func (m *mockDiaryEntryStorage) Search(filter *storage.DiaryEntrySearchFilter) (interface{}, error) {
+	m.LastSearch = filter
+	if m.SearchErr != nil {
+		return nil, m.SearchErr
+	}
+	searchResults := []*models.DiaryEntrySearchResult{}
+	for _, entry := range m.UserEntries[filter.UserId] {
+		searchResults = append(searchResults, &models.DiaryEntrySearchResult{Entry: entry, TitleSnippet: entry.Title})
+	}
+	return searchResults, nil
+}
+
 func (m *mockDiaryEntryStorage) Create(data interface{}) error {
 	if m.CreateErr != nil {
 		return m.CreateErr
@@ -82,14 +138,30 @@ func (m *mockDiaryEntryStorage) Update(data interface{}) error {
 	if m.UpdateErr != nil {
 		return m.UpdateErr
 	}
-	entry, ok := data.(*models.DiaryEntry)
+	entryUpdate, ok := data.(*storage.DiaryEntryUpdate)
 	if !ok {
-		return errors.New("update: invalid type for DiaryEntry")
+		return errors.New("update: invalid type for DiaryEntryUpdate")
 	}
-	_, exists := m.Entries[entry.Id]
+	entry := entryUpdate.Entry
+	storedEntry, exists := m.Entries[entry.Id]
 	if !exists {
 		return errors.New("update: diary entry not found")
 	}
+	if storedEntry.Version != entry.Version {
+		return &models.DbVersionConflictError{DbItem: &models.DiaryEntry{}, CurrentVersion: storedEntry.Version}
+	}
+	if m.RevisionStorage != nil {
+		if entryUpdate.DeleteRevisions {
+			m.RevisionStorage.DeleteByDiaryEntryId(entry.Id)
+		}
+		if entryUpdate.Revision != nil {
+			if revisionErr := m.RevisionStorage.Create(entryUpdate.Revision); revisionErr != nil {
+				return revisionErr
+			}
+		}
+	}
+	entry.Version++
+	entry.Registration.Version++
 	m.Entries[entry.Id] = entry
 	// Update in UserEntries as well
 	userEntries, uExists := m.UserEntries[entry.Registration.UserRefer]
@@ -104,6 +176,40 @@ func (m *mockDiaryEntryStorage) Update(data interface{}) error {
 	return nil
 }
 
+func (m *mockDiaryEntryStorage) GetTrashedByUserId(userId uint) (interface{}, error) {
+	trashedEntries := []*models.DiaryEntry{}
+	for _, entry := range m.Entries {
+		if entry.Registration.UserRefer == userId && entry.Registration.DeletedAt != 0 {
+			trashedEntries = append(trashedEntries, entry)
+		}
+	}
+	return trashedEntries, nil
+}
+
+func (m *mockDiaryEntryStorage) GetTrashedBefore(deletedBefore int64) (interface{}, error) {
+	expiredEntries := []*models.DiaryEntry{}
+	for _, entry := range m.Entries {
+		if entry.Registration.DeletedAt != 0 && entry.Registration.DeletedAt < deletedBefore {
+			expiredEntries = append(expiredEntries, entry)
+		}
+	}
+	return expiredEntries, nil
+}
+
+func (m *mockDiaryEntryStorage) Delete(id uint) error {
+	if m.DeleteErr != nil {
+		return m.DeleteErr
+	}
+	delete(m.Entries, id)
+	m.DeletedId = id
+	return nil
+}
+
+func (m *mockDiaryEntryStorage) RebuildUserSearchIndex(userId uint) error {
+	m.IndexedUsers = append(m.IndexedUsers, userId)
+	return nil
+}
+
 var diaryEntryService DiaryEntryService = &DefaultDiaryEntryService{}
 
 func TestGetDiaryEntryById(t *testing.T) {
@@ -206,31 +312,30 @@ func TestSaveDiaryEntry(t *testing.T) {
 		Title:       "New Diary Entry",
 		Content:     "Diary content here.",
 		PublishDate: time.Now().Unix(),
-		UserRefer:   uint(5),
 	}
 
 	// --- Test successful save ---
-	createdEntry, err := diaryEntryService.SaveDiaryEntry(saveBody)
+	createdEntry, err := diaryEntryService.SaveDiaryEntry(5, saveBody, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, createdEntry)
 	assert.Equal(t, saveBody.Title, createdEntry.Title)
 	assert.Equal(t, saveBody.Content, createdEntry.Content)
 	assert.Equal(t, saveBody.PublishDate, createdEntry.Registration.RegistrationDate)
-	assert.Equal(t, saveBody.UserRefer, createdEntry.Registration.UserRefer)
+	assert.Equal(t, uint(5), createdEntry.Registration.UserRefer)
 	assert.NotNil(t, activityRegistrationStorageMock.CreatedActivity) // Check activity was passed to mock ARS
 	assert.Equal(t, saveBody.PublishDate, activityRegistrationStorageMock.CreatedActivity.RegistrationDate)
 
 	// --- Test error from activityRegistrationStorage.Create ---
 	activityRegistrationStorageMock.Err = errors.New("ARS create failed")
-	_, err = diaryEntryService.SaveDiaryEntry(saveBody)
+	_, err = diaryEntryService.SaveDiaryEntry(5, saveBody, nil)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "ARS create failed")
 	activityRegistrationStorageMock.Err = nil // Reset error
 
 	// --- Test error from diaryEntryStorage.Create ---
 	diaryEntryStorageMock.CreateErr = errors.New("DES create failed")
-	_, err = diaryEntryService.SaveDiaryEntry(saveBody)
+	_, err = diaryEntryService.SaveDiaryEntry(5, saveBody, nil)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "DES create failed")
 	diaryEntryStorageMock.CreateErr = nil // Reset error
@@ -271,7 +376,7 @@ func TestUpdateDiaryEntry(t *testing.T) {
 	}
 
 	// Test successful update
-	updatedEntry, err := diaryEntryService.UpdateDiaryEntry(storedEntry.Id, updateBody)
+	updatedEntry, err := diaryEntryService.UpdateDiaryEntry(storedEntry.Id, updateBody, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, updatedEntry)
 	assert.Equal(t, updateBody.Title, updatedEntry.Title)
@@ -279,31 +384,137 @@ func TestUpdateDiaryEntry(t *testing.T) {
 	assert.Equal(t, updateBody.PublishDate, updatedEntry.Registration.RegistrationDate)
 	assert.Equal(t, userId, updatedEntry.Registration.UserRefer)
 	assert.Equal(t, activityReg.Id, updatedEntry.Registration.Id)
-	assert.NotNil(t, activityRegistrationStorageMock.UpdatedActivity)
-	assert.Equal(t, updateBody.PublishDate, activityRegistrationStorageMock.UpdatedActivity.RegistrationDate)
+	// the publish date is written along with the entry
+	assert.Equal(t, updateBody.PublishDate, diaryEntryStorageMock.Entries[storedEntry.Id].Registration.RegistrationDate)
 
 	// Test error from GetDiaryEntryById
 	diaryEntryStorageMock.GetErr = errors.New("get failed for update")
-	_, err = diaryEntryService.UpdateDiaryEntry(storedEntry.Id, updateBody)
+	_, err = diaryEntryService.UpdateDiaryEntry(storedEntry.Id, updateBody, nil)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "get failed for update")
 	diaryEntryStorageMock.GetErr = nil
 
-	// Test error from activityRegistrationStorage.Update
-	activityRegistrationStorageMock.UpdateErr = errors.New("ARS update failed")
-	_, err = diaryEntryService.UpdateDiaryEntry(storedEntry.Id, updateBody)
-	assert.Error(t, err)
-	assert.EqualError(t, err, "ARS update failed")
-	activityRegistrationStorageMock.UpdateErr = nil
-
 	// Test error from diaryEntryStorage.Update
 	diaryEntryStorageMock.UpdateErr = errors.New("DES update failed")
-	_, err = diaryEntryService.UpdateDiaryEntry(storedEntry.Id, updateBody)
+	_, err = diaryEntryService.UpdateDiaryEntry(storedEntry.Id, updateBody, nil)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "DES update failed")
 	diaryEntryStorageMock.UpdateErr = nil
 }
 
+func TestUpdateDiaryEntryVersion(t *testing.T) {
+	originalDiaryEntryStorage := diaryEntryStorage
+	originalActivityRegistrationStorage := activityRegistrationStorage
+
+	diaryEntryStorageMock := &mockDiaryEntryStorage{
+		Entries:     make(map[uint]*models.DiaryEntry),
+		UserEntries: make(map[uint][]*models.DiaryEntry),
+	}
+
+	diaryEntryStorage = diaryEntryStorageMock
+	activityRegistrationStorage = &mockActivityRegistrationStorage{}
+	defer func() {
+		diaryEntryStorage = originalDiaryEntryStorage
+		activityRegistrationStorage = originalActivityRegistrationStorage
+	}()
+
+	storedEntry := &models.DiaryEntry{
+		Id:           3,
+		Title:        "Original Title",
+		Content:      "Original Content",
+		Registration: models.ActivityRegistration{Id: 300, UserRefer: 10},
+		Version:      4,
+	}
+	diaryEntryStorageMock.Entries[storedEntry.Id] = storedEntry
+
+	updateBody := &UpdateDiaryEntryBody{
+		Title:           "Updated Title",
+		Content:         "Updated Content",
+		PublishDate:     time.Now().Unix(),
+		ExpectedVersion: 4,
+	}
+
+	// Test the version is incremented on update
+	updatedEntry, err := diaryEntryService.UpdateDiaryEntry(storedEntry.Id, updateBody, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), updatedEntry.Version)
+
+	// Test a stale expected version is rejected with the current one
+	_, err = diaryEntryService.UpdateDiaryEntry(storedEntry.Id, updateBody, nil)
+	var conflictErr *models.DbVersionConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, int64(5), conflictErr.CurrentVersion)
+	assert.Equal(t, "Updated Title", diaryEntryStorageMock.Entries[storedEntry.Id].Title)
+
+	// Test updates without expected version skip the check
+	updateBody.ExpectedVersion = 0
+	updatedEntry, err = diaryEntryService.UpdateDiaryEntry(storedEntry.Id, updateBody, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), updatedEntry.Version)
+}
+
+func TestPatchDiaryEntry(t *testing.T) {
+	originalDiaryEntryStorage := diaryEntryStorage
+	originalActivityRegistrationStorage := activityRegistrationStorage
+
+	diaryEntryStorageMock := &mockDiaryEntryStorage{
+		Entries:     make(map[uint]*models.DiaryEntry),
+		UserEntries: make(map[uint][]*models.DiaryEntry),
+	}
+
+	diaryEntryStorage = diaryEntryStorageMock
+	activityRegistrationStorage = &mockActivityRegistrationStorage{}
+	defer func() {
+		diaryEntryStorage = originalDiaryEntryStorage
+		activityRegistrationStorage = originalActivityRegistrationStorage
+	}()
+
+	mood := 4
+	storedEntry := &models.DiaryEntry{
+		Id:           4,
+		Title:        "Tpyo",
+		Content:      "Original Content",
+		Tags:         []string{"work"},
+		Mood:         &mood,
+		Feelings:     []string{"calm"},
+		Registration: models.ActivityRegistration{Id: 400, RegistrationDate: 1000, UserRefer: 10},
+		Version:      1,
+	}
+	diaryEntryStorageMock.Entries[storedEntry.Id] = storedEntry
+
+	// Test only the patched fields change
+	patchedEntry, err := diaryEntryService.PatchDiaryEntry(10, storedEntry.Id, []byte(`{"title":"Typo"}`), 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Typo", patchedEntry.Title)
+	assert.Equal(t, "Original Content", patchedEntry.Content)
+	assert.Equal(t, int64(1000), patchedEntry.Registration.RegistrationDate)
+	assert.Equal(t, []string{"work"}, patchedEntry.Tags)
+	assert.Equal(t, 4, *patchedEntry.Mood)
+	assert.Equal(t, []string{"calm"}, patchedEntry.Feelings)
+
+	// Test null members remove the optional fields
+	patchedEntry, err = diaryEntryService.PatchDiaryEntry(10, storedEntry.Id, []byte(`{"mood":null,"tags":null}`), 0, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, patchedEntry.Mood)
+	assert.Empty(t, patchedEntry.Tags)
+	assert.Equal(t, []string{"calm"}, patchedEntry.Feelings)
+
+	// Test the merged entry is validated
+	_, err = diaryEntryService.PatchDiaryEntry(10, storedEntry.Id, []byte(`{"content":null}`), 0, nil)
+	var invalidBodyErr *utils.InvalidBodyError
+	assert.ErrorAs(t, err, &invalidBodyErr)
+
+	// Test the expected version is checked
+	_, err = diaryEntryService.PatchDiaryEntry(10, storedEntry.Id, []byte(`{"title":"Stale"}`), 1, nil)
+	var conflictErr *models.DbVersionConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+
+	// Test entries of other users are not found
+	_, err = diaryEntryService.PatchDiaryEntry(11, storedEntry.Id, []byte(`{"title":"Other"}`), 0, nil)
+	var notFoundErr *models.DbNotFoundError
+	assert.ErrorAs(t, err, &notFoundErr)
+}
+
 func TestDeleteDiaryEntry(t *testing.T) {
 	originalDiaryEntryStorage := diaryEntryStorage
 	originalActivityRegistrationStorage := activityRegistrationStorage
@@ -324,24 +535,225 @@ func TestDeleteDiaryEntry(t *testing.T) {
 	entryToDelete := &models.DiaryEntry{Id: 2, Registration: models.ActivityRegistration{Id: activityRegId}}
 	diaryEntryStorageMock.Entries[entryToDelete.Id] = entryToDelete
 
-	// Test successful delete
-	err := diaryEntryService.DeleteDiaryEntry(entryToDelete.Id)
+	// Test successful delete moves the entry to the trash
+	err := diaryEntryService.DeleteDiaryEntry(entryToDelete.Id, nil)
 	assert.NoError(t, err)
-	assert.Equal(t, activityRegId, activityRegistrationStorageMock.DeletedId)
+	assert.Equal(t, activityRegId, activityRegistrationStorageMock.TrashedId)
+	assert.Zero(t, diaryEntryStorageMock.DeletedId)
+	assert.Zero(t, activityRegistrationStorageMock.DeletedId)
 
 	// Test error from GetDiaryEntryById
 	diaryEntryStorageMock.GetErr = errors.New("get failed for delete")
-	err = diaryEntryService.DeleteDiaryEntry(entryToDelete.Id)
+	err = diaryEntryService.DeleteDiaryEntry(entryToDelete.Id, nil)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "get failed for delete")
 	diaryEntryStorageMock.GetErr = nil
 
-	// Test error from activityRegistrationStorage.Delete
+	// Test error from activityRegistrationStorage.Trash
 	// Need to ensure the entry is found again by GetDiaryEntryById for this sub-test
 	diaryEntryStorageMock.Entries[entryToDelete.Id] = entryToDelete
 	activityRegistrationStorageMock.DeleteErr = errors.New("ARS delete failed")
-	err = diaryEntryService.DeleteDiaryEntry(entryToDelete.Id)
+	err = diaryEntryService.DeleteDiaryEntry(entryToDelete.Id, nil)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "ARS delete failed")
 	activityRegistrationStorageMock.DeleteErr = nil
 }
+
+func TestGetUserEntriesPage(t *testing.T) {
+	originalDiaryEntryStorage := diaryEntryStorage
+	diaryEntryStorageMock := &mockDiaryEntryStorage{
+		UserEntries: make(map[uint][]*models.DiaryEntry),
+	}
+	diaryEntryStorage = diaryEntryStorageMock
+	defer func() { diaryEntryStorage = originalDiaryEntryStorage }()
+
+	userId := uint(1)
+	entry1 := &models.DiaryEntry{Id: 1, Registration: models.ActivityRegistration{UserRefer: userId, RegistrationDate: 100}}
+	entry2 := &models.DiaryEntry{Id: 2, Registration: models.ActivityRegistration{UserRefer: userId, RegistrationDate: 200}}
+	entry3 := &models.DiaryEntry{Id: 3, Registration: models.ActivityRegistration{UserRefer: userId, RegistrationDate: 200}}
+	diaryEntryStorageMock.UserEntries[userId] = []*models.DiaryEntry{entry1, entry2, entry3}
+
+	// Test descending order by default, with next cursor
+	firstPage, err := diaryEntryService.GetUserEntriesPage(userId, &DiaryEntryPageQuery{Limit: 2, StartDate: 50})
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.DiaryEntry{entry3, entry2}, firstPage.Entries)
+	assert.NotEmpty(t, firstPage.NextCursor)
+	assert.True(t, diaryEntryStorageMock.LastFilter.Descending)
+	assert.Equal(t, int64(50), diaryEntryStorageMock.LastFilter.StartDate)
+
+	// Test last page has no next cursor
+	lastPage, err := diaryEntryService.GetUserEntriesPage(userId, &DiaryEntryPageQuery{Limit: 2, Cursor: firstPage.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.DiaryEntry{entry1}, lastPage.Entries)
+	assert.Empty(t, lastPage.NextCursor)
+
+	// Test ascending order and default limit
+	ascendingPage, err := diaryEntryService.GetUserEntriesPage(userId, &DiaryEntryPageQuery{Sort: DiaryEntriesSortAscending})
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.DiaryEntry{entry1, entry2, entry3}, ascendingPage.Entries)
+	assert.Equal(t, defaultDiaryEntriesPageLimit+1, diaryEntryStorageMock.LastFilter.Limit)
+
+	// Test invalid cursor
+	_, err = diaryEntryService.GetUserEntriesPage(userId, &DiaryEntryPageQuery{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidDiaryEntryCursor)
+
+	// Test storage error
+	diaryEntryStorageMock.GetPageErr = errors.New("forced GetPageErr error")
+	_, err = diaryEntryService.GetUserEntriesPage(userId, &DiaryEntryPageQuery{})
+	assert.EqualError(t, err, "forced GetPageErr error")
+}
+
+func TestSearchUserEntries(t *testing.T) {
+	originalDiaryEntryStorage := diaryEntryStorage
+	diaryEntryStorageMock := &mockDiaryEntryStorage{
+		UserEntries: make(map[uint][]*models.DiaryEntry),
+	}
+	diaryEntryStorage = diaryEntryStorageMock
+	defer func() { diaryEntryStorage = originalDiaryEntryStorage }()
+
+	userId := uint(1)
+	entry := &models.DiaryEntry{Id: 1, Title: "Trip", Registration: models.ActivityRegistration{UserRefer: userId}}
+	diaryEntryStorageMock.UserEntries[userId] = []*models.DiaryEntry{entry}
+
+	searchPage, err := diaryEntryService.SearchUserEntries(userId, &DiaryEntrySearchQuery{Query: "trip*", StartDate: 10, Page: 2})
+	assert.NoError(t, err)
+	assert.Len(t, searchPage.Results, 1)
+	assert.Equal(t, entry, searchPage.Results[0].Entry)
+	assert.Equal(t, 2, searchPage.Page)
+	assert.Equal(t, defaultDiaryEntrySearchPageSize, searchPage.PageSize)
+	assert.Equal(t, `"trip"*`, diaryEntryStorageMock.LastSearch.Query)
+	assert.Equal(t, userId, diaryEntryStorageMock.LastSearch.UserId)
+	assert.Equal(t, int64(10), diaryEntryStorageMock.LastSearch.StartDate)
+	assert.Equal(t, defaultDiaryEntrySearchPageSize, diaryEntryStorageMock.LastSearch.Offset)
+
+	// Test query without searchable terms
+	_, err = diaryEntryService.SearchUserEntries(userId, &DiaryEntrySearchQuery{Query: ` " * - `})
+	assert.ErrorIs(t, err, ErrInvalidDiaryEntrySearchQuery)
+
+	// Test storage error
+	diaryEntryStorageMock.SearchErr = errors.New("forced SearchErr error")
+	_, err = diaryEntryService.SearchUserEntries(userId, &DiaryEntrySearchQuery{Query: "trip"})
+	assert.EqualError(t, err, "forced SearchErr error")
+}
+
+func TestSearchUserEntriesEncryptedAtRest(t *testing.T) {
+	originalDiaryEntryStorage := diaryEntryStorage
+	originalUserStorage := userStorage
+	originalIsContentEncryptionEnabled := isContentEncryptionEnabled
+	diaryEntryStorage = &mockDiaryEntryStorage{UserEntries: make(map[uint][]*models.DiaryEntry)}
+	userStorageMock := newuserStorageMockUserStorage()
+	userStorage = userStorageMock
+	isContentEncryptionEnabled = func() bool { return true }
+	defer func() {
+		diaryEntryStorage = originalDiaryEntryStorage
+		userStorage = originalUserStorage
+		isContentEncryptionEnabled = originalIsContentEncryptionEnabled
+	}()
+
+	userStorageMock.UsersById[1] = &models.User{Id: 1}
+
+	// Test users must opt into the search index
+	_, err := diaryEntryService.SearchUserEntries(1, &DiaryEntrySearchQuery{Query: "trip"})
+	assert.ErrorIs(t, err, ErrDiaryEntrySearchIndexDisabled)
+
+	userStorageMock.UsersById[1].SearchIndexEnabled = true
+	_, err = diaryEntryService.SearchUserEntries(1, &DiaryEntrySearchQuery{Query: "trip"})
+	assert.NoError(t, err)
+
+	// Test missing user
+	_, err = diaryEntryService.SearchUserEntries(2, &DiaryEntrySearchQuery{Query: "trip"})
+	assert.Error(t, err)
+}
+
+func TestBuildFtsMatchExpression(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"trip", `"trip"`},
+		{"summer trip", `"summer" "trip"`},
+		{"mount*", `"mount"*`},
+		{`"road trip" beach`, `"road trip" "beach"`},
+		{`"road tr"*`, `"road tr"*`},
+		{`"unterminated phrase`, `"unterminated phrase"`},
+		{`title:trip OR NEAR(a b)`, `"title:trip" "OR" "NEAR(a" "b)"`},
+		{`  `, ``},
+		{`- "" *`, ``},
+	}
+
+	for _, testCase := range tests {
+		assert.Equal(t, testCase.expected, buildFtsMatchExpression(testCase.query), testCase.query)
+	}
+}
+
+func TestDiaryEntryEncryptionValidation(t *testing.T) {
+	bodyValidator := validator.New()
+	mood := 3
+	encryptionBody := &DiaryEntryEncryptionBody{
+		Algorithm: models.EncryptionAlgorithmAesGcm, Nonce: "bm9uY2U=", KeyId: "device-key-1", WrappedKey: "a2V5",
+	}
+
+	tests := []struct {
+		name    string
+		body    *SaveDiaryEntryBody
+		isValid bool
+	}{
+		{"Plaintext entry", &SaveDiaryEntryBody{Title: "Title", Content: "Content", PublishDate: 1, Mood: &mood}, true},
+		{"Plaintext entry without title", &SaveDiaryEntryBody{Content: "Content", PublishDate: 1}, false},
+		{"Encrypted entry", &SaveDiaryEntryBody{Content: "Y2lwaGVydGV4dA==", PublishDate: 1, Encryption: encryptionBody}, true},
+		{"Encrypted entry with plaintext title", &SaveDiaryEntryBody{Title: "Title", Content: "Y2lwaGVydGV4dA==", PublishDate: 1, Encryption: encryptionBody}, false},
+		{"Encrypted entry with plaintext tags", &SaveDiaryEntryBody{Content: "Y2lwaGVydGV4dA==", PublishDate: 1, Tags: []string{"trip"}, Encryption: encryptionBody}, false},
+		{"Encrypted entry with plaintext mood", &SaveDiaryEntryBody{Content: "Y2lwaGVydGV4dA==", PublishDate: 1, Mood: &mood, Encryption: encryptionBody}, false},
+		{"Encrypted entry with plaintext feelings", &SaveDiaryEntryBody{Content: "Y2lwaGVydGV4dA==", PublishDate: 1, Feelings: []string{models.FeelingCalm}, Encryption: encryptionBody}, false},
+		{"Unsupported algorithm", &SaveDiaryEntryBody{Content: "Y2lwaGVydGV4dA==", PublishDate: 1,
+			Encryption: &DiaryEntryEncryptionBody{Algorithm: "ROT13", Nonce: "bm9uY2U=", KeyId: "device-key-1", WrappedKey: "a2V5"}}, false},
+	}
+
+	for _, testCase := range tests {
+		err := bodyValidator.Struct(testCase.body)
+		assert.Equal(t, testCase.isValid, err == nil, testCase.name)
+	}
+}
+
+func TestEncryptDiaryEntry(t *testing.T) {
+	diaryEntryStorageMock, revisionStorageMock := setUpDiaryEntryRevisionMocks(t)
+
+	mood := 4
+	createdEntry, _ := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Title", Content: "Plaintext", PublishDate: 1, Tags: []string{"trip"}, Mood: &mood,
+	}, nil)
+	diaryEntryService.UpdateDiaryEntry(createdEntry.Id, &UpdateDiaryEntryBody{Title: "Title", Content: "Plaintext 2", PublishDate: 1}, nil)
+	assert.Len(t, revisionStorageMock.Revisions, 1)
+
+	// Test encrypting removes the plaintext metadata and revisions
+	encryptionBody := &DiaryEntryEncryptionBody{
+		Algorithm: models.EncryptionAlgorithmXChaCha20Poly1305, Nonce: "bm9uY2U=", KeyId: "device-key-1", WrappedKey: "a2V5",
+	}
+	encryptedEntry, err := diaryEntryService.UpdateDiaryEntry(createdEntry.Id, &UpdateDiaryEntryBody{
+		Content: "Y2lwaGVydGV4dA==", PublishDate: 1, Encryption: encryptionBody,
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.DiaryEntryEncryption{
+		Algorithm: models.EncryptionAlgorithmXChaCha20Poly1305, Nonce: "bm9uY2U=", KeyId: "device-key-1", WrappedKey: "a2V5",
+	}, encryptedEntry.Encryption)
+	assert.Empty(t, encryptedEntry.Title)
+	assert.Nil(t, encryptedEntry.Mood)
+	assert.Empty(t, encryptedEntry.Tags)
+	assert.Empty(t, diaryEntryStorageMock.Entries[createdEntry.Id].Tags)
+	assert.Empty(t, revisionStorageMock.Revisions)
+	assert.Equal(t, encryptedEntry, diaryEntryStorageMock.Entries[createdEntry.Id])
+
+	// Test updates of encrypted entries keep the encrypted versions
+	_, err = diaryEntryService.UpdateDiaryEntry(createdEntry.Id, &UpdateDiaryEntryBody{
+		Content: "bmV3IGNpcGhlcnRleHQ=", PublishDate: 1, Encryption: encryptionBody,
+	}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, revisionStorageMock.Revisions, 1)
+	assert.Equal(t, "Y2lwaGVydGV4dA==", revisionStorageMock.Revisions[0].Content)
+	assert.NotNil(t, revisionStorageMock.Revisions[0].Encryption)
+
+	// Test encrypted versions cannot be diffed by the server
+	revisionService := NewDiaryEntryRevisionServiceImpl(diaryEntryService)
+	_, err = revisionService.DiffDiaryEntryRevisions(1, createdEntry.Id, revisionStorageMock.Revisions[0].Id, 0)
+	assert.ErrorIs(t, err, ErrEncryptedDiaryEntryDiff)
+}
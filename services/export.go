@@ -0,0 +1,235 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	exportProfileFileName             = "profile.json"
+	exportDiaryEntriesFileName        = "diary_entries.json"
+	exportBookRegistrationsFileName   = "book_registrations.json"
+	exportGameRegistrationsFileName   = "game_registrations.json"
+	exportCustomRegistrationsFileName = "custom_registrations.json"
+	exportDiaryEntriesDirectory       = "entries/"
+
+	// exportDiaryEntriesPageSize is the number of diary entries held in memory at once while writing the archive.
+	exportDiaryEntriesPageSize = maxDiaryEntriesPageLimit
+)
+
+var exportSlugInvalidCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// UserDataExport groups the data of a user included in the export archive.
+// Diary entries are not included, as they are read page by page while the archive is written.
+type UserDataExport struct {
+	User                *models.User
+	BookRegistrations   []*models.BookActivityRegistration
+	GameRegistrations   []*models.GameActivityRegistration
+	CustomRegistrations []*models.CustomActivityRegistration
+}
+
+// UserDataExportService defines all operations for the user data export service.
+type UserDataExportService interface {
+	GetUserDataExport(user *models.User) (*UserDataExport, error)
+	WriteUserDataExport(userDataExport *UserDataExport, writer io.Writer) error
+}
+
+// UserDataExportServiceImpl is the concrete implementation of UserDataExportService.
+type UserDataExportServiceImpl struct {
+	diaryEntryService         DiaryEntryService
+	bookRegistrationService   BookActivityRegistrationService
+	gameRegistrationService   GameActivityRegistrationService
+	customRegistrationService CustomActivityRegistrationService
+}
+
+// NewUserDataExportServiceImpl creates a new UserDataExportServiceImpl.
+func NewUserDataExportServiceImpl(
+	diaryEntryService DiaryEntryService,
+	bookRegistrationService BookActivityRegistrationService,
+	gameRegistrationService GameActivityRegistrationService,
+	customRegistrationService CustomActivityRegistrationService,
+) *UserDataExportServiceImpl {
+	return &UserDataExportServiceImpl{
+		diaryEntryService:         diaryEntryService,
+		bookRegistrationService:   bookRegistrationService,
+		gameRegistrationService:   gameRegistrationService,
+		customRegistrationService: customRegistrationService,
+	}
+}
+
+// GetUserDataExport gathers the activity registrations of the user.
+func (userDataExportService *UserDataExportServiceImpl) GetUserDataExport(user *models.User) (*UserDataExport, error) {
+	bookRegistrations, getBookRegistrationsErr := userDataExportService.bookRegistrationService.GetUserBookActivityRegistrations(user.Id)
+	if getBookRegistrationsErr != nil {
+		return nil, getBookRegistrationsErr
+	}
+
+	gameRegistrations, getGameRegistrationsErr := userDataExportService.gameRegistrationService.GetUserGameActivityRegistrations(user.Id)
+	if getGameRegistrationsErr != nil {
+		return nil, getGameRegistrationsErr
+	}
+
+	customRegistrations, getCustomRegistrationsErr := userDataExportService.customRegistrationService.GetAllUserCustomActivityRegistrations(user.Id)
+	if getCustomRegistrationsErr != nil {
+		return nil, getCustomRegistrationsErr
+	}
+
+	return &UserDataExport{
+		User:                user,
+		BookRegistrations:   bookRegistrations,
+		GameRegistrations:   gameRegistrations,
+		CustomRegistrations: customRegistrations,
+	}, nil
+}
+
+// WriteUserDataExport writes a zip archive with the exported data to the writer.
+// The archive contains the user profile, diary entries and activity registrations as JSON files,
+// and one Markdown file per diary entry. Every file is written straight to the writer, and diary entries
+// are read one page at a time, so neither the archive nor the entries are ever held in memory as a whole.
+func (userDataExportService *UserDataExportServiceImpl) WriteUserDataExport(userDataExport *UserDataExport, writer io.Writer) error {
+	zipWriter := zip.NewWriter(writer)
+
+	if err := writeExportJSONFile(zipWriter, exportProfileFileName, userDataExport.User); err != nil {
+		return err
+	}
+	if err := userDataExportService.writeExportDiaryEntriesFile(zipWriter, userDataExport.User.Id); err != nil {
+		return err
+	}
+	if err := writeExportJSONFile(zipWriter, exportBookRegistrationsFileName, userDataExport.BookRegistrations); err != nil {
+		return err
+	}
+	if err := writeExportJSONFile(zipWriter, exportGameRegistrationsFileName, userDataExport.GameRegistrations); err != nil {
+		return err
+	}
+	if err := writeExportJSONFile(zipWriter, exportCustomRegistrationsFileName, userDataExport.CustomRegistrations); err != nil {
+		return err
+	}
+
+	// a zip file must be written entirely before the next one is created, so the entries are read a second time
+	markdownErr := userDataExportService.forEachExportDiaryEntry(userDataExport.User.Id, func(diaryEntry *models.DiaryEntry) error {
+		return writeExportDiaryEntryMarkdownFile(zipWriter, diaryEntry)
+	})
+
+	if markdownErr != nil {
+		return markdownErr
+	}
+
+	return zipWriter.Close()
+}
+
+// writeExportDiaryEntriesFile writes the diary entries of the user as a JSON array, encoding one entry at a time.
+func (userDataExportService *UserDataExportServiceImpl) writeExportDiaryEntriesFile(zipWriter *zip.Writer, userId uint) error {
+	fileWriter, createErr := createExportFile(zipWriter, exportDiaryEntriesFileName)
+	if createErr != nil {
+		return createErr
+	}
+
+	separator := "[\n  "
+
+	forEachErr := userDataExportService.forEachExportDiaryEntry(userId, func(diaryEntry *models.DiaryEntry) error {
+		encodedEntry, encodeErr := json.MarshalIndent(diaryEntry, "  ", "  ")
+		if encodeErr != nil {
+			return encodeErr
+		}
+
+		if _, writeErr := io.WriteString(fileWriter, separator); writeErr != nil {
+			return writeErr
+		}
+		separator = ",\n  "
+
+		_, writeErr := fileWriter.Write(encodedEntry)
+		return writeErr
+	})
+
+	if forEachErr != nil {
+		return forEachErr
+	}
+
+	closing := "\n]\n"
+	if separator == "[\n  " {
+		closing = "[]\n"
+	}
+
+	_, writeErr := io.WriteString(fileWriter, closing)
+	return writeErr
+}
+
+// forEachExportDiaryEntry calls the function with every diary entry of the user, oldest first, fetching them page by page.
+func (userDataExportService *UserDataExportServiceImpl) forEachExportDiaryEntry(userId uint, apply func(*models.DiaryEntry) error) error {
+	pageQuery := &DiaryEntryPageQuery{Sort: DiaryEntriesSortAscending, Limit: exportDiaryEntriesPageSize}
+
+	for {
+		diaryEntryPage, pageErr := userDataExportService.diaryEntryService.GetUserEntriesPage(userId, pageQuery)
+		if pageErr != nil {
+			return pageErr
+		}
+
+		for _, diaryEntry := range diaryEntryPage.Entries {
+			if err := apply(diaryEntry); err != nil {
+				return err
+			}
+		}
+
+		if len(diaryEntryPage.NextCursor) == 0 {
+			return nil
+		}
+
+		pageQuery.Cursor = diaryEntryPage.NextCursor
+	}
+}
+
+// AUX FUNCTIONS
+
+func writeExportJSONFile(zipWriter *zip.Writer, fileName string, value any) error {
+	fileWriter, createErr := createExportFile(zipWriter, fileName)
+	if createErr != nil {
+		return createErr
+	}
+
+	encoder := json.NewEncoder(fileWriter)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+func writeExportDiaryEntryMarkdownFile(zipWriter *zip.Writer, diaryEntry *models.DiaryEntry) error {
+	fileWriter, createErr := createExportFile(zipWriter, getDiaryEntryExportFileName(diaryEntry))
+	if createErr != nil {
+		return createErr
+	}
+
+	_, writeErr := fmt.Fprintf(fileWriter, "---\nid: %d\ntitle: %q\nregistrationDate: %d\n---\n\n# %s\n\n%s\n",
+		diaryEntry.Id,
+		diaryEntry.Title,
+		diaryEntry.Registration.RegistrationDate,
+		diaryEntry.Title,
+		diaryEntry.Content)
+
+	return writeErr
+}
+
+func createExportFile(zipWriter *zip.Writer, fileName string) (io.Writer, error) {
+	return zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     fileName,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+}
+
+// getDiaryEntryExportFileName returns the path of the Markdown file of a diary entry inside the export archive.
+func getDiaryEntryExportFileName(diaryEntry *models.DiaryEntry) string {
+	slug := strings.Trim(exportSlugInvalidCharacters.ReplaceAllString(strings.ToLower(diaryEntry.Title), "-"), "-")
+
+	if len(slug) == 0 {
+		return fmt.Sprintf("%s%d.md", exportDiaryEntriesDirectory, diaryEntry.Id)
+	}
+
+	return fmt.Sprintf("%s%d-%s.md", exportDiaryEntriesDirectory, diaryEntry.Id, slug)
+}
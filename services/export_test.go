@@ -0,0 +1,183 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// Mock implementation for DiaryEntryService
+type mockExportDiaryEntryService struct {
+	DefaultDiaryEntryService
+	Entries     []*models.DiaryEntry
+	PageQueries []DiaryEntryPageQuery
+	Err         error
+}
+
+// GetUserEntriesPage returns one entry per page, so that the export has to follow the cursors.
+func (m *mockExportDiaryEntryService) GetUserEntriesPage(userId uint, pageQuery *DiaryEntryPageQuery) (*DiaryEntryPage, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	entryIndex, _ := strconv.Atoi(pageQuery.Cursor)
+	m.PageQueries = append(m.PageQueries, *pageQuery)
+
+	if entryIndex >= len(m.Entries) {
+		return &DiaryEntryPage{Entries: []*models.DiaryEntry{}}, nil
+	}
+
+	diaryEntryPage := &DiaryEntryPage{Entries: m.Entries[entryIndex : entryIndex+1]}
+
+	if entryIndex+1 < len(m.Entries) {
+		diaryEntryPage.NextCursor = strconv.Itoa(entryIndex + 1)
+	}
+
+	return diaryEntryPage, nil
+}
+
+// Mock implementation for BookActivityRegistrationService
+type mockExportBookRegistrationService struct {
+	BookActivityRegistrationServiceImpl
+	Registrations []*models.BookActivityRegistration
+	Err           error
+}
+
+func (m *mockExportBookRegistrationService) GetUserBookActivityRegistrations(userId uint) ([]*models.BookActivityRegistration, error) {
+	return m.Registrations, m.Err
+}
+
+// Mock implementation for GameActivityRegistrationService
+type mockExportGameRegistrationService struct {
+	GameActivityRegistrationServiceImpl
+	Registrations []*models.GameActivityRegistration
+	Err           error
+}
+
+func (m *mockExportGameRegistrationService) GetUserGameActivityRegistrations(userId uint) ([]*models.GameActivityRegistration, error) {
+	return m.Registrations, m.Err
+}
+
+// Mock implementation for CustomActivityRegistrationService
+type mockExportCustomRegistrationService struct {
+	CustomActivityRegistrationServiceImpl
+	Registrations []*models.CustomActivityRegistration
+	Err           error
+}
+
+func (m *mockExportCustomRegistrationService) GetAllUserCustomActivityRegistrations(userId uint) ([]*models.CustomActivityRegistration, error) {
+	return m.Registrations, m.Err
+}
+
+func TestGetUserDataExport(t *testing.T) {
+	user := &models.User{Id: 1, Email: "user@example.com", UserName: "user"}
+	bookRegistrationServiceMock := &mockExportBookRegistrationService{
+		Registrations: []*models.BookActivityRegistration{{Id: 1, InternetArchiveIdentifier: "book"}},
+	}
+	gameRegistrationServiceMock := &mockExportGameRegistrationService{
+		Registrations: []*models.GameActivityRegistration{{Id: 1, GameName: "sudoku"}},
+	}
+	customRegistrationServiceMock := &mockExportCustomRegistrationService{
+		Registrations: []*models.CustomActivityRegistration{{Id: 1, ActivityType: "walk", Payload: []byte(`{"durationMinutes":30}`)}},
+	}
+	exportService := NewUserDataExportServiceImpl(nil, bookRegistrationServiceMock, gameRegistrationServiceMock, customRegistrationServiceMock)
+
+	userDataExport, err := exportService.GetUserDataExport(user)
+	assert.NoError(t, err)
+	assert.Equal(t, user, userDataExport.User)
+	assert.Equal(t, bookRegistrationServiceMock.Registrations, userDataExport.BookRegistrations)
+	assert.Equal(t, gameRegistrationServiceMock.Registrations, userDataExport.GameRegistrations)
+	assert.Equal(t, customRegistrationServiceMock.Registrations, userDataExport.CustomRegistrations)
+
+	gameRegistrationServiceMock.Err = errors.New("forced game registrations error")
+	_, err = exportService.GetUserDataExport(user)
+	assert.EqualError(t, err, "forced game registrations error")
+
+	gameRegistrationServiceMock.Err = nil
+	customRegistrationServiceMock.Err = errors.New("forced custom registrations error")
+	_, err = exportService.GetUserDataExport(user)
+	assert.EqualError(t, err, "forced custom registrations error")
+}
+
+func TestWriteUserDataExport(t *testing.T) {
+	diaryEntryServiceMock := &mockExportDiaryEntryService{
+		Entries: []*models.DiaryEntry{
+			{Id: 7, Title: "My Trip, Day 1!", Content: "We went to the lake."},
+			{Id: 8, Title: "???", Content: "No title."},
+		},
+	}
+	exportService := NewUserDataExportServiceImpl(diaryEntryServiceMock, nil, nil, nil)
+	userDataExport := &UserDataExport{
+		User:              &models.User{Id: 1, Email: "user@example.com", UserName: "user"},
+		BookRegistrations: []*models.BookActivityRegistration{{Id: 1, InternetArchiveIdentifier: "book"}},
+		GameRegistrations: []*models.GameActivityRegistration{},
+		CustomRegistrations: []*models.CustomActivityRegistration{
+			{Id: 1, ActivityType: "walk", Payload: []byte(`{"durationMinutes":30}`)},
+		},
+	}
+
+	var archive bytes.Buffer
+	err := exportService.WriteUserDataExport(userDataExport, &archive)
+	assert.NoError(t, err)
+
+	zipReader, readErr := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	assert.NoError(t, readErr)
+
+	files := make(map[string]string)
+	for _, file := range zipReader.File {
+		fileReader, openErr := file.Open()
+		assert.NoError(t, openErr)
+		content, _ := io.ReadAll(fileReader)
+		fileReader.Close()
+		files[file.Name] = string(content)
+	}
+
+	assert.Len(t, files, 7)
+	assert.Contains(t, files, "profile.json")
+	assert.Contains(t, files, "game_registrations.json")
+	assert.Contains(t, files["entries/7-my-trip-day-1.md"], "We went to the lake.")
+	assert.Contains(t, files["entries/8.md"], "No title.")
+
+	var exportedEntries []*models.DiaryEntry
+	assert.NoError(t, json.Unmarshal([]byte(files["diary_entries.json"]), &exportedEntries))
+	assert.Equal(t, diaryEntryServiceMock.Entries, exportedEntries)
+
+	// entries are read oldest first, one page at a time
+	for _, pageQuery := range diaryEntryServiceMock.PageQueries {
+		assert.Equal(t, DiaryEntriesSortAscending, pageQuery.Sort)
+		assert.Equal(t, exportDiaryEntriesPageSize, pageQuery.Limit)
+	}
+	assert.Equal(t, "1", diaryEntryServiceMock.PageQueries[1].Cursor)
+
+	// Test users without entries export an empty array
+	diaryEntryServiceMock.Entries = nil
+	archive.Reset()
+	assert.NoError(t, exportService.WriteUserDataExport(userDataExport, &archive))
+	zipReader, readErr = zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	assert.NoError(t, readErr)
+
+	for _, file := range zipReader.File {
+		if file.Name == "diary_entries.json" {
+			fileReader, _ := file.Open()
+			content, _ := io.ReadAll(fileReader)
+			fileReader.Close()
+			assert.JSONEq(t, `[]`, string(content))
+		}
+	}
+
+	// Test errors while reading the entries are returned
+	diaryEntryServiceMock.Err = errors.New("forced diary entries error")
+	assert.EqualError(t, exportService.WriteUserDataExport(userDataExport, io.Discard), "forced diary entries error")
+
+	var exportedCustomRegistrations []*models.CustomActivityRegistration
+	assert.NoError(t, json.Unmarshal([]byte(files["custom_registrations.json"]), &exportedCustomRegistrations))
+	assert.Len(t, exportedCustomRegistrations, 1)
+	assert.JSONEq(t, `{"durationMinutes":30}`, string(exportedCustomRegistrations[0].Payload))
+}
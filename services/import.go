@@ -0,0 +1,256 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	ImportFormatDayOne   = "dayone"
+	ImportFormatMarkdown = "markdown"
+	ImportFormatCSV      = "csv"
+
+	// MaxImportSize is the maximum size of the imported files.
+	MaxImportSize = 50 << 20
+	// maxImportItemErrors is the number of item errors kept in the job, the rest are only counted.
+	maxImportItemErrors = 500
+	// importJobProgressInterval is the number of items imported between saves of the job progress.
+	importJobProgressInterval = 50
+)
+
+var (
+	ErrUnsupportedImportFormat = errors.New("the import format must be one of dayone, markdown or csv")
+	ErrImportTooLarge          = errors.New("the imported file exceeds the maximum size of 50 MiB")
+)
+
+// importItem is a diary entry read from an imported file. Err is set when the item cannot be imported.
+type importItem struct {
+	Reference   string
+	Title       string
+	Content     string
+	PublishDate int64
+	Err         error
+}
+
+var importJobStorage storage.ImportJobStorageInterface = &storage.ImportJobStorage{}
+var importBodyValidator = validator.New()
+
+// ImportService defines all operations for the diary entry import service.
+type ImportService interface {
+	StartImport(user *models.User, format string, content io.Reader, auditMetadata *AuditMetadata) (*models.ImportJob, error)
+	GetImportJob(userId uint, importJobId uint) (*models.ImportJob, error)
+}
+
+// ImportServiceImpl is the concrete implementation of ImportService.
+type ImportServiceImpl struct {
+	diaryEntryService DiaryEntryService
+}
+
+// NewImportServiceImpl creates a new ImportServiceImpl.
+func NewImportServiceImpl(diaryEntryService DiaryEntryService) *ImportServiceImpl {
+	return &ImportServiceImpl{diaryEntryService: diaryEntryService}
+}
+
+// StartImport creates an import job for the file, and imports its diary entries in the background.
+// The progress of the job is read with GetImportJob.
+func (importService *ImportServiceImpl) StartImport(user *models.User, format string, content io.Reader, auditMetadata *AuditMetadata) (*models.ImportJob, error) {
+	if format != ImportFormatDayOne && format != ImportFormatMarkdown && format != ImportFormatCSV {
+		return nil, ErrUnsupportedImportFormat
+	}
+
+	importContent, readErr := io.ReadAll(io.LimitReader(content, MaxImportSize+1))
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if len(importContent) > MaxImportSize {
+		return nil, ErrImportTooLarge
+	}
+
+	importJob := &models.ImportJob{
+		UserRefer: user.Id,
+		Format:    format,
+		Status:    models.ImportJobPending,
+		Errors:    []models.ImportItemError{},
+		CreatedAt: time.Now().Unix(),
+	}
+
+	if err := importJobStorage.Create(importJob); err != nil {
+		return nil, err
+	}
+
+	go importService.runImportJob(importJob, GetUserLocation(user), importContent, auditMetadata)
+
+	return importJob, nil
+}
+
+func (importService *ImportServiceImpl) GetImportJob(userId uint, importJobId uint) (*models.ImportJob, error) {
+	importJob, err := importJobStorage.Get(importJobId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the jobs of other users are not disclosed
+	if importJob.(*models.ImportJob).UserRefer != userId {
+		return nil, &models.DbNotFoundError{DbItem: &models.ImportJob{}}
+	}
+
+	return importJob.(*models.ImportJob), nil
+}
+
+// FailInterruptedImportJobs marks the import jobs left unfinished by a previous run of the server as failed.
+func FailInterruptedImportJobs() {
+	failedJobs, err := importJobStorage.FailUnfinished("the import was interrupted, import the file again", time.Now().Unix())
+
+	if err != nil {
+		servicesLogger.ErrorLogger.Printf("error when failing interrupted import jobs: %s", err.Error())
+	} else if failedJobs > 0 {
+		servicesLogger.InfoLogger.Printf("Marked %d interrupted import jobs as failed\n", failedJobs)
+	}
+}
+
+// runImportJob imports the diary entries of the file, saving the job progress periodically.
+// Dates without time zone are read in the given location. A panic while importing fails the job.
+func (importService *ImportServiceImpl) runImportJob(importJob *models.ImportJob, location *time.Location, content []byte, auditMetadata *AuditMetadata) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			servicesLogger.ErrorLogger.Printf("panic when running import job %d: %v", importJob.Id, recovered)
+			importJob.Status = models.ImportJobFailed
+			importJob.Error = "the import failed unexpectedly"
+			importJob.FinishedAt = time.Now().Unix()
+			importService.saveImportJob(importJob)
+		}
+	}()
+
+	importJob.Status = models.ImportJobRunning
+	importService.saveImportJob(importJob)
+
+	items, parseErr := parseImportItems(importJob.Format, content, location)
+
+	if parseErr != nil {
+		importJob.Status = models.ImportJobFailed
+		importJob.Error = parseErr.Error()
+		importJob.FinishedAt = time.Now().Unix()
+		importService.saveImportJob(importJob)
+		return
+	}
+
+	importJob.TotalItems = len(items)
+
+	for i, item := range items {
+		imported, importErr := importService.importDiaryEntry(importJob.UserRefer, item, auditMetadata)
+
+		switch {
+		case importErr != nil:
+			importJob.FailedItems++
+
+			if len(importJob.Errors) < maxImportItemErrors {
+				importJob.Errors = append(importJob.Errors, models.ImportItemError{Item: i + 1, Reference: item.Reference, Error: importErr.Error()})
+			}
+		case imported:
+			importJob.ImportedItems++
+		default:
+			importJob.SkippedItems++
+		}
+
+		if (i+1)%importJobProgressInterval == 0 {
+			importService.saveImportJob(importJob)
+		}
+	}
+
+	importJob.Status = models.ImportJobCompleted
+	importJob.FinishedAt = time.Now().Unix()
+	importService.saveImportJob(importJob)
+}
+
+// importDiaryEntry saves the item as a diary entry of the user, unless it was already imported.
+// Returns whether the entry was saved.
+func (importService *ImportServiceImpl) importDiaryEntry(userId uint, item *importItem, auditMetadata *AuditMetadata) (bool, error) {
+	if item.Err != nil {
+		return false, item.Err
+	}
+
+	if len(item.Content) == 0 {
+		return false, errors.New("the entry has no content")
+	}
+
+	entryBody := &SaveDiaryEntryBody{
+		Title:       item.Title,
+		Content:     item.Content,
+		PublishDate: item.PublishDate,
+	}
+
+	if validationErr := importBodyValidator.Struct(entryBody); validationErr != nil {
+		return false, translateImportValidationError(validationErr)
+	}
+
+	fingerprint := getImportFingerprint(item)
+	alreadyImported, err := importJobStorage.IsDiaryEntryImported(userId, fingerprint)
+
+	if err != nil || alreadyImported {
+		return false, err
+	}
+
+	savedEntry, saveErr := importService.diaryEntryService.SaveDiaryEntry(userId, entryBody, auditMetadata)
+
+	if saveErr != nil {
+		return false, saveErr
+	}
+
+	return true, importJobStorage.SaveDiaryEntryImport(userId, fingerprint, savedEntry.Id)
+}
+
+func (importService *ImportServiceImpl) saveImportJob(importJob *models.ImportJob) {
+	if err := importJobStorage.Update(importJob); err != nil {
+		servicesLogger.ErrorLogger.Printf("error when saving import job %d: %s", importJob.Id, err.Error())
+	}
+}
+
+// AUX FUNCTIONS
+
+// getImportFingerprint identifies an imported diary entry by its publish date, title and content.
+func getImportFingerprint(item *importItem) string {
+	fingerprint := sha256.New()
+	fingerprint.Write([]byte(strconv.FormatInt(item.PublishDate, 10)))
+	fingerprint.Write([]byte{0})
+	fingerprint.Write([]byte(item.Title))
+	fingerprint.Write([]byte{0})
+	fingerprint.Write([]byte(item.Content))
+
+	return hex.EncodeToString(fingerprint.Sum(nil))
+}
+
+// translateImportValidationError describes the first field of an imported entry that did not pass validation.
+func translateImportValidationError(err error) error {
+	if validationErrs, ok := err.(validator.ValidationErrors); ok && len(validationErrs) > 0 {
+		return fmt.Errorf("the entry field %s is not valid", validationErrs[0].Field())
+	}
+
+	return err
+}
+
+// parseImportItems reads the diary entries of an imported file in the given format.
+func parseImportItems(format string, content []byte, location *time.Location) ([]*importItem, error) {
+	switch format {
+	case ImportFormatDayOne:
+		return parseDayOneImport(content)
+	case ImportFormatMarkdown:
+		return parseMarkdownImport(content, location)
+	case ImportFormatCSV:
+		return parseCSVImport(bytes.NewReader(content), location)
+	default:
+		return nil, fmt.Errorf("unsupported import format %s", format)
+	}
+}
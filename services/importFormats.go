@@ -0,0 +1,384 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxImportTitleLength is the length of the titles taken from the first line of entries without title.
+	maxImportTitleLength = 80
+	// maxImportArchiveFiles is the maximum number of files of the imported zip archives.
+	maxImportArchiveFiles = 10000
+)
+
+// importDateLayouts are the accepted date formats without time zone, read in the time zone of the user.
+var importDateLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", time.DateOnly}
+
+var (
+	errImportDateMissing  = errors.New("the entry has no date")
+	errImportFileTooLarge = errors.New("the zip archive decompresses beyond the maximum import size")
+	errImportTooManyFiles = fmt.Errorf("the zip archive has more than %d files", maxImportArchiveFiles)
+)
+
+// dayOneExport is the JSON export of Day One. It is also found inside the zip archives exported by the app.
+type dayOneExport struct {
+	Entries []struct {
+		UUID         string `json:"uuid"`
+		CreationDate string `json:"creationDate"`
+		Text         string `json:"text"`
+	} `json:"entries"`
+}
+
+// parseDayOneImport reads the entries of a Day One JSON export, or of the first JSON file of a zip archive.
+// Entries have no title, so the first line of the text is used.
+func parseDayOneImport(content []byte) ([]*importItem, error) {
+	if isZipArchive(content) {
+		jsonContent, err := readFirstZipFile(content, ".json")
+
+		if err != nil {
+			return nil, err
+		}
+
+		content = jsonContent
+	}
+
+	export := &dayOneExport{}
+
+	if err := json.Unmarshal(content, export); err != nil {
+		return nil, fmt.Errorf("the file is not a valid Day One JSON export: %s", err.Error())
+	}
+
+	items := make([]*importItem, len(export.Entries))
+
+	for i, entry := range export.Entries {
+		item := &importItem{Reference: entry.UUID}
+		item.Title, item.Content = splitImportText(entry.Text)
+		creationDate, err := time.Parse(time.RFC3339, entry.CreationDate)
+
+		if err != nil {
+			item.Err = fmt.Errorf("the date %q is not valid", entry.CreationDate)
+		}
+
+		item.PublishDate = creationDate.Unix()
+		items[i] = item
+	}
+
+	return items, nil
+}
+
+// parseMarkdownImport reads a zip archive of Markdown files with front matter, one entry per file.
+// The title and date are read from the title and date front matter keys, and the title falls back
+// to the first heading or the file name. The files together cannot decompress beyond the maximum import size.
+func parseMarkdownImport(content []byte, location *time.Location) ([]*importItem, error) {
+	if !isZipArchive(content) {
+		return nil, errors.New("the file is not a zip archive")
+	}
+
+	zipReader, err := openZipArchive(content)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*importItem{}
+	remainingSize := int64(MaxImportSize)
+
+	for _, file := range zipReader.File {
+		if !isImportedZipFile(file, ".md", ".markdown") {
+			continue
+		}
+
+		item := &importItem{Reference: file.Name}
+		fileContent, readErr := readZipFile(file, remainingSize)
+
+		if errors.Is(readErr, errImportFileTooLarge) {
+			return nil, readErr
+		}
+
+		if readErr != nil {
+			item.Err = readErr
+		} else {
+			remainingSize -= int64(len(fileContent))
+			parseMarkdownImportItem(item, string(fileContent), location)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func parseMarkdownImportItem(item *importItem, content string, location *time.Location) {
+	frontMatter, body := splitMarkdownFrontMatter(strings.ReplaceAll(content, "\r\n", "\n"))
+	item.Title = frontMatter["title"]
+	heading, bodyWithoutHeading, _ := strings.Cut(body, "\n")
+
+	// the heading is removed when it is the title, as in the Markdown files of the Analock export
+	if headingTitle, isHeading := strings.CutPrefix(heading, "# "); isHeading && (len(item.Title) == 0 || strings.TrimSpace(headingTitle) == item.Title) {
+		item.Title = strings.TrimSpace(headingTitle)
+		body = bodyWithoutHeading
+	}
+
+	if len(item.Title) == 0 {
+		item.Title = strings.TrimSuffix(path.Base(item.Reference), path.Ext(item.Reference))
+	}
+
+	item.Content = strings.TrimSpace(body)
+	date := getFirstValue(frontMatter, "date", "publishdate", "registrationdate", "created")
+
+	if len(date) == 0 {
+		item.Err = errImportDateMissing
+		return
+	}
+
+	item.PublishDate, item.Err = parseImportDate(date, location)
+}
+
+// parseCSVImport reads a CSV file whose first line names the columns. Content and date columns are required,
+// and the title is taken from the first line of the content when there is no title column.
+func parseCSVImport(content io.Reader, location *time.Location) ([]*importItem, error) {
+	csvReader := csv.NewReader(content)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	header, err := csvReader.Read()
+
+	if err != nil {
+		return nil, fmt.Errorf("the file is not a valid CSV file: %s", err.Error())
+	}
+
+	columns := map[string]int{}
+
+	for i, column := range header {
+		// spreadsheet apps may start the file with a byte order mark
+		columnName := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		columnName = strings.NewReplacer("_", "", " ", "").Replace(columnName)
+		columns[columnName] = i
+	}
+
+	titleColumn := getFirstColumn(columns, "title")
+	contentColumn := getFirstColumn(columns, "content", "body", "text", "entry")
+	dateColumn := getFirstColumn(columns, "date", "publishdate", "createdat", "created")
+
+	if contentColumn < 0 || dateColumn < 0 {
+		return nil, errors.New("the CSV file must have a header line with content and date columns")
+	}
+
+	items := []*importItem{}
+
+	for {
+		record, readErr := csvReader.Read()
+
+		if readErr == io.EOF {
+			break
+		}
+
+		if readErr != nil {
+			return nil, fmt.Errorf("the file is not a valid CSV file: %s", readErr.Error())
+		}
+
+		line, _ := csvReader.FieldPos(0)
+		item := &importItem{Reference: "line " + strconv.Itoa(line)}
+		item.Title, item.Content = splitImportText(getCSVField(record, contentColumn))
+
+		if title := getCSVField(record, titleColumn); len(title) > 0 {
+			item.Title = title
+			item.Content = getCSVField(record, contentColumn)
+		}
+
+		if date := getCSVField(record, dateColumn); len(date) == 0 {
+			item.Err = errImportDateMissing
+		} else {
+			item.PublishDate, item.Err = parseImportDate(date, location)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// AUX FUNCTIONS
+
+// splitImportText returns the title and content of an entry without title. A first line heading is taken as
+// the title and removed from the content, otherwise the title is the start of the first line.
+func splitImportText(text string) (string, string) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	firstLine, rest, _ := strings.Cut(text, "\n")
+
+	if heading, isHeading := strings.CutPrefix(firstLine, "#"); isHeading && len(strings.TrimSpace(rest)) > 0 {
+		return strings.TrimSpace(strings.TrimLeft(heading, "#")), strings.TrimSpace(rest)
+	}
+
+	title := strings.TrimSpace(firstLine)
+
+	if utf8.RuneCountInString(title) > maxImportTitleLength {
+		title = strings.TrimSpace(string([]rune(title)[:maxImportTitleLength-1])) + "…"
+	}
+
+	return title, text
+}
+
+// splitMarkdownFrontMatter returns the lowercase keys and values of the front matter of a Markdown file, and the rest of the file.
+func splitMarkdownFrontMatter(content string) (map[string]string, string) {
+	frontMatter := map[string]string{}
+	rest, hasFrontMatter := strings.CutPrefix(content, "---\n")
+
+	if !hasFrontMatter {
+		return frontMatter, strings.TrimSpace(content)
+	}
+
+	frontMatterContent, body, closed := strings.Cut(rest, "\n---")
+
+	if !closed {
+		return frontMatter, strings.TrimSpace(content)
+	}
+
+	for _, line := range strings.Split(frontMatterContent, "\n") {
+		key, value, isKeyValue := strings.Cut(line, ":")
+
+		if !isKeyValue {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+
+		if unquotedValue, unquoteErr := strconv.Unquote(value); unquoteErr == nil {
+			value = unquotedValue
+		} else if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+			value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+
+		frontMatter[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+
+	// the closing line may be followed by more dashes or spaces
+	_, body, _ = strings.Cut(body, "\n")
+
+	return frontMatter, strings.TrimSpace(body)
+}
+
+// parseImportDate reads a Unix timestamp, an RFC 3339 date or a date without time zone in the given location.
+func parseImportDate(value string, location *time.Location) (int64, error) {
+	value = strings.TrimSpace(value)
+
+	if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return timestamp, nil
+	}
+
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date.Unix(), nil
+	}
+
+	for _, layout := range importDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, location); err == nil {
+			return date.Unix(), nil
+		}
+	}
+
+	return 0, fmt.Errorf("the date %q is not valid", value)
+}
+
+func isZipArchive(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("PK\x03\x04"))
+}
+
+// isImportedZipFile reports whether a file of a zip archive has one of the extensions, skipping directories
+// and the metadata added by macOS.
+func isImportedZipFile(file *zip.File, extensions ...string) bool {
+	if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") || strings.HasPrefix(path.Base(file.Name), ".") {
+		return false
+	}
+
+	return slices.Contains(extensions, strings.ToLower(path.Ext(file.Name)))
+}
+
+func readFirstZipFile(content []byte, extension string) ([]byte, error) {
+	zipReader, err := openZipArchive(content)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range zipReader.File {
+		if isImportedZipFile(file, extension) {
+			return readZipFile(file, MaxImportSize)
+		}
+	}
+
+	return nil, fmt.Errorf("the zip archive has no %s file", extension)
+}
+
+// openZipArchive reads the file list of a zip archive, failing when it has more than the maximum number of files.
+func openZipArchive(content []byte) (*zip.Reader, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+
+	if err != nil {
+		return nil, fmt.Errorf("the file is not a valid zip archive: %s", err.Error())
+	}
+
+	if len(zipReader.File) > maxImportArchiveFiles {
+		return nil, errImportTooManyFiles
+	}
+
+	return zipReader, nil
+}
+
+// readZipFile reads a file of a zip archive, failing when it decompresses beyond the given size.
+func readZipFile(file *zip.File, maxSize int64) ([]byte, error) {
+	fileReader, err := file.Open()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer fileReader.Close()
+
+	fileContent, err := io.ReadAll(io.LimitReader(fileReader, maxSize+1))
+
+	if err == nil && int64(len(fileContent)) > maxSize {
+		return nil, errImportFileTooLarge
+	}
+
+	return fileContent, err
+}
+
+func getFirstValue(values map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value, found := values[key]; found && len(value) > 0 {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// getFirstColumn returns the position of the first of the columns present in the header, or -1.
+func getFirstColumn(columns map[string]int, names ...string) int {
+	for _, name := range names {
+		if column, found := columns[name]; found {
+			return column
+		}
+	}
+
+	return -1
+}
+
+func getCSVField(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[column])
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockImportJobStorage implements ImportJobStorageInterface
+type mockImportJobStorage struct {
+	Jobs         map[uint]*models.ImportJob
+	Fingerprints map[string]uint
+}
+
+func (m *mockImportJobStorage) Get(id uint) (interface{}, error) {
+	importJob, found := m.Jobs[id]
+	if !found {
+		return nil, &models.DbNotFoundError{DbItem: &models.ImportJob{}}
+	}
+	return importJob, nil
+}
+
+func (m *mockImportJobStorage) Create(data interface{}) error {
+	importJob := data.(*models.ImportJob)
+	importJob.Id = uint(len(m.Jobs) + 1)
+	m.Jobs[importJob.Id] = importJob
+	return nil
+}
+
+func (m *mockImportJobStorage) Update(data interface{}) error {
+	importJob := data.(*models.ImportJob)
+	m.Jobs[importJob.Id] = importJob
+	return nil
+}
+
+func (m *mockImportJobStorage) FailUnfinished(errorMessage string, finishedAt int64) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockImportJobStorage) IsDiaryEntryImported(userId uint, fingerprint string) (bool, error) {
+	_, found := m.Fingerprints[fingerprint]
+	return found, nil
+}
+
+func (m *mockImportJobStorage) SaveDiaryEntryImport(userId uint, fingerprint string, diaryEntryId uint) error {
+	m.Fingerprints[fingerprint] = diaryEntryId
+	return nil
+}
+
+func setUpImportMocks(t *testing.T) (*mockImportJobStorage, *mockDiaryEntryStorage) {
+	diaryEntryStorageMock, _ := setUpDiaryEntryRevisionMocks(t)
+	originalImportJobStorage := importJobStorage
+	importJobStorageMock := &mockImportJobStorage{Jobs: make(map[uint]*models.ImportJob), Fingerprints: make(map[string]uint)}
+	importJobStorage = importJobStorageMock
+	t.Cleanup(func() {
+		importJobStorage = originalImportJobStorage
+	})
+
+	return importJobStorageMock, diaryEntryStorageMock
+}
+
+func newTestZip(t *testing.T, files map[string]string) []byte {
+	content := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(content)
+	for fileName, fileContent := range files {
+		fileWriter, err := zipWriter.Create(fileName)
+		assert.NoError(t, err)
+		_, err = fileWriter.Write([]byte(fileContent))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zipWriter.Close())
+	return content.Bytes()
+}
+
+func TestParseDayOneImport(t *testing.T) {
+	dayOneJSON := `{"metadata": {"version": "1.0"}, "entries": [
+		{"uuid": "A1", "creationDate": "2021-03-04T08:30:00Z", "text": "# Morning walk\n\nIt was sunny."},
+		{"uuid": "B2", "creationDate": "2021-03-05T21:00:00Z", "text": "Just one line"},
+		{"uuid": "C3", "creationDate": "yesterday", "text": "Bad date"}
+	]}`
+
+	items, err := parseDayOneImport([]byte(dayOneJSON))
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+	assert.Equal(t, "Morning walk", items[0].Title)
+	assert.Equal(t, "It was sunny.", items[0].Content)
+	assert.Equal(t, time.Date(2021, 3, 4, 8, 30, 0, 0, time.UTC).Unix(), items[0].PublishDate)
+	assert.Equal(t, "Just one line", items[1].Title)
+	assert.Equal(t, "Just one line", items[1].Content)
+	assert.Equal(t, "C3", items[2].Reference)
+	assert.Error(t, items[2].Err)
+
+	// Test the zip archives exported by Day One
+	items, err = parseDayOneImport(newTestZip(t, map[string]string{"Journal.json": dayOneJSON}))
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+
+	_, err = parseDayOneImport([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestParseMarkdownImport(t *testing.T) {
+	madridLocation, _ := time.LoadLocation("Europe/Madrid")
+	archive := newTestZip(t, map[string]string{
+		"entries/1-trip.md":           "---\nid: 1\ntitle: \"Trip\"\nregistrationDate: 1600000000\n---\n\n# Trip\n\nWe went to the coast.\n",
+		"entries/notes.markdown":      "---\ndate: 2022-01-02 10:00\n---\nNo title here",
+		"entries/missing-date.md":     "# Heading\n\nContent",
+		"__MACOSX/entries/._notes.md": "ignored",
+		"entries/image.png":           "ignored",
+	})
+
+	items, err := parseMarkdownImport(archive, madridLocation)
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+
+	itemsByReference := map[string]*importItem{}
+	for _, item := range items {
+		itemsByReference[item.Reference] = item
+	}
+
+	// Test the Markdown files of the Analock export
+	assert.Equal(t, "Trip", itemsByReference["entries/1-trip.md"].Title)
+	assert.Equal(t, "We went to the coast.", itemsByReference["entries/1-trip.md"].Content)
+	assert.Equal(t, int64(1600000000), itemsByReference["entries/1-trip.md"].PublishDate)
+
+	// Test dates without time zone are read in the location, and titles fall back to the file name
+	assert.Equal(t, "notes", itemsByReference["entries/notes.markdown"].Title)
+	assert.Equal(t, time.Date(2022, 1, 2, 10, 0, 0, 0, madridLocation).Unix(), itemsByReference["entries/notes.markdown"].PublishDate)
+	assert.NoError(t, itemsByReference["entries/notes.markdown"].Err)
+
+	assert.Equal(t, "Heading", itemsByReference["entries/missing-date.md"].Title)
+	assert.ErrorIs(t, itemsByReference["entries/missing-date.md"].Err, errImportDateMissing)
+
+	_, err = parseMarkdownImport([]byte("# not a zip"), time.UTC)
+	assert.Error(t, err)
+
+	// Test archives whose files together decompress beyond the maximum import size are rejected
+	largeContent := strings.Repeat("a", MaxImportSize/2+1)
+	_, err = parseMarkdownImport(newTestZip(t, map[string]string{"first.md": largeContent, "second.md": largeContent}), time.UTC)
+	assert.ErrorIs(t, err, errImportFileTooLarge)
+
+	// Test archives with too many files are rejected
+	manyFiles := map[string]string{}
+	for i := 0; i <= maxImportArchiveFiles; i++ {
+		manyFiles[fmt.Sprintf("%d.md", i)] = ""
+	}
+	_, err = parseMarkdownImport(newTestZip(t, manyFiles), time.UTC)
+	assert.ErrorIs(t, err, errImportTooManyFiles)
+}
+
+func TestParseCSVImport(t *testing.T) {
+	csvContent := "\ufeffTitle,Body,Created At\n" +
+		"First,\"Line one\nLine two\",2020-05-06\n" +
+		",Untitled entry,2020-05-07T10:00:00+02:00\n" +
+		"Missing date,Content,\n"
+
+	items, err := parseCSVImport(strings.NewReader(csvContent), time.UTC)
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+	assert.Equal(t, "First", items[0].Title)
+	assert.Equal(t, "Line one\nLine two", items[0].Content)
+	assert.Equal(t, time.Date(2020, 5, 6, 0, 0, 0, 0, time.UTC).Unix(), items[0].PublishDate)
+	assert.Equal(t, "Untitled entry", items[1].Title)
+	assert.Equal(t, time.Date(2020, 5, 7, 8, 0, 0, 0, time.UTC).Unix(), items[1].PublishDate)
+	assert.Equal(t, "line 5", items[2].Reference)
+	assert.ErrorIs(t, items[2].Err, errImportDateMissing)
+
+	_, err = parseCSVImport(strings.NewReader("title,content\nA,B\n"), time.UTC)
+	assert.Error(t, err)
+}
+
+func TestRunImportJob(t *testing.T) {
+	importJobStorageMock, diaryEntryStorageMock := setUpImportMocks(t)
+	importService := NewImportServiceImpl(diaryEntryService)
+	csvContent := []byte("title,content,date\nFirst,Content,2020-05-06\nSecond,,2020-05-07\nThird,Content,not a date\n")
+
+	importJob := &models.ImportJob{UserRefer: 1, Format: ImportFormatCSV, Status: models.ImportJobPending}
+	assert.NoError(t, importJobStorageMock.Create(importJob))
+	importService.runImportJob(importJob, time.UTC, csvContent, nil)
+
+	assert.Equal(t, models.ImportJobCompleted, importJob.Status)
+	assert.Equal(t, 3, importJob.TotalItems)
+	assert.Equal(t, 1, importJob.ImportedItems)
+	assert.Equal(t, 2, importJob.FailedItems)
+	assert.Len(t, importJob.Errors, 2)
+	assert.Equal(t, 2, importJob.Errors[0].Item)
+	assert.Equal(t, "line 3", importJob.Errors[0].Reference)
+	assert.NotZero(t, importJob.FinishedAt)
+	assert.Len(t, diaryEntryStorageMock.Entries, 1)
+
+	// Test importing the same file again skips the imported entries
+	importJob = &models.ImportJob{UserRefer: 1, Format: ImportFormatCSV, Status: models.ImportJobPending}
+	assert.NoError(t, importJobStorageMock.Create(importJob))
+	importService.runImportJob(importJob, time.UTC, csvContent, nil)
+
+	assert.Equal(t, 0, importJob.ImportedItems)
+	assert.Equal(t, 1, importJob.SkippedItems)
+	assert.Len(t, diaryEntryStorageMock.Entries, 1)
+
+	// Test files that cannot be read fail the job
+	importJob = &models.ImportJob{UserRefer: 1, Format: ImportFormatDayOne, Status: models.ImportJobPending}
+	assert.NoError(t, importJobStorageMock.Create(importJob))
+	importService.runImportJob(importJob, time.UTC, []byte("not json"), nil)
+
+	assert.Equal(t, models.ImportJobFailed, importJob.Status)
+	assert.NotEmpty(t, importJob.Error)
+
+	// Test a panic while importing fails the job
+	importJob = &models.ImportJob{UserRefer: 1, Format: ImportFormatCSV, Status: models.ImportJobPending}
+	assert.NoError(t, importJobStorageMock.Create(importJob))
+	NewImportServiceImpl(nil).runImportJob(importJob, time.UTC, []byte("title,content,date\nNew,Content,2021-01-01\n"), nil)
+
+	assert.Equal(t, models.ImportJobFailed, importJob.Status)
+	assert.NotEmpty(t, importJob.Error)
+	assert.Equal(t, importJob, importJobStorageMock.Jobs[importJob.Id])
+}
+
+func TestImportDiaryEntryValidatesEntries(t *testing.T) {
+	importJobStorageMock, diaryEntryStorageMock := setUpImportMocks(t)
+	importService := NewImportServiceImpl(diaryEntryService)
+
+	// Test entries without title or date are not saved
+	imported, err := importService.importDiaryEntry(1, &importItem{Reference: "line 2", Content: "Content", PublishDate: 1600000000}, nil)
+	assert.False(t, imported)
+	assert.EqualError(t, err, "the entry field Title is not valid")
+
+	imported, err = importService.importDiaryEntry(1, &importItem{Reference: "line 3", Title: "Title", Content: "Content"}, nil)
+	assert.False(t, imported)
+	assert.EqualError(t, err, "the entry field PublishDate is not valid")
+
+	assert.Empty(t, diaryEntryStorageMock.Entries)
+	assert.Empty(t, importJobStorageMock.Fingerprints)
+
+	imported, err = importService.importDiaryEntry(1, &importItem{Reference: "line 4", Title: "Title", Content: "Content", PublishDate: 1600000000}, nil)
+	assert.True(t, imported)
+	assert.NoError(t, err)
+	assert.Len(t, diaryEntryStorageMock.Entries, 1)
+}
+
+func TestStartImport(t *testing.T) {
+	importJobStorageMock, _ := setUpImportMocks(t)
+	importService := NewImportServiceImpl(diaryEntryService)
+	importJobStorageMock.Jobs[1] = &models.ImportJob{Id: 1, UserRefer: 1, Status: models.ImportJobCompleted}
+
+	_, err := importService.StartImport(&models.User{Id: 1}, "evernote", strings.NewReader("content"), nil)
+	assert.ErrorIs(t, err, ErrUnsupportedImportFormat)
+
+	_, err = importService.StartImport(&models.User{Id: 1}, ImportFormatCSV, bytes.NewReader(make([]byte, MaxImportSize+1)), nil)
+	assert.ErrorIs(t, err, ErrImportTooLarge)
+
+	// Test the jobs of other users are not found
+	importJob, err := importService.GetImportJob(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ImportJobCompleted, importJob.Status)
+	_, err = importService.GetImportJob(2, 1)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
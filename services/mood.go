@@ -0,0 +1,332 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	MoodPeriodDay   = "day"
+	MoodPeriodWeek  = "week"
+	MoodPeriodMonth = "month"
+
+	maxMoodPeriods = 400
+)
+
+// ErrInvalidMoodPeriod is returned when the aggregation period is not a day, a week or a month.
+var ErrInvalidMoodPeriod = errors.New("the period must be one of day, week or month")
+
+// ErrInvalidMoodDateRange is returned when the date range is reversed or spans too many periods.
+var ErrInvalidMoodDateRange = errors.New("the date range must start before it ends and span at most 400 periods")
+
+// MoodQuery holds the date range and the period of the mood aggregates.
+type MoodQuery struct {
+	StartDate int64
+	EndDate   int64
+	Period    string
+}
+
+// MoodPeriodSummary aggregates the moods and feelings of the diary entries of a period.
+// The mood statistics are nil when no entry of the period has a mood.
+type MoodPeriodSummary struct {
+	PeriodStart int64          `json:"periodStart"`
+	EntryCount  int            `json:"entryCount"`
+	MoodCount   int            `json:"moodCount"`
+	AverageMood *float64       `json:"averageMood"`
+	MinMood     *int           `json:"minMood"`
+	MaxMood     *int           `json:"maxMood"`
+	Feelings    map[string]int `json:"feelings"`
+}
+
+type MoodSummary struct {
+	Period  string               `json:"period"`
+	Periods []*MoodPeriodSummary `json:"periods"`
+}
+
+// MoodActivityPeriod holds the average mood and the activity counts of a period.
+type MoodActivityPeriod struct {
+	PeriodStart       int64    `json:"periodStart"`
+	AverageMood       *float64 `json:"averageMood"`
+	BookActivityCount int      `json:"bookActivityCount"`
+	GameActivityCount int      `json:"gameActivityCount"`
+}
+
+// MoodActivityCorrelation holds the Pearson correlation between the average mood and the activity counts,
+// computed over the periods having a mood. A correlation is nil when there is not enough data to compute it.
+type MoodActivityCorrelation struct {
+	Period                  string                `json:"period"`
+	Periods                 []*MoodActivityPeriod `json:"periods"`
+	BookActivityCorrelation *float64              `json:"bookActivityCorrelation"`
+	GameActivityCorrelation *float64              `json:"gameActivityCorrelation"`
+}
+
+// MoodService defines all operations for the mood service.
+type MoodService interface {
+	GetMoodSummary(user *models.User, moodQuery *MoodQuery) (*MoodSummary, error)
+	GetMoodActivityCorrelation(user *models.User, moodQuery *MoodQuery) (*MoodActivityCorrelation, error)
+}
+
+// MoodServiceImpl is the concrete implementation of MoodService.
+type MoodServiceImpl struct {
+	diaryEntryService       DiaryEntryService
+	bookRegistrationService BookActivityRegistrationService
+	gameRegistrationService GameActivityRegistrationService
+}
+
+// NewMoodServiceImpl creates a new MoodServiceImpl.
+func NewMoodServiceImpl(
+	diaryEntryService DiaryEntryService,
+	bookRegistrationService BookActivityRegistrationService,
+	gameRegistrationService GameActivityRegistrationService,
+) *MoodServiceImpl {
+	return &MoodServiceImpl{
+		diaryEntryService:       diaryEntryService,
+		bookRegistrationService: bookRegistrationService,
+		gameRegistrationService: gameRegistrationService,
+	}
+}
+
+// GetMoodSummary aggregates the moods and feelings of the user diary entries for every period of the date range.
+// Periods start at midnight in the time zone of the user, weeks on Monday.
+func (moodService *MoodServiceImpl) GetMoodSummary(user *models.User, moodQuery *MoodQuery) (*MoodSummary, error) {
+	periodStarts, periodsErr := getMoodPeriodStarts(moodQuery, GetUserLocation(user))
+
+	if periodsErr != nil {
+		return nil, periodsErr
+	}
+
+	diaryEntries, err := moodService.diaryEntryService.GetUserEntriesTimeRange(user.Id, moodQuery.StartDate, moodQuery.EndDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	periodSummaries := make([]*MoodPeriodSummary, len(periodStarts))
+	periodMoods := make([][]int, len(periodStarts))
+
+	for i, periodStart := range periodStarts {
+		periodSummaries[i] = &MoodPeriodSummary{PeriodStart: periodStart, Feelings: map[string]int{}}
+	}
+
+	for _, diaryEntry := range diaryEntries {
+		periodIndex := getMoodPeriodIndex(periodStarts, diaryEntry.Registration.RegistrationDate)
+
+		if periodIndex < 0 {
+			continue
+		}
+
+		periodSummary := periodSummaries[periodIndex]
+		periodSummary.EntryCount++
+
+		for _, feeling := range diaryEntry.Feelings {
+			periodSummary.Feelings[feeling]++
+		}
+
+		if diaryEntry.Mood != nil {
+			periodMoods[periodIndex] = append(periodMoods[periodIndex], *diaryEntry.Mood)
+		}
+	}
+
+	for i, moods := range periodMoods {
+		if len(moods) == 0 {
+			continue
+		}
+
+		minMood := slices.Min(moods)
+		maxMood := slices.Max(moods)
+		averageMood := getAverageMood(moods)
+
+		periodSummaries[i].MoodCount = len(moods)
+		periodSummaries[i].AverageMood = &averageMood
+		periodSummaries[i].MinMood = &minMood
+		periodSummaries[i].MaxMood = &maxMood
+	}
+
+	return &MoodSummary{Period: moodQuery.Period, Periods: periodSummaries}, nil
+}
+
+// GetMoodActivityCorrelation compares the average mood of the user with the number of book and game activities
+// registered for every period of the date range.
+func (moodService *MoodServiceImpl) GetMoodActivityCorrelation(user *models.User, moodQuery *MoodQuery) (*MoodActivityCorrelation, error) {
+	moodSummary, summaryErr := moodService.GetMoodSummary(user, moodQuery)
+
+	if summaryErr != nil {
+		return nil, summaryErr
+	}
+
+	bookRegistrations, bookErr := moodService.bookRegistrationService.GetUserBookActivityRegistrationsTimeRange(user.Id, moodQuery.StartDate, moodQuery.EndDate)
+
+	if bookErr != nil {
+		return nil, bookErr
+	}
+
+	gameRegistrations, gameErr := moodService.gameRegistrationService.GetUserGameActivityRegistrationsTimeRange(user.Id, moodQuery.StartDate, moodQuery.EndDate)
+
+	if gameErr != nil {
+		return nil, gameErr
+	}
+
+	periodStarts := make([]int64, len(moodSummary.Periods))
+	activityPeriods := make([]*MoodActivityPeriod, len(moodSummary.Periods))
+
+	for i, periodSummary := range moodSummary.Periods {
+		periodStarts[i] = periodSummary.PeriodStart
+		activityPeriods[i] = &MoodActivityPeriod{PeriodStart: periodSummary.PeriodStart, AverageMood: periodSummary.AverageMood}
+	}
+
+	for _, bookRegistration := range bookRegistrations {
+		if periodIndex := getMoodPeriodIndex(periodStarts, bookRegistration.Registration.RegistrationDate); periodIndex >= 0 {
+			activityPeriods[periodIndex].BookActivityCount++
+		}
+	}
+
+	for _, gameRegistration := range gameRegistrations {
+		if periodIndex := getMoodPeriodIndex(periodStarts, gameRegistration.Registration.RegistrationDate); periodIndex >= 0 {
+			activityPeriods[periodIndex].GameActivityCount++
+		}
+	}
+
+	averageMoods := make([]float64, 0, len(activityPeriods))
+	bookActivityCounts := make([]float64, 0, len(activityPeriods))
+	gameActivityCounts := make([]float64, 0, len(activityPeriods))
+
+	for _, activityPeriod := range activityPeriods {
+		if activityPeriod.AverageMood == nil {
+			continue
+		}
+
+		averageMoods = append(averageMoods, *activityPeriod.AverageMood)
+		bookActivityCounts = append(bookActivityCounts, float64(activityPeriod.BookActivityCount))
+		gameActivityCounts = append(gameActivityCounts, float64(activityPeriod.GameActivityCount))
+	}
+
+	return &MoodActivityCorrelation{
+		Period:                  moodQuery.Period,
+		Periods:                 activityPeriods,
+		BookActivityCorrelation: getPearsonCorrelation(averageMoods, bookActivityCounts),
+		GameActivityCorrelation: getPearsonCorrelation(averageMoods, gameActivityCounts),
+	}, nil
+}
+
+// getMoodPeriodStarts returns the start timestamps of the periods covering the query date range, in order.
+func getMoodPeriodStarts(moodQuery *MoodQuery, location *time.Location) ([]int64, error) {
+	if moodQuery.Period != MoodPeriodDay && moodQuery.Period != MoodPeriodWeek && moodQuery.Period != MoodPeriodMonth {
+		return nil, ErrInvalidMoodPeriod
+	}
+
+	if moodQuery.EndDate < moodQuery.StartDate {
+		return nil, ErrInvalidMoodDateRange
+	}
+
+	startDate := time.Unix(moodQuery.StartDate, 0).In(location)
+	periodStart := getDayStart(startDate)
+
+	switch moodQuery.Period {
+	case MoodPeriodWeek:
+		periodStart = periodStart.AddDate(0, 0, -(int(periodStart.Weekday())+6)%7)
+	case MoodPeriodMonth:
+		periodStart = periodStart.AddDate(0, 0, 1-periodStart.Day())
+	}
+
+	periodStarts := make([]int64, 0)
+
+	for periodStart.Unix() <= moodQuery.EndDate {
+		if len(periodStarts) == maxMoodPeriods {
+			return nil, ErrInvalidMoodDateRange
+		}
+
+		periodStarts = append(periodStarts, periodStart.Unix())
+
+		switch moodQuery.Period {
+		case MoodPeriodDay:
+			periodStart = periodStart.AddDate(0, 0, 1)
+		case MoodPeriodWeek:
+			periodStart = periodStart.AddDate(0, 0, 7)
+		case MoodPeriodMonth:
+			periodStart = periodStart.AddDate(0, 1, 0)
+		}
+	}
+
+	return periodStarts, nil
+}
+
+// getMoodPeriodIndex returns the index of the period containing the date, or -1 if it is before the first period.
+func getMoodPeriodIndex(periodStarts []int64, date int64) int {
+	periodIndex, found := slices.BinarySearch(periodStarts, date)
+
+	if found {
+		return periodIndex
+	}
+
+	return periodIndex - 1
+}
+
+func getAverageMood(moods []int) float64 {
+	moodSum := 0
+
+	for _, mood := range moods {
+		moodSum += mood
+	}
+
+	return float64(moodSum) / float64(len(moods))
+}
+
+// getPearsonCorrelation returns the correlation coefficient of two samples,
+// or nil when there are less than two values or one of the samples is constant.
+func getPearsonCorrelation(xs []float64, ys []float64) *float64 {
+	if len(xs) < 2 {
+		return nil
+	}
+
+	var xSum, ySum float64
+
+	for i := range xs {
+		xSum += xs[i]
+		ySum += ys[i]
+	}
+
+	xMean := xSum / float64(len(xs))
+	yMean := ySum / float64(len(ys))
+
+	var covariance, xVariance, yVariance float64
+
+	for i := range xs {
+		covariance += (xs[i] - xMean) * (ys[i] - yMean)
+		xVariance += (xs[i] - xMean) * (xs[i] - xMean)
+		yVariance += (ys[i] - yMean) * (ys[i] - yMean)
+	}
+
+	if xVariance == 0 || yVariance == 0 {
+		return nil
+	}
+
+	correlation := covariance / math.Sqrt(xVariance*yVariance)
+
+	return &correlation
+}
+
+// normalizeMood returns nil for a missing or zero mood score.
+func normalizeMood(mood *int) *int {
+	if mood == nil || *mood == 0 {
+		return nil
+	}
+
+	return mood
+}
+
+// normalizeFeelings returns a sorted copy of the feelings, which are already validated to be unique.
+func normalizeFeelings(feelings []string) []string {
+	normalizedFeelings := slices.Clone(feelings)
+
+	if normalizedFeelings == nil {
+		normalizedFeelings = []string{}
+	}
+
+	slices.Sort(normalizedFeelings)
+
+	return normalizedFeelings
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// Mock implementation for DiaryEntryService
+type mockMoodDiaryEntryService struct {
+	DefaultDiaryEntryService
+	Entries []*models.DiaryEntry
+	Err     error
+}
+
+func (m *mockMoodDiaryEntryService) GetUserEntriesTimeRange(userId uint, startDate int64, endDate int64) ([]*models.DiaryEntry, error) {
+	return m.Entries, m.Err
+}
+
+// Mock implementation for BookActivityRegistrationService
+type mockMoodBookRegistrationService struct {
+	BookActivityRegistrationServiceImpl
+	Registrations []*models.BookActivityRegistration
+}
+
+func (m *mockMoodBookRegistrationService) GetUserBookActivityRegistrationsTimeRange(userId uint, startTime int64, endTime int64) ([]*models.BookActivityRegistration, error) {
+	return m.Registrations, nil
+}
+
+// Mock implementation for GameActivityRegistrationService
+type mockMoodGameRegistrationService struct {
+	GameActivityRegistrationServiceImpl
+	Registrations []*models.GameActivityRegistration
+}
+
+func (m *mockMoodGameRegistrationService) GetUserGameActivityRegistrationsTimeRange(userId uint, startDate int64, endDate int64) ([]*models.GameActivityRegistration, error) {
+	return m.Registrations, nil
+}
+
+func newMoodDiaryEntry(registrationDate time.Time, mood int, feelings ...string) *models.DiaryEntry {
+	return &models.DiaryEntry{Mood: &mood, Feelings: feelings, Registration: models.ActivityRegistration{RegistrationDate: registrationDate.Unix()}}
+}
+
+func TestGetMoodSummary(t *testing.T) {
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	user := &models.User{Id: 1, TimeZone: "Europe/Madrid"}
+	diaryEntryServiceMock := &mockMoodDiaryEntryService{
+		Entries: []*models.DiaryEntry{
+			newMoodDiaryEntry(time.Date(2024, 5, 6, 0, 30, 0, 0, madrid), 4, models.FeelingCalm),
+			newMoodDiaryEntry(time.Date(2024, 5, 6, 22, 0, 0, 0, madrid), 2, models.FeelingCalm, models.FeelingTired),
+			{Feelings: []string{}, Registration: models.ActivityRegistration{RegistrationDate: time.Date(2024, 5, 8, 12, 0, 0, 0, madrid).Unix()}},
+		},
+	}
+	moodService := NewMoodServiceImpl(diaryEntryServiceMock, &mockMoodBookRegistrationService{}, &mockMoodGameRegistrationService{})
+	moodQuery := &MoodQuery{
+		StartDate: time.Date(2024, 5, 6, 0, 0, 0, 0, madrid).Unix(),
+		EndDate:   time.Date(2024, 5, 8, 23, 59, 59, 0, madrid).Unix(),
+		Period:    MoodPeriodDay,
+	}
+
+	// Test daily periods in the time zone of the user
+	moodSummary, err := moodService.GetMoodSummary(user, moodQuery)
+	assert.NoError(t, err)
+	assert.Len(t, moodSummary.Periods, 3)
+	firstDay := moodSummary.Periods[0]
+	assert.Equal(t, moodQuery.StartDate, firstDay.PeriodStart)
+	assert.Equal(t, 2, firstDay.EntryCount)
+	assert.Equal(t, 2, firstDay.MoodCount)
+	assert.Equal(t, 3.0, *firstDay.AverageMood)
+	assert.Equal(t, 2, *firstDay.MinMood)
+	assert.Equal(t, 4, *firstDay.MaxMood)
+	assert.Equal(t, map[string]int{models.FeelingCalm: 2, models.FeelingTired: 1}, firstDay.Feelings)
+	assert.Zero(t, moodSummary.Periods[1].EntryCount)
+	assert.Equal(t, 1, moodSummary.Periods[2].EntryCount)
+	assert.Nil(t, moodSummary.Periods[2].AverageMood)
+
+	// Test weeks start on Monday
+	moodQuery.Period = MoodPeriodWeek
+	moodQuery.StartDate = time.Date(2024, 5, 8, 0, 0, 0, 0, madrid).Unix()
+	moodSummary, err = moodService.GetMoodSummary(user, moodQuery)
+	assert.NoError(t, err)
+	assert.Len(t, moodSummary.Periods, 1)
+	assert.Equal(t, time.Date(2024, 5, 6, 0, 0, 0, 0, madrid).Unix(), moodSummary.Periods[0].PeriodStart)
+	assert.Equal(t, 3, moodSummary.Periods[0].EntryCount)
+
+	// Test invalid queries
+	_, err = moodService.GetMoodSummary(user, &MoodQuery{StartDate: 0, EndDate: 1, Period: "year"})
+	assert.ErrorIs(t, err, ErrInvalidMoodPeriod)
+	_, err = moodService.GetMoodSummary(user, &MoodQuery{StartDate: 1, EndDate: 0, Period: MoodPeriodDay})
+	assert.ErrorIs(t, err, ErrInvalidMoodDateRange)
+	_, err = moodService.GetMoodSummary(user, &MoodQuery{StartDate: 0, EndDate: 5 * 365 * 24 * 3600, Period: MoodPeriodDay})
+	assert.ErrorIs(t, err, ErrInvalidMoodDateRange)
+
+	// Test diary entry service error
+	diaryEntryServiceMock.Err = errors.New("forced diary entries error")
+	_, err = moodService.GetMoodSummary(user, &MoodQuery{StartDate: 0, EndDate: 1, Period: MoodPeriodMonth})
+	assert.EqualError(t, err, "forced diary entries error")
+}
+
+func TestGetMoodActivityCorrelation(t *testing.T) {
+	user := &models.User{Id: 1}
+	firstDay := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	diaryEntryServiceMock := &mockMoodDiaryEntryService{
+		Entries: []*models.DiaryEntry{
+			newMoodDiaryEntry(firstDay, 2),
+			newMoodDiaryEntry(firstDay.AddDate(0, 0, 1), 4),
+			newMoodDiaryEntry(firstDay.AddDate(0, 0, 2), 5),
+		},
+	}
+	bookRegistrationServiceMock := &mockMoodBookRegistrationService{
+		Registrations: []*models.BookActivityRegistration{
+			{Registration: models.ActivityRegistration{RegistrationDate: firstDay.AddDate(0, 0, 1).Unix()}},
+			{Registration: models.ActivityRegistration{RegistrationDate: firstDay.AddDate(0, 0, 2).Unix()}},
+			{Registration: models.ActivityRegistration{RegistrationDate: firstDay.AddDate(0, 0, 2).Unix()}},
+			{Registration: models.ActivityRegistration{RegistrationDate: firstDay.AddDate(0, 0, 3).Unix()}},
+		},
+	}
+	gameRegistrationServiceMock := &mockMoodGameRegistrationService{
+		Registrations: []*models.GameActivityRegistration{
+			{Registration: models.ActivityRegistration{RegistrationDate: firstDay.Unix()}},
+		},
+	}
+	moodService := NewMoodServiceImpl(diaryEntryServiceMock, bookRegistrationServiceMock, gameRegistrationServiceMock)
+
+	moodActivityCorrelation, err := moodService.GetMoodActivityCorrelation(user, &MoodQuery{
+		StartDate: firstDay.Unix(),
+		EndDate:   firstDay.AddDate(0, 0, 3).Unix(),
+		Period:    MoodPeriodDay,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, moodActivityCorrelation.Periods, 4)
+	assert.Equal(t, []int{0, 1, 2, 1}, []int{
+		moodActivityCorrelation.Periods[0].BookActivityCount,
+		moodActivityCorrelation.Periods[1].BookActivityCount,
+		moodActivityCorrelation.Periods[2].BookActivityCount,
+		moodActivityCorrelation.Periods[3].BookActivityCount,
+	})
+	assert.Equal(t, 1, moodActivityCorrelation.Periods[0].GameActivityCount)
+
+	// the periods without mood are not correlated
+	assert.InDelta(t, 0.9820, *moodActivityCorrelation.BookActivityCorrelation, 0.0001)
+	assert.InDelta(t, -0.9449, *moodActivityCorrelation.GameActivityCorrelation, 0.0001)
+
+	// Test no correlation for constant samples
+	diaryEntryServiceMock.Entries = diaryEntryServiceMock.Entries[:1]
+	moodActivityCorrelation, err = moodService.GetMoodActivityCorrelation(user, &MoodQuery{
+		StartDate: firstDay.Unix(),
+		EndDate:   firstDay.AddDate(0, 0, 3).Unix(),
+		Period:    MoodPeriodMonth,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, moodActivityCorrelation.Periods, 1)
+	assert.Nil(t, moodActivityCorrelation.BookActivityCorrelation)
+}
+
+func TestDiaryEntryMood(t *testing.T) {
+	originalDiaryEntryStorage := diaryEntryStorage
+	originalActivityRegistrationStorage := activityRegistrationStorage
+	diaryEntryStorage = &mockDiaryEntryStorage{
+		Entries:     make(map[uint]*models.DiaryEntry),
+		UserEntries: make(map[uint][]*models.DiaryEntry),
+	}
+	activityRegistrationStorage = &mockActivityRegistrationStorage{}
+	defer func() {
+		diaryEntryStorage = originalDiaryEntryStorage
+		activityRegistrationStorage = originalActivityRegistrationStorage
+	}()
+
+	mood := 4
+	createdEntry, err := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Walk", Content: "Content", PublishDate: time.Now().Unix(),
+		Mood: &mood, Feelings: []string{models.FeelingHappy, models.FeelingCalm},
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, *createdEntry.Mood)
+	assert.Equal(t, []string{models.FeelingCalm, models.FeelingHappy}, createdEntry.Feelings)
+
+	// Test mood and feelings are kept when not provided on update
+	updateBody := &UpdateDiaryEntryBody{Title: "Walk", Content: "Updated", PublishDate: time.Now().Unix()}
+	updatedEntry, err := diaryEntryService.UpdateDiaryEntry(createdEntry.Id, updateBody, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, *updatedEntry.Mood)
+	assert.Equal(t, []string{models.FeelingCalm, models.FeelingHappy}, updatedEntry.Feelings)
+
+	// Test a zero mood and an empty list remove them
+	noMood := 0
+	updateBody.Mood = &noMood
+	updateBody.Feelings = []string{}
+	updatedEntry, err = diaryEntryService.UpdateDiaryEntry(createdEntry.Id, updateBody, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, updatedEntry.Mood)
+	assert.Empty(t, updatedEntry.Feelings)
+}
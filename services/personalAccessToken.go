@@ -0,0 +1,171 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+)
+
+const (
+	// PersonalAccessTokenPrefix tells personal access tokens apart from JWTs and from secrets of other services.
+	PersonalAccessTokenPrefix = "alk_pat_"
+	// personalAccessTokenDisplayLength is the length of the token start kept to recognise it in listings.
+	personalAccessTokenDisplayLength = len(PersonalAccessTokenPrefix) + 6
+	personalAccessTokenBytes         = 32
+	// last used timestamps are not updated more often than this, to avoid a write per request
+	personalAccessTokenUsageGranularity = time.Minute
+)
+
+var errInvalidPersonalAccessToken = errors.New("personal access token not valid")
+var errExpiredPersonalAccessToken = errors.New("personal access token expired")
+
+type CreatePersonalAccessTokenBody struct {
+	Name          string   `json:"name" validate:"required,max=64"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,unique,dive,oneof=diary:read diary:write activities:read activities:write profile:read profile:write"`
+	ExpiresInDays int      `json:"expiresInDays" validate:"omitempty,min=1,max=365"`
+}
+
+// CreatedPersonalAccessTokenResponse holds a new personal access token. The token is only returned here.
+type CreatedPersonalAccessTokenResponse struct {
+	Token               string                      `json:"token"`
+	PersonalAccessToken *models.PersonalAccessToken `json:"personalAccessToken"`
+}
+
+var personalAccessTokenStorage storage.PersonalAccessTokenStorageInterface = &storage.PersonalAccessTokenStorage{}
+
+// PersonalAccessTokenService defines all operations for the personal access token service.
+type PersonalAccessTokenService interface {
+	GetUserPersonalAccessTokens(userId uint) ([]*models.PersonalAccessToken, error)
+	CreatePersonalAccessToken(userId uint, createBody *CreatePersonalAccessTokenBody, auditMetadata *AuditMetadata) (*CreatedPersonalAccessTokenResponse, error)
+	DeletePersonalAccessToken(userId uint, id uint, auditMetadata *AuditMetadata) error
+	AuthenticatePersonalAccessToken(tokenValue string) (*models.PersonalAccessToken, error)
+}
+
+// PersonalAccessTokenServiceImpl is the concrete implementation of PersonalAccessTokenService.
+type PersonalAccessTokenServiceImpl struct{}
+
+// IsPersonalAccessToken reports whether a bearer token is a personal access token rather than a JWT.
+func IsPersonalAccessToken(tokenValue string) bool {
+	return strings.HasPrefix(tokenValue, PersonalAccessTokenPrefix)
+}
+
+func (personalAccessTokenService *PersonalAccessTokenServiceImpl) GetUserPersonalAccessTokens(userId uint) ([]*models.PersonalAccessToken, error) {
+	personalAccessTokens, err := personalAccessTokenStorage.GetByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return personalAccessTokens.([]*models.PersonalAccessToken), nil
+}
+
+func (personalAccessTokenService *PersonalAccessTokenServiceImpl) CreatePersonalAccessToken(userId uint, createBody *CreatePersonalAccessTokenBody, auditMetadata *AuditMetadata) (*CreatedPersonalAccessTokenResponse, error) {
+	tokenValue, generateErr := generatePersonalAccessToken()
+
+	if generateErr != nil {
+		return nil, generateErr
+	}
+
+	now := time.Now()
+	personalAccessToken := &models.PersonalAccessToken{
+		Name:      createBody.Name,
+		Prefix:    tokenValue[:personalAccessTokenDisplayLength],
+		TokenHash: hashPersonalAccessToken(tokenValue),
+		Scopes:    createBody.Scopes,
+		UserRefer: userId,
+		CreatedAt: now.Unix(),
+	}
+
+	if createBody.ExpiresInDays > 0 {
+		personalAccessToken.ExpiresAt = now.AddDate(0, 0, createBody.ExpiresInDays).Unix()
+	}
+
+	if err := personalAccessTokenStorage.Create(personalAccessToken); err != nil {
+		return nil, err
+	}
+
+	auditService.RecordEvent(models.AuditPersonalAccessTokenCreated, models.AuditTargetPersonalAccessToken,
+		personalAccessToken.Id, auditMetadata, strings.Join(personalAccessToken.Scopes, " "))
+
+	return &CreatedPersonalAccessTokenResponse{Token: tokenValue, PersonalAccessToken: personalAccessToken}, nil
+}
+
+// DeletePersonalAccessToken revokes a personal access token of the user.
+// Tokens of other users are reported as not found.
+func (personalAccessTokenService *PersonalAccessTokenServiceImpl) DeletePersonalAccessToken(userId uint, id uint, auditMetadata *AuditMetadata) error {
+	personalAccessToken, getErr := personalAccessTokenStorage.Get(id)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	if personalAccessToken.(*models.PersonalAccessToken).UserRefer != userId {
+		return &models.DbNotFoundError{DbItem: &models.PersonalAccessToken{}}
+	}
+
+	if err := personalAccessTokenStorage.Delete(id); err != nil {
+		return err
+	}
+
+	auditService.RecordEvent(models.AuditPersonalAccessTokenRevoked, models.AuditTargetPersonalAccessToken, id, auditMetadata, "")
+
+	return nil
+}
+
+// AuthenticatePersonalAccessToken returns the personal access token matching the value,
+// recording its usage. Returns error if it does not exist or is expired.
+func (personalAccessTokenService *PersonalAccessTokenServiceImpl) AuthenticatePersonalAccessToken(tokenValue string) (*models.PersonalAccessToken, error) {
+	storedToken, getErr := personalAccessTokenStorage.GetByHash(hashPersonalAccessToken(tokenValue))
+
+	if getErr != nil {
+		return nil, errInvalidPersonalAccessToken
+	}
+
+	personalAccessToken := storedToken.(*models.PersonalAccessToken)
+	now := time.Now()
+
+	if personalAccessToken.ExpiresAt != 0 && now.Unix() >= personalAccessToken.ExpiresAt {
+		return nil, errExpiredPersonalAccessToken
+	}
+
+	if now.Sub(time.Unix(personalAccessToken.LastUsedAt, 0)) >= personalAccessTokenUsageGranularity {
+		// failing to track the usage must not reject a valid token
+		if updateErr := personalAccessTokenStorage.UpdateLastUsed(personalAccessToken.Id, now.Unix()); updateErr != nil {
+			servicesLogger.ErrorLogger.Printf("error when updating personal access token %d usage: %s", personalAccessToken.Id, updateErr.Error())
+		} else {
+			personalAccessToken.LastUsedAt = now.Unix()
+		}
+	}
+
+	return personalAccessToken, nil
+}
+
+// HasScope reports whether the token grants a scope.
+func HasScope(personalAccessToken *models.PersonalAccessToken, scope string) bool {
+	return slices.Contains(personalAccessToken.Scopes, scope)
+}
+
+func generatePersonalAccessToken() (string, error) {
+	tokenBytes := make([]byte, personalAccessTokenBytes)
+
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+
+	return PersonalAccessTokenPrefix + hex.EncodeToString(tokenBytes), nil
+}
+
+// hashPersonalAccessToken hashes a token for storage. A fast unsalted hash is enough, as tokens are random
+// and long enough to make brute forcing them unfeasible.
+func hashPersonalAccessToken(tokenValue string) string {
+	tokenHash := sha256.Sum256([]byte(tokenValue))
+
+	return hex.EncodeToString(tokenHash[:])
+}
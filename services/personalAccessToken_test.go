@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPersonalAccessTokenStorage implements PersonalAccessTokenStorageInterface
+type mockPersonalAccessTokenStorage struct {
+	TokensById map[uint]*models.PersonalAccessToken
+
+	CreateErr         error
+	UpdateLastUsedErr error
+}
+
+func newMockPersonalAccessTokenStorage() *mockPersonalAccessTokenStorage {
+	return &mockPersonalAccessTokenStorage{TokensById: make(map[uint]*models.PersonalAccessToken)}
+}
+
+func (m *mockPersonalAccessTokenStorage) Get(id uint) (interface{}, error) {
+	personalAccessToken, ok := m.TokensById[id]
+	if !ok {
+		return nil, &models.DbNotFoundError{DbItem: &models.PersonalAccessToken{}}
+	}
+	return personalAccessToken, nil
+}
+
+func (m *mockPersonalAccessTokenStorage) GetByHash(tokenHash string) (interface{}, error) {
+	for _, personalAccessToken := range m.TokensById {
+		if personalAccessToken.TokenHash == tokenHash {
+			return personalAccessToken, nil
+		}
+	}
+	return nil, &models.DbNotFoundError{DbItem: &models.PersonalAccessToken{}}
+}
+
+func (m *mockPersonalAccessTokenStorage) GetByUserId(userId uint) (interface{}, error) {
+	personalAccessTokens := []*models.PersonalAccessToken{}
+	for _, personalAccessToken := range m.TokensById {
+		if personalAccessToken.UserRefer == userId {
+			personalAccessTokens = append(personalAccessTokens, personalAccessToken)
+		}
+	}
+	return personalAccessTokens, nil
+}
+
+func (m *mockPersonalAccessTokenStorage) Create(data interface{}) error {
+	if m.CreateErr != nil {
+		return m.CreateErr
+	}
+	personalAccessToken := data.(*models.PersonalAccessToken)
+	personalAccessToken.Id = uint(len(m.TokensById) + 1)
+	m.TokensById[personalAccessToken.Id] = personalAccessToken
+	return nil
+}
+
+func (m *mockPersonalAccessTokenStorage) UpdateLastUsed(id uint, lastUsedAt int64) error {
+	if m.UpdateLastUsedErr != nil {
+		return m.UpdateLastUsedErr
+	}
+	m.TokensById[id].LastUsedAt = lastUsedAt
+	return nil
+}
+
+func (m *mockPersonalAccessTokenStorage) Delete(id uint) error {
+	if _, ok := m.TokensById[id]; !ok {
+		return &models.DbNotFoundError{DbItem: &models.PersonalAccessToken{}}
+	}
+	delete(m.TokensById, id)
+	return nil
+}
+
+func TestCreatePersonalAccessToken(t *testing.T) {
+	originalStorage := personalAccessTokenStorage
+	mockStorage := newMockPersonalAccessTokenStorage()
+	personalAccessTokenStorage = mockStorage
+	defer func() { personalAccessTokenStorage = originalStorage }()
+
+	personalAccessTokenService := &PersonalAccessTokenServiceImpl{}
+	createBody := &CreatePersonalAccessTokenBody{Name: "stats script", Scopes: []string{models.ScopeDiaryRead}, ExpiresInDays: 30}
+
+	createdToken, err := personalAccessTokenService.CreatePersonalAccessToken(1, createBody, nil)
+	assert.NoError(t, err)
+	assert.True(t, IsPersonalAccessToken(createdToken.Token))
+	assert.True(t, strings.HasPrefix(createdToken.Token, createdToken.PersonalAccessToken.Prefix))
+	assert.Equal(t, uint(1), createdToken.PersonalAccessToken.UserRefer)
+	assert.NotZero(t, createdToken.PersonalAccessToken.ExpiresAt)
+
+	// only the hash of the token is stored
+	storedToken := mockStorage.TokensById[createdToken.PersonalAccessToken.Id]
+	assert.NotContains(t, storedToken.TokenHash, createdToken.Token)
+	assert.Equal(t, hashPersonalAccessToken(createdToken.Token), storedToken.TokenHash)
+
+	// Test storage error
+	mockStorage.CreateErr = errors.New("db error")
+	_, err = personalAccessTokenService.CreatePersonalAccessToken(1, createBody, nil)
+	assert.Error(t, err)
+}
+
+func TestAuthenticatePersonalAccessToken(t *testing.T) {
+	originalStorage := personalAccessTokenStorage
+	mockStorage := newMockPersonalAccessTokenStorage()
+	personalAccessTokenStorage = mockStorage
+	defer func() { personalAccessTokenStorage = originalStorage }()
+
+	personalAccessTokenService := &PersonalAccessTokenServiceImpl{}
+	createdToken, _ := personalAccessTokenService.CreatePersonalAccessToken(1,
+		&CreatePersonalAccessTokenBody{Name: "script", Scopes: []string{models.ScopeDiaryRead}}, nil)
+
+	// Test valid token, tracking its usage
+	personalAccessToken, err := personalAccessTokenService.AuthenticatePersonalAccessToken(createdToken.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, createdToken.PersonalAccessToken.Id, personalAccessToken.Id)
+	assert.NotZero(t, mockStorage.TokensById[personalAccessToken.Id].LastUsedAt)
+	assert.True(t, HasScope(personalAccessToken, models.ScopeDiaryRead))
+	assert.False(t, HasScope(personalAccessToken, models.ScopeDiaryWrite))
+
+	// Test usage tracking errors do not reject the token
+	mockStorage.TokensById[personalAccessToken.Id].LastUsedAt = 0
+	mockStorage.UpdateLastUsedErr = errors.New("db error")
+	_, err = personalAccessTokenService.AuthenticatePersonalAccessToken(createdToken.Token)
+	assert.NoError(t, err)
+
+	// Test unknown token
+	_, err = personalAccessTokenService.AuthenticatePersonalAccessToken(PersonalAccessTokenPrefix + "unknown")
+	assert.ErrorIs(t, err, errInvalidPersonalAccessToken)
+
+	// Test expired token
+	mockStorage.TokensById[personalAccessToken.Id].ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	_, err = personalAccessTokenService.AuthenticatePersonalAccessToken(createdToken.Token)
+	assert.ErrorIs(t, err, errExpiredPersonalAccessToken)
+}
+
+func TestDeletePersonalAccessToken(t *testing.T) {
+	originalStorage := personalAccessTokenStorage
+	mockStorage := newMockPersonalAccessTokenStorage()
+	personalAccessTokenStorage = mockStorage
+	defer func() { personalAccessTokenStorage = originalStorage }()
+
+	personalAccessTokenService := &PersonalAccessTokenServiceImpl{}
+	createdToken, _ := personalAccessTokenService.CreatePersonalAccessToken(1,
+		&CreatePersonalAccessTokenBody{Name: "script", Scopes: []string{models.ScopeDiaryRead}}, nil)
+	tokenId := createdToken.PersonalAccessToken.Id
+
+	// Test other users cannot revoke the token
+	err := personalAccessTokenService.DeletePersonalAccessToken(2, tokenId, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	assert.Contains(t, mockStorage.TokensById, tokenId)
+
+	// Test revoking own token
+	err = personalAccessTokenService.DeletePersonalAccessToken(1, tokenId, nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, mockStorage.TokensById, tokenId)
+
+	// Test revoking a missing token
+	err = personalAccessTokenService.DeletePersonalAccessToken(1, tokenId, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+)
+
+const (
+	// defaultPromptLanguage is used when the user has no locale, or there are no prompts in their language.
+	defaultPromptLanguage = "en"
+	// promptRepeatDays is the number of days an answered prompt is not suggested again, unless every prompt was answered.
+	promptRepeatDays = 30
+)
+
+// SavePromptBody creates or replaces a prompt. The language is stored as its ISO 639-1 code, without region.
+type SavePromptBody struct {
+	Text     string `json:"text" validate:"required,max=500"`
+	Category string `json:"category" validate:"required,max=32"`
+	Language string `json:"language" validate:"required,bcp47_language_tag"`
+}
+
+// PromptQuery holds the filters of the prompts listing. Empty filters are not applied.
+type PromptQuery struct {
+	Category string
+	Language string
+}
+
+var promptStorage storage.PromptStorageInterface = &storage.PromptStorage{}
+
+// PromptService defines all operations for the prompt service.
+type PromptService interface {
+	GetPrompts(promptQuery *PromptQuery) ([]*models.PromptUsage, error)
+	GetPromptById(id uint) (*models.Prompt, error)
+	GetDailyPrompt(user *models.User, language string) (*models.Prompt, error)
+	SavePrompt(promptBody *SavePromptBody, auditMetadata *AuditMetadata) (*models.Prompt, error)
+	UpdatePrompt(id uint, promptBody *SavePromptBody, auditMetadata *AuditMetadata) (*models.Prompt, error)
+	DeletePrompt(id uint, auditMetadata *AuditMetadata) error
+}
+
+// PromptServiceImpl is the concrete implementation of PromptService.
+type PromptServiceImpl struct{}
+
+func (promptService *PromptServiceImpl) GetPrompts(promptQuery *PromptQuery) ([]*models.PromptUsage, error) {
+	promptUsages, err := promptStorage.GetUsages(strings.ToLower(strings.TrimSpace(promptQuery.Category)), getPromptLanguage(promptQuery.Language))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return promptUsages.([]*models.PromptUsage), nil
+}
+
+func (promptService *PromptServiceImpl) GetPromptById(id uint) (*models.Prompt, error) {
+	prompt, err := promptStorage.Get(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return prompt.(*models.Prompt), nil
+}
+
+// GetDailyPrompt returns the prompt of the day for the user, in the given language or else in the language of the user locale.
+// The prompt is the same during the whole day in the time zone of the user, and prompts answered by the user
+// in the previous days are skipped.
+func (promptService *PromptServiceImpl) GetDailyPrompt(user *models.User, language string) (*models.Prompt, error) {
+	if len(language) == 0 {
+		language = user.Locale
+	}
+
+	prompts, err := getPromptsByLanguage(getPromptLanguage(language))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prompts) == 0 {
+		return nil, &models.DbNotFoundError{DbItem: &models.Prompt{}}
+	}
+
+	now := time.Now().In(GetUserLocation(user))
+	dayStart := getDayStart(now)
+	// the answers of the current day are not taken into account, so the prompt does not change once answered
+	answeredPromptIds, err := promptStorage.GetAnsweredIdsByUserId(user.Id, dayStart.AddDate(0, 0, -promptRepeatDays).Unix(), dayStart.Unix())
+
+	if err != nil {
+		return nil, err
+	}
+
+	candidatePrompts := []*models.Prompt{}
+
+	for _, prompt := range prompts {
+		if !slices.Contains(answeredPromptIds.([]uint), prompt.Id) {
+			candidatePrompts = append(candidatePrompts, prompt)
+		}
+	}
+
+	if len(candidatePrompts) == 0 {
+		candidatePrompts = prompts
+	}
+
+	dayHash := fnv.New32a()
+	fmt.Fprintf(dayHash, "%d:%s", user.Id, dayStart.Format(time.DateOnly))
+
+	return candidatePrompts[dayHash.Sum32()%uint32(len(candidatePrompts))], nil
+}
+
+func (promptService *PromptServiceImpl) SavePrompt(promptBody *SavePromptBody, auditMetadata *AuditMetadata) (*models.Prompt, error) {
+	dbPrompt := &models.Prompt{
+		Text:      strings.TrimSpace(promptBody.Text),
+		Category:  strings.ToLower(strings.TrimSpace(promptBody.Category)),
+		Language:  getPromptLanguage(promptBody.Language),
+		CreatedAt: time.Now().Unix(),
+	}
+
+	if err := promptStorage.Create(dbPrompt); err != nil {
+		return nil, err
+	}
+
+	auditService.RecordEvent(models.AuditPromptCreated, models.AuditTargetPrompt, dbPrompt.Id, auditMetadata, "")
+
+	return dbPrompt, nil
+}
+
+func (promptService *PromptServiceImpl) UpdatePrompt(id uint, promptBody *SavePromptBody, auditMetadata *AuditMetadata) (*models.Prompt, error) {
+	dbPrompt, getErr := promptService.GetPromptById(id)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	dbPrompt.Text = strings.TrimSpace(promptBody.Text)
+	dbPrompt.Category = strings.ToLower(strings.TrimSpace(promptBody.Category))
+	dbPrompt.Language = getPromptLanguage(promptBody.Language)
+
+	if err := promptStorage.Update(dbPrompt); err != nil {
+		return nil, err
+	}
+
+	auditService.RecordEvent(models.AuditPromptUpdated, models.AuditTargetPrompt, id, auditMetadata, "")
+
+	return dbPrompt, nil
+}
+
+// DeletePrompt deletes a prompt. The diary entries that answered it are kept, without prompt.
+func (promptService *PromptServiceImpl) DeletePrompt(id uint, auditMetadata *AuditMetadata) error {
+	if err := promptStorage.Delete(id); err != nil {
+		return err
+	}
+
+	auditService.RecordEvent(models.AuditPromptDeleted, models.AuditTargetPrompt, id, auditMetadata, "")
+
+	return nil
+}
+
+// getPromptsByLanguage returns the prompts in the language, falling back to the default language when there are none.
+func getPromptsByLanguage(language string) ([]*models.Prompt, error) {
+	prompts, err := promptStorage.GetByLanguage(language)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prompts.([]*models.Prompt)) == 0 && language != defaultPromptLanguage {
+		return getPromptsByLanguage(defaultPromptLanguage)
+	}
+
+	return prompts.([]*models.Prompt), nil
+}
+
+// getPromptLanguage returns the lowercase language of a BCP 47 tag, like es for es-ES.
+func getPromptLanguage(languageTag string) string {
+	language, _, _ := strings.Cut(strings.TrimSpace(languageTag), "-")
+
+	return strings.ToLower(language)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPromptStorage implements PromptStorageInterface
+type mockPromptStorage struct {
+	Prompts     []*models.Prompt
+	AnsweredIds []uint
+	// AnsweredStartDate and AnsweredEndDate are the interval of the last answered prompts query
+	AnsweredStartDate int64
+	AnsweredEndDate   int64
+}
+
+func (m *mockPromptStorage) Get(id uint) (interface{}, error) {
+	for _, prompt := range m.Prompts {
+		if prompt.Id == id {
+			return prompt, nil
+		}
+	}
+	return nil, &models.DbNotFoundError{DbItem: &models.Prompt{}}
+}
+
+func (m *mockPromptStorage) GetByLanguage(language string) (interface{}, error) {
+	prompts := []*models.Prompt{}
+	for _, prompt := range m.Prompts {
+		if prompt.Language == language {
+			prompts = append(prompts, prompt)
+		}
+	}
+	return prompts, nil
+}
+
+func (m *mockPromptStorage) GetUsages(category string, language string) (interface{}, error) {
+	promptUsages := []*models.PromptUsage{}
+	for _, prompt := range m.Prompts {
+		if (len(category) == 0 || prompt.Category == category) && (len(language) == 0 || prompt.Language == language) {
+			promptUsages = append(promptUsages, &models.PromptUsage{Prompt: *prompt})
+		}
+	}
+	return promptUsages, nil
+}
+
+func (m *mockPromptStorage) GetAnsweredIdsByUserId(userId uint, startDate int64, endDate int64) (interface{}, error) {
+	m.AnsweredStartDate = startDate
+	m.AnsweredEndDate = endDate
+	return m.AnsweredIds, nil
+}
+
+func (m *mockPromptStorage) Create(data interface{}) error {
+	prompt := data.(*models.Prompt)
+	prompt.Id = uint(len(m.Prompts) + 1)
+	m.Prompts = append(m.Prompts, prompt)
+	return nil
+}
+
+func (m *mockPromptStorage) Update(data interface{}) error {
+	prompt := data.(*models.Prompt)
+	for i, storedPrompt := range m.Prompts {
+		if storedPrompt.Id == prompt.Id {
+			m.Prompts[i] = prompt
+			return nil
+		}
+	}
+	return &models.DbNotFoundError{DbItem: &models.Prompt{}}
+}
+
+func (m *mockPromptStorage) Delete(id uint) error {
+	for i, prompt := range m.Prompts {
+		if prompt.Id == id {
+			m.Prompts = append(m.Prompts[:i], m.Prompts[i+1:]...)
+			return nil
+		}
+	}
+	return &models.DbNotFoundError{DbItem: &models.Prompt{}}
+}
+
+var promptService PromptService = &PromptServiceImpl{}
+
+func setUpPromptMocks(t *testing.T, prompts []*models.Prompt) *mockPromptStorage {
+	originalPromptStorage := promptStorage
+	promptStorageMock := &mockPromptStorage{Prompts: prompts}
+	promptStorage = promptStorageMock
+	t.Cleanup(func() {
+		promptStorage = originalPromptStorage
+	})
+
+	return promptStorageMock
+}
+
+func TestSavePrompt(t *testing.T) {
+	promptStorageMock := setUpPromptMocks(t, []*models.Prompt{})
+
+	savedPrompt, err := promptService.SavePrompt(&SavePromptBody{Text: " What made you smile today? ", Category: "Gratitude", Language: "en-GB"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "What made you smile today?", savedPrompt.Text)
+	assert.Equal(t, "gratitude", savedPrompt.Category)
+	assert.Equal(t, "en", savedPrompt.Language)
+	assert.NotZero(t, savedPrompt.CreatedAt)
+
+	updatedPrompt, err := promptService.UpdatePrompt(savedPrompt.Id, &SavePromptBody{Text: "¿Qué te hizo sonreír hoy?", Category: "gratitude", Language: "es"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "es", updatedPrompt.Language)
+	assert.Equal(t, savedPrompt.CreatedAt, updatedPrompt.CreatedAt)
+
+	promptUsages, err := promptService.GetPrompts(&PromptQuery{Language: "ES"})
+	assert.NoError(t, err)
+	assert.Len(t, promptUsages, 1)
+
+	// Test missing prompts
+	_, err = promptService.UpdatePrompt(5, &SavePromptBody{Text: "Text", Category: "goals", Language: "en"}, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+
+	assert.NoError(t, promptService.DeletePrompt(savedPrompt.Id, nil))
+	assert.Empty(t, promptStorageMock.Prompts)
+	assert.IsType(t, &models.DbNotFoundError{}, promptService.DeletePrompt(savedPrompt.Id, nil))
+}
+
+func TestGetDailyPrompt(t *testing.T) {
+	prompts := []*models.Prompt{
+		{Id: 1, Text: "One", Category: "gratitude", Language: "en"},
+		{Id: 2, Text: "Two", Category: "goals", Language: "en"},
+		{Id: 3, Text: "Three", Category: "reflection", Language: "en"},
+		{Id: 4, Text: "Cuatro", Category: "reflection", Language: "es"},
+	}
+	promptStorageMock := setUpPromptMocks(t, prompts)
+	user := &models.User{Id: 1, Locale: "en-US", TimeZone: "Europe/Madrid"}
+
+	// Test the prompt is the same during the day
+	dailyPrompt, err := promptService.GetDailyPrompt(user, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "en", dailyPrompt.Language)
+	sameDailyPrompt, _ := promptService.GetDailyPrompt(user, "")
+	assert.Equal(t, dailyPrompt, sameDailyPrompt)
+
+	// Test the answers of the current day are not taken into account
+	madridLocation, _ := time.LoadLocation("Europe/Madrid")
+	now := time.Now().In(madridLocation)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, madridLocation)
+	assert.Equal(t, dayStart.Unix(), promptStorageMock.AnsweredEndDate)
+	assert.Equal(t, dayStart.AddDate(0, 0, -promptRepeatDays).Unix(), promptStorageMock.AnsweredStartDate)
+
+	// Test recently answered prompts are skipped
+	promptStorageMock.AnsweredIds = []uint{dailyPrompt.Id}
+	otherDailyPrompt, err := promptService.GetDailyPrompt(user, "")
+	assert.NoError(t, err)
+	assert.NotEqual(t, dailyPrompt.Id, otherDailyPrompt.Id)
+
+	// Test prompts are suggested again once all of them were answered
+	promptStorageMock.AnsweredIds = []uint{1, 2, 3}
+	dailyPrompt, err = promptService.GetDailyPrompt(user, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "en", dailyPrompt.Language)
+
+	// Test language param
+	dailyPrompt, err = promptService.GetDailyPrompt(user, "es")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), dailyPrompt.Id)
+
+	// Test languages without prompts fall back to English
+	dailyPrompt, err = promptService.GetDailyPrompt(&models.User{Id: 2, Locale: "fr-FR"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "en", dailyPrompt.Language)
+
+	// Test no prompts
+	promptStorageMock.Prompts = []*models.Prompt{}
+	_, err = promptService.GetDailyPrompt(user, "")
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
+
+func TestSaveDiaryEntryWithPrompt(t *testing.T) {
+	setUpDiaryEntryRevisionMocks(t)
+	setUpPromptMocks(t, []*models.Prompt{{Id: 1, Text: "One", Category: "gratitude", Language: "en"}})
+	promptId := uint(1)
+
+	savedEntry, err := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Title", Content: "Content", PublishDate: time.Now().Unix(), PromptRefer: &promptId,
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &promptId, savedEntry.PromptRefer)
+
+	// Test unknown prompts
+	unknownPromptId := uint(2)
+	_, err = diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Title", Content: "Content", PublishDate: time.Now().Unix(), PromptRefer: &unknownPromptId,
+	}, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
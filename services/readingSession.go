@@ -0,0 +1,334 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+)
+
+const maxReadingDays = 400
+
+// ErrReadingSessionAlreadyStopped is returned when stopping a reading session which has already ended.
+var ErrReadingSessionAlreadyStopped = errors.New("the reading session is already stopped")
+
+// ErrInvalidReadingSessionEnd is returned when a reading session would end before it started.
+var ErrInvalidReadingSessionEnd = errors.New("a reading session cannot end before it started")
+
+// ErrInvalidReadingDateRange is returned when the date range is reversed or spans too many days.
+var ErrInvalidReadingDateRange = errors.New("the date range must start before it ends and span at most 400 days")
+
+// StartReadingSessionBody holds the start of a new reading session, which defaults to now.
+type StartReadingSessionBody struct {
+	StartedAt int64 `json:"startedAt,omitempty" validate:"min=0"`
+}
+
+// StopReadingSessionBody holds the end of a reading session, which defaults to now, and the progress reached.
+type StopReadingSessionBody struct {
+	EndedAt    int64    `json:"endedAt,omitempty" validate:"min=0"`
+	Page       *int     `json:"page,omitempty" validate:"omitempty,min=0"`
+	Percentage *float64 `json:"percentage,omitempty" validate:"omitempty,min=0,max=100"`
+	Position   string   `json:"position,omitempty" validate:"max=1024"`
+}
+
+// ReadingProgress holds the point reached in a book and its reading statistics. Each progress field comes from
+// the most recent session having it, so that a book can be resumed on another device.
+type ReadingProgress struct {
+	BookRegistrationId  uint                   `json:"bookRegistrationId"`
+	Page                *int                   `json:"page,omitempty"`
+	Percentage          *float64               `json:"percentage,omitempty"`
+	Position            string                 `json:"position,omitempty"`
+	LastReadAt          int64                  `json:"lastReadAt"`
+	TotalReadingSeconds int64                  `json:"totalReadingSeconds"`
+	SessionCount        int                    `json:"sessionCount"`
+	ActiveSession       *models.ReadingSession `json:"activeSession"`
+}
+
+// ReadingDay holds the reading time of a calendar day in the time zone of the user.
+type ReadingDay struct {
+	Date           string `json:"date"`
+	ReadingSeconds int64  `json:"readingSeconds"`
+	SessionCount   int    `json:"sessionCount"`
+}
+
+type ReadingTimeSummary struct {
+	TimeZone     string        `json:"timeZone"`
+	TotalSeconds int64         `json:"totalSeconds"`
+	Days         []*ReadingDay `json:"days"`
+}
+
+// ReadingSessionService defines the operations on the reading sessions of book registrations.
+// Book registrations of other users, and sessions of another book than the requested one, are reported as not found.
+type ReadingSessionService interface {
+	StartReadingSession(userId uint, bookRegistrationId uint, startBody *StartReadingSessionBody, auditMetadata *AuditMetadata) (*models.ReadingSession, error)
+	StopReadingSession(userId uint, bookRegistrationId uint, sessionId uint, stopBody *StopReadingSessionBody, auditMetadata *AuditMetadata) (*models.ReadingSession, error)
+	GetBookReadingSessions(userId uint, bookRegistrationId uint) ([]*models.ReadingSession, error)
+	GetBookReadingProgress(userId uint, bookRegistrationId uint) (*ReadingProgress, error)
+	GetReadingTime(user *models.User, startDate int64, endDate int64) (*ReadingTimeSummary, error)
+}
+
+type ReadingSessionServiceImpl struct{}
+
+var readingSessionStorage storage.ReadingSessionStorageInterface = &storage.ReadingSessionStorage{}
+
+// StartReadingSession opens a reading session of a book of the user. A session of the book still active,
+// left open on another device, is stopped when the new one starts.
+func (readingSessionService *ReadingSessionServiceImpl) StartReadingSession(userId uint, bookRegistrationId uint, startBody *StartReadingSessionBody, auditMetadata *AuditMetadata) (*models.ReadingSession, error) {
+	bookSessions, getErr := readingSessionService.GetBookReadingSessions(userId, bookRegistrationId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	startedAt := startBody.StartedAt
+
+	if startedAt == 0 {
+		startedAt = time.Now().Unix()
+	}
+
+	for _, bookSession := range bookSessions {
+		if bookSession.EndedAt != 0 {
+			continue
+		}
+
+		bookSession.EndedAt = max(bookSession.StartedAt, startedAt)
+
+		if updateErr := readingSessionStorage.Update(bookSession); updateErr != nil {
+			return nil, updateErr
+		}
+
+		auditService.RecordEvent(models.AuditReadingSessionStopped, models.AuditTargetReadingSession, bookSession.Id, auditMetadata,
+			fmt.Sprintf("book registration id: %d", bookRegistrationId))
+	}
+
+	readingSession := &models.ReadingSession{
+		BookRegistrationRefer: bookRegistrationId,
+		UserRefer:             userId,
+		StartedAt:             startedAt,
+	}
+
+	if createErr := readingSessionStorage.Create(readingSession); createErr != nil {
+		return nil, createErr
+	}
+
+	auditService.RecordEvent(models.AuditReadingSessionStarted, models.AuditTargetReadingSession, readingSession.Id, auditMetadata,
+		fmt.Sprintf("book registration id: %d", bookRegistrationId))
+
+	return readingSession, nil
+}
+
+// StopReadingSession ends an active reading session of a book of the user, saving the progress reached.
+func (readingSessionService *ReadingSessionServiceImpl) StopReadingSession(userId uint, bookRegistrationId uint, sessionId uint, stopBody *StopReadingSessionBody, auditMetadata *AuditMetadata) (*models.ReadingSession, error) {
+	if ownershipErr := checkBookRegistrationOwnership(userId, bookRegistrationId); ownershipErr != nil {
+		return nil, ownershipErr
+	}
+
+	storedSession, getErr := readingSessionStorage.Get(sessionId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	readingSession := storedSession.(*models.ReadingSession)
+
+	if readingSession.BookRegistrationRefer != bookRegistrationId {
+		return nil, &models.DbNotFoundError{DbItem: &models.ReadingSession{}}
+	}
+
+	if readingSession.EndedAt != 0 {
+		return nil, ErrReadingSessionAlreadyStopped
+	}
+
+	endedAt := stopBody.EndedAt
+
+	if endedAt == 0 {
+		endedAt = max(time.Now().Unix(), readingSession.StartedAt)
+	}
+
+	if endedAt < readingSession.StartedAt {
+		return nil, ErrInvalidReadingSessionEnd
+	}
+
+	readingSession.EndedAt = endedAt
+	readingSession.Page = stopBody.Page
+	readingSession.Percentage = stopBody.Percentage
+	readingSession.Position = stopBody.Position
+
+	if updateErr := readingSessionStorage.Update(readingSession); updateErr != nil {
+		return nil, updateErr
+	}
+
+	auditService.RecordEvent(models.AuditReadingSessionStopped, models.AuditTargetReadingSession, readingSession.Id, auditMetadata,
+		fmt.Sprintf("book registration id: %d", bookRegistrationId))
+
+	return readingSession, nil
+}
+
+// GetBookReadingSessions returns the reading sessions of a book of the user, most recent first.
+func (readingSessionService *ReadingSessionServiceImpl) GetBookReadingSessions(userId uint, bookRegistrationId uint) ([]*models.ReadingSession, error) {
+	if ownershipErr := checkBookRegistrationOwnership(userId, bookRegistrationId); ownershipErr != nil {
+		return nil, ownershipErr
+	}
+
+	dbBookSessions, err := readingSessionStorage.GetByBookRegistrationId(bookRegistrationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dbBookSessions.([]*models.ReadingSession), nil
+}
+
+// GetBookReadingProgress returns the point reached in a book of the user and the time spent reading it.
+// The active session, if any, counts until now.
+func (readingSessionService *ReadingSessionServiceImpl) GetBookReadingProgress(userId uint, bookRegistrationId uint) (*ReadingProgress, error) {
+	bookSessions, getErr := readingSessionService.GetBookReadingSessions(userId, bookRegistrationId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	now := time.Now().Unix()
+	readingProgress := &ReadingProgress{BookRegistrationId: bookRegistrationId, SessionCount: len(bookSessions)}
+
+	// sessions are sorted from the most recent
+	for _, bookSession := range bookSessions {
+		sessionEnd := bookSession.EndedAt
+
+		if sessionEnd == 0 {
+			sessionEnd = max(now, bookSession.StartedAt)
+
+			if readingProgress.ActiveSession == nil {
+				readingProgress.ActiveSession = bookSession
+			}
+		}
+
+		readingProgress.TotalReadingSeconds += sessionEnd - bookSession.StartedAt
+		readingProgress.LastReadAt = max(readingProgress.LastReadAt, sessionEnd)
+
+		if readingProgress.Page == nil {
+			readingProgress.Page = bookSession.Page
+		}
+		if readingProgress.Percentage == nil {
+			readingProgress.Percentage = bookSession.Percentage
+		}
+		if len(readingProgress.Position) == 0 {
+			readingProgress.Position = bookSession.Position
+		}
+	}
+
+	return readingProgress, nil
+}
+
+// GetReadingTime returns the time the user spent reading on every day of a date range, in the time zone of the user.
+// Sessions are split at midnight, and active sessions count until now.
+func (readingSessionService *ReadingSessionServiceImpl) GetReadingTime(user *models.User, startDate int64, endDate int64) (*ReadingTimeSummary, error) {
+	location := GetUserLocation(user)
+	dayStarts, daysErr := getReadingDayStarts(startDate, endDate, location)
+
+	if daysErr != nil {
+		return nil, daysErr
+	}
+
+	dbUserSessions, err := readingSessionStorage.GetByUserIdAndInterval(user.Id, startDate, endDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	readingDays := getReadingDays(dbUserSessions.([]*models.ReadingSession), dayStarts, startDate, endDate, location, time.Now().Unix())
+	readingTimeSummary := &ReadingTimeSummary{TimeZone: location.String(), Days: readingDays}
+
+	for _, readingDay := range readingDays {
+		readingTimeSummary.TotalSeconds += readingDay.ReadingSeconds
+	}
+
+	return readingTimeSummary, nil
+}
+
+// checkBookRegistrationOwnership reports the book registrations of other users, or in the trash, as not found.
+func checkBookRegistrationOwnership(userId uint, bookRegistrationId uint) error {
+	storedRegistration, getErr := bookActivityRegistrationStorage.Get(bookRegistrationId)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	if storedRegistration.(*models.BookActivityRegistration).Registration.UserRefer != userId {
+		return &models.DbNotFoundError{DbItem: &models.BookActivityRegistration{}}
+	}
+
+	return nil
+}
+
+// getReadingDayStarts returns the midnights starting the days of the date range in the location, in order.
+func getReadingDayStarts(startDate int64, endDate int64, location *time.Location) ([]int64, error) {
+	if endDate < startDate {
+		return nil, ErrInvalidReadingDateRange
+	}
+
+	dayStarts := make([]int64, 0)
+
+	for dayStart := getDayStart(time.Unix(startDate, 0).In(location)); dayStart.Unix() <= endDate; dayStart = dayStart.AddDate(0, 0, 1) {
+		if len(dayStarts) == maxReadingDays {
+			return nil, ErrInvalidReadingDateRange
+		}
+
+		dayStarts = append(dayStarts, dayStart.Unix())
+	}
+
+	return dayStarts, nil
+}
+
+// getReadingDays spreads the time of the sessions, cut to the date range, over the days starting at dayStarts.
+// A session counts in every day it was read on.
+func getReadingDays(sessions []*models.ReadingSession, dayStarts []int64, startDate int64, endDate int64, location *time.Location, now int64) []*ReadingDay {
+	readingDays := make([]*ReadingDay, len(dayStarts))
+
+	for dayIndex, dayStart := range dayStarts {
+		readingDays[dayIndex] = &ReadingDay{Date: time.Unix(dayStart, 0).In(location).Format(LocalDateLayout)}
+	}
+
+	for _, session := range sessions {
+		sessionEnd := session.EndedAt
+
+		if sessionEnd == 0 {
+			sessionEnd = max(now, session.StartedAt)
+		}
+
+		// the range end is the last second of the range, which is read entirely
+		sessionStart := max(session.StartedAt, startDate)
+		sessionEnd = min(sessionEnd, endDate+1)
+
+		if sessionEnd < sessionStart {
+			continue
+		}
+
+		dayIndex, found := slices.BinarySearch(dayStarts, sessionStart)
+
+		if !found {
+			dayIndex--
+		}
+
+		for ; dayIndex < len(dayStarts) && dayStarts[dayIndex] <= sessionEnd; dayIndex++ {
+			dayEnd := endDate + 1
+
+			if dayIndex+1 < len(dayStarts) {
+				dayEnd = dayStarts[dayIndex+1]
+			}
+
+			// a session ending at midnight is not read on the next day, unless it also starts then
+			if dayStarts[dayIndex] == sessionEnd && sessionStart != sessionEnd {
+				break
+			}
+
+			readingDays[dayIndex].ReadingSeconds += min(sessionEnd, dayEnd) - max(sessionStart, dayStarts[dayIndex])
+			readingDays[dayIndex].SessionCount++
+		}
+	}
+
+	return readingDays
+}
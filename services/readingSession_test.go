@@ -0,0 +1,234 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockReadingSessionStorage implements ReadingSessionStorageInterface
+type mockReadingSessionStorage struct {
+	Sessions map[uint]*models.ReadingSession
+}
+
+func (m *mockReadingSessionStorage) Get(id uint) (interface{}, error) {
+	session, found := m.Sessions[id]
+
+	if !found {
+		return nil, &models.DbNotFoundError{DbItem: &models.ReadingSession{}}
+	}
+
+	storedSession := *session
+	return &storedSession, nil
+}
+
+func (m *mockReadingSessionStorage) GetByBookRegistrationId(bookRegistrationId uint) (interface{}, error) {
+	sessions := []*models.ReadingSession{}
+
+	for id := uint(len(m.Sessions)); id >= 1; id-- {
+		if session, found := m.Sessions[id]; found && session.BookRegistrationRefer == bookRegistrationId {
+			storedSession := *session
+			sessions = append(sessions, &storedSession)
+		}
+	}
+
+	slices.SortStableFunc(sessions, func(a, b *models.ReadingSession) int {
+		return int(b.StartedAt - a.StartedAt)
+	})
+
+	return sessions, nil
+}
+
+func (m *mockReadingSessionStorage) GetByUserIdAndInterval(userId uint, startDate int64, endDate int64) (interface{}, error) {
+	sessions := []*models.ReadingSession{}
+
+	for id := uint(1); id <= uint(len(m.Sessions)); id++ {
+		if session, found := m.Sessions[id]; found && session.UserRefer == userId && session.StartedAt <= endDate &&
+			(session.EndedAt == 0 || session.EndedAt >= startDate) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (m *mockReadingSessionStorage) Create(data interface{}) error {
+	session := data.(*models.ReadingSession)
+	session.Id = uint(len(m.Sessions) + 1)
+	storedSession := *session
+	m.Sessions[session.Id] = &storedSession
+	return nil
+}
+
+func (m *mockReadingSessionStorage) Update(data interface{}) error {
+	session := data.(*models.ReadingSession)
+	storedSession := *session
+	m.Sessions[session.Id] = &storedSession
+	return nil
+}
+
+func setUpReadingSessionMocks(t *testing.T) *mockReadingSessionStorage {
+	originalReadingSessionStorage := readingSessionStorage
+	originalBookStorage := bookActivityRegistrationStorage
+	readingSessionStorageMock := &mockReadingSessionStorage{Sessions: make(map[uint]*models.ReadingSession)}
+	readingSessionStorage = readingSessionStorageMock
+	bookActivityRegistrationStorage = &mockBookActivityRegistrationStorage{Registrations: map[uint][]*models.BookActivityRegistration{
+		1: {{Id: 1, Registration: models.ActivityRegistration{UserRefer: 1}}},
+		2: {{Id: 2, Registration: models.ActivityRegistration{UserRefer: 2}}},
+	}}
+	t.Cleanup(func() {
+		readingSessionStorage = originalReadingSessionStorage
+		bookActivityRegistrationStorage = originalBookStorage
+	})
+
+	return readingSessionStorageMock
+}
+
+var readingSessionService ReadingSessionService = &ReadingSessionServiceImpl{}
+
+// Ensure the mock satisfies the storage interface
+var _ storage.ReadingSessionStorageInterface = &mockReadingSessionStorage{}
+
+func TestStartReadingSession(t *testing.T) {
+	sessionStorageMock := setUpReadingSessionMocks(t)
+
+	firstSession, err := readingSessionService.StartReadingSession(1, 1, &StartReadingSessionBody{StartedAt: 1000}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), firstSession.BookRegistrationRefer)
+	assert.Equal(t, uint(1), firstSession.UserRefer)
+	assert.Equal(t, int64(1000), firstSession.StartedAt)
+	assert.Zero(t, firstSession.EndedAt)
+
+	// Test a session left active on another device is stopped by the new one
+	secondSession, err := readingSessionService.StartReadingSession(1, 1, &StartReadingSessionBody{StartedAt: 1600}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1600), sessionStorageMock.Sessions[firstSession.Id].EndedAt)
+	assert.Zero(t, sessionStorageMock.Sessions[secondSession.Id].EndedAt)
+
+	// Test the start defaults to now
+	before := time.Now().Unix()
+	thirdSession, err := readingSessionService.StartReadingSession(1, 1, &StartReadingSessionBody{}, nil)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, thirdSession.StartedAt, before)
+
+	// Test the books of other users are not found
+	_, err = readingSessionService.StartReadingSession(1, 2, &StartReadingSessionBody{}, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	_, err = readingSessionService.StartReadingSession(1, 3, &StartReadingSessionBody{}, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	assert.Len(t, sessionStorageMock.Sessions, 3)
+}
+
+func TestStopReadingSession(t *testing.T) {
+	sessionStorageMock := setUpReadingSessionMocks(t)
+	sessionStorageMock.Sessions[1] = &models.ReadingSession{Id: 1, BookRegistrationRefer: 1, UserRefer: 1, StartedAt: 1000}
+	sessionStorageMock.Sessions[2] = &models.ReadingSession{Id: 2, BookRegistrationRefer: 2, UserRefer: 2, StartedAt: 1000}
+
+	page := 42
+	percentage := 12.5
+	stopBody := &StopReadingSessionBody{EndedAt: 900, Page: &page, Percentage: &percentage, Position: "epubcfi(/6/4!/4/2/1:0)"}
+
+	// Test a session cannot end before it started
+	_, err := readingSessionService.StopReadingSession(1, 1, 1, stopBody, nil)
+	assert.ErrorIs(t, err, ErrInvalidReadingSessionEnd)
+
+	stopBody.EndedAt = 2800
+	stoppedSession, err := readingSessionService.StopReadingSession(1, 1, 1, stopBody, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2800), stoppedSession.EndedAt)
+	assert.Equal(t, 42, *sessionStorageMock.Sessions[1].Page)
+	assert.Equal(t, 12.5, *sessionStorageMock.Sessions[1].Percentage)
+	assert.Equal(t, "epubcfi(/6/4!/4/2/1:0)", sessionStorageMock.Sessions[1].Position)
+
+	// Test stopped sessions cannot be stopped again
+	_, err = readingSessionService.StopReadingSession(1, 1, 1, stopBody, nil)
+	assert.ErrorIs(t, err, ErrReadingSessionAlreadyStopped)
+
+	// Test the sessions of other books and users are not found
+	_, err = readingSessionService.StopReadingSession(1, 1, 2, stopBody, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	_, err = readingSessionService.StopReadingSession(1, 2, 2, stopBody, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	assert.Zero(t, sessionStorageMock.Sessions[2].EndedAt)
+}
+
+func TestGetBookReadingProgress(t *testing.T) {
+	sessionStorageMock := setUpReadingSessionMocks(t)
+
+	progress, err := readingSessionService.GetBookReadingProgress(1, 1)
+	assert.NoError(t, err)
+	assert.Zero(t, progress.SessionCount)
+	assert.Nil(t, progress.Page)
+	assert.Nil(t, progress.ActiveSession)
+
+	page := 10
+	percentage := 55.0
+	sessionStorageMock.Sessions[1] = &models.ReadingSession{Id: 1, BookRegistrationRefer: 1, UserRefer: 1, StartedAt: 1000, EndedAt: 1600, Page: &page}
+	sessionStorageMock.Sessions[2] = &models.ReadingSession{Id: 2, BookRegistrationRefer: 1, UserRefer: 1, StartedAt: 2000, EndedAt: 2300, Percentage: &percentage, Position: "epubcfi(/6/8)"}
+
+	progress, err = readingSessionService.GetBookReadingProgress(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, progress.SessionCount)
+	assert.Equal(t, int64(900), progress.TotalReadingSeconds)
+	assert.Equal(t, int64(2300), progress.LastReadAt)
+	assert.Equal(t, 10, *progress.Page)
+	assert.Equal(t, 55.0, *progress.Percentage)
+	assert.Equal(t, "epubcfi(/6/8)", progress.Position)
+	assert.Nil(t, progress.ActiveSession)
+
+	// Test an active session counts until now
+	activeStart := time.Now().Unix() - 60
+	sessionStorageMock.Sessions[3] = &models.ReadingSession{Id: 3, BookRegistrationRefer: 1, UserRefer: 1, StartedAt: activeStart}
+
+	progress, err = readingSessionService.GetBookReadingProgress(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), progress.ActiveSession.Id)
+	assert.GreaterOrEqual(t, progress.TotalReadingSeconds, int64(960))
+	assert.Equal(t, "epubcfi(/6/8)", progress.Position)
+
+	_, err = readingSessionService.GetBookReadingProgress(2, 1)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
+
+func TestGetReadingTime(t *testing.T) {
+	sessionStorageMock := setUpReadingSessionMocks(t)
+	user := &models.User{Id: 1, TimeZone: "Europe/Madrid"}
+	location := GetUserLocation(user)
+	startDate, _, _ := GetLocalDayRange("2026-03-28", location)
+	_, endDate, _ := GetLocalDayRange("2026-03-30", location)
+
+	// a session across midnight, one across the daylight saving time change, and one starting before the range
+	sessionStorageMock.Sessions[1] = &models.ReadingSession{Id: 1, UserRefer: 1, BookRegistrationRefer: 1,
+		StartedAt: time.Date(2026, 3, 28, 23, 30, 0, 0, location).Unix(), EndedAt: time.Date(2026, 3, 29, 0, 15, 0, 0, location).Unix()}
+	sessionStorageMock.Sessions[2] = &models.ReadingSession{Id: 2, UserRefer: 1, BookRegistrationRefer: 1,
+		StartedAt: time.Date(2026, 3, 29, 1, 30, 0, 0, location).Unix(), EndedAt: time.Date(2026, 3, 29, 3, 30, 0, 0, location).Unix()}
+	sessionStorageMock.Sessions[3] = &models.ReadingSession{Id: 3, UserRefer: 1, BookRegistrationRefer: 1,
+		StartedAt: time.Date(2026, 3, 27, 23, 0, 0, 0, location).Unix(), EndedAt: time.Date(2026, 3, 28, 0, 20, 0, 0, location).Unix()}
+	sessionStorageMock.Sessions[4] = &models.ReadingSession{Id: 4, UserRefer: 2, BookRegistrationRefer: 2,
+		StartedAt: time.Date(2026, 3, 28, 10, 0, 0, 0, location).Unix(), EndedAt: time.Date(2026, 3, 28, 11, 0, 0, 0, location).Unix()}
+
+	readingTime, err := readingSessionService.GetReadingTime(user, startDate, endDate)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Madrid", readingTime.TimeZone)
+	assert.Len(t, readingTime.Days, 3)
+	assert.Equal(t, "2026-03-28", readingTime.Days[0].Date)
+	assert.Equal(t, int64(50*60), readingTime.Days[0].ReadingSeconds)
+	assert.Equal(t, 2, readingTime.Days[0].SessionCount)
+	assert.Equal(t, "2026-03-29", readingTime.Days[1].Date)
+	// clocks move forward from 2:00 to 3:00, so the second session lasts one hour
+	assert.Equal(t, int64(75*60), readingTime.Days[1].ReadingSeconds)
+	assert.Equal(t, 2, readingTime.Days[1].SessionCount)
+	assert.Zero(t, readingTime.Days[2].ReadingSeconds)
+	assert.Zero(t, readingTime.Days[2].SessionCount)
+	assert.Equal(t, int64(125*60), readingTime.TotalSeconds)
+
+	// Test reversed and too long ranges
+	_, err = readingSessionService.GetReadingTime(user, endDate, startDate)
+	assert.ErrorIs(t, err, ErrInvalidReadingDateRange)
+	_, err = readingSessionService.GetReadingTime(user, startDate, startDate+400*24*60*60)
+	assert.ErrorIs(t, err, ErrInvalidReadingDateRange)
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/adfer-dev/analock-api/constants"
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// ShareLinkTokenPrefix tells share link tokens apart from other secrets.
+	ShareLinkTokenPrefix = "alk_shr_"
+	// shareLinkDisplayLength is the length of the token start kept to recognise the link in listings.
+	shareLinkDisplayLength = len(ShareLinkTokenPrefix) + 6
+	shareLinkTokenBytes    = 32
+)
+
+var (
+	ErrEncryptedDiaryEntryShare    = errors.New("end-to-end encrypted diary entries cannot be shared, as the server cannot read them")
+	ErrShareLinkPassphraseRequired = errors.New("the share link is protected by a passphrase")
+	ErrInvalidShareLinkPassphrase  = errors.New("the share link passphrase is not valid")
+	ErrShareLinkExpired            = errors.New("the share link has expired")
+	ErrShareLinkViewLimitReached   = errors.New("the share link has reached its view limit")
+)
+
+type CreateShareLinkBody struct {
+	ExpiresInHours int `json:"expiresInHours" validate:"required,min=1,max=720"`
+	// Passphrase is limited to the 72 bytes hashed by bcrypt.
+	Passphrase string `json:"passphrase" validate:"omitempty,min=8,max=72"`
+	MaxViews   int    `json:"maxViews" validate:"omitempty,min=1,max=1000"`
+}
+
+// CreatedShareLinkResponse holds a new share link. The token, and so the URL, are only returned here.
+type CreatedShareLinkResponse struct {
+	Token     string            `json:"token"`
+	Url       string            `json:"url"`
+	ShareLink *models.ShareLink `json:"shareLink"`
+}
+
+var shareLinkStorage storage.ShareLinkStorageInterface = &storage.ShareLinkStorage{}
+
+// ShareLinkService defines all operations for the diary entry share link service.
+type ShareLinkService interface {
+	GetUserShareLinks(userId uint) ([]*models.ShareLink, error)
+	CreateShareLink(userId uint, diaryEntryId uint, createBody *CreateShareLinkBody, auditMetadata *AuditMetadata) (*CreatedShareLinkResponse, error)
+	DeleteShareLink(userId uint, id uint, auditMetadata *AuditMetadata) error
+	GetSharedDiaryEntry(tokenValue string, passphrase string) (*models.SharedDiaryEntry, error)
+}
+
+// ShareLinkServiceImpl is the concrete implementation of ShareLinkService.
+type ShareLinkServiceImpl struct {
+	diaryEntryService DiaryEntryService
+}
+
+// NewShareLinkServiceImpl creates a new ShareLinkServiceImpl.
+func NewShareLinkServiceImpl(diaryEntryService DiaryEntryService) *ShareLinkServiceImpl {
+	return &ShareLinkServiceImpl{diaryEntryService: diaryEntryService}
+}
+
+func (shareLinkService *ShareLinkServiceImpl) GetUserShareLinks(userId uint) ([]*models.ShareLink, error) {
+	shareLinks, err := shareLinkStorage.GetByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return shareLinks.([]*models.ShareLink), nil
+}
+
+// CreateShareLink creates a link to a diary entry of the user. Diary entries of other users are reported as not found.
+func (shareLinkService *ShareLinkServiceImpl) CreateShareLink(userId uint, diaryEntryId uint, createBody *CreateShareLinkBody, auditMetadata *AuditMetadata) (*CreatedShareLinkResponse, error) {
+	diaryEntry, getErr := shareLinkService.diaryEntryService.GetDiaryEntryById(diaryEntryId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if diaryEntry.Registration.UserRefer != userId {
+		return nil, &models.DbNotFoundError{DbItem: &models.DiaryEntry{}}
+	}
+
+	if diaryEntry.Encryption != nil {
+		return nil, ErrEncryptedDiaryEntryShare
+	}
+
+	tokenValue, generateErr := generateShareLinkToken()
+
+	if generateErr != nil {
+		return nil, generateErr
+	}
+
+	now := time.Now()
+	shareLink := &models.ShareLink{
+		DiaryEntryRefer: diaryEntryId,
+		UserRefer:       userId,
+		Prefix:          tokenValue[:shareLinkDisplayLength],
+		TokenHash:       hashShareLinkToken(tokenValue),
+		MaxViews:        createBody.MaxViews,
+		CreatedAt:       now.Unix(),
+		ExpiresAt:       now.Add(time.Duration(createBody.ExpiresInHours) * time.Hour).Unix(),
+	}
+
+	// passphrases are chosen by people, so unlike tokens they are hashed with a slow salted hash
+	if len(createBody.Passphrase) > 0 {
+		passphraseHash, hashErr := bcrypt.GenerateFromPassword([]byte(createBody.Passphrase), bcrypt.DefaultCost)
+
+		if hashErr != nil {
+			return nil, hashErr
+		}
+
+		shareLink.PassphraseHash = string(passphraseHash)
+		shareLink.HasPassphrase = true
+	}
+
+	if err := shareLinkStorage.Create(shareLink); err != nil {
+		return nil, err
+	}
+
+	auditService.RecordEvent(models.AuditShareLinkCreated, models.AuditTargetShareLink, shareLink.Id, auditMetadata, "")
+
+	return &CreatedShareLinkResponse{
+		Token:     tokenValue,
+		Url:       constants.ApiV1UrlRoot + "/shared/" + tokenValue,
+		ShareLink: shareLink,
+	}, nil
+}
+
+// DeleteShareLink revokes a share link of the user. Links of other users are reported as not found.
+func (shareLinkService *ShareLinkServiceImpl) DeleteShareLink(userId uint, id uint, auditMetadata *AuditMetadata) error {
+	shareLink, getErr := shareLinkStorage.Get(id)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	if shareLink.(*models.ShareLink).UserRefer != userId {
+		return &models.DbNotFoundError{DbItem: &models.ShareLink{}}
+	}
+
+	if err := shareLinkStorage.Delete(id); err != nil {
+		return err
+	}
+
+	auditService.RecordEvent(models.AuditShareLinkRevoked, models.AuditTargetShareLink, id, auditMetadata, "")
+
+	return nil
+}
+
+// GetSharedDiaryEntry returns the diary entry of a share link, counting the view.
+// Wrong passphrases are rejected before the view is counted, so they do not use up the views of the link.
+func (shareLinkService *ShareLinkServiceImpl) GetSharedDiaryEntry(tokenValue string, passphrase string) (*models.SharedDiaryEntry, error) {
+	storedShareLink, getErr := shareLinkStorage.GetByHash(hashShareLinkToken(tokenValue))
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	shareLink := storedShareLink.(*models.ShareLink)
+	now := time.Now().Unix()
+
+	if now >= shareLink.ExpiresAt {
+		return nil, ErrShareLinkExpired
+	}
+
+	if shareLink.HasPassphrase {
+		if len(passphrase) == 0 {
+			return nil, ErrShareLinkPassphraseRequired
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(shareLink.PassphraseHash), []byte(passphrase)) != nil {
+			return nil, ErrInvalidShareLinkPassphrase
+		}
+	}
+
+	diaryEntry, entryErr := shareLinkService.diaryEntryService.GetDiaryEntryById(shareLink.DiaryEntryRefer)
+
+	// entries moved to the trash are not found, so they are not shared until they are restored
+	if entryErr != nil {
+		return nil, entryErr
+	}
+
+	// the entry may have been encrypted end-to-end after the link was created
+	if diaryEntry.Encryption != nil {
+		return nil, &models.DbNotFoundError{DbItem: &models.DiaryEntry{}}
+	}
+
+	previousViewCount := shareLink.ViewCount
+	counted, countErr := shareLinkStorage.IncrementViewCount(shareLink.Id, now)
+
+	if countErr != nil {
+		return nil, countErr
+	}
+
+	if !counted {
+		return nil, ErrShareLinkViewLimitReached
+	}
+
+	sharedDiaryEntry := &models.SharedDiaryEntry{
+		Title:       diaryEntry.Title,
+		Content:     diaryEntry.Content,
+		PublishDate: diaryEntry.Registration.RegistrationDate,
+		ExpiresAt:   shareLink.ExpiresAt,
+	}
+
+	if shareLink.MaxViews > 0 {
+		remainingViews := max(shareLink.MaxViews-previousViewCount-1, 0)
+		sharedDiaryEntry.RemainingViews = &remainingViews
+	}
+
+	return sharedDiaryEntry, nil
+}
+
+func generateShareLinkToken() (string, error) {
+	tokenBytes := make([]byte, shareLinkTokenBytes)
+
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+
+	return ShareLinkTokenPrefix + hex.EncodeToString(tokenBytes), nil
+}
+
+// hashShareLinkToken hashes a token for storage. As with personal access tokens, a fast unsalted hash is enough for random tokens.
+func hashShareLinkToken(tokenValue string) string {
+	tokenHash := sha256.Sum256([]byte(tokenValue))
+
+	return hex.EncodeToString(tokenHash[:])
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockShareLinkStorage implements ShareLinkStorageInterface
+type mockShareLinkStorage struct {
+	ShareLinks []*models.ShareLink
+}
+
+func (m *mockShareLinkStorage) Get(id uint) (interface{}, error) {
+	for _, shareLink := range m.ShareLinks {
+		if shareLink.Id == id {
+			return shareLink, nil
+		}
+	}
+	return nil, &models.DbNotFoundError{DbItem: &models.ShareLink{}}
+}
+
+func (m *mockShareLinkStorage) GetByHash(tokenHash string) (interface{}, error) {
+	for _, shareLink := range m.ShareLinks {
+		if shareLink.TokenHash == tokenHash {
+			return shareLink, nil
+		}
+	}
+	return nil, &models.DbNotFoundError{DbItem: &models.ShareLink{}}
+}
+
+func (m *mockShareLinkStorage) GetByUserId(userId uint) (interface{}, error) {
+	shareLinks := []*models.ShareLink{}
+	for _, shareLink := range m.ShareLinks {
+		if shareLink.UserRefer == userId {
+			shareLinks = append(shareLinks, shareLink)
+		}
+	}
+	return shareLinks, nil
+}
+
+func (m *mockShareLinkStorage) Create(data interface{}) error {
+	shareLink := data.(*models.ShareLink)
+	shareLink.Id = uint(len(m.ShareLinks) + 1)
+	m.ShareLinks = append(m.ShareLinks, shareLink)
+	return nil
+}
+
+func (m *mockShareLinkStorage) IncrementViewCount(id uint, now int64) (bool, error) {
+	for _, shareLink := range m.ShareLinks {
+		if shareLink.Id == id && shareLink.ExpiresAt > now && (shareLink.MaxViews == 0 || shareLink.ViewCount < shareLink.MaxViews) {
+			shareLink.ViewCount++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockShareLinkStorage) Delete(id uint) error {
+	for i, shareLink := range m.ShareLinks {
+		if shareLink.Id == id {
+			m.ShareLinks = append(m.ShareLinks[:i], m.ShareLinks[i+1:]...)
+			return nil
+		}
+	}
+	return &models.DbNotFoundError{DbItem: &models.ShareLink{}}
+}
+
+func setUpShareLinkMocks(t *testing.T) (*mockShareLinkStorage, *mockDiaryEntryStorage) {
+	diaryEntryStorageMock, _ := setUpDiaryEntryRevisionMocks(t)
+	originalShareLinkStorage := shareLinkStorage
+	shareLinkStorageMock := &mockShareLinkStorage{}
+	shareLinkStorage = shareLinkStorageMock
+	t.Cleanup(func() {
+		shareLinkStorage = originalShareLinkStorage
+	})
+
+	diaryEntryStorageMock.Entries[1] = &models.DiaryEntry{Id: 1, Title: "Shared", Content: "Shared content",
+		Registration: models.ActivityRegistration{Id: 10, UserRefer: 1, RegistrationDate: 1700000000}}
+	diaryEntryStorageMock.Entries[2] = &models.DiaryEntry{Id: 2, Title: "enc:v1:title", Content: "enc:v1:content",
+		Encryption: &models.DiaryEntryEncryption{Algorithm: models.EncryptionAlgorithmAesGcm}, Registration: models.ActivityRegistration{Id: 20, UserRefer: 1}}
+
+	return shareLinkStorageMock, diaryEntryStorageMock
+}
+
+func TestCreateShareLink(t *testing.T) {
+	shareLinkStorageMock, _ := setUpShareLinkMocks(t)
+	shareLinkService := NewShareLinkServiceImpl(diaryEntryService)
+
+	createdShareLink, err := shareLinkService.CreateShareLink(1, 1, &CreateShareLinkBody{ExpiresInHours: 24, Passphrase: "correct horse", MaxViews: 3}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(createdShareLink.Token, ShareLinkTokenPrefix))
+	assert.Equal(t, "/api/v1/shared/"+createdShareLink.Token, createdShareLink.Url)
+	assert.True(t, strings.HasPrefix(createdShareLink.Token, createdShareLink.ShareLink.Prefix))
+	assert.True(t, createdShareLink.ShareLink.HasPassphrase)
+	assert.NotEqual(t, createdShareLink.Token, createdShareLink.ShareLink.TokenHash)
+	assert.NotContains(t, createdShareLink.ShareLink.PassphraseHash, "correct horse")
+	assert.InDelta(t, time.Now().Add(24*time.Hour).Unix(), createdShareLink.ShareLink.ExpiresAt, 5)
+	assert.Len(t, shareLinkStorageMock.ShareLinks, 1)
+
+	// Test the entries of other users are not found
+	_, err = shareLinkService.CreateShareLink(2, 1, &CreateShareLinkBody{ExpiresInHours: 24}, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+
+	_, err = shareLinkService.CreateShareLink(1, 2, &CreateShareLinkBody{ExpiresInHours: 24}, nil)
+	assert.ErrorIs(t, err, ErrEncryptedDiaryEntryShare)
+}
+
+func TestGetSharedDiaryEntry(t *testing.T) {
+	shareLinkStorageMock, diaryEntryStorageMock := setUpShareLinkMocks(t)
+	shareLinkService := NewShareLinkServiceImpl(diaryEntryService)
+
+	createdShareLink, err := shareLinkService.CreateShareLink(1, 1, &CreateShareLinkBody{ExpiresInHours: 1, Passphrase: "correct horse", MaxViews: 2}, nil)
+	assert.NoError(t, err)
+
+	_, err = shareLinkService.GetSharedDiaryEntry(createdShareLink.Token, "")
+	assert.ErrorIs(t, err, ErrShareLinkPassphraseRequired)
+	_, err = shareLinkService.GetSharedDiaryEntry(createdShareLink.Token, "wrong horse")
+	assert.ErrorIs(t, err, ErrInvalidShareLinkPassphrase)
+
+	// Test wrong passphrases do not use up the views
+	sharedDiaryEntry, err := shareLinkService.GetSharedDiaryEntry(createdShareLink.Token, "correct horse")
+	assert.NoError(t, err)
+	assert.Equal(t, "Shared", sharedDiaryEntry.Title)
+	assert.Equal(t, "Shared content", sharedDiaryEntry.Content)
+	assert.Equal(t, int64(1700000000), sharedDiaryEntry.PublishDate)
+	assert.Equal(t, 1, *sharedDiaryEntry.RemainingViews)
+
+	sharedDiaryEntry, err = shareLinkService.GetSharedDiaryEntry(createdShareLink.Token, "correct horse")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, *sharedDiaryEntry.RemainingViews)
+
+	_, err = shareLinkService.GetSharedDiaryEntry(createdShareLink.Token, "correct horse")
+	assert.ErrorIs(t, err, ErrShareLinkViewLimitReached)
+
+	// Test links without passphrase nor view limit
+	createdShareLink, err = shareLinkService.CreateShareLink(1, 1, &CreateShareLinkBody{ExpiresInHours: 1}, nil)
+	assert.NoError(t, err)
+	sharedDiaryEntry, err = shareLinkService.GetSharedDiaryEntry(createdShareLink.Token, "")
+	assert.NoError(t, err)
+	assert.Nil(t, sharedDiaryEntry.RemainingViews)
+
+	// Test expired links
+	createdShareLink.ShareLink.ExpiresAt = time.Now().Unix() - 1
+	_, err = shareLinkService.GetSharedDiaryEntry(createdShareLink.Token, "")
+	assert.ErrorIs(t, err, ErrShareLinkExpired)
+
+	// Test entries moved to the trash are not shared
+	shareLinkStorageMock.ShareLinks[1].ExpiresAt = time.Now().Add(time.Hour).Unix()
+	delete(diaryEntryStorageMock.Entries, 1)
+	_, err = shareLinkService.GetSharedDiaryEntry(createdShareLink.Token, "")
+	assert.Error(t, err)
+
+	_, err = shareLinkService.GetSharedDiaryEntry(ShareLinkTokenPrefix+"unknown", "")
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
+
+func TestDeleteShareLink(t *testing.T) {
+	shareLinkStorageMock, _ := setUpShareLinkMocks(t)
+	shareLinkService := NewShareLinkServiceImpl(diaryEntryService)
+
+	createdShareLink, err := shareLinkService.CreateShareLink(1, 1, &CreateShareLinkBody{ExpiresInHours: 1}, nil)
+	assert.NoError(t, err)
+
+	shareLinks, err := shareLinkService.GetUserShareLinks(1)
+	assert.NoError(t, err)
+	assert.Len(t, shareLinks, 1)
+
+	// Test the links of other users are not found
+	err = shareLinkService.DeleteShareLink(2, createdShareLink.ShareLink.Id, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+
+	assert.NoError(t, shareLinkService.DeleteShareLink(1, createdShareLink.ShareLink.Id, nil))
+	assert.Empty(t, shareLinkStorageMock.ShareLinks)
+
+	// Test revoked links are not found
+	_, err = shareLinkService.GetSharedDiaryEntry(createdShareLink.Token, "")
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
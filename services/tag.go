@@ -0,0 +1,125 @@
+package services
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+)
+
+type RenameTagBody struct {
+	Name string `json:"name" validate:"required,max=32,excludesall=0x2C"`
+}
+
+type MergeTagsBody struct {
+	SourceTagIds []uint `json:"sourceTagIds" validate:"required,min=1,max=50,dive,required"`
+}
+
+var tagStorage storage.TagStorageInterface = &storage.TagStorage{}
+
+// TagService defines all operations for the tag service.
+type TagService interface {
+	GetUserTags(userId uint) ([]*models.TagUsage, error)
+	RenameTag(userId uint, tagId uint, renameBody *RenameTagBody, auditMetadata *AuditMetadata) (*models.Tag, error)
+	MergeTags(userId uint, targetTagId uint, mergeBody *MergeTagsBody, auditMetadata *AuditMetadata) error
+}
+
+// TagServiceImpl is the concrete implementation of TagService.
+type TagServiceImpl struct{}
+
+func (tagService *TagServiceImpl) GetUserTags(userId uint) ([]*models.TagUsage, error) {
+	tagUsages, err := tagStorage.GetUsagesByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tagUsages.([]*models.TagUsage), nil
+}
+
+// RenameTag renames a tag of the user, which renames it on every tagged diary entry.
+func (tagService *TagServiceImpl) RenameTag(userId uint, tagId uint, renameBody *RenameTagBody, auditMetadata *AuditMetadata) (*models.Tag, error) {
+	tag, getErr := getUserTag(userId, tagId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	previousName := tag.Name
+	tag.Name = strings.TrimSpace(renameBody.Name)
+
+	if err := tagStorage.Rename(tagId, tag.Name); err != nil {
+		return nil, err
+	}
+
+	auditService.RecordEvent(models.AuditTagRenamed, models.AuditTargetTag, tagId, auditMetadata, previousName+" -> "+tag.Name)
+
+	return tag, nil
+}
+
+// MergeTags moves the diary entries of the source tags to the target tag, deleting the source tags.
+// All tags must belong to the user.
+func (tagService *TagServiceImpl) MergeTags(userId uint, targetTagId uint, mergeBody *MergeTagsBody, auditMetadata *AuditMetadata) error {
+	if _, getErr := getUserTag(userId, targetTagId); getErr != nil {
+		return getErr
+	}
+
+	for _, sourceTagId := range mergeBody.SourceTagIds {
+		if _, getErr := getUserTag(userId, sourceTagId); getErr != nil {
+			return getErr
+		}
+	}
+
+	for _, sourceTagId := range mergeBody.SourceTagIds {
+		if sourceTagId == targetTagId {
+			continue
+		}
+
+		if err := tagStorage.Merge(sourceTagId, targetTagId); err != nil {
+			return err
+		}
+
+		auditService.RecordEvent(models.AuditTagMerged, models.AuditTargetTag, targetTagId, auditMetadata, "merged tag "+strconv.FormatUint(uint64(sourceTagId), 10))
+	}
+
+	return nil
+}
+
+// getUserTag returns a tag of the user. Tags of other users are reported as not found.
+func getUserTag(userId uint, tagId uint) (*models.Tag, error) {
+	storedTag, err := tagStorage.Get(tagId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tag := storedTag.(*models.Tag)
+
+	if tag.UserRefer != userId {
+		return nil, &models.DbNotFoundError{DbItem: &models.Tag{}}
+	}
+
+	return tag, nil
+}
+
+// normalizeTagNames trims the tag names and removes the empty and repeated ones, ignoring case.
+// The result is sorted by name.
+func normalizeTagNames(tagNames []string) []string {
+	normalizedTagNames := make([]string, 0, len(tagNames))
+
+	for _, tagName := range tagNames {
+		trimmedTagName := strings.TrimSpace(tagName)
+
+		if len(trimmedTagName) == 0 || slices.ContainsFunc(normalizedTagNames, func(name string) bool { return strings.EqualFold(name, trimmedTagName) }) {
+			continue
+		}
+
+		normalizedTagNames = append(normalizedTagNames, trimmedTagName)
+	}
+
+	slices.Sort(normalizedTagNames)
+
+	return normalizedTagNames
+}
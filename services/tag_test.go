@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockTagStorage implements TagStorageInterface
+type mockTagStorage struct {
+	Tags       map[uint]*models.Tag
+	MergedTags [][2]uint
+
+	RenameErr error
+}
+
+func newMockTagStorage() *mockTagStorage {
+	return &mockTagStorage{Tags: make(map[uint]*models.Tag)}
+}
+
+func (m *mockTagStorage) Get(id uint) (interface{}, error) {
+	tag, ok := m.Tags[id]
+	if !ok {
+		return nil, &models.DbNotFoundError{DbItem: &models.Tag{}}
+	}
+	tagCopy := *tag
+	return &tagCopy, nil
+}
+
+func (m *mockTagStorage) GetUsagesByUserId(userId uint) (interface{}, error) {
+	tagUsages := []*models.TagUsage{}
+	for _, tag := range m.Tags {
+		if tag.UserRefer == userId {
+			tagUsages = append(tagUsages, &models.TagUsage{Tag: *tag})
+		}
+	}
+	return tagUsages, nil
+}
+
+func (m *mockTagStorage) Rename(id uint, name string) error {
+	if m.RenameErr != nil {
+		return m.RenameErr
+	}
+	m.Tags[id].Name = name
+	return nil
+}
+
+func (m *mockTagStorage) Merge(sourceId uint, targetId uint) error {
+	m.MergedTags = append(m.MergedTags, [2]uint{sourceId, targetId})
+	delete(m.Tags, sourceId)
+	return nil
+}
+
+func TestRenameTag(t *testing.T) {
+	originalTagStorage := tagStorage
+	tagStorageMock := newMockTagStorage()
+	tagStorage = tagStorageMock
+	defer func() { tagStorage = originalTagStorage }()
+
+	tagService := &TagServiceImpl{}
+	tagStorageMock.Tags[1] = &models.Tag{Id: 1, Name: "trip", UserRefer: 1}
+
+	// Test successful rename
+	renamedTag, err := tagService.RenameTag(1, 1, &RenameTagBody{Name: " travel "}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "travel", renamedTag.Name)
+	assert.Equal(t, "travel", tagStorageMock.Tags[1].Name)
+
+	// Test tags of other users are not found
+	_, err = tagService.RenameTag(2, 1, &RenameTagBody{Name: "other"}, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+
+	// Test storage error
+	tagStorageMock.RenameErr = &models.DbItemAlreadyExistsError{DbItem: &models.Tag{}}
+	_, err = tagService.RenameTag(1, 1, &RenameTagBody{Name: "work"}, nil)
+	assert.IsType(t, &models.DbItemAlreadyExistsError{}, err)
+}
+
+func TestMergeTags(t *testing.T) {
+	originalTagStorage := tagStorage
+	tagStorageMock := newMockTagStorage()
+	tagStorage = tagStorageMock
+	defer func() { tagStorage = originalTagStorage }()
+
+	tagService := &TagServiceImpl{}
+	tagStorageMock.Tags[1] = &models.Tag{Id: 1, Name: "travel", UserRefer: 1}
+	tagStorageMock.Tags[2] = &models.Tag{Id: 2, Name: "trip", UserRefer: 1}
+	tagStorageMock.Tags[3] = &models.Tag{Id: 3, Name: "trips", UserRefer: 1}
+	tagStorageMock.Tags[4] = &models.Tag{Id: 4, Name: "other user tag", UserRefer: 2}
+
+	// Test nothing is merged when a source tag belongs to other user
+	err := tagService.MergeTags(1, 1, &MergeTagsBody{SourceTagIds: []uint{2, 4}}, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	assert.Empty(t, tagStorageMock.MergedTags)
+
+	// Test successful merge, ignoring the target tag as source
+	err = tagService.MergeTags(1, 1, &MergeTagsBody{SourceTagIds: []uint{2, 3, 1}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]uint{{2, 1}, {3, 1}}, tagStorageMock.MergedTags)
+	assert.Contains(t, tagStorageMock.Tags, uint(1))
+
+	// Test missing target tag
+	err = tagService.MergeTags(1, 5, &MergeTagsBody{SourceTagIds: []uint{1}}, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
+
+func TestDiaryEntryTags(t *testing.T) {
+	originalDiaryEntryStorage := diaryEntryStorage
+	originalActivityRegistrationStorage := activityRegistrationStorage
+	diaryEntryStorageMock := &mockDiaryEntryStorage{
+		Entries:     make(map[uint]*models.DiaryEntry),
+		UserEntries: make(map[uint][]*models.DiaryEntry),
+	}
+	diaryEntryStorage = diaryEntryStorageMock
+	activityRegistrationStorage = &mockActivityRegistrationStorage{}
+	defer func() {
+		diaryEntryStorage = originalDiaryEntryStorage
+		activityRegistrationStorage = originalActivityRegistrationStorage
+	}()
+
+	// Test tags are normalized when saving, and stored with the entry
+	createdEntry, err := diaryEntryService.SaveDiaryEntry(1, &SaveDiaryEntryBody{
+		Title: "Trip", Content: "Content", PublishDate: time.Now().Unix(),
+		Tags: []string{" Travel", "beach", "travel", ""},
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Travel", "beach"}, createdEntry.Tags)
+	assert.Equal(t, createdEntry.Tags, diaryEntryStorageMock.Entries[createdEntry.Id].Tags)
+
+	// Test tags are kept when not provided on update
+	updateBody := &UpdateDiaryEntryBody{Title: "Trip", Content: "Updated", PublishDate: time.Now().Unix()}
+	updatedEntry, err := diaryEntryService.UpdateDiaryEntry(createdEntry.Id, updateBody, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Travel", "beach"}, updatedEntry.Tags)
+	assert.Equal(t, []string{"Travel", "beach"}, diaryEntryStorageMock.Entries[createdEntry.Id].Tags)
+
+	// Test an empty list removes the tags
+	updateBody.Tags = []string{}
+	updatedEntry, err = diaryEntryService.UpdateDiaryEntry(createdEntry.Id, updateBody, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, updatedEntry.Tags)
+	assert.Empty(t, diaryEntryStorageMock.Entries[createdEntry.Id].Tags)
+
+	// Test the tags are not changed when the entry update fails
+	diaryEntryStorageMock.UpdateErr = errors.New("diary entry storage failed")
+	updateBody.Tags = []string{"work"}
+	_, err = diaryEntryService.UpdateDiaryEntry(createdEntry.Id, updateBody, nil)
+	assert.EqualError(t, err, "diary entry storage failed")
+	assert.Empty(t, diaryEntryStorageMock.Entries[createdEntry.Id].Tags)
+}
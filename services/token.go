@@ -21,7 +21,7 @@ type TokenService interface {
 	GetUserTokenPair(userId uint) ([2]*models.Token, error)
 	SaveToken(tokenBody *models.Token) (*models.Token, error)
 	UpdateToken(tokenBody *models.Token) (*models.Token, error)
-	DeleteToken(id uint) error
+	DeleteToken(id uint, auditMetadata *AuditMetadata) error
 }
 
 // TokenServiceImpl is the concrete implementation of TokenService.
@@ -80,6 +80,13 @@ func (tokenService *TokenServiceImpl) UpdateToken(tokenBody *models.Token) (*mod
 	return tokenBody, nil
 }
 
-func (tokenService *TokenServiceImpl) DeleteToken(id uint) error {
-	return tokenStorage.Delete(id)
+// DeleteToken revokes a token by deleting it.
+func (tokenService *TokenServiceImpl) DeleteToken(id uint, auditMetadata *AuditMetadata) error {
+	err := tokenStorage.Delete(id)
+	if err != nil {
+		return err
+	}
+
+	auditService.RecordEvent(models.AuditTokenRevoked, models.AuditTargetToken, id, auditMetadata, "")
+	return nil
 }
@@ -74,7 +74,7 @@ func (m *mockTokenStorage) GetByUserId(userId uint) ([2]*models.Token, error) {
 	}
 	pair, ok := m.TokenPairByUserID[userId]
 	if !ok {
-		return [2]*models.Token{}, errors.New("token pair not found for user")
+		return [2]*models.Token{}, &models.DbNotFoundError{DbItem: &models.Token{}}
 	}
 	return pair, nil
 }
@@ -287,19 +287,19 @@ func TestDeleteToken(t *testing.T) {
 	tokenStorageMock.TokensByValue[tokenToDelete.TokenValue] = tokenToDelete
 	tokenStorageMock.TokensByUserAndKind[getTokenStorageKey(tokenToDelete.UserRefer, tokenToDelete.Kind)] = tokenToDelete
 
-	err := tokenService.DeleteToken(tokenToDelete.Id)
+	err := tokenService.DeleteToken(tokenToDelete.Id, nil)
 	assert.NoError(t, err)
 	_, exists := tokenStorageMock.TokensById[tokenToDelete.Id]
 	assert.False(t, exists)
 
 	// Test deleting non-existent
-	err = tokenService.DeleteToken(999)
+	err = tokenService.DeleteToken(999, nil)
 	assert.Error(t, err) // Mock returns error for not found
 
 	tokenStorageMock.DeleteErr = errors.New("forced Delete error")
 	// Re-add the token so the delete operation has something to target before the forced error
 	tokenStorageMock.TokensById[tokenToDelete.Id] = tokenToDelete
-	err = tokenService.DeleteToken(tokenToDelete.Id)
+	err = tokenService.DeleteToken(tokenToDelete.Id, nil)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "forced Delete error")
 }
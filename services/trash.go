@@ -0,0 +1,276 @@
+package services
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	defaultTrashRetentionDays = 30
+	trashRetentionDaysEnv     = "TRASH_RETENTION_DAYS"
+	trashPurgeInterval        = time.Hour
+)
+
+// Trash holds the diary entries and activity registrations deleted by a user, most recently deleted first.
+type Trash struct {
+	DiaryEntries      []*models.DiaryEntry               `json:"diaryEntries"`
+	BookRegistrations []*models.BookActivityRegistration `json:"bookRegistrations"`
+	GameRegistrations []*models.GameActivityRegistration `json:"gameRegistrations"`
+	// CustomRegistrations are the registrations of the activity types declared in the activity type registry.
+	CustomRegistrations []*models.CustomActivityRegistration `json:"customRegistrations"`
+	// RetentionDays is the number of days items are kept in the trash before being permanently deleted.
+	RetentionDays int `json:"retentionDays"`
+}
+
+// TrashService defines the operations on the items deleted by users.
+type TrashService interface {
+	GetUserTrash(userId uint) (*Trash, error)
+	RestoreDiaryEntry(userId uint, diaryEntryId uint, auditMetadata *AuditMetadata) (*models.DiaryEntry, error)
+	RestoreBookActivityRegistration(userId uint, registrationId uint, auditMetadata *AuditMetadata) (*models.BookActivityRegistration, error)
+	RestoreGameActivityRegistration(userId uint, registrationId uint, auditMetadata *AuditMetadata) (*models.GameActivityRegistration, error)
+	RestoreCustomActivityRegistration(userId uint, registrationId uint, auditMetadata *AuditMetadata) (*models.CustomActivityRegistration, error)
+	EmptyUserTrash(userId uint, auditMetadata *AuditMetadata) error
+	PurgeExpiredTrash() (int64, error)
+}
+
+type TrashServiceImpl struct{}
+
+func (trashService *TrashServiceImpl) GetUserTrash(userId uint) (*Trash, error) {
+	diaryEntries, err := diaryEntryStorage.GetTrashedByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bookRegistrations, err := bookActivityRegistrationStorage.GetTrashedByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gameRegistrations, err := gameActivityRegistrationStorage.GetTrashedByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	customRegistrations, err := customActivityRegistrationStorage.GetTrashedByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trash{
+		DiaryEntries:        diaryEntries.([]*models.DiaryEntry),
+		BookRegistrations:   bookRegistrations.([]*models.BookActivityRegistration),
+		GameRegistrations:   gameRegistrations.([]*models.GameActivityRegistration),
+		CustomRegistrations: customRegistrations.([]*models.CustomActivityRegistration),
+		RetentionDays:       getTrashRetentionDays(),
+	}, nil
+}
+
+// RestoreDiaryEntry moves a diary entry out of the trash of the user. Entries not in the trash are reported as not found.
+func (trashService *TrashServiceImpl) RestoreDiaryEntry(userId uint, diaryEntryId uint, auditMetadata *AuditMetadata) (*models.DiaryEntry, error) {
+	trashedDiaryEntries, err := diaryEntryStorage.GetTrashedByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, diaryEntry := range trashedDiaryEntries.([]*models.DiaryEntry) {
+		if diaryEntry.Id != diaryEntryId {
+			continue
+		}
+
+		if restoreErr := activityRegistrationStorage.Restore(diaryEntry.Registration.Id); restoreErr != nil {
+			return nil, restoreErr
+		}
+
+		diaryEntry.Registration.DeletedAt = 0
+		auditService.RecordEvent(models.AuditTrashItemRestored, models.AuditTargetDiaryEntry, diaryEntry.Id, auditMetadata, "")
+
+		return diaryEntry, nil
+	}
+
+	return nil, &models.DbNotFoundError{DbItem: &models.DiaryEntry{}}
+}
+
+// RestoreBookActivityRegistration moves a book registration out of the trash of the user.
+// Registrations not in the trash are reported as not found.
+func (trashService *TrashServiceImpl) RestoreBookActivityRegistration(userId uint, registrationId uint, auditMetadata *AuditMetadata) (*models.BookActivityRegistration, error) {
+	trashedRegistrations, err := bookActivityRegistrationStorage.GetTrashedByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bookRegistration := range trashedRegistrations.([]*models.BookActivityRegistration) {
+		if bookRegistration.Id != registrationId {
+			continue
+		}
+
+		if restoreErr := activityRegistrationStorage.Restore(bookRegistration.Registration.Id); restoreErr != nil {
+			return nil, restoreErr
+		}
+
+		bookRegistration.Registration.DeletedAt = 0
+		auditService.RecordEvent(models.AuditTrashItemRestored, models.AuditTargetBookRegistration, bookRegistration.Id, auditMetadata, "")
+
+		return bookRegistration, nil
+	}
+
+	return nil, &models.DbNotFoundError{DbItem: &models.BookActivityRegistration{}}
+}
+
+// RestoreGameActivityRegistration moves a game registration out of the trash of the user.
+// Registrations not in the trash are reported as not found.
+func (trashService *TrashServiceImpl) RestoreGameActivityRegistration(userId uint, registrationId uint, auditMetadata *AuditMetadata) (*models.GameActivityRegistration, error) {
+	trashedRegistrations, err := gameActivityRegistrationStorage.GetTrashedByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, gameRegistration := range trashedRegistrations.([]*models.GameActivityRegistration) {
+		if gameRegistration.Id != registrationId {
+			continue
+		}
+
+		if restoreErr := activityRegistrationStorage.Restore(gameRegistration.Registration.Id); restoreErr != nil {
+			return nil, restoreErr
+		}
+
+		gameRegistration.Registration.DeletedAt = 0
+		auditService.RecordEvent(models.AuditTrashItemRestored, models.AuditTargetGameRegistration, gameRegistration.Id, auditMetadata, "")
+
+		return gameRegistration, nil
+	}
+
+	return nil, &models.DbNotFoundError{DbItem: &models.GameActivityRegistration{}}
+}
+
+// RestoreCustomActivityRegistration moves a registration of a declared activity type out of the trash of the user.
+// Registrations not in the trash are reported as not found.
+func (trashService *TrashServiceImpl) RestoreCustomActivityRegistration(userId uint, registrationId uint, auditMetadata *AuditMetadata) (*models.CustomActivityRegistration, error) {
+	trashedRegistrations, err := customActivityRegistrationStorage.GetTrashedByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, customRegistration := range trashedRegistrations.([]*models.CustomActivityRegistration) {
+		if customRegistration.Id != registrationId {
+			continue
+		}
+
+		if restoreErr := activityRegistrationStorage.Restore(customRegistration.Registration.Id); restoreErr != nil {
+			return nil, restoreErr
+		}
+
+		customRegistration.Registration.DeletedAt = 0
+		auditService.RecordEvent(models.AuditTrashItemRestored, models.AuditTargetCustomRegistration, customRegistration.Id, auditMetadata, "")
+
+		return customRegistration, nil
+	}
+
+	return nil, &models.DbNotFoundError{DbItem: &models.CustomActivityRegistration{}}
+}
+
+// EmptyUserTrash permanently deletes every item in the trash of the user.
+func (trashService *TrashServiceImpl) EmptyUserTrash(userId uint, auditMetadata *AuditMetadata) error {
+	trashedDiaryEntries, err := diaryEntryStorage.GetTrashedByUserId(userId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, diaryEntry := range trashedDiaryEntries.([]*models.DiaryEntry) {
+		if purgeErr := purgeDiaryEntry(diaryEntry); purgeErr != nil {
+			return purgeErr
+		}
+	}
+
+	if _, deleteErr := activityRegistrationStorage.DeleteTrashedByUserId(userId); deleteErr != nil {
+		return deleteErr
+	}
+
+	auditService.RecordEvent(models.AuditTrashEmptied, models.AuditTargetUser, userId, auditMetadata, "")
+
+	return nil
+}
+
+// PurgeExpiredTrash permanently deletes the items of every user kept in the trash longer than the retention period,
+// returning how many were deleted.
+func (trashService *TrashServiceImpl) PurgeExpiredTrash() (int64, error) {
+	deletedBefore := time.Now().AddDate(0, 0, -getTrashRetentionDays()).Unix()
+	expiredDiaryEntries, err := diaryEntryStorage.GetTrashedBefore(deletedBefore)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, diaryEntry := range expiredDiaryEntries.([]*models.DiaryEntry) {
+		if purgeErr := purgeDiaryEntry(diaryEntry); purgeErr != nil {
+			return 0, purgeErr
+		}
+	}
+
+	purgedRegistrations, deleteErr := activityRegistrationStorage.DeleteTrashedBefore(deletedBefore)
+
+	if deleteErr != nil {
+		return 0, deleteErr
+	}
+
+	return int64(len(expiredDiaryEntries.([]*models.DiaryEntry))) + purgedRegistrations, nil
+}
+
+// StartTrashPurge purges the expired trash in the background, right away and then every hour.
+func StartTrashPurge(trashService TrashService) {
+	go func() {
+		for {
+			purgedItems, err := trashService.PurgeExpiredTrash()
+
+			if err != nil {
+				servicesLogger.ErrorLogger.Printf("error when purging the trash: %s", err.Error())
+			} else if purgedItems > 0 {
+				servicesLogger.InfoLogger.Printf("Purged %d items from the trash\n", purgedItems)
+			}
+
+			time.Sleep(trashPurgeInterval)
+		}
+	}()
+}
+
+// purgeDiaryEntry permanently deletes a diary entry with its attachments.
+func purgeDiaryEntry(diaryEntry *models.DiaryEntry) error {
+	// the attachments are read first, as deleting the entry may cascade to their rows
+	attachments, getAttachmentsErr := diaryEntryAttachmentStorage.GetByDiaryEntryId(diaryEntry.Id)
+
+	if getAttachmentsErr != nil {
+		return getAttachmentsErr
+	}
+
+	if deleteEntryErr := diaryEntryStorage.Delete(diaryEntry.Id); deleteEntryErr != nil {
+		return deleteEntryErr
+	}
+
+	if deleteAttachmentsErr := deleteDiaryEntryAttachments(diaryEntry.Id, attachments.([]*models.DiaryEntryAttachment)); deleteAttachmentsErr != nil {
+		return deleteAttachmentsErr
+	}
+
+	return activityRegistrationStorage.Delete(diaryEntry.Registration.Id)
+}
+
+// getTrashRetentionDays returns the days deleted items are kept in the trash, configured by TRASH_RETENTION_DAYS.
+func getTrashRetentionDays() int {
+	retentionDays, err := strconv.Atoi(os.Getenv(trashRetentionDaysEnv))
+
+	if err != nil || retentionDays < 0 {
+		return defaultTrashRetentionDays
+	}
+
+	return retentionDays
+}
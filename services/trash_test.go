@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var trashService TrashService = &TrashServiceImpl{}
+
+func setUpTrashMocks(t *testing.T) (*mockDiaryEntryStorage, *mockBookActivityRegistrationStorage, *mockActivityRegistrationStorage) {
+	diaryEntryStorageMock, _ := setUpDiaryEntryRevisionMocks(t)
+	activityRegistrationStorageMock := activityRegistrationStorage.(*mockActivityRegistrationStorage)
+	originalBookStorage := bookActivityRegistrationStorage
+	originalGameStorage := gameActivityRegistrationStorage
+	originalAttachmentStorage := diaryEntryAttachmentStorage
+	originalCustomStorage := customActivityRegistrationStorage
+	bookStorageMock := &mockBookActivityRegistrationStorage{Registrations: make(map[uint][]*models.BookActivityRegistration)}
+	bookActivityRegistrationStorage = bookStorageMock
+	gameActivityRegistrationStorage = &mockGameActivityRegistrationStorage{Registrations: make(map[uint][]*models.GameActivityRegistration)}
+	diaryEntryAttachmentStorage = &mockDiaryEntryAttachmentStorage{}
+	customActivityRegistrationStorage = &mockCustomActivityRegistrationStorage{Registrations: make(map[uint]*models.CustomActivityRegistration)}
+	t.Cleanup(func() {
+		bookActivityRegistrationStorage = originalBookStorage
+		gameActivityRegistrationStorage = originalGameStorage
+		diaryEntryAttachmentStorage = originalAttachmentStorage
+		customActivityRegistrationStorage = originalCustomStorage
+	})
+
+	return diaryEntryStorageMock, bookStorageMock, activityRegistrationStorageMock
+}
+
+func TestGetUserTrash(t *testing.T) {
+	diaryEntryStorageMock, bookStorageMock, _ := setUpTrashMocks(t)
+	deletedAt := time.Now().Unix()
+	diaryEntryStorageMock.Entries[1] = &models.DiaryEntry{Id: 1, Registration: models.ActivityRegistration{Id: 10, UserRefer: 1, DeletedAt: deletedAt}}
+	diaryEntryStorageMock.Entries[2] = &models.DiaryEntry{Id: 2, Registration: models.ActivityRegistration{Id: 20, UserRefer: 1}}
+	diaryEntryStorageMock.Entries[3] = &models.DiaryEntry{Id: 3, Registration: models.ActivityRegistration{Id: 30, UserRefer: 2, DeletedAt: deletedAt}}
+	bookStorageMock.Registrations[1] = []*models.BookActivityRegistration{
+		{Id: 4, Registration: models.ActivityRegistration{Id: 40, UserRefer: 1, DeletedAt: deletedAt}},
+		{Id: 5, Registration: models.ActivityRegistration{Id: 50, UserRefer: 1}},
+	}
+
+	trash, err := trashService.GetUserTrash(1)
+	assert.NoError(t, err)
+	assert.Len(t, trash.DiaryEntries, 1)
+	assert.Equal(t, uint(1), trash.DiaryEntries[0].Id)
+	assert.Len(t, trash.BookRegistrations, 1)
+	assert.Equal(t, uint(4), trash.BookRegistrations[0].Id)
+	assert.Empty(t, trash.GameRegistrations)
+	assert.Equal(t, defaultTrashRetentionDays, trash.RetentionDays)
+
+	// Test the retention period can be configured
+	t.Setenv(trashRetentionDaysEnv, "7")
+	trash, err = trashService.GetUserTrash(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, trash.RetentionDays)
+}
+
+func TestRestoreTrashedItems(t *testing.T) {
+	diaryEntryStorageMock, bookStorageMock, activityRegistrationStorageMock := setUpTrashMocks(t)
+	deletedAt := time.Now().Unix()
+	diaryEntryStorageMock.Entries[1] = &models.DiaryEntry{Id: 1, Registration: models.ActivityRegistration{Id: 10, UserRefer: 1, DeletedAt: deletedAt}}
+	diaryEntryStorageMock.Entries[2] = &models.DiaryEntry{Id: 2, Registration: models.ActivityRegistration{Id: 20, UserRefer: 1}}
+	bookStorageMock.Registrations[1] = []*models.BookActivityRegistration{
+		{Id: 4, Registration: models.ActivityRegistration{Id: 40, UserRefer: 1, DeletedAt: deletedAt}},
+	}
+
+	restoredEntry, err := trashService.RestoreDiaryEntry(1, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(10), activityRegistrationStorageMock.RestoredId)
+	assert.Zero(t, restoredEntry.Registration.DeletedAt)
+
+	restoredBookRegistration, err := trashService.RestoreBookActivityRegistration(1, 4, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(40), activityRegistrationStorageMock.RestoredId)
+	assert.Zero(t, restoredBookRegistration.Registration.DeletedAt)
+
+	// Test entries not in the trash, or of other users, are not found
+	_, err = trashService.RestoreDiaryEntry(1, 2, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	_, err = trashService.RestoreDiaryEntry(2, 1, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	_, err = trashService.RestoreGameActivityRegistration(1, 4, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
+
+func TestRestoreTrashedCustomActivityRegistration(t *testing.T) {
+	_, _, activityRegistrationStorageMock := setUpTrashMocks(t)
+	customStorageMock := customActivityRegistrationStorage.(*mockCustomActivityRegistrationStorage)
+	deletedAt := time.Now().Unix()
+	customStorageMock.Registrations[1] = &models.CustomActivityRegistration{Id: 1, ActivityType: "walk", Registration: models.ActivityRegistration{Id: 60, UserRefer: 1, DeletedAt: deletedAt}}
+	customStorageMock.Registrations[2] = &models.CustomActivityRegistration{Id: 2, ActivityType: "walk", Registration: models.ActivityRegistration{Id: 70, UserRefer: 1}}
+
+	trash, err := trashService.GetUserTrash(1)
+	assert.NoError(t, err)
+	assert.Len(t, trash.CustomRegistrations, 1)
+	assert.Equal(t, uint(1), trash.CustomRegistrations[0].Id)
+
+	restoredRegistration, err := trashService.RestoreCustomActivityRegistration(1, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(60), activityRegistrationStorageMock.RestoredId)
+	assert.Zero(t, restoredRegistration.Registration.DeletedAt)
+
+	_, err = trashService.RestoreCustomActivityRegistration(1, 2, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	_, err = trashService.RestoreCustomActivityRegistration(2, 1, nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+}
+
+func TestEmptyUserTrash(t *testing.T) {
+	diaryEntryStorageMock, _, activityRegistrationStorageMock := setUpTrashMocks(t)
+	deletedAt := time.Now().Unix()
+	diaryEntryStorageMock.Entries[1] = &models.DiaryEntry{Id: 1, Registration: models.ActivityRegistration{Id: 10, UserRefer: 1, DeletedAt: deletedAt}}
+	diaryEntryStorageMock.Entries[2] = &models.DiaryEntry{Id: 2, Registration: models.ActivityRegistration{Id: 20, UserRefer: 1}}
+	diaryEntryStorageMock.Entries[3] = &models.DiaryEntry{Id: 3, Registration: models.ActivityRegistration{Id: 30, UserRefer: 2, DeletedAt: deletedAt}}
+
+	err := trashService.EmptyUserTrash(1, nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, diaryEntryStorageMock.Entries, uint(1))
+	assert.Contains(t, diaryEntryStorageMock.Entries, uint(2))
+	assert.Contains(t, diaryEntryStorageMock.Entries, uint(3))
+	assert.Equal(t, []uint{10}, activityRegistrationStorageMock.DeletedIds)
+	assert.Equal(t, uint(1), activityRegistrationStorageMock.EmptiedUserId)
+
+	// Test storage error
+	activityRegistrationStorageMock.DeleteErr = errors.New("db error")
+	err = trashService.EmptyUserTrash(1, nil)
+	assert.EqualError(t, err, "db error")
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	diaryEntryStorageMock, _, activityRegistrationStorageMock := setUpTrashMocks(t)
+	t.Setenv(trashRetentionDaysEnv, "10")
+	diaryEntryStorageMock.Entries[1] = &models.DiaryEntry{Id: 1, Registration: models.ActivityRegistration{Id: 10, UserRefer: 1, DeletedAt: time.Now().AddDate(0, 0, -11).Unix()}}
+	diaryEntryStorageMock.Entries[2] = &models.DiaryEntry{Id: 2, Registration: models.ActivityRegistration{Id: 20, UserRefer: 2, DeletedAt: time.Now().AddDate(0, 0, -9).Unix()}}
+	activityRegistrationStorageMock.PurgedRegistration = 3
+
+	purgedItems, err := trashService.PurgeExpiredTrash()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), purgedItems)
+	assert.NotContains(t, diaryEntryStorageMock.Entries, uint(1))
+	assert.Contains(t, diaryEntryStorageMock.Entries, uint(2))
+	assert.InDelta(t, time.Now().AddDate(0, 0, -10).Unix(), activityRegistrationStorageMock.PurgedBefore, 5)
+}
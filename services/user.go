@@ -10,6 +10,18 @@ type UserBody struct {
 	UserName string `json:"username" validate:"required,alphanum"`
 }
 
+// UpdateUserProfileBody holds the profile fields a user can change. Only the provided fields are updated.
+type UpdateUserProfileBody struct {
+	UserName  *string `json:"userName" validate:"omitnil,min=1,max=64,alphanum"`
+	AvatarUrl *string `json:"avatarUrl" validate:"omitempty,url,max=2048"`
+	Locale    *string `json:"locale" validate:"omitempty,bcp47_language_tag"`
+	TimeZone  *string `json:"timeZone" validate:"omitempty,timezone"`
+	// SearchIndexEnabled opts in or out of the full-text index of diary entries encrypted at rest.
+	SearchIndexEnabled *bool `json:"searchIndexEnabled"`
+	// ExpectedVersion is the version the client based the update on, from the If-Match header. Zero skips the check.
+	ExpectedVersion int64 `json:"-"`
+}
+
 var userStorage storage.UserStorageInterface = &storage.UserStorage{}
 
 // UserService defines all operations for the user service.
@@ -17,7 +29,7 @@ type UserService interface {
 	GetUserById(id uint) (*models.User, error)
 	GetUserByEmail(email string) (*models.User, error)
 	SaveUser(userBody UserBody) (*models.User, error)
-	UpdateUser(userBody UserBody) (*models.User, error)
+	UpdateUserProfile(userId uint, profileBody *UpdateUserProfileBody) (*models.User, error)
 	DeleteUser(id uint) error
 }
 
@@ -53,18 +65,64 @@ func (userService *UserServiceImpl) SaveUser(userBody UserBody) (*models.User, e
 	return savedUser, nil
 }
 
-func (userService *UserServiceImpl) UpdateUser(userBody UserBody) (*models.User, error) {
-	updatedUser := &models.User{}
-	updatedUser.UserName = userBody.UserName
-	updatedUser.Email = userBody.Email
-	updatedUser.Role = models.Standard
-	err := userStorage.Update(updatedUser)
+func (userService *UserServiceImpl) UpdateUserProfile(userId uint, profileBody *UpdateUserProfileBody) (*models.User, error) {
+	user, err := userService.GetUserById(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if profileBody.ExpectedVersion != 0 && profileBody.ExpectedVersion != user.Version {
+		return nil, &models.DbVersionConflictError{DbItem: &models.User{}, CurrentVersion: user.Version}
+	}
+
+	if profileBody.UserName != nil {
+		user.UserName = *profileBody.UserName
+	}
+	if profileBody.AvatarUrl != nil {
+		user.AvatarUrl = *profileBody.AvatarUrl
+	}
+	if profileBody.Locale != nil {
+		user.Locale = *profileBody.Locale
+	}
+	if profileBody.TimeZone != nil {
+		user.TimeZone = *profileBody.TimeZone
+	}
+	rebuildSearchIndex := profileBody.SearchIndexEnabled != nil && *profileBody.SearchIndexEnabled != user.SearchIndexEnabled
+	if rebuildSearchIndex {
+		user.SearchIndexEnabled = *profileBody.SearchIndexEnabled
+	}
+
+	err = userStorage.Update(user)
 	if err != nil {
 		return nil, err
 	}
-	return updatedUser, nil
+
+	// the index is filled when the user opts in, and emptied when they opt out
+	if rebuildSearchIndex {
+		if indexErr := diaryEntryStorage.RebuildUserSearchIndex(userId); indexErr != nil {
+			return nil, indexErr
+		}
+	}
+	return user, nil
 }
 
+// DeleteUser deletes the user, revoking its tokens first.
 func (userService *UserServiceImpl) DeleteUser(id uint) error {
+	tokenPair, getTokensErr := tokenStorage.GetByUserId(id)
+
+	if _, isNotFoundErr := getTokensErr.(*models.DbNotFoundError); getTokensErr != nil && !isNotFoundErr {
+		return getTokensErr
+	}
+
+	for _, token := range tokenPair {
+		if token == nil {
+			continue
+		}
+
+		if deleteErr := (&TokenServiceImpl{}).DeleteToken(token.Id, nil); deleteErr != nil {
+			return deleteErr
+		}
+	}
+
 	return userStorage.Delete(id)
 }
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"time"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/storage"
+)
+
+// SaveUserKeyBackupBody holds a user encryption key, encrypted on the client.
+type SaveUserKeyBackupBody struct {
+	Blob string `json:"blob" validate:"required,base64,max=16384"`
+}
+
+var userKeyBackupStorage storage.UserKeyBackupStorageInterface = &storage.UserKeyBackupStorage{}
+
+// UserKeyBackupService defines all operations for the user key backup service.
+type UserKeyBackupService interface {
+	GetUserKeyBackups(userId uint) ([]*models.UserKeyBackup, error)
+	SaveUserKeyBackup(userId uint, keyId string, saveBody *SaveUserKeyBackupBody, auditMetadata *AuditMetadata) (*models.UserKeyBackup, error)
+	DeleteUserKeyBackup(userId uint, keyId string, auditMetadata *AuditMetadata) error
+}
+
+// UserKeyBackupServiceImpl is the concrete implementation of UserKeyBackupService.
+type UserKeyBackupServiceImpl struct{}
+
+func (userKeyBackupService *UserKeyBackupServiceImpl) GetUserKeyBackups(userId uint) ([]*models.UserKeyBackup, error) {
+	keyBackups, err := userKeyBackupStorage.GetByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return keyBackups.([]*models.UserKeyBackup), nil
+}
+
+// SaveUserKeyBackup stores the backup of a user key, replacing the previous backup of the same key.
+func (userKeyBackupService *UserKeyBackupServiceImpl) SaveUserKeyBackup(userId uint, keyId string, saveBody *SaveUserKeyBackupBody, auditMetadata *AuditMetadata) (*models.UserKeyBackup, error) {
+	now := time.Now().Unix()
+	keyBackup := &models.UserKeyBackup{
+		KeyId:     keyId,
+		Blob:      saveBody.Blob,
+		UserRefer: userId,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := userKeyBackupStorage.Save(keyBackup); err != nil {
+		return nil, err
+	}
+
+	savedKeyBackup, getErr := userKeyBackupStorage.Get(userId, keyId)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	auditService.RecordEvent(models.AuditKeyBackupSaved, models.AuditTargetKeyBackup, savedKeyBackup.(*models.UserKeyBackup).Id, auditMetadata, "key id: "+keyId)
+
+	return savedKeyBackup.(*models.UserKeyBackup), nil
+}
+
+func (userKeyBackupService *UserKeyBackupServiceImpl) DeleteUserKeyBackup(userId uint, keyId string, auditMetadata *AuditMetadata) error {
+	keyBackup, getErr := userKeyBackupStorage.Get(userId, keyId)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	if err := userKeyBackupStorage.Delete(userId, keyId); err != nil {
+		return err
+	}
+
+	auditService.RecordEvent(models.AuditKeyBackupDeleted, models.AuditTargetKeyBackup, keyBackup.(*models.UserKeyBackup).Id, auditMetadata, "key id: "+keyId)
+
+	return nil
+}
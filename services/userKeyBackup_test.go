@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUserKeyBackupStorage implements UserKeyBackupStorageInterface
+type mockUserKeyBackupStorage struct {
+	KeyBackups []*models.UserKeyBackup
+}
+
+func (m *mockUserKeyBackupStorage) Get(userId uint, keyId string) (interface{}, error) {
+	for _, keyBackup := range m.KeyBackups {
+		if keyBackup.UserRefer == userId && keyBackup.KeyId == keyId {
+			return keyBackup, nil
+		}
+	}
+	return nil, &models.DbNotFoundError{DbItem: &models.UserKeyBackup{}}
+}
+
+func (m *mockUserKeyBackupStorage) GetByUserId(userId uint) (interface{}, error) {
+	keyBackups := []*models.UserKeyBackup{}
+	for _, keyBackup := range m.KeyBackups {
+		if keyBackup.UserRefer == userId {
+			keyBackups = append(keyBackups, keyBackup)
+		}
+	}
+	return keyBackups, nil
+}
+
+func (m *mockUserKeyBackupStorage) Save(data interface{}) error {
+	keyBackup := data.(*models.UserKeyBackup)
+	if storedKeyBackup, err := m.Get(keyBackup.UserRefer, keyBackup.KeyId); err == nil {
+		storedKeyBackup.(*models.UserKeyBackup).Blob = keyBackup.Blob
+		storedKeyBackup.(*models.UserKeyBackup).UpdatedAt = keyBackup.UpdatedAt
+		return nil
+	}
+	keyBackup.Id = uint(len(m.KeyBackups) + 1)
+	m.KeyBackups = append(m.KeyBackups, keyBackup)
+	return nil
+}
+
+func (m *mockUserKeyBackupStorage) Delete(userId uint, keyId string) error {
+	for i, keyBackup := range m.KeyBackups {
+		if keyBackup.UserRefer == userId && keyBackup.KeyId == keyId {
+			m.KeyBackups = append(m.KeyBackups[:i], m.KeyBackups[i+1:]...)
+			return nil
+		}
+	}
+	return &models.DbNotFoundError{DbItem: &models.UserKeyBackup{}}
+}
+
+func TestSaveUserKeyBackup(t *testing.T) {
+	originalStorage := userKeyBackupStorage
+	mockStorage := &mockUserKeyBackupStorage{}
+	userKeyBackupStorage = mockStorage
+	defer func() { userKeyBackupStorage = originalStorage }()
+
+	userKeyBackupService := &UserKeyBackupServiceImpl{}
+
+	savedKeyBackup, err := userKeyBackupService.SaveUserKeyBackup(1, "device-key-1", &SaveUserKeyBackupBody{Blob: "Zmlyc3Q="}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Zmlyc3Q=", savedKeyBackup.Blob)
+	assert.NotZero(t, savedKeyBackup.CreatedAt)
+
+	// Test the backup of the same key is replaced
+	replacedKeyBackup, err := userKeyBackupService.SaveUserKeyBackup(1, "device-key-1", &SaveUserKeyBackupBody{Blob: "c2Vjb25k"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, savedKeyBackup.Id, replacedKeyBackup.Id)
+	assert.Equal(t, "c2Vjb25k", replacedKeyBackup.Blob)
+
+	// Test the keys of other users are not listed
+	userKeyBackupService.SaveUserKeyBackup(2, "device-key-1", &SaveUserKeyBackupBody{Blob: "b3RoZXI="}, nil)
+	keyBackups, err := userKeyBackupService.GetUserKeyBackups(1)
+	assert.NoError(t, err)
+	assert.Len(t, keyBackups, 1)
+}
+
+func TestDeleteUserKeyBackup(t *testing.T) {
+	originalStorage := userKeyBackupStorage
+	mockStorage := &mockUserKeyBackupStorage{}
+	userKeyBackupStorage = mockStorage
+	defer func() { userKeyBackupStorage = originalStorage }()
+
+	userKeyBackupService := &UserKeyBackupServiceImpl{}
+	userKeyBackupService.SaveUserKeyBackup(1, "device-key-1", &SaveUserKeyBackupBody{Blob: "Zmlyc3Q="}, nil)
+
+	// Test other users cannot delete the backup
+	err := userKeyBackupService.DeleteUserKeyBackup(2, "device-key-1", nil)
+	assert.IsType(t, &models.DbNotFoundError{}, err)
+	assert.Len(t, mockStorage.KeyBackups, 1)
+
+	err = userKeyBackupService.DeleteUserKeyBackup(1, "device-key-1", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, mockStorage.KeyBackups)
+}
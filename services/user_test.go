@@ -3,9 +3,11 @@ package services
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -197,63 +199,104 @@ func TestSaveUser(t *testing.T) {
 	assert.EqualError(t, err, "forced Create error")
 }
 
-func TestUpdateUser(t *testing.T) {
+func TestUpdateUserProfile(t *testing.T) {
 	originalStorage := userStorage
 	userStorageMock := newuserStorageMockUserStorage()
 	userStorage = userStorageMock
 	defer func() { userStorage = originalStorage }()
 
-	// Pre-populate a user
-	initialEmail := "update@example.com"
-	initialUser := &models.User{Id: 5, Email: initialEmail, UserName: "initialuser", Role: models.Standard}
+	initialUser := &models.User{Id: 7, Email: "profile@example.com", UserName: "initialuser", Role: models.Standard, Locale: "en"}
 	userStorageMock.UsersById[initialUser.Id] = initialUser
 	userStorageMock.UsersByEmail[initialUser.Email] = initialUser
 
-	updateBody := UserBody{Email: initialEmail, UserName: "updateduser"}
+	newUserName := "newname"
+	newTimeZone := "Europe/Madrid"
+	profileBody := &UpdateUserProfileBody{UserName: &newUserName, TimeZone: &newTimeZone}
 
-	// Test successful update
-	updatedUser, err := userService.UpdateUser(updateBody)
+	// Test successful partial update
+	updatedUser, err := userService.UpdateUserProfile(initialUser.Id, profileBody)
 	assert.NoError(t, err)
-	assert.NotNil(t, updatedUser)
-	assert.Equal(t, updateBody.UserName, updatedUser.UserName)
-	assert.Equal(t, updateBody.Email, updatedUser.Email)
+	assert.Equal(t, newUserName, updatedUser.UserName)
+	assert.Equal(t, newTimeZone, updatedUser.TimeZone)
+	assert.Equal(t, "en", updatedUser.Locale)
+	assert.Equal(t, initialUser.Email, updatedUser.Email)
 	assert.Equal(t, models.Standard, updatedUser.Role)
+	assert.Equal(t, newUserName, userStorageMock.UsersById[initialUser.Id].UserName)
+
+	// Test the search index is rebuilt only when the opt-in changes
+	originalDiaryEntryStorage := diaryEntryStorage
+	diaryEntryStorageMock := &mockDiaryEntryStorage{}
+	diaryEntryStorage = diaryEntryStorageMock
+	defer func() { diaryEntryStorage = originalDiaryEntryStorage }()
+	searchIndexEnabled := true
+	updatedUser, err = userService.UpdateUserProfile(initialUser.Id, &UpdateUserProfileBody{SearchIndexEnabled: &searchIndexEnabled})
+	assert.NoError(t, err)
+	assert.True(t, updatedUser.SearchIndexEnabled)
+	assert.Equal(t, []uint{initialUser.Id}, diaryEntryStorageMock.IndexedUsers)
+	_, err = userService.UpdateUserProfile(initialUser.Id, &UpdateUserProfileBody{SearchIndexEnabled: &searchIndexEnabled})
+	assert.NoError(t, err)
+	assert.Len(t, diaryEntryStorageMock.IndexedUsers, 1)
 
-	userInuserStorageMock := userStorageMock.UsersByEmail[initialEmail]
-	assert.NotNil(t, userInuserStorageMock)
-	assert.Equal(t, "updateduser", userInuserStorageMock.UserName)
-
-	// Test error from storage.Update if user not found by email
-	userStorageMock.UpdateErr = nil // reset
-	_, err = userService.UpdateUser(UserBody{Email: "nonexistent@example.com", UserName: "ghost"})
+	// Test error when user does not exist
+	_, err = userService.UpdateUserProfile(99, profileBody)
 	assert.Error(t, err)
 
 	// Test forced error from storage.Update
-	userStorageMock.UsersByEmail["forceerror@example.com"] = &models.User{Id: 6, Email: "forceerror@example.com", UserName: "pre"}
-	userStorageMock.UsersById[6] = userStorageMock.UsersByEmail["forceerror@example.com"]
 	userStorageMock.UpdateErr = errors.New("forced Update error")
-	_, err = userService.UpdateUser(UserBody{Email: "forceerror@example.com", UserName: "forcingerror"})
-	assert.Error(t, err)
+	_, err = userService.UpdateUserProfile(initialUser.Id, profileBody)
 	assert.EqualError(t, err, "forced Update error")
 }
 
+func TestUpdateUserProfileBodyValidation(t *testing.T) {
+	validBodies := []string{`{}`, `{"userName": null}`, `{"userName": "newname"}`}
+	invalidBodies := []string{`{"userName": ""}`, `{"userName": "new name"}`, `{"userName": "` + strings.Repeat("a", 65) + `"}`}
+
+	for _, validBody := range validBodies {
+		assert.NoError(t, utils.ReadJSON(strings.NewReader(validBody), &UpdateUserProfileBody{}), validBody)
+	}
+
+	for _, invalidBody := range invalidBodies {
+		assert.Error(t, utils.ReadJSON(strings.NewReader(invalidBody), &UpdateUserProfileBody{}), invalidBody)
+	}
+}
+
 func TestDeleteUser(t *testing.T) {
 	originalStorage := userStorage
 	userStorageMock := newuserStorageMockUserStorage()
 	userStorage = userStorageMock
 	defer func() { userStorage = originalStorage }()
 
+	originalTokenStorage := tokenStorage
+	tokenStorageMock := newMockTokenStorage()
+	tokenStorage = tokenStorageMock
+	defer func() { tokenStorage = originalTokenStorage }()
+
+	auditStorageMock := &mockAuditEventStorage{}
+	originalAuditStorage := auditEventStorage
+	auditEventStorage = auditStorageMock
+	defer func() { auditEventStorage = originalAuditStorage }()
+
 	userToDelete := &models.User{Id: 10, Email: "delete@example.com", UserName: "deleteuser"}
 	userStorageMock.UsersById[userToDelete.Id] = userToDelete
 	userStorageMock.UsersByEmail[userToDelete.Email] = userToDelete
+	accessToken := &models.Token{Id: 3, TokenValue: "access", Kind: models.Access, UserRefer: userToDelete.Id}
+	refreshToken := &models.Token{Id: 4, TokenValue: "refresh", Kind: models.Refresh, UserRefer: userToDelete.Id}
+	tokenStorageMock.Create(accessToken)
+	tokenStorageMock.Create(refreshToken)
+	tokenStorageMock.TokenPairByUserID[userToDelete.Id] = [2]*models.Token{accessToken, refreshToken}
 
-	// Test successful delete
+	// Test successful delete, revoking the tokens of the user
 	err := userService.DeleteUser(userToDelete.Id)
 	assert.NoError(t, err)
 	_, okId := userStorageMock.UsersById[userToDelete.Id]
 	_, okEmail := userStorageMock.UsersByEmail[userToDelete.Email]
 	assert.False(t, okId)
 	assert.False(t, okEmail)
+	assert.Empty(t, tokenStorageMock.TokensById)
+	assert.Len(t, auditStorageMock.Events, 2)
+	assert.Equal(t, models.AuditTokenRevoked, auditStorageMock.Events[0].EventType)
+	assert.Equal(t, []uint{3, 4}, []uint{auditStorageMock.Events[0].TargetId, auditStorageMock.Events[1].TargetId})
+	delete(tokenStorageMock.TokenPairByUserID, userToDelete.Id)
 
 	// Test delete non-existent
 	err = userService.DeleteUser(999) // ID that doesn't exist
@@ -2,22 +2,35 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 
 	"github.com/adfer-dev/analock-api/database"
 	"github.com/adfer-dev/analock-api/models"
 )
 
 const (
-	getActivityRegistrationByIdentifierQuery = "SELECT * FROM activity_registration WHERE id = ?;"
+	getActivityRegistrationByIdentifierQuery = "SELECT id, registration_date, user_id, deleted_at, version FROM activity_registration WHERE id = ?;"
 	insertActivityRegistrationQuery          = "INSERT INTO activity_registration (registration_date, user_id) VALUES (?, ?);"
-	updateActivityRegistrationQuery          = "UPDATE activity_registration SET registration_date = ? WHERE id = ?;"
-	deleteActivityRegistrationQuery          = "DELETE FROM activity_registration WHERE id = ?;"
+	// updateActivityRegistrationQuery only updates the registration when it still has the version the update is based on
+	updateActivityRegistrationQuery     = "UPDATE activity_registration SET registration_date = ?, version = version + 1 WHERE id = ? AND version = ?;"
+	getActivityRegistrationVersionQuery = "SELECT version FROM activity_registration WHERE id = ?;"
+	deleteActivityRegistrationQuery     = "DELETE FROM activity_registration WHERE id = ?;"
+	trashActivityRegistrationQuery      = "UPDATE activity_registration SET deleted_at = ? WHERE id = ? AND deleted_at = 0;"
+	restoreActivityRegistrationQuery    = "UPDATE activity_registration SET deleted_at = 0 WHERE id = ? AND deleted_at != 0;"
+	// the registrations of diary entries are left, as they are deleted along with their attachments
+	deleteUserTrashedActivityRegistrationsQuery = "DELETE FROM activity_registration WHERE user_id = ? AND deleted_at != 0" +
+		" AND id NOT IN (SELECT registration_id FROM diary_entry);"
+	deleteExpiredActivityRegistrationsQuery = "DELETE FROM activity_registration WHERE deleted_at != 0 AND deleted_at < ?" +
+		" AND id NOT IN (SELECT registration_id FROM diary_entry);"
 )
 
 type ActivityRegistrationStorageInterface interface {
 	Create(data interface{}) error
-	Update(data interface{}) error
 	Delete(id uint) error
+	Trash(id uint, deletedAt int64) error
+	Restore(id uint) error
+	DeleteTrashedByUserId(userId uint) (int64, error)
+	DeleteTrashedBefore(deletedBefore int64) (int64, error)
 }
 
 type ActivityRegistrationStorage struct{}
@@ -73,20 +86,73 @@ func (activityRegistrationStorage *ActivityRegistrationStorage) Create(activityR
 	}
 
 	dbActivityRegistration.Id = uint(diaryEntryId)
+	dbActivityRegistration.Version = 1
 
 	return nil
 }
 
-func (activityRegistrationStorage *ActivityRegistrationStorage) Update(activityRegistration interface{}) error {
-	dbActivityRegistration, ok := activityRegistration.(*models.ActivityRegistration)
+func (activityRegistrationStorage *ActivityRegistrationStorage) Delete(id uint) error {
 
-	if !ok {
-		return failedToParseActivityRegistrationError
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(deleteActivityRegistrationQuery, id)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
 	}
 
-	result, err := database.GetDatabaseInstance().GetConnection().Exec(updateActivityRegistrationQuery,
+	if affectedRows == 0 {
+		return activityRegistrationNotFoundError
+	}
+
+	return nil
+}
+
+// Trash moves the registration and its activity to the trash. Registrations already in the trash are not found.
+func (activityRegistrationStorage *ActivityRegistrationStorage) Trash(id uint, deletedAt int64) error {
+	return execActivityRegistrationTrashQuery(trashActivityRegistrationQuery, deletedAt, id)
+}
+
+// Restore moves the registration and its activity out of the trash. Registrations not in the trash are not found.
+func (activityRegistrationStorage *ActivityRegistrationStorage) Restore(id uint) error {
+	return execActivityRegistrationTrashQuery(restoreActivityRegistrationQuery, id)
+}
+
+// DeleteTrashedByUserId permanently deletes the book and game registrations in the trash of the user,
+// returning how many were deleted. Diary entries must be deleted on their own.
+func (activityRegistrationStorage *ActivityRegistrationStorage) DeleteTrashedByUserId(userId uint) (int64, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(deleteUserTrashedActivityRegistrationsQuery, userId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteTrashedBefore permanently deletes the book and game registrations moved to the trash before the given time,
+// returning how many were deleted. Diary entries must be deleted on their own.
+func (activityRegistrationStorage *ActivityRegistrationStorage) DeleteTrashedBefore(deletedBefore int64) (int64, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(deleteExpiredActivityRegistrationsQuery, deletedBefore)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// updateActivityRegistration updates the registration date in the transaction, when the registration still has the version
+// the update is based on. Otherwise a DbVersionConflictError is returned. The version is incremented by the callers once committed.
+func updateActivityRegistration(transaction *sql.Tx, dbActivityRegistration *models.ActivityRegistration) error {
+	result, err := transaction.Exec(updateActivityRegistrationQuery,
 		dbActivityRegistration.RegistrationDate,
-		dbActivityRegistration.Id)
+		dbActivityRegistration.Id,
+		dbActivityRegistration.Version)
 
 	if err != nil {
 		return err
@@ -99,15 +165,23 @@ func (activityRegistrationStorage *ActivityRegistrationStorage) Update(activityR
 	}
 
 	if affectedRows == 0 {
-		return activityRegistrationNotFoundError
+		var currentVersion int64
+
+		if versionErr := transaction.QueryRow(getActivityRegistrationVersionQuery, dbActivityRegistration.Id).Scan(&currentVersion); versionErr != nil {
+			if errors.Is(versionErr, sql.ErrNoRows) {
+				return activityRegistrationNotFoundError
+			}
+			return versionErr
+		}
+
+		return &models.DbVersionConflictError{DbItem: &models.ActivityRegistration{}, CurrentVersion: currentVersion}
 	}
 
 	return nil
 }
 
-func (activityRegistrationStorage *ActivityRegistrationStorage) Delete(id uint) error {
-
-	result, err := database.GetDatabaseInstance().GetConnection().Exec(deleteActivityRegistrationQuery, id)
+func execActivityRegistrationTrashQuery(query string, args ...interface{}) error {
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(query, args...)
 
 	if err != nil {
 		return err
@@ -129,7 +203,8 @@ func (activityRegistrationStorage *ActivityRegistrationStorage) Delete(id uint)
 func (activityRegistrationStorage *ActivityRegistrationStorage) Scan(rows *sql.Rows) (interface{}, error) {
 	var activityRegistration models.ActivityRegistration
 
-	scanErr := rows.Scan(&activityRegistration.Id, &activityRegistration.RegistrationDate, &activityRegistration.UserRefer)
+	scanErr := rows.Scan(&activityRegistration.Id, &activityRegistration.RegistrationDate, &activityRegistration.UserRefer,
+		&activityRegistration.DeletedAt, &activityRegistration.Version)
 
 	return activityRegistration, scanErr
 }
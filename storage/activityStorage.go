@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+// getUserActivitiesQuery joins the activity registrations with their child tables. A registration is either
+// a diary entry, a book, a game or the payload of a declared activity type, so only the columns of one of them are not null.
+const getUserActivitiesQuery = "SELECT ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version," +
+	" de.id, de.title, de.mood, de.encryption_algorithm, arb.id, arb.internet_archive_id, arg.id, arg.game_name," +
+	" arp.id, arp.activity_type, arp.payload" +
+	" FROM activity_registration ar" +
+	" LEFT JOIN diary_entry de ON (de.registration_id = ar.id)" +
+	" LEFT JOIN activity_registration_book arb ON (arb.registration_id = ar.id)" +
+	" LEFT JOIN activity_registration_game arg ON (arg.registration_id = ar.id)" +
+	" LEFT JOIN activity_registration_payload arp ON (arp.registration_id = ar.id)"
+
+// activityTypeConditions match the registrations of each activity type with its own table.
+// Other types are matched by the activity type of their payload.
+var activityTypeConditions = map[models.ActivityType]string{
+	models.ActivityTypeDiaryEntry: "de.id IS NOT NULL",
+	models.ActivityTypeBook:       "arb.id IS NOT NULL",
+	models.ActivityTypeGame:       "arg.id IS NOT NULL",
+}
+
+// anyActivityTypeCondition matches the registrations of every activity type.
+const anyActivityTypeCondition = "(de.id IS NOT NULL OR arb.id IS NOT NULL OR arg.id IS NOT NULL OR arp.id IS NOT NULL)"
+
+type ActivityStorageInterface interface {
+	GetUserPage(filter *ActivityPageFilter) (interface{}, error)
+}
+
+// ActivityCursor is the position of an activity in the timeline, sorted by registration date and registration id.
+type ActivityCursor struct {
+	RegistrationDate int64
+	RegistrationId   uint
+}
+
+// ActivityPageFilter holds the filters of a page of the activity timeline of a user.
+// Zero dates are not applied, and a nil cursor returns the first page. When types are given,
+// only the activities of those types are returned, either kinds with their own table or declared activity types.
+type ActivityPageFilter struct {
+	UserId    uint
+	StartDate int64
+	EndDate   int64
+	Types     []models.ActivityType
+	After     *ActivityCursor
+	Limit     int
+}
+
+type ActivityStorage struct{}
+
+var failedToParseActivityError = &models.DbCouldNotParseItemError{DbItem: &models.Activity{}}
+
+// GetUserPage returns up to filter.Limit activities of the user following the cursor, most recent first.
+func (activityStorage *ActivityStorage) GetUserPage(filter *ActivityPageFilter) (interface{}, error) {
+	activities := []*models.Activity{}
+	conditions := []string{"ar.user_id = ?", "ar.deleted_at = 0"}
+	args := []interface{}{filter.UserId}
+	typeConditions := []string{}
+
+	for _, activityType := range filter.Types {
+		typeCondition, found := activityTypeConditions[activityType]
+
+		if !found {
+			typeCondition = "arp.activity_type = ?"
+			args = append(args, string(activityType))
+		}
+
+		typeConditions = append(typeConditions, typeCondition)
+	}
+
+	if len(typeConditions) == 0 {
+		conditions = append(conditions, anyActivityTypeCondition)
+	} else {
+		conditions = append(conditions, "("+strings.Join(typeConditions, " OR ")+")")
+	}
+
+	if filter.StartDate != 0 {
+		conditions = append(conditions, "ar.registration_date >= ?")
+		args = append(args, filter.StartDate)
+	}
+	if filter.EndDate != 0 {
+		conditions = append(conditions, "ar.registration_date <= ?")
+		args = append(args, filter.EndDate)
+	}
+	if filter.After != nil {
+		conditions = append(conditions, "(ar.registration_date < ? OR (ar.registration_date = ? AND ar.id < ?))")
+		args = append(args, filter.After.RegistrationDate, filter.After.RegistrationDate, filter.After.RegistrationId)
+	}
+
+	query := getUserActivitiesQuery + " WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY ar.registration_date DESC, ar.id DESC LIMIT ?;"
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query, append(args, filter.Limit)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedActivity, scanErr := activityStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		activity, ok := scannedActivity.(models.Activity)
+
+		if !ok {
+			return nil, failedToParseActivityError
+		}
+
+		activities = append(activities, &activity)
+	}
+
+	if rowsErr := result.Err(); rowsErr != nil {
+		return nil, rowsErr
+	}
+
+	return activities, nil
+}
+
+func (activityStorage *ActivityStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var activity models.Activity
+	var diaryEntryId, bookId, gameId, payloadId, mood sql.NullInt64
+	var title, encryptionAlgorithm, internetArchiveId, gameName, activityType, payload sql.NullString
+
+	scanErr := rows.Scan(&activity.Registration.Id, &activity.Registration.RegistrationDate, &activity.Registration.UserRefer,
+		&activity.Registration.DeletedAt, &activity.Registration.Version, &diaryEntryId, &title, &mood, &encryptionAlgorithm, &bookId, &internetArchiveId,
+		&gameId, &gameName, &payloadId, &activityType, &payload)
+
+	if scanErr != nil {
+		return activity, scanErr
+	}
+
+	switch {
+	case diaryEntryId.Valid:
+		activity.Type = models.ActivityTypeDiaryEntry
+		activity.Id = uint(diaryEntryId.Int64)
+		activity.Encrypted = len(encryptionAlgorithm.String) > 0
+		activity.Mood = parseDiaryEntryMood(mood)
+		activity.Title, scanErr = decryptDiaryText(activity.Registration.UserRefer, diaryEntryTitleColumn, title.String)
+	case bookId.Valid:
+		activity.Type = models.ActivityTypeBook
+		activity.Id = uint(bookId.Int64)
+		activity.InternetArchiveIdentifier = internetArchiveId.String
+	case gameId.Valid:
+		activity.Type = models.ActivityTypeGame
+		activity.Id = uint(gameId.Int64)
+		activity.GameName = gameName.String
+	case payloadId.Valid:
+		activity.Type = models.ActivityType(activityType.String)
+		activity.Id = uint(payloadId.Int64)
+		activity.Payload = []byte(payload.String)
+	}
+
+	return activity, scanErr
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	getAuditEventsQuery   = "SELECT id, event_type, actor_id, target_type, target_id, ip, user_agent, details, created_at FROM audit_event"
+	countAuditEventsQuery = "SELECT COUNT(*) FROM audit_event"
+	insertAuditEventQuery = "INSERT INTO audit_event (event_type, actor_id, target_type, target_id, ip, user_agent, details, created_at)" +
+		" VALUES (?, ?, ?, ?, ?, ?, ?, ?);"
+)
+
+// AuditEventFilter holds the optional filters of an audit event query. Zero values are not applied.
+type AuditEventFilter struct {
+	ActorId    uint
+	EventType  models.AuditEventType
+	TargetType string
+	TargetId   uint
+	StartDate  int64
+	EndDate    int64
+	Limit      int
+	Offset     int
+}
+
+type AuditEventStorageInterface interface {
+	GetFiltered(filter *AuditEventFilter) (interface{}, error)
+	CountFiltered(filter *AuditEventFilter) (int, error)
+	Create(data interface{}) error
+}
+
+type AuditEventStorage struct{}
+
+var failedToParseAuditEventError = &models.DbCouldNotParseItemError{DbItem: &models.AuditEvent{}}
+
+// GetFiltered returns the audit events matching the filter, most recent first.
+func (auditEventStorage *AuditEventStorage) GetFiltered(filter *AuditEventFilter) (interface{}, error) {
+	auditEvents := []*models.AuditEvent{}
+	whereClause, args := buildAuditEventWhereClause(filter)
+	query := getAuditEventsQuery + whereClause + " ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?;"
+
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query, append(args, filter.Limit, filter.Offset)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedAuditEvent, scanErr := auditEventStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		auditEvent, ok := scannedAuditEvent.(models.AuditEvent)
+
+		if !ok {
+			return nil, failedToParseAuditEventError
+		}
+
+		auditEvents = append(auditEvents, &auditEvent)
+	}
+
+	return auditEvents, nil
+}
+
+// CountFiltered returns the number of audit events matching the filter, ignoring its limit and offset.
+func (auditEventStorage *AuditEventStorage) CountFiltered(filter *AuditEventFilter) (int, error) {
+	var count int
+	whereClause, args := buildAuditEventWhereClause(filter)
+
+	err := database.GetDatabaseInstance().GetConnection().QueryRow(countAuditEventsQuery+whereClause+";", args...).Scan(&count)
+
+	return count, err
+}
+
+func (auditEventStorage *AuditEventStorage) Create(auditEvent interface{}) error {
+	dbAuditEvent, ok := auditEvent.(*models.AuditEvent)
+
+	if !ok {
+		return failedToParseAuditEventError
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertAuditEventQuery,
+		dbAuditEvent.EventType,
+		dbAuditEvent.ActorId,
+		dbAuditEvent.TargetType,
+		dbAuditEvent.TargetId,
+		dbAuditEvent.IP,
+		dbAuditEvent.UserAgent,
+		dbAuditEvent.Details,
+		dbAuditEvent.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	auditEventId, idErr := result.LastInsertId()
+	if idErr != nil {
+		return idErr
+	}
+
+	dbAuditEvent.Id = uint(auditEventId)
+
+	return nil
+}
+
+func (auditEventStorage *AuditEventStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var auditEvent models.AuditEvent
+
+	scanErr := rows.Scan(&auditEvent.Id, &auditEvent.EventType, &auditEvent.ActorId, &auditEvent.TargetType,
+		&auditEvent.TargetId, &auditEvent.IP, &auditEvent.UserAgent, &auditEvent.Details, &auditEvent.CreatedAt)
+
+	return auditEvent, scanErr
+}
+
+func buildAuditEventWhereClause(filter *AuditEventFilter) (string, []interface{}) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	if filter.ActorId != 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorId)
+	}
+	if len(filter.EventType) > 0 {
+		conditions = append(conditions, "event_type = ?")
+		args = append(args, filter.EventType)
+	}
+	if len(filter.TargetType) > 0 {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetId != 0 {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, filter.TargetId)
+	}
+	if filter.StartDate != 0 {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.StartDate)
+	}
+	if filter.EndDate != 0 {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.EndDate)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	blobStoreLocalPathEnv     = "BLOB_STORE_LOCAL_PATH"
+	defaultBlobStoreLocalPath = "data/blobs"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+var ErrInvalidBlobKey = errors.New("invalid blob key")
+
+// BlobStore stores file contents by key. Keys are slash separated paths, such as "attachments/1/abc".
+// Implementations backed by S3-compatible object stores can map keys to object names as they are.
+type BlobStore interface {
+	Put(key string, content io.Reader) error
+	// Get returns the content of the blob, or ErrBlobNotFound. Callers must close it.
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the blob. Deleting a missing blob is not an error.
+	Delete(key string) error
+}
+
+// LocalBlobStore stores blobs as files under a root directory. When Root is empty, the directory is read
+// from the BLOB_STORE_LOCAL_PATH environment variable, defaulting to data/blobs.
+type LocalBlobStore struct {
+	Root string
+}
+
+func (localBlobStore *LocalBlobStore) Put(key string, content io.Reader) error {
+	blobPath, pathErr := localBlobStore.getBlobPath(key)
+
+	if pathErr != nil {
+		return pathErr
+	}
+
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0o750); err != nil {
+		return err
+	}
+
+	// the content is written to a temporary file first, so readers never see a partial blob
+	tempFile, err := os.CreateTemp(filepath.Dir(blobPath), ".upload-*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tempFile.Name())
+
+	if _, copyErr := io.Copy(tempFile, content); copyErr != nil {
+		tempFile.Close()
+		return copyErr
+	}
+
+	if closeErr := tempFile.Close(); closeErr != nil {
+		return closeErr
+	}
+
+	return os.Rename(tempFile.Name(), blobPath)
+}
+
+func (localBlobStore *LocalBlobStore) Get(key string) (io.ReadCloser, error) {
+	blobPath, pathErr := localBlobStore.getBlobPath(key)
+
+	if pathErr != nil {
+		return nil, pathErr
+	}
+
+	blobFile, err := os.Open(blobPath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+
+	return blobFile, err
+}
+
+func (localBlobStore *LocalBlobStore) Delete(key string) error {
+	blobPath, pathErr := localBlobStore.getBlobPath(key)
+
+	if pathErr != nil {
+		return pathErr
+	}
+
+	if err := os.Remove(blobPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// getBlobPath returns the file of the blob, rejecting keys that would escape the root directory.
+func (localBlobStore *LocalBlobStore) getBlobPath(key string) (string, error) {
+	if len(key) == 0 || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidBlobKey
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if len(segment) == 0 || segment == "." || segment == ".." {
+			return "", ErrInvalidBlobKey
+		}
+	}
+
+	root := localBlobStore.Root
+
+	if len(root) == 0 {
+		root = os.Getenv(blobStoreLocalPathEnv)
+	}
+	if len(root) == 0 {
+		root = defaultBlobStoreLocalPath
+	}
+
+	return filepath.Join(root, filepath.FromSlash(key)), nil
+}
@@ -8,18 +8,22 @@ import (
 )
 
 const (
-	getBookActivityRegistrationByIdentifierQuery  = "SELECT arb.id, arb.internet_archive_id, ar.registration_date, ar.user_id FROM activity_registration_book arb INNER JOIN activity_registration ar ON (arb.registration_id = ar.id) WHERE arb.id = ?;"
-	getUserBookActivityRegistrationsQuery         = "SELECT arb.id, arb.internet_archive_id, ar.id, ar.registration_date, ar.user_id FROM activity_registration_book arb INNER JOIN activity_registration ar ON (arb.registration_id = ar.id) WHERE ar.user_id = ?;"
-	getIntervalUserBookActivityRegistrationsQuery = "SELECT arb.id, arb.internet_archive_id, ar.id, ar.registration_date, ar.user_id FROM activity_registration_book arb INNER JOIN activity_registration ar ON (arb.registration_id = ar.id) WHERE ar.user_id = ? AND ar.registration_date >= ? AND ar.registration_date <= ?;"
+	getBookActivityRegistrationByIdentifierQuery  = "SELECT arb.id, arb.internet_archive_id, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_book arb INNER JOIN activity_registration ar ON (arb.registration_id = ar.id) WHERE arb.id = ? AND ar.deleted_at = 0;"
+	getUserBookActivityRegistrationsQuery         = "SELECT arb.id, arb.internet_archive_id, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_book arb INNER JOIN activity_registration ar ON (arb.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at = 0;"
+	getIntervalUserBookActivityRegistrationsQuery = "SELECT arb.id, arb.internet_archive_id, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_book arb INNER JOIN activity_registration ar ON (arb.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at = 0 AND ar.registration_date >= ? AND ar.registration_date <= ?;"
+	getUserTrashedBookActivityRegistrationsQuery  = "SELECT arb.id, arb.internet_archive_id, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_book arb INNER JOIN activity_registration ar ON (arb.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at != 0 ORDER BY ar.deleted_at DESC, arb.id DESC;"
 	insertBookActivityRegistrationQuery           = "INSERT INTO activity_registration_book (internet_archive_id, registration_id) VALUES (?, ?);"
 	updateBookActivityRegistrationQuery           = "UPDATE activity_registration_book SET internet_archive_id = ? WHERE id = ?;"
 	deleteBookActivityRegistrationQuery           = "DELETE FROM activity_registration_book WHERE id = ?;"
 )
 
 type BookActivityRegistrationStorageInterface interface {
+	Get(id uint) (interface{}, error)
 	GetByUserId(userId uint) (interface{}, error)
+	GetTrashedByUserId(userId uint) (interface{}, error)
 	GetByUserIdAndTimeRange(userId uint, startTime int64, endTime int64) (interface{}, error)
 	Create(data interface{}) error
+	Update(data interface{}) error
 }
 
 type BookActivityRegistrationStorage struct{}
@@ -119,6 +123,35 @@ func (bookActivityRegistrationStorage *BookActivityRegistrationStorage) GetByUse
 	return userBookActivityRegistrations, nil
 }
 
+// GetTrashedByUserId returns the book registrations in the trash of the user, most recently deleted first.
+func (bookActivityRegistrationStorage *BookActivityRegistrationStorage) GetTrashedByUserId(userId uint) (interface{}, error) {
+	trashedBookActivityRegistrations := []*models.BookActivityRegistration{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getUserTrashedBookActivityRegistrationsQuery, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedBookActivityRegistration, scanErr := bookActivityRegistrationStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		bookActivityRegistration, ok := scannedBookActivityRegistration.(models.BookActivityRegistration)
+
+		if !ok {
+			return nil, failedToParseBookActivityRegistrationError
+		}
+
+		trashedBookActivityRegistrations = append(trashedBookActivityRegistrations, &bookActivityRegistration)
+	}
+
+	return trashedBookActivityRegistrations, nil
+}
+
 func (bookActivityRegistrationStorage *BookActivityRegistrationStorage) Create(bookRegistration interface{}) error {
 	dbBookRegistration, ok := bookRegistration.(*models.BookActivityRegistration)
 
@@ -144,6 +177,8 @@ func (bookActivityRegistrationStorage *BookActivityRegistrationStorage) Create(b
 	return nil
 }
 
+// Update saves the book and the date of its registration in one transaction. The registration version must be
+// the stored one, otherwise a DbVersionConflictError is returned. It is incremented on success.
 func (bookActivityRegistrationStorage *BookActivityRegistrationStorage) Update(bookRegistration interface{}) error {
 	dbBookRegistration, ok := bookRegistration.(*models.BookActivityRegistration)
 
@@ -151,7 +186,19 @@ func (bookActivityRegistrationStorage *BookActivityRegistrationStorage) Update(b
 		return failedToParseBookActivityRegistrationError
 	}
 
-	result, err := database.GetDatabaseInstance().GetConnection().Exec(updateDiaryEntryQuery,
+	transaction, txErr := database.GetDatabaseInstance().GetConnection().Begin()
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer transaction.Rollback()
+
+	if registrationErr := updateActivityRegistration(transaction, &dbBookRegistration.Registration); registrationErr != nil {
+		return registrationErr
+	}
+
+	result, err := transaction.Exec(updateBookActivityRegistrationQuery,
 		dbBookRegistration.InternetArchiveIdentifier,
 		dbBookRegistration.Id)
 
@@ -169,6 +216,12 @@ func (bookActivityRegistrationStorage *BookActivityRegistrationStorage) Update(b
 		return bookActivityRegistrationNotFoundError
 	}
 
+	if commitErr := transaction.Commit(); commitErr != nil {
+		return commitErr
+	}
+
+	dbBookRegistration.Registration.Version++
+
 	return nil
 }
 
@@ -196,7 +249,7 @@ func (bookActivityRegistrationStorage *BookActivityRegistrationStorage) Scan(row
 	var bookActivityRegistration models.BookActivityRegistration
 
 	scanErr := rows.Scan(&bookActivityRegistration.Id, &bookActivityRegistration.InternetArchiveIdentifier, &bookActivityRegistration.Registration.Id,
-		&bookActivityRegistration.Registration.RegistrationDate, &bookActivityRegistration.Registration.UserRefer)
+		&bookActivityRegistration.Registration.RegistrationDate, &bookActivityRegistration.Registration.UserRefer, &bookActivityRegistration.Registration.DeletedAt, &bookActivityRegistration.Registration.Version)
 
 	return bookActivityRegistration, scanErr
 }
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+// getUserCalendarDaysQuery groups the activity registrations of a user by the day ranges of its calendar_day values,
+// which are added for each query. Days are identified by their position, as the driver reads date-like text as times.
+// A registration is either a diary entry, a book or a game, so the joins do not multiply the rows.
+const getUserCalendarDaysQuery = "WITH calendar_day (position, start_date, end_date) AS (VALUES %s)" +
+	" SELECT cd.position, COUNT(de.id), COUNT(arb.id), COUNT(arg.id)," +
+	" json_group_array(json_object('id', de.id, 'title', de.title, 'mood', de.mood," +
+	" 'encrypted', json(iif(de.encryption_algorithm != '', 'true', 'false')), 'registrationDate', ar.registration_date))" +
+	" FILTER (WHERE de.id IS NOT NULL)," +
+	" json_group_array(json_object('id', arb.id, 'internetArchiveId', arb.internet_archive_id, 'registrationDate', ar.registration_date))" +
+	" FILTER (WHERE arb.id IS NOT NULL)," +
+	" json_group_array(json_object('id', arg.id, 'gameName', arg.game_name, 'registrationDate', ar.registration_date))" +
+	" FILTER (WHERE arg.id IS NOT NULL)" +
+	" FROM calendar_day cd" +
+	" INNER JOIN activity_registration ar ON (ar.registration_date BETWEEN cd.start_date AND cd.end_date)" +
+	" LEFT JOIN diary_entry de ON (de.registration_id = ar.id)" +
+	" LEFT JOIN activity_registration_book arb ON (arb.registration_id = ar.id)" +
+	" LEFT JOIN activity_registration_game arg ON (arg.registration_id = ar.id)" +
+	" WHERE ar.user_id = ? AND ar.deleted_at = 0" +
+	" GROUP BY cd.position ORDER BY cd.position;"
+
+// CalendarDayRange is a day of the calendar of a user, from its first to its last second in the time zone of the user.
+type CalendarDayRange struct {
+	Date      string
+	StartDate int64
+	EndDate   int64
+}
+
+type CalendarStorageInterface interface {
+	GetUserDays(userId uint, dayRanges []CalendarDayRange) (interface{}, error)
+}
+
+type CalendarStorage struct{}
+
+var failedToParseCalendarDayError = &models.DbCouldNotParseItemError{DbItem: &models.CalendarDay{}}
+
+// GetUserDays returns the activity of the user on the days with any, in the order of the day ranges. The summaries of each day
+// are sorted by registration date.
+func (calendarStorage *CalendarStorage) GetUserDays(userId uint, dayRanges []CalendarDayRange) (interface{}, error) {
+	calendarDays := []*models.CalendarDay{}
+
+	if len(dayRanges) == 0 {
+		return calendarDays, nil
+	}
+
+	args := make([]interface{}, 0, len(dayRanges)*3+1)
+
+	for position, dayRange := range dayRanges {
+		args = append(args, position, dayRange.StartDate, dayRange.EndDate)
+	}
+
+	args = append(args, userId)
+	query := fmt.Sprintf(getUserCalendarDaysQuery, "(?, ?, ?)"+strings.Repeat(", (?, ?, ?)", len(dayRanges)-1))
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		calendarDay := &models.CalendarDay{}
+		var position int
+		var diaryEntries, books, games string
+
+		if scanErr := result.Scan(&position, &calendarDay.DiaryEntryCount, &calendarDay.BookCount, &calendarDay.GameCount,
+			&diaryEntries, &books, &games); scanErr != nil {
+			return nil, scanErr
+		}
+
+		if position < 0 || position >= len(dayRanges) {
+			return nil, failedToParseCalendarDayError
+		}
+
+		calendarDay.Date = dayRanges[position].Date
+
+		if json.Unmarshal([]byte(diaryEntries), &calendarDay.DiaryEntries) != nil ||
+			json.Unmarshal([]byte(books), &calendarDay.Books) != nil ||
+			json.Unmarshal([]byte(games), &calendarDay.Games) != nil {
+			return nil, failedToParseCalendarDayError
+		}
+
+		for _, diaryEntry := range calendarDay.DiaryEntries {
+			decryptedTitle, decryptErr := decryptDiaryText(userId, diaryEntryTitleColumn, diaryEntry.Title)
+
+			if decryptErr != nil {
+				return nil, decryptErr
+			}
+
+			diaryEntry.Title = decryptedTitle
+		}
+
+		slices.SortStableFunc(calendarDay.DiaryEntries, func(a, b *models.CalendarDiaryEntry) int {
+			return cmp.Compare(a.RegistrationDate, b.RegistrationDate)
+		})
+		slices.SortStableFunc(calendarDay.Books, func(a, b *models.CalendarBook) int {
+			return cmp.Compare(a.RegistrationDate, b.RegistrationDate)
+		})
+		slices.SortStableFunc(calendarDay.Games, func(a, b *models.CalendarGame) int {
+			return cmp.Compare(a.RegistrationDate, b.RegistrationDate)
+		})
+
+		calendarDays = append(calendarDays, calendarDay)
+	}
+
+	if rowsErr := result.Err(); rowsErr != nil {
+		return nil, rowsErr
+	}
+
+	return calendarDays, nil
+}
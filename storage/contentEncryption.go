@@ -0,0 +1,383 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/adfer-dev/analock-api/utils"
+)
+
+const (
+	diaryEntryTitleColumn          = "title"
+	diaryEntryContentColumn        = "content"
+	getUserSearchIndexEnabledQuery = "SELECT search_index_enabled FROM user WHERE id = ?;"
+	getDiaryEntryUserIdQuery       = "SELECT ar.user_id FROM diary_entry de INNER JOIN activity_registration ar ON (de.registration_id = ar.id) WHERE de.id = ?;"
+	getPlaintextDiaryEntriesQuery  = "SELECT de.id, de.title, de.content, de.encryption_algorithm, ar.user_id FROM diary_entry de" +
+		" INNER JOIN activity_registration ar ON (de.registration_id = ar.id)" +
+		" WHERE de.title NOT LIKE '" + utils.EncryptedValuePrefix + "%' OR de.content NOT LIKE '" + utils.EncryptedValuePrefix + "%';"
+	getPlaintextDiaryEntryRevisionsQuery = "SELECT r.id, r.title, r.content, ar.user_id FROM diary_entry_revision r" +
+		" INNER JOIN diary_entry de ON (r.diary_entry_id = de.id) INNER JOIN activity_registration ar ON (de.registration_id = ar.id)" +
+		" WHERE r.title NOT LIKE '" + utils.EncryptedValuePrefix + "%' OR r.content NOT LIKE '" + utils.EncryptedValuePrefix + "%';"
+	updateDiaryEntryTextQuery         = "UPDATE diary_entry SET title = ?, content = ? WHERE id = ?;"
+	updateDiaryEntryRevisionTextQuery = "UPDATE diary_entry_revision SET title = ?, content = ? WHERE id = ?;"
+)
+
+var ErrContentEncryptionDisabled = errors.New("no master key is configured")
+
+// the master keys are read once, the first time diary content is encrypted or decrypted
+var loadMasterKeyRing = sync.OnceValues(utils.LoadMasterKeyRing)
+
+// unwrapped data keys by user id, so the master key is only used once per user
+var userDataKeys = make(map[uint][]byte)
+var userDataKeysMutex sync.Mutex
+
+var userDataKeyStorage UserDataKeyStorageInterface = &UserDataKeyStorage{}
+
+// LoadContentEncryptionKeys reads the master keys, so a malformed key is reported on startup instead of on the first diary write.
+func LoadContentEncryptionKeys() error {
+	_, err := loadMasterKeyRing()
+
+	return err
+}
+
+// IsContentEncryptionEnabled reports whether diary content is encrypted at rest, which is the case when a master key is configured.
+func IsContentEncryptionEnabled() bool {
+	keyRing, err := loadMasterKeyRing()
+
+	return err == nil && keyRing != nil
+}
+
+// getUserDataKey returns the unwrapped data key of the user, creating it when the user has none yet.
+func getUserDataKey(userId uint) ([]byte, error) {
+	userDataKeysMutex.Lock()
+	defer userDataKeysMutex.Unlock()
+
+	if dataKey, found := userDataKeys[userId]; found {
+		return dataKey, nil
+	}
+
+	keyRing, keyRingErr := loadMasterKeyRing()
+
+	if keyRingErr != nil {
+		return nil, keyRingErr
+	}
+
+	if keyRing == nil {
+		return nil, ErrContentEncryptionDisabled
+	}
+
+	storedDataKey, err := userDataKeyStorage.Get(userId)
+
+	if _, isNotFoundErr := err.(*models.DbNotFoundError); isNotFoundErr {
+		storedDataKey, err = createUserDataKey(keyRing, userId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	userDataKey := storedDataKey.(*models.UserDataKey)
+	dataKey, unwrapErr := keyRing.UnwrapKey(userDataKey.WrappedKey, userDataKey.MasterKeyId)
+
+	if unwrapErr != nil {
+		return nil, unwrapErr
+	}
+
+	userDataKeys[userId] = dataKey
+
+	return dataKey, nil
+}
+
+func createUserDataKey(keyRing *utils.MasterKeyRing, userId uint) (interface{}, error) {
+	dataKey, err := utils.GenerateDataKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, masterKeyId, wrapErr := keyRing.WrapKey(dataKey)
+
+	if wrapErr != nil {
+		return nil, wrapErr
+	}
+
+	createErr := userDataKeyStorage.Create(&models.UserDataKey{
+		UserRefer:   userId,
+		WrappedKey:  wrappedKey,
+		MasterKeyId: masterKeyId,
+		CreatedAt:   time.Now().Unix(),
+	})
+
+	if createErr != nil {
+		return nil, createErr
+	}
+
+	return userDataKeyStorage.Get(userId)
+}
+
+// forgetUserDataKey drops the cached data key of a deleted user, as its id may be reused.
+func forgetUserDataKey(userId uint) {
+	userDataKeysMutex.Lock()
+	defer userDataKeysMutex.Unlock()
+
+	delete(userDataKeys, userId)
+}
+
+// encryptDiaryText encrypts a diary column value with the data key of its owner. Values are stored as they are when
+// no master key is configured.
+func encryptDiaryText(userId uint, column string, value string) (string, error) {
+	if !IsContentEncryptionEnabled() {
+		return value, nil
+	}
+
+	dataKey, err := getUserDataKey(userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	return utils.EncryptValue(dataKey, value, getDiaryTextAssociatedData(userId, column))
+}
+
+// decryptDiaryText decrypts a diary column value. Values stored before encryption at rest was enabled are returned as they are.
+func decryptDiaryText(userId uint, column string, value string) (string, error) {
+	if !utils.IsEncryptedValue(value) {
+		return value, nil
+	}
+
+	dataKey, err := getUserDataKey(userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	return utils.DecryptValue(dataKey, value, getDiaryTextAssociatedData(userId, column))
+}
+
+// encryptDiaryTitleAndContent returns the title and content as they are stored.
+func encryptDiaryTitleAndContent(userId uint, title string, content string) (string, string, error) {
+	encryptedTitle, titleErr := encryptDiaryText(userId, diaryEntryTitleColumn, title)
+
+	if titleErr != nil {
+		return "", "", titleErr
+	}
+
+	encryptedContent, contentErr := encryptDiaryText(userId, diaryEntryContentColumn, content)
+
+	return encryptedTitle, encryptedContent, contentErr
+}
+
+// decryptDiaryTitleAndContent returns the stored title and content in plaintext.
+func decryptDiaryTitleAndContent(userId uint, title string, content string) (string, string, error) {
+	decryptedTitle, titleErr := decryptDiaryText(userId, diaryEntryTitleColumn, title)
+
+	if titleErr != nil {
+		return "", "", titleErr
+	}
+
+	decryptedContent, contentErr := decryptDiaryText(userId, diaryEntryContentColumn, content)
+
+	return decryptedTitle, decryptedContent, contentErr
+}
+
+// getDiaryTextAssociatedData binds the ciphertext to its owner and column. Diary entries and their revisions share it,
+// so both are decrypted the same way.
+func getDiaryTextAssociatedData(userId uint, column string) string {
+	return fmt.Sprintf("diary_entry.%s:%d", column, userId)
+}
+
+// isUserSearchIndexEnabled reports whether the diary entries of the user can be indexed for full-text search.
+// The index holds plaintext, so users whose content is encrypted at rest must opt into it.
+func isUserSearchIndexEnabled(transaction *sql.Tx, userId uint) (bool, error) {
+	if !IsContentEncryptionEnabled() {
+		return true, nil
+	}
+
+	var searchIndexEnabled bool
+
+	err := transaction.QueryRow(getUserSearchIndexEnabledQuery, userId).Scan(&searchIndexEnabled)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	return searchIndexEnabled, err
+}
+
+// RewrapUserDataKeys wraps with the active master key the data keys wrapped by previous master keys,
+// returning how many were re-wrapped. Previous master keys can be removed from the configuration afterwards.
+func RewrapUserDataKeys() (int, error) {
+	keyRing, keyRingErr := loadMasterKeyRing()
+
+	if keyRingErr != nil {
+		return 0, keyRingErr
+	}
+
+	if keyRing == nil {
+		return 0, ErrContentEncryptionDisabled
+	}
+
+	storedDataKeys, err := userDataKeyStorage.GetAll()
+
+	if err != nil {
+		return 0, err
+	}
+
+	rewrappedKeys := 0
+
+	for _, userDataKey := range storedDataKeys.([]*models.UserDataKey) {
+		if userDataKey.MasterKeyId == keyRing.ActiveKeyId() {
+			continue
+		}
+
+		dataKey, unwrapErr := keyRing.UnwrapKey(userDataKey.WrappedKey, userDataKey.MasterKeyId)
+
+		if unwrapErr != nil {
+			return rewrappedKeys, fmt.Errorf("could not unwrap the data key of user %d: %w", userDataKey.UserRefer, unwrapErr)
+		}
+
+		userDataKey.WrappedKey, userDataKey.MasterKeyId, err = keyRing.WrapKey(dataKey)
+
+		if err != nil {
+			return rewrappedKeys, err
+		}
+
+		if updateErr := userDataKeyStorage.Update(userDataKey); updateErr != nil {
+			return rewrappedKeys, updateErr
+		}
+
+		rewrappedKeys++
+	}
+
+	return rewrappedKeys, nil
+}
+
+// plaintextDiaryText is a stored diary entry or revision whose title or content is not encrypted yet.
+type plaintextDiaryText struct {
+	id         uint
+	userId     uint
+	title      string
+	content    string
+	encryption string
+}
+
+// EncryptDiaryContent encrypts the diary entries and revisions stored before encryption at rest was enabled,
+// returning how many rows were encrypted. The full-text index of the entries is rebuilt, so it only keeps
+// the content of the users who opted into it.
+func EncryptDiaryContent() (int, error) {
+	if !IsContentEncryptionEnabled() {
+		return 0, ErrContentEncryptionDisabled
+	}
+
+	plaintextEntries, err := getPlaintextDiaryTexts(getPlaintextDiaryEntriesQuery, true)
+
+	if err != nil {
+		return 0, err
+	}
+
+	plaintextRevisions, err := getPlaintextDiaryTexts(getPlaintextDiaryEntryRevisionsQuery, false)
+
+	if err != nil {
+		return 0, err
+	}
+
+	encryptedRows := 0
+
+	for _, plaintextEntry := range plaintextEntries {
+		if err := encryptDiaryEntryText(plaintextEntry); err != nil {
+			return encryptedRows, err
+		}
+
+		encryptedRows++
+	}
+
+	for _, plaintextRevision := range plaintextRevisions {
+		title, content, err := encryptDiaryTitleAndContent(plaintextRevision.userId, plaintextRevision.title, plaintextRevision.content)
+
+		if err != nil {
+			return encryptedRows, err
+		}
+
+		if _, err := database.GetDatabaseInstance().GetConnection().Exec(updateDiaryEntryRevisionTextQuery, title, content, plaintextRevision.id); err != nil {
+			return encryptedRows, err
+		}
+
+		encryptedRows++
+	}
+
+	return encryptedRows, nil
+}
+
+func getPlaintextDiaryTexts(query string, withEncryption bool) ([]*plaintextDiaryText, error) {
+	plaintextTexts := []*plaintextDiaryText{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		plaintextText := &plaintextDiaryText{}
+		columns := []any{&plaintextText.id, &plaintextText.title, &plaintextText.content}
+
+		if withEncryption {
+			columns = append(columns, &plaintextText.encryption)
+		}
+
+		if scanErr := result.Scan(append(columns, &plaintextText.userId)...); scanErr != nil {
+			return nil, scanErr
+		}
+
+		plaintextTexts = append(plaintextTexts, plaintextText)
+	}
+
+	return plaintextTexts, result.Err()
+}
+
+// encryptDiaryEntryText encrypts a plaintext diary entry and indexes it again.
+func encryptDiaryEntryText(plaintextEntry *plaintextDiaryText) error {
+	// values already encrypted are kept, only the plaintext column is encrypted
+	title, content, err := decryptDiaryTitleAndContent(plaintextEntry.userId, plaintextEntry.title, plaintextEntry.content)
+
+	if err != nil {
+		return err
+	}
+
+	encryptedTitle, encryptedContent, err := encryptDiaryTitleAndContent(plaintextEntry.userId, title, content)
+
+	if err != nil {
+		return err
+	}
+
+	transaction, txErr := database.GetDatabaseInstance().GetConnection().Begin()
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer transaction.Rollback()
+
+	if _, err := transaction.Exec(updateDiaryEntryTextQuery, encryptedTitle, encryptedContent, plaintextEntry.id); err != nil {
+		return err
+	}
+
+	diaryEntry := &models.DiaryEntry{Id: plaintextEntry.id, Title: title, Content: content}
+	diaryEntry.Registration.UserRefer = plaintextEntry.userId
+
+	if len(plaintextEntry.encryption) > 0 {
+		diaryEntry.Encryption = &models.DiaryEntryEncryption{Algorithm: plaintextEntry.encryption}
+	}
+
+	if err := indexDiaryEntry(transaction, diaryEntry); err != nil {
+		return err
+	}
+
+	return transaction.Commit()
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	getCustomActivityRegistrationByIdentifierQuery = "SELECT arp.id, arp.activity_type, arp.payload, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_payload arp INNER JOIN activity_registration ar ON (arp.registration_id = ar.id) WHERE arp.id = ? AND ar.deleted_at = 0;"
+	getUserCustomActivityRegistrationsByTypeQuery  = "SELECT arp.id, arp.activity_type, arp.payload, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_payload arp INNER JOIN activity_registration ar ON (arp.registration_id = ar.id) WHERE ar.user_id = ? AND arp.activity_type = ? AND ar.deleted_at = 0" +
+		" AND (? = 0 OR ar.registration_date >= ?) AND (? = 0 OR ar.registration_date <= ?) ORDER BY ar.registration_date, arp.id;"
+	getUserCustomActivityRegistrationsQuery        = "SELECT arp.id, arp.activity_type, arp.payload, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_payload arp INNER JOIN activity_registration ar ON (arp.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at = 0 ORDER BY ar.registration_date, arp.id;"
+	getUserTrashedCustomActivityRegistrationsQuery = "SELECT arp.id, arp.activity_type, arp.payload, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_payload arp INNER JOIN activity_registration ar ON (arp.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at != 0 ORDER BY ar.deleted_at DESC, arp.id DESC;"
+	insertCustomActivityRegistrationQuery          = "INSERT INTO activity_registration_payload (activity_type, payload, registration_id) VALUES (?, ?, ?);"
+	updateCustomActivityRegistrationQuery          = "UPDATE activity_registration_payload SET payload = ? WHERE id = ?;"
+)
+
+type CustomActivityRegistrationStorageInterface interface {
+	Get(id uint) (interface{}, error)
+	GetByUserId(userId uint) (interface{}, error)
+	GetByUserIdAndType(userId uint, activityType string, startDate int64, endDate int64) (interface{}, error)
+	GetTrashedByUserId(userId uint) (interface{}, error)
+	Create(data interface{}) error
+	Update(data interface{}) error
+}
+
+type CustomActivityRegistrationStorage struct{}
+
+var customActivityRegistrationNotFoundError = &models.DbNotFoundError{DbItem: &models.CustomActivityRegistration{}}
+var failedToParseCustomActivityRegistrationError = &models.DbCouldNotParseItemError{DbItem: &models.CustomActivityRegistration{}}
+
+func (customActivityRegistrationStorage *CustomActivityRegistrationStorage) Get(id uint) (interface{}, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getCustomActivityRegistrationByIdentifierQuery, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	if !result.Next() {
+		return nil, customActivityRegistrationNotFoundError
+	}
+
+	scannedCustomActivityRegistration, scanErr := customActivityRegistrationStorage.Scan(result)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	customActivityRegistration, ok := scannedCustomActivityRegistration.(models.CustomActivityRegistration)
+
+	if !ok {
+		return nil, failedToParseCustomActivityRegistrationError
+	}
+
+	return &customActivityRegistration, nil
+}
+
+// GetByUserId returns the registrations of every declared activity type of the user, oldest first.
+func (customActivityRegistrationStorage *CustomActivityRegistrationStorage) GetByUserId(userId uint) (interface{}, error) {
+	return customActivityRegistrationStorage.queryList(getUserCustomActivityRegistrationsQuery, userId)
+}
+
+// GetByUserIdAndType returns the registrations of an activity type of the user, oldest first.
+// Zero dates are not applied.
+func (customActivityRegistrationStorage *CustomActivityRegistrationStorage) GetByUserIdAndType(userId uint, activityType string, startDate int64, endDate int64) (interface{}, error) {
+	return customActivityRegistrationStorage.queryList(getUserCustomActivityRegistrationsByTypeQuery, userId, activityType,
+		startDate, startDate, endDate, endDate)
+}
+
+// GetTrashedByUserId returns the registrations of declared activity types in the trash of the user, most recently deleted first.
+func (customActivityRegistrationStorage *CustomActivityRegistrationStorage) GetTrashedByUserId(userId uint) (interface{}, error) {
+	return customActivityRegistrationStorage.queryList(getUserTrashedCustomActivityRegistrationsQuery, userId)
+}
+
+func (customActivityRegistrationStorage *CustomActivityRegistrationStorage) Create(customRegistration interface{}) error {
+	dbCustomRegistration, ok := customRegistration.(*models.CustomActivityRegistration)
+
+	if !ok {
+		return failedToParseCustomActivityRegistrationError
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertCustomActivityRegistrationQuery,
+		dbCustomRegistration.ActivityType,
+		string(dbCustomRegistration.Payload),
+		dbCustomRegistration.Registration.Id)
+
+	if err != nil {
+		return err
+	}
+
+	customRegistrationId, idErr := result.LastInsertId()
+	if idErr != nil {
+		return idErr
+	}
+
+	dbCustomRegistration.Id = uint(customRegistrationId)
+
+	return nil
+}
+
+// Update saves the payload and the date of a registration in one transaction. Its activity type cannot be changed.
+// The registration version must be the stored one, otherwise a DbVersionConflictError is returned. It is incremented on success.
+func (customActivityRegistrationStorage *CustomActivityRegistrationStorage) Update(customRegistration interface{}) error {
+	dbCustomRegistration, ok := customRegistration.(*models.CustomActivityRegistration)
+
+	if !ok {
+		return failedToParseCustomActivityRegistrationError
+	}
+
+	transaction, txErr := database.GetDatabaseInstance().GetConnection().Begin()
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer transaction.Rollback()
+
+	if registrationErr := updateActivityRegistration(transaction, &dbCustomRegistration.Registration); registrationErr != nil {
+		return registrationErr
+	}
+
+	result, err := transaction.Exec(updateCustomActivityRegistrationQuery,
+		string(dbCustomRegistration.Payload),
+		dbCustomRegistration.Id)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
+	}
+
+	if affectedRows == 0 {
+		return customActivityRegistrationNotFoundError
+	}
+
+	if commitErr := transaction.Commit(); commitErr != nil {
+		return commitErr
+	}
+
+	dbCustomRegistration.Registration.Version++
+
+	return nil
+}
+
+func (customActivityRegistrationStorage *CustomActivityRegistrationStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var customActivityRegistration models.CustomActivityRegistration
+	var payload string
+
+	scanErr := rows.Scan(&customActivityRegistration.Id, &customActivityRegistration.ActivityType, &payload,
+		&customActivityRegistration.Registration.Id, &customActivityRegistration.Registration.RegistrationDate,
+		&customActivityRegistration.Registration.UserRefer, &customActivityRegistration.Registration.DeletedAt, &customActivityRegistration.Registration.Version)
+	customActivityRegistration.Payload = []byte(payload)
+
+	return customActivityRegistration, scanErr
+}
+
+func (customActivityRegistrationStorage *CustomActivityRegistrationStorage) queryList(query string, args ...interface{}) (interface{}, error) {
+	customActivityRegistrations := []*models.CustomActivityRegistration{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedCustomActivityRegistration, scanErr := customActivityRegistrationStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		customActivityRegistration, ok := scannedCustomActivityRegistration.(models.CustomActivityRegistration)
+
+		if !ok {
+			return nil, failedToParseCustomActivityRegistrationError
+		}
+
+		customActivityRegistrations = append(customActivityRegistrations, &customActivityRegistration)
+	}
+
+	return customActivityRegistrations, nil
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	diaryEntryAttachmentColumns          = "id, diary_entry_id, user_id, file_name, content_type, size, blob_key, thumbnail_key, created_at"
+	getDiaryEntryAttachmentQuery         = "SELECT " + diaryEntryAttachmentColumns + " FROM diary_entry_attachment WHERE id = ?;"
+	getDiaryEntryAttachmentsByEntryQuery = "SELECT " + diaryEntryAttachmentColumns + " FROM diary_entry_attachment WHERE diary_entry_id = ? ORDER BY created_at, id;"
+	getUserAttachmentsSizeQuery          = "SELECT COALESCE(SUM(size), 0) FROM diary_entry_attachment WHERE user_id = ?;"
+	insertDiaryEntryAttachmentQuery      = "INSERT INTO diary_entry_attachment (diary_entry_id, user_id, file_name, content_type, size, blob_key, thumbnail_key, created_at)" +
+		" VALUES (?, ?, ?, ?, ?, ?, ?, ?);"
+	deleteDiaryEntryAttachmentQuery         = "DELETE FROM diary_entry_attachment WHERE id = ?;"
+	deleteDiaryEntryAttachmentsByEntryQuery = "DELETE FROM diary_entry_attachment WHERE diary_entry_id = ?;"
+)
+
+type DiaryEntryAttachmentStorageInterface interface {
+	Get(id uint) (interface{}, error)
+	GetByDiaryEntryId(diaryEntryId uint) (interface{}, error)
+	GetUserTotalSize(userId uint) (int64, error)
+	Create(data interface{}) error
+	Delete(id uint) error
+	DeleteByDiaryEntryId(diaryEntryId uint) error
+}
+
+type DiaryEntryAttachmentStorage struct{}
+
+var diaryEntryAttachmentNotFoundError = &models.DbNotFoundError{DbItem: &models.DiaryEntryAttachment{}}
+var failedToParseDiaryEntryAttachmentError = &models.DbCouldNotParseItemError{DbItem: &models.DiaryEntryAttachment{}}
+
+func (diaryEntryAttachmentStorage *DiaryEntryAttachmentStorage) Get(id uint) (interface{}, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getDiaryEntryAttachmentQuery, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	if !result.Next() {
+		return nil, diaryEntryAttachmentNotFoundError
+	}
+
+	scannedAttachment, scanErr := diaryEntryAttachmentStorage.Scan(result)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	attachment, ok := scannedAttachment.(models.DiaryEntryAttachment)
+
+	if !ok {
+		return nil, failedToParseDiaryEntryAttachmentError
+	}
+
+	return &attachment, nil
+}
+
+// GetByDiaryEntryId returns the attachments of a diary entry, oldest first.
+func (diaryEntryAttachmentStorage *DiaryEntryAttachmentStorage) GetByDiaryEntryId(diaryEntryId uint) (interface{}, error) {
+	attachments := []*models.DiaryEntryAttachment{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getDiaryEntryAttachmentsByEntryQuery, diaryEntryId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedAttachment, scanErr := diaryEntryAttachmentStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		attachment, ok := scannedAttachment.(models.DiaryEntryAttachment)
+
+		if !ok {
+			return nil, failedToParseDiaryEntryAttachmentError
+		}
+
+		attachments = append(attachments, &attachment)
+	}
+
+	return attachments, nil
+}
+
+// GetUserTotalSize returns the bytes taken by the attachments of the user.
+func (diaryEntryAttachmentStorage *DiaryEntryAttachmentStorage) GetUserTotalSize(userId uint) (int64, error) {
+	var totalSize int64
+
+	err := database.GetDatabaseInstance().GetConnection().QueryRow(getUserAttachmentsSizeQuery, userId).Scan(&totalSize)
+
+	return totalSize, err
+}
+
+func (diaryEntryAttachmentStorage *DiaryEntryAttachmentStorage) Create(diaryEntryAttachment interface{}) error {
+	dbAttachment, ok := diaryEntryAttachment.(*models.DiaryEntryAttachment)
+
+	if !ok {
+		return failedToParseDiaryEntryAttachmentError
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertDiaryEntryAttachmentQuery,
+		dbAttachment.DiaryEntryRefer,
+		dbAttachment.UserRefer,
+		dbAttachment.FileName,
+		dbAttachment.ContentType,
+		dbAttachment.Size,
+		dbAttachment.BlobKey,
+		dbAttachment.ThumbnailKey,
+		dbAttachment.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	attachmentId, idErr := result.LastInsertId()
+	if idErr != nil {
+		return idErr
+	}
+
+	dbAttachment.Id = uint(attachmentId)
+
+	return nil
+}
+
+func (diaryEntryAttachmentStorage *DiaryEntryAttachmentStorage) Delete(id uint) error {
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(deleteDiaryEntryAttachmentQuery, id)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
+	}
+
+	if affectedRows == 0 {
+		return diaryEntryAttachmentNotFoundError
+	}
+
+	return nil
+}
+
+// DeleteByDiaryEntryId deletes all the attachments of a diary entry. Their blobs must be deleted by the caller.
+func (diaryEntryAttachmentStorage *DiaryEntryAttachmentStorage) DeleteByDiaryEntryId(diaryEntryId uint) error {
+	_, err := database.GetDatabaseInstance().GetConnection().Exec(deleteDiaryEntryAttachmentsByEntryQuery, diaryEntryId)
+
+	return err
+}
+
+func (diaryEntryAttachmentStorage *DiaryEntryAttachmentStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var attachment models.DiaryEntryAttachment
+
+	scanErr := rows.Scan(&attachment.Id, &attachment.DiaryEntryRefer, &attachment.UserRefer, &attachment.FileName,
+		&attachment.ContentType, &attachment.Size, &attachment.BlobKey, &attachment.ThumbnailKey, &attachment.CreatedAt)
+	attachment.HasThumbnail = len(attachment.ThumbnailKey) > 0
+
+	return attachment, scanErr
+}
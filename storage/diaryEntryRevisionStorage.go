@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	// diaryEntryRevisionColumns are the columns read by Scan. The owner of the entry is needed to decrypt the revision.
+	diaryEntryRevisionColumns = "r.id, r.diary_entry_id, r.title, r.content, r.publish_date, r.mood, r.feelings," +
+		" r.encryption_algorithm, r.encryption_nonce, r.encryption_key_id, r.encryption_wrapped_key, r.created_at, ar.user_id" +
+		" FROM diary_entry_revision r INNER JOIN diary_entry de ON (r.diary_entry_id = de.id)" +
+		" INNER JOIN activity_registration ar ON (de.registration_id = ar.id)"
+	getDiaryEntryRevisionQuery         = "SELECT " + diaryEntryRevisionColumns + " WHERE r.id = ?;"
+	getDiaryEntryRevisionsByEntryQuery = "SELECT " + diaryEntryRevisionColumns + " WHERE r.diary_entry_id = ? ORDER BY r.created_at DESC, r.id DESC;"
+	insertDiaryEntryRevisionQuery      = "INSERT INTO diary_entry_revision (diary_entry_id, title, content, publish_date, mood, feelings," +
+		" encryption_algorithm, encryption_nonce, encryption_key_id, encryption_wrapped_key, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);"
+	deleteDiaryEntryRevisionsByEntryQuery = "DELETE FROM diary_entry_revision WHERE diary_entry_id = ?;"
+)
+
+type DiaryEntryRevisionStorageInterface interface {
+	Get(id uint) (interface{}, error)
+	GetByDiaryEntryId(diaryEntryId uint) (interface{}, error)
+}
+
+type DiaryEntryRevisionStorage struct{}
+
+var diaryEntryRevisionNotFoundError = &models.DbNotFoundError{DbItem: &models.DiaryEntryRevision{}}
+var failedToParseDiaryEntryRevisionError = &models.DbCouldNotParseItemError{DbItem: &models.DiaryEntryRevision{}}
+
+func (diaryEntryRevisionStorage *DiaryEntryRevisionStorage) Get(id uint) (interface{}, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getDiaryEntryRevisionQuery, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	if !result.Next() {
+		return nil, diaryEntryRevisionNotFoundError
+	}
+
+	scannedRevision, scanErr := diaryEntryRevisionStorage.Scan(result)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	revision, ok := scannedRevision.(models.DiaryEntryRevision)
+
+	if !ok {
+		return nil, failedToParseDiaryEntryRevisionError
+	}
+
+	return &revision, nil
+}
+
+// GetByDiaryEntryId returns the revisions of a diary entry, newest first.
+func (diaryEntryRevisionStorage *DiaryEntryRevisionStorage) GetByDiaryEntryId(diaryEntryId uint) (interface{}, error) {
+	revisions := []*models.DiaryEntryRevision{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getDiaryEntryRevisionsByEntryQuery, diaryEntryId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedRevision, scanErr := diaryEntryRevisionStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		revision, ok := scannedRevision.(models.DiaryEntryRevision)
+
+		if !ok {
+			return nil, failedToParseDiaryEntryRevisionError
+		}
+
+		revisions = append(revisions, &revision)
+	}
+
+	return revisions, nil
+}
+
+// insertDiaryEntryRevision stores a replaced version of a diary entry within the transaction updating the entry.
+// The title and content are given already encrypted at rest.
+func insertDiaryEntryRevision(transaction *sql.Tx, revision *models.DiaryEntryRevision, title string, content string) error {
+	encryption := getDiaryEntryEncryptionColumns(revision.Encryption)
+	result, err := transaction.Exec(insertDiaryEntryRevisionQuery,
+		revision.DiaryEntryRefer,
+		title,
+		content,
+		revision.PublishDate,
+		revision.Mood,
+		strings.Join(revision.Feelings, ","),
+		encryption.Algorithm,
+		encryption.Nonce,
+		encryption.KeyId,
+		encryption.WrappedKey,
+		revision.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	revisionId, idErr := result.LastInsertId()
+	if idErr != nil {
+		return idErr
+	}
+
+	revision.Id = uint(revisionId)
+
+	return nil
+}
+
+func (diaryEntryRevisionStorage *DiaryEntryRevisionStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var revision models.DiaryEntryRevision
+	var mood sql.NullInt64
+	var feelings string
+	var encryption models.DiaryEntryEncryption
+	var userId uint
+
+	scanErr := rows.Scan(&revision.Id, &revision.DiaryEntryRefer, &revision.Title, &revision.Content,
+		&revision.PublishDate, &mood, &feelings, &encryption.Algorithm, &encryption.Nonce, &encryption.KeyId,
+		&encryption.WrappedKey, &revision.CreatedAt, &userId)
+	revision.Mood = parseDiaryEntryMood(mood)
+	revision.Encryption = parseDiaryEntryEncryption(encryption)
+	revision.Feelings = parseDiaryEntryFeelings(feelings)
+
+	if scanErr != nil {
+		return revision, scanErr
+	}
+
+	revision.Title, revision.Content, scanErr = decryptDiaryTitleAndContent(userId, revision.Title, revision.Content)
+
+	return revision, scanErr
+}
@@ -2,26 +2,97 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
+	"slices"
+	"strings"
 
 	"github.com/adfer-dev/analock-api/database"
 	"github.com/adfer-dev/analock-api/models"
 )
 
 const (
-	getDiaryEntryByIdentifierQuery   = "SELECT de.id, de.title, de.content, ar.id, ar.registration_date, ar.user_id FROM diary_entry de INNER JOIN activity_registration ar ON (de.id = ar.id) WHERE de.id = ?;"
-	getUserDiaryEntriesQuery         = "SELECT de.id, de.title, de.content, ar.id, ar.registration_date, ar.user_id FROM diary_entry de INNER JOIN activity_registration ar ON (de.registration_id = ar.id) WHERE ar.user_id = ?;"
-	getIntervalUserDiaryEntriesQuery = "SELECT de.id, de.title, de.content, ar.id, ar.registration_date, ar.user_id FROM diary_entry de INNER JOIN activity_registration ar ON (de.registration_id = ar.id) WHERE ar.user_id = ? AND ar.registration_date >= ? AND ar.registration_date <= ?;"
-	insertDiaryEntryQuery            = "INSERT INTO diary_entry (title, content, registration_id) VALUES (?, ?, ?);"
-	updateDiaryEntryQuery            = "UPDATE diary_entry SET title = ?, content = ? WHERE id = ?;"
-	deleteDiaryEntryQuery            = "DELETE FROM diary_entry WHERE id = ?;"
+	// diaryEntryColumns are the columns read by Scan. The tags of the entry are aggregated in a single comma separated column.
+	diaryEntryColumns = "de.id, de.title, de.content, de.mood, de.feelings," +
+		" de.encryption_algorithm, de.encryption_nonce, de.encryption_key_id, de.encryption_wrapped_key, de.version, de.prompt_id," +
+		" ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version," +
+		" (SELECT group_concat(t.name) FROM diary_entry_tag dt INNER JOIN tag t ON (dt.tag_id = t.id) WHERE dt.diary_entry_id = de.id)"
+	// the entries in the trash are only read by the trash queries
+	getDiaryEntryByIdentifierQuery   = "SELECT " + diaryEntryColumns + " FROM diary_entry de INNER JOIN activity_registration ar ON (de.registration_id = ar.id) WHERE de.id = ? AND ar.deleted_at = 0;"
+	getUserDiaryEntriesQuery         = "SELECT " + diaryEntryColumns + " FROM diary_entry de INNER JOIN activity_registration ar ON (de.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at = 0;"
+	getIntervalUserDiaryEntriesQuery = "SELECT " + diaryEntryColumns + " FROM diary_entry de INNER JOIN activity_registration ar ON (de.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at = 0 AND ar.registration_date >= ? AND ar.registration_date <= ?;"
+	getUserDiaryEntriesPageQuery     = "SELECT " + diaryEntryColumns + " FROM diary_entry de INNER JOIN activity_registration ar ON (de.registration_id = ar.id)"
+	getUserTrashedDiaryEntriesQuery  = "SELECT " + diaryEntryColumns + " FROM diary_entry de INNER JOIN activity_registration ar ON (de.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at != 0 ORDER BY ar.deleted_at DESC, de.id DESC;"
+	getExpiredDiaryEntriesQuery      = "SELECT " + diaryEntryColumns + " FROM diary_entry de INNER JOIN activity_registration ar ON (de.registration_id = ar.id) WHERE ar.deleted_at != 0 AND ar.deleted_at < ?;"
+	insertDiaryEntryQuery            = "INSERT INTO diary_entry (title, content, mood, feelings," +
+		" encryption_algorithm, encryption_nonce, encryption_key_id, encryption_wrapped_key, prompt_id, registration_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);"
+	// updateDiaryEntryQuery only updates the entry when it still has the version the update is based on
+	updateDiaryEntryQuery = "UPDATE diary_entry SET title = ?, content = ?, mood = ?, feelings = ?," +
+		" encryption_algorithm = ?, encryption_nonce = ?, encryption_key_id = ?, encryption_wrapped_key = ?, version = version + 1" +
+		" WHERE id = ? AND version = ?;"
+	getDiaryEntryVersionQuery = "SELECT version FROM diary_entry WHERE id = ?;"
+	// the publish date is updated along with the entry, whose version guards the update
+	updateDiaryEntryRegistrationDateQuery = "UPDATE activity_registration SET registration_date = ?, version = version + 1 WHERE id = ?;"
+	deleteDiaryEntryQuery                 = "DELETE FROM diary_entry WHERE id = ?;"
+	insertDiaryEntryFtsQuery              = "INSERT INTO diary_entry_fts (rowid, title, content) VALUES (?, ?, ?);"
+	deleteDiaryEntryFtsQuery              = "DELETE FROM diary_entry_fts WHERE rowid = ?;"
+	searchUserDiaryEntriesQuery           = "SELECT " + diaryEntryColumns + "," +
+		" snippet(diary_entry_fts, 0, '<mark>', '</mark>', '…', 8), snippet(diary_entry_fts, 1, '<mark>', '</mark>', '…', 24)" +
+		" FROM diary_entry_fts INNER JOIN diary_entry de ON (de.id = diary_entry_fts.rowid)" +
+		" INNER JOIN activity_registration ar ON (de.registration_id = ar.id)" +
+		" WHERE diary_entry_fts MATCH ? AND ar.user_id = ? AND ar.deleted_at = 0"
 )
 
 type DiaryEntryStorageInterface interface {
 	Get(id uint) (interface{}, error)
 	GetByUserId(userId uint) (interface{}, error)
 	GetByUserIdAndDateInterval(userId uint, startDate int64, endDate int64) (interface{}, error)
+	GetUserPage(filter *DiaryEntryPageFilter) (interface{}, error)
+	Search(filter *DiaryEntrySearchFilter) (interface{}, error)
 	Create(data interface{}) error
 	Update(data interface{}) error
+	GetTrashedByUserId(userId uint) (interface{}, error)
+	GetTrashedBefore(deletedBefore int64) (interface{}, error)
+	Delete(id uint) error
+	RebuildUserSearchIndex(userId uint) error
+}
+
+// DiaryEntryUpdate is an update of a diary entry, with the change to its revisions written in the same transaction.
+type DiaryEntryUpdate struct {
+	Entry *models.DiaryEntry
+	// Revision is the replaced version of the entry, kept so it can be restored. Nil stores no revision.
+	Revision *models.DiaryEntryRevision
+	// DeleteRevisions deletes the previous versions of the entry, which are not kept once it is end-to-end encrypted.
+	DeleteRevisions bool
+}
+
+// DiaryEntryCursor is the position of a diary entry in a listing, sorted by registration date and id.
+type DiaryEntryCursor struct {
+	RegistrationDate int64
+	Id               uint
+}
+
+// DiaryEntryPageFilter holds the filters of a page of user diary entries.
+// Zero dates are not applied, and a nil cursor returns the first page.
+// When tags are given, only the entries having all of them are returned.
+type DiaryEntryPageFilter struct {
+	UserId     uint
+	StartDate  int64
+	EndDate    int64
+	Tags       []string
+	Descending bool
+	After      *DiaryEntryCursor
+	Limit      int
+}
+
+// DiaryEntrySearchFilter holds a full-text search over the diary entries of a user.
+// Query is an FTS5 query expression. Zero dates are not applied.
+type DiaryEntrySearchFilter struct {
+	UserId    uint
+	Query     string
+	StartDate int64
+	EndDate   int64
+	Limit     int
+	Offset    int
 }
 
 type DiaryEntryStorage struct{}
@@ -113,6 +184,150 @@ func (diaryEntryStorage *DiaryEntryStorage) GetByUserIdAndDateInterval(userId ui
 	return userDiaryEntries, nil
 }
 
+// GetTrashedByUserId returns the diary entries in the trash of the user, most recently deleted first.
+func (diaryEntryStorage *DiaryEntryStorage) GetTrashedByUserId(userId uint) (interface{}, error) {
+	return diaryEntryStorage.queryDiaryEntries(getUserTrashedDiaryEntriesQuery, userId)
+}
+
+// GetTrashedBefore returns the diary entries of every user moved to the trash before the given time.
+func (diaryEntryStorage *DiaryEntryStorage) GetTrashedBefore(deletedBefore int64) (interface{}, error) {
+	return diaryEntryStorage.queryDiaryEntries(getExpiredDiaryEntriesQuery, deletedBefore)
+}
+
+func (diaryEntryStorage *DiaryEntryStorage) queryDiaryEntries(query string, args ...interface{}) ([]*models.DiaryEntry, error) {
+	diaryEntries := []*models.DiaryEntry{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedDiaryEntry, scanErr := diaryEntryStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		diaryEntry, ok := scannedDiaryEntry.(models.DiaryEntry)
+
+		if !ok {
+			return nil, failedToParseDiaryEntryError
+		}
+
+		diaryEntries = append(diaryEntries, &diaryEntry)
+	}
+
+	return diaryEntries, nil
+}
+
+// GetUserPage returns up to filter.Limit user diary entries following the cursor, in the requested order.
+func (diaryEntryStorage *DiaryEntryStorage) GetUserPage(filter *DiaryEntryPageFilter) (interface{}, error) {
+	userDiaryEntries := []*models.DiaryEntry{}
+	conditions := []string{"ar.user_id = ?", "ar.deleted_at = 0"}
+	args := []interface{}{filter.UserId}
+	order := "ASC"
+	comparison := ">"
+
+	if filter.Descending {
+		order = "DESC"
+		comparison = "<"
+	}
+	if filter.StartDate != 0 {
+		conditions = append(conditions, "ar.registration_date >= ?")
+		args = append(args, filter.StartDate)
+	}
+	if filter.EndDate != 0 {
+		conditions = append(conditions, "ar.registration_date <= ?")
+		args = append(args, filter.EndDate)
+	}
+	if len(filter.Tags) > 0 {
+		conditions = append(conditions, "de.id IN (SELECT dt.diary_entry_id FROM diary_entry_tag dt INNER JOIN tag t ON (dt.tag_id = t.id)"+
+			" WHERE t.user_id = ? AND t.name IN (?"+strings.Repeat(", ?", len(filter.Tags)-1)+") GROUP BY dt.diary_entry_id HAVING COUNT(*) = ?)")
+		args = append(args, filter.UserId)
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(filter.Tags))
+	}
+	if filter.After != nil {
+		conditions = append(conditions, "(ar.registration_date "+comparison+" ? OR (ar.registration_date = ? AND de.id "+comparison+" ?))")
+		args = append(args, filter.After.RegistrationDate, filter.After.RegistrationDate, filter.After.Id)
+	}
+
+	query := getUserDiaryEntriesPageQuery + " WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY ar.registration_date " + order + ", de.id " + order + " LIMIT ?;"
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query, append(args, filter.Limit)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedDiaryEntry, scanErr := diaryEntryStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		diaryEntry, ok := scannedDiaryEntry.(models.DiaryEntry)
+
+		if !ok {
+			return nil, failedToParseDiaryEntryError
+		}
+
+		userDiaryEntries = append(userDiaryEntries, &diaryEntry)
+	}
+
+	return userDiaryEntries, nil
+}
+
+// Search returns the user diary entries matching the filter query, best matches first.
+func (diaryEntryStorage *DiaryEntryStorage) Search(filter *DiaryEntrySearchFilter) (interface{}, error) {
+	searchResults := []*models.DiaryEntrySearchResult{}
+	query := searchUserDiaryEntriesQuery
+	args := []interface{}{filter.Query, filter.UserId}
+
+	if filter.StartDate != 0 {
+		query += " AND ar.registration_date >= ?"
+		args = append(args, filter.StartDate)
+	}
+	if filter.EndDate != 0 {
+		query += " AND ar.registration_date <= ?"
+		args = append(args, filter.EndDate)
+	}
+
+	query += " ORDER BY bm25(diary_entry_fts), ar.registration_date DESC LIMIT ? OFFSET ?;"
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query, append(args, filter.Limit, filter.Offset)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		searchResult := &models.DiaryEntrySearchResult{}
+		diaryEntry, scanErr := scanDiaryEntry(result, &searchResult.TitleSnippet, &searchResult.ContentSnippet)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		searchResult.Entry = &diaryEntry
+		searchResults = append(searchResults, searchResult)
+	}
+
+	if rowsErr := result.Err(); rowsErr != nil {
+		return nil, rowsErr
+	}
+
+	return searchResults, nil
+}
+
+// Create inserts the diary entry with its tags, encrypting its title and content at rest, and indexes it for full-text search.
 func (diaryEntryStorage *DiaryEntryStorage) Create(diaryEntry interface{}) error {
 	dbDiaryEntry, ok := diaryEntry.(*models.DiaryEntry)
 
@@ -120,9 +335,31 @@ func (diaryEntryStorage *DiaryEntryStorage) Create(diaryEntry interface{}) error
 		return failedToParseDiaryEntryError
 	}
 
-	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertDiaryEntryQuery,
-		dbDiaryEntry.Title,
-		dbDiaryEntry.Content,
+	title, content, encryptErr := encryptDiaryTitleAndContent(dbDiaryEntry.Registration.UserRefer, dbDiaryEntry.Title, dbDiaryEntry.Content)
+
+	if encryptErr != nil {
+		return encryptErr
+	}
+
+	transaction, txErr := database.GetDatabaseInstance().GetConnection().Begin()
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer transaction.Rollback()
+
+	encryption := getDiaryEntryEncryptionColumns(dbDiaryEntry.Encryption)
+	result, err := transaction.Exec(insertDiaryEntryQuery,
+		title,
+		content,
+		dbDiaryEntry.Mood,
+		strings.Join(dbDiaryEntry.Feelings, ","),
+		encryption.Algorithm,
+		encryption.Nonce,
+		encryption.KeyId,
+		encryption.WrappedKey,
+		dbDiaryEntry.PromptRefer,
 		dbDiaryEntry.Registration.Id)
 
 	if err != nil {
@@ -135,21 +372,76 @@ func (diaryEntryStorage *DiaryEntryStorage) Create(diaryEntry interface{}) error
 	}
 
 	dbDiaryEntry.Id = uint(diaryEntryId)
+	dbDiaryEntry.Version = 1
+
+	if len(dbDiaryEntry.Tags) > 0 {
+		if tagsErr := setDiaryEntryTags(transaction, dbDiaryEntry.Registration.UserRefer, dbDiaryEntry.Id, dbDiaryEntry.Tags); tagsErr != nil {
+			dbDiaryEntry.Id = 0
+			return tagsErr
+		}
+	}
+
+	if ftsErr := indexDiaryEntry(transaction, dbDiaryEntry); ftsErr != nil {
+		dbDiaryEntry.Id = 0
+		return ftsErr
+	}
+
+	if commitErr := transaction.Commit(); commitErr != nil {
+		dbDiaryEntry.Id = 0
+		return commitErr
+	}
 
 	return nil
 }
 
-func (diaryEntryStorage *DiaryEntryStorage) Update(diaryEntry interface{}) error {
-	dbDiaryEntry, ok := diaryEntry.(*models.DiaryEntry)
+// Update updates the diary entry of a DiaryEntryUpdate and its publish date, encrypting its title and content at rest,
+// replaces its tags and updates its full-text index. Its revisions are changed in the same transaction, so they are only written with the update.
+// The entry version must be the stored one, otherwise a DbVersionConflictError is returned. It is incremented on success.
+func (diaryEntryStorage *DiaryEntryStorage) Update(diaryEntryUpdate interface{}) error {
+	dbDiaryEntryUpdate, ok := diaryEntryUpdate.(*DiaryEntryUpdate)
 
-	if !ok {
+	if !ok || dbDiaryEntryUpdate.Entry == nil {
 		return failedToParseDiaryEntryError
 	}
 
-	result, err := database.GetDatabaseInstance().GetConnection().Exec(updateDiaryEntryQuery,
-		dbDiaryEntry.Title,
-		dbDiaryEntry.Content,
-		dbDiaryEntry.Id)
+	dbDiaryEntry := dbDiaryEntryUpdate.Entry
+	title, content, encryptErr := encryptDiaryTitleAndContent(dbDiaryEntry.Registration.UserRefer, dbDiaryEntry.Title, dbDiaryEntry.Content)
+
+	if encryptErr != nil {
+		return encryptErr
+	}
+
+	var revisionTitle, revisionContent string
+
+	if dbDiaryEntryUpdate.Revision != nil {
+		revisionTitle, revisionContent, encryptErr = encryptDiaryTitleAndContent(dbDiaryEntry.Registration.UserRefer,
+			dbDiaryEntryUpdate.Revision.Title, dbDiaryEntryUpdate.Revision.Content)
+
+		if encryptErr != nil {
+			return encryptErr
+		}
+	}
+
+	transaction, txErr := database.GetDatabaseInstance().GetConnection().Begin()
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer transaction.Rollback()
+
+	encryption := getDiaryEntryEncryptionColumns(dbDiaryEntry.Encryption)
+	result, err := transaction.Exec(updateDiaryEntryQuery,
+		title,
+		content,
+		dbDiaryEntry.Mood,
+		strings.Join(dbDiaryEntry.Feelings, ","),
+		encryption.Algorithm,
+		encryption.Nonce,
+		encryption.KeyId,
+		encryption.WrappedKey,
+		dbDiaryEntry.Id,
+		dbDiaryEntry.Version)
 
 	if err != nil {
 		return err
@@ -162,14 +454,63 @@ func (diaryEntryStorage *DiaryEntryStorage) Update(diaryEntry interface{}) error
 	}
 
 	if affectedRows == 0 {
-		return diaryEntryNotFoundError
+		var currentVersion int64
+
+		if versionErr := transaction.QueryRow(getDiaryEntryVersionQuery, dbDiaryEntry.Id).Scan(&currentVersion); versionErr != nil {
+			if errors.Is(versionErr, sql.ErrNoRows) {
+				return diaryEntryNotFoundError
+			}
+			return versionErr
+		}
+
+		return &models.DbVersionConflictError{DbItem: &models.DiaryEntry{}, CurrentVersion: currentVersion}
 	}
 
+	if _, registrationErr := transaction.Exec(updateDiaryEntryRegistrationDateQuery, dbDiaryEntry.Registration.RegistrationDate, dbDiaryEntry.Registration.Id); registrationErr != nil {
+		return registrationErr
+	}
+
+	if dbDiaryEntryUpdate.DeleteRevisions {
+		if _, deleteRevisionsErr := transaction.Exec(deleteDiaryEntryRevisionsByEntryQuery, dbDiaryEntry.Id); deleteRevisionsErr != nil {
+			return deleteRevisionsErr
+		}
+	}
+
+	if dbDiaryEntryUpdate.Revision != nil {
+		if revisionErr := insertDiaryEntryRevision(transaction, dbDiaryEntryUpdate.Revision, revisionTitle, revisionContent); revisionErr != nil {
+			return revisionErr
+		}
+	}
+
+	if tagsErr := setDiaryEntryTags(transaction, dbDiaryEntry.Registration.UserRefer, dbDiaryEntry.Id, dbDiaryEntry.Tags); tagsErr != nil {
+		return tagsErr
+	}
+
+	if ftsErr := indexDiaryEntry(transaction, dbDiaryEntry); ftsErr != nil {
+		return ftsErr
+	}
+
+	if commitErr := transaction.Commit(); commitErr != nil {
+		return commitErr
+	}
+
+	dbDiaryEntry.Version++
+	dbDiaryEntry.Registration.Version++
+
 	return nil
 }
 
+// Delete deletes the diary entry and removes it from the full-text index.
 func (diaryEntryStorage *DiaryEntryStorage) Delete(id uint) error {
-	result, err := database.GetDatabaseInstance().GetConnection().Exec(deleteDiaryEntryQuery, id)
+	transaction, txErr := database.GetDatabaseInstance().GetConnection().Begin()
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer transaction.Rollback()
+
+	result, err := transaction.Exec(deleteDiaryEntryQuery, id)
 
 	if err != nil {
 		return err
@@ -185,14 +526,160 @@ func (diaryEntryStorage *DiaryEntryStorage) Delete(id uint) error {
 		return diaryEntryNotFoundError
 	}
 
-	return nil
+	if _, ftsErr := transaction.Exec(deleteDiaryEntryFtsQuery, id); ftsErr != nil {
+		return ftsErr
+	}
+
+	return transaction.Commit()
+}
+
+// RebuildUserSearchIndex indexes again all the diary entries of the user, after they opted in or out of the full-text index.
+func (diaryEntryStorage *DiaryEntryStorage) RebuildUserSearchIndex(userId uint) error {
+	userDiaryEntries, err := diaryEntryStorage.GetByUserId(userId)
+
+	if err != nil {
+		return err
+	}
+
+	// the entries in the trash are indexed too, so they are found once restored
+	trashedDiaryEntries, trashErr := diaryEntryStorage.GetTrashedByUserId(userId)
+
+	if trashErr != nil {
+		return trashErr
+	}
+
+	transaction, txErr := database.GetDatabaseInstance().GetConnection().Begin()
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer transaction.Rollback()
+
+	for _, diaryEntry := range append(userDiaryEntries.([]*models.DiaryEntry), trashedDiaryEntries.([]*models.DiaryEntry)...) {
+		if ftsErr := indexDiaryEntry(transaction, diaryEntry); ftsErr != nil {
+			return ftsErr
+		}
+	}
+
+	return transaction.Commit()
 }
 
 func (diaryEntryStorage *DiaryEntryStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	return scanDiaryEntry(rows)
+}
+
+// scanDiaryEntry reads a diary entry row, decrypting its title and content. The columns selected after the entry ones,
+// like search snippets, are scanned into the extra destinations.
+func scanDiaryEntry(rows *sql.Rows, extraDest ...interface{}) (models.DiaryEntry, error) {
 	var diaryEntry models.DiaryEntry
+	var mood sql.NullInt64
+	var feelings string
+	var encryption models.DiaryEntryEncryption
+	var tags sql.NullString
+	var promptId sql.NullInt64
+
+	dest := []interface{}{&diaryEntry.Id, &diaryEntry.Title, &diaryEntry.Content, &mood, &feelings,
+		&encryption.Algorithm, &encryption.Nonce, &encryption.KeyId, &encryption.WrappedKey, &diaryEntry.Version, &promptId, &diaryEntry.Registration.Id,
+		&diaryEntry.Registration.RegistrationDate, &diaryEntry.Registration.UserRefer, &diaryEntry.Registration.DeletedAt, &diaryEntry.Registration.Version, &tags}
+
+	if scanErr := rows.Scan(append(dest, extraDest...)...); scanErr != nil {
+		return diaryEntry, scanErr
+	}
+
+	diaryEntry.Mood = parseDiaryEntryMood(mood)
+	diaryEntry.Encryption = parseDiaryEntryEncryption(encryption)
+	diaryEntry.Feelings = parseDiaryEntryFeelings(feelings)
+	diaryEntry.Tags = parseDiaryEntryTags(tags)
+	diaryEntry.PromptRefer = parseDiaryEntryPromptId(promptId)
+
+	var decryptErr error
+	diaryEntry.Title, diaryEntry.Content, decryptErr = decryptDiaryTitleAndContent(diaryEntry.Registration.UserRefer, diaryEntry.Title, diaryEntry.Content)
+
+	return diaryEntry, decryptErr
+}
+
+// parseDiaryEntryTags splits the aggregated tags column, sorting the tags by name.
+func parseDiaryEntryTags(tags sql.NullString) []string {
+	if !tags.Valid || len(tags.String) == 0 {
+		return []string{}
+	}
+
+	diaryEntryTags := strings.Split(tags.String, ",")
+	slices.Sort(diaryEntryTags)
+
+	return diaryEntryTags
+}
+
+// parseDiaryEntryMood returns the mood score of the entry, or nil when it has none.
+func parseDiaryEntryMood(mood sql.NullInt64) *int {
+	if !mood.Valid {
+		return nil
+	}
+
+	moodScore := int(mood.Int64)
+
+	return &moodScore
+}
+
+// parseDiaryEntryFeelings splits the comma separated feelings column.
+func parseDiaryEntryFeelings(feelings string) []string {
+	if len(feelings) == 0 {
+		return []string{}
+	}
+
+	return strings.Split(feelings, ",")
+}
+
+// parseDiaryEntryPromptId returns the id of the prompt answered by the entry, or nil when it answers none.
+func parseDiaryEntryPromptId(promptId sql.NullInt64) *uint {
+	if !promptId.Valid {
+		return nil
+	}
+
+	diaryEntryPromptId := uint(promptId.Int64)
+
+	return &diaryEntryPromptId
+}
+
+// parseDiaryEntryEncryption returns the encryption metadata of the entry, or nil when it is not encrypted.
+func parseDiaryEntryEncryption(encryption models.DiaryEntryEncryption) *models.DiaryEntryEncryption {
+	if len(encryption.Algorithm) == 0 {
+		return nil
+	}
+
+	return &encryption
+}
+
+// getDiaryEntryEncryptionColumns returns the values of the encryption columns, which are empty for plaintext entries.
+func getDiaryEntryEncryptionColumns(encryption *models.DiaryEntryEncryption) models.DiaryEntryEncryption {
+	if encryption == nil {
+		return models.DiaryEntryEncryption{}
+	}
+
+	return *encryption
+}
+
+// indexDiaryEntry replaces the full-text index row of the diary entry. Encrypted entries are indexed empty, as their
+// ciphertext is meaningless to the server, and so are the entries of users who did not opt into the index.
+func indexDiaryEntry(transaction *sql.Tx, diaryEntry *models.DiaryEntry) error {
+	searchIndexEnabled, err := isUserSearchIndexEnabled(transaction, diaryEntry.Registration.UserRefer)
+
+	if err != nil {
+		return err
+	}
+
+	searchableTitle, searchableContent := "", ""
+
+	if diaryEntry.Encryption == nil && searchIndexEnabled {
+		searchableTitle, searchableContent = diaryEntry.Title, diaryEntry.Content
+	}
+
+	if _, ftsErr := transaction.Exec(deleteDiaryEntryFtsQuery, diaryEntry.Id); ftsErr != nil {
+		return ftsErr
+	}
 
-	scanErr := rows.Scan(&diaryEntry.Id, &diaryEntry.Title, &diaryEntry.Content, &diaryEntry.Registration.Id,
-		&diaryEntry.Registration.RegistrationDate, &diaryEntry.Registration.UserRefer)
+	_, ftsErr := transaction.Exec(insertDiaryEntryFtsQuery, diaryEntry.Id, searchableTitle, searchableContent)
 
-	return diaryEntry, scanErr
+	return ftsErr
 }
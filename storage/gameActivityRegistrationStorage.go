@@ -8,18 +8,22 @@ import (
 )
 
 const (
-	getGameActivityRegistrationByIdentifierQuery    = "SELECT arg.id, arb.game_name, ar.id, ar.registration_date, ar.user_id FROM activity_registration_game arg INNER JOIN activity_registration ar ON (arg.registration_id = ar.id) WHERE arg.id = ?;"
-	getUserGameActivityRegistrationsQuery           = "SELECT arg.id, arg.game_name, ar.id, ar.registration_date, ar.user_id FROM activity_registration_game arg INNER JOIN activity_registration ar ON (arg.registration_id = ar.id) WHERE ar.user_id = ?;"
-	getUserGameActivityRegistrationsByIntervalQuery = "SELECT arg.id, arg.game_name, ar.id, ar.registration_date, ar.user_id FROM activity_registration_game arg INNER JOIN activity_registration ar ON (arg.registration_id = ar.id) WHERE ar.user_id = ? AND ar.registration_date >= ? AND ar.registration_date <= ?;"
+	getGameActivityRegistrationByIdentifierQuery    = "SELECT arg.id, arg.game_name, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_game arg INNER JOIN activity_registration ar ON (arg.registration_id = ar.id) WHERE arg.id = ? AND ar.deleted_at = 0;"
+	getUserGameActivityRegistrationsQuery           = "SELECT arg.id, arg.game_name, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_game arg INNER JOIN activity_registration ar ON (arg.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at = 0;"
+	getUserGameActivityRegistrationsByIntervalQuery = "SELECT arg.id, arg.game_name, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_game arg INNER JOIN activity_registration ar ON (arg.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at = 0 AND ar.registration_date >= ? AND ar.registration_date <= ?;"
+	getUserTrashedGameActivityRegistrationsQuery    = "SELECT arg.id, arg.game_name, ar.id, ar.registration_date, ar.user_id, ar.deleted_at, ar.version FROM activity_registration_game arg INNER JOIN activity_registration ar ON (arg.registration_id = ar.id) WHERE ar.user_id = ? AND ar.deleted_at != 0 ORDER BY ar.deleted_at DESC, arg.id DESC;"
 	insertGameActivityRegistrationQuery             = "INSERT INTO activity_registration_game (game_name, registration_id) VALUES (?, ?);"
 	updateGameActivityRegistrationQuery             = "UPDATE activity_registration_game SET game_name = ? WHERE id = ?;"
 	deleteGameActivityRegistrationQuery             = "DELETE FROM activity_registration_game WHERE id = ?;"
 )
 
 type GameActivityRegistrationStorageInterface interface {
+	Get(id uint) (interface{}, error)
 	GetByUserId(userId uint) (interface{}, error)
+	GetTrashedByUserId(userId uint) (interface{}, error)
 	GetByUserIdAndInterval(userId uint, startDate int64, endDate int64) (interface{}, error)
 	Create(data interface{}) error
+	Update(data interface{}) error
 }
 
 type GameActivityRegistrationStorage struct{}
@@ -119,6 +123,35 @@ func (gameActivityRegistrationStorage *GameActivityRegistrationStorage) GetByUse
 	return userGameActivityRegistrations, nil
 }
 
+// GetTrashedByUserId returns the game registrations in the trash of the user, most recently deleted first.
+func (gameActivityRegistrationStorage *GameActivityRegistrationStorage) GetTrashedByUserId(userId uint) (interface{}, error) {
+	trashedGameActivityRegistrations := []*models.GameActivityRegistration{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getUserTrashedGameActivityRegistrationsQuery, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedGameActivityRegistration, scanErr := gameActivityRegistrationStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		gameActivityRegistration, ok := scannedGameActivityRegistration.(models.GameActivityRegistration)
+
+		if !ok {
+			return nil, failedToParseGameActivityRegistrationError
+		}
+
+		trashedGameActivityRegistrations = append(trashedGameActivityRegistrations, &gameActivityRegistration)
+	}
+
+	return trashedGameActivityRegistrations, nil
+}
+
 func (gameActivityRegistrationStorage *GameActivityRegistrationStorage) Create(gameRegistration interface{}) error {
 	dbGameRegistration, ok := gameRegistration.(*models.GameActivityRegistration)
 
@@ -144,6 +177,8 @@ func (gameActivityRegistrationStorage *GameActivityRegistrationStorage) Create(g
 	return nil
 }
 
+// Update saves the game and the date of its registration in one transaction. The registration version must be
+// the stored one, otherwise a DbVersionConflictError is returned. It is incremented on success.
 func (gameActivityRegistrationStorage *GameActivityRegistrationStorage) Update(gameRegistration interface{}) error {
 	dbGameRegistration, ok := gameRegistration.(*models.GameActivityRegistration)
 
@@ -151,7 +186,19 @@ func (gameActivityRegistrationStorage *GameActivityRegistrationStorage) Update(g
 		return failedToParseGameActivityRegistrationError
 	}
 
-	result, err := database.GetDatabaseInstance().GetConnection().Exec(updateDiaryEntryQuery,
+	transaction, txErr := database.GetDatabaseInstance().GetConnection().Begin()
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer transaction.Rollback()
+
+	if registrationErr := updateActivityRegistration(transaction, &dbGameRegistration.Registration); registrationErr != nil {
+		return registrationErr
+	}
+
+	result, err := transaction.Exec(updateGameActivityRegistrationQuery,
 		dbGameRegistration.GameName,
 		dbGameRegistration.Id)
 
@@ -169,6 +216,12 @@ func (gameActivityRegistrationStorage *GameActivityRegistrationStorage) Update(g
 		return gameActivityRegistrationNotFoundError
 	}
 
+	if commitErr := transaction.Commit(); commitErr != nil {
+		return commitErr
+	}
+
+	dbGameRegistration.Registration.Version++
+
 	return nil
 }
 
@@ -196,7 +249,7 @@ func (gameActivityRegistrationStorage *GameActivityRegistrationStorage) Scan(row
 	var gameActivityRegistration models.GameActivityRegistration
 
 	scanErr := rows.Scan(&gameActivityRegistration.Id, &gameActivityRegistration.GameName, &gameActivityRegistration.Registration.Id,
-		&gameActivityRegistration.Registration.RegistrationDate, &gameActivityRegistration.Registration.UserRefer)
+		&gameActivityRegistration.Registration.RegistrationDate, &gameActivityRegistration.Registration.UserRefer, &gameActivityRegistration.Registration.DeletedAt, &gameActivityRegistration.Registration.Version)
 
 	return gameActivityRegistration, scanErr
 }
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	importJobColumns     = "id, user_id, format, status, total_items, imported_items, skipped_items, failed_items, errors, error, created_at, finished_at"
+	getImportJobQuery    = "SELECT " + importJobColumns + " FROM import_job WHERE id = ?;"
+	insertImportJobQuery = "INSERT INTO import_job (user_id, format, status, errors, created_at) VALUES (?, ?, ?, ?, ?);"
+	updateImportJobQuery = "UPDATE import_job SET status = ?, total_items = ?, imported_items = ?, skipped_items = ?, failed_items = ?," +
+		" errors = ?, error = ?, finished_at = ? WHERE id = ?;"
+	// jobs are run by the server process, so the ones unfinished when it starts were interrupted
+	failUnfinishedImportJobsQuery = "UPDATE import_job SET status = 'failed', error = ?, finished_at = ? WHERE status IN ('pending', 'running');"
+	getDiaryEntryImportQuery      = "SELECT diary_entry_id FROM diary_entry_import WHERE user_id = ? AND fingerprint = ?;"
+	insertDiaryEntryImportQuery   = "INSERT OR IGNORE INTO diary_entry_import (user_id, fingerprint, diary_entry_id) VALUES (?, ?, ?);"
+)
+
+type ImportJobStorageInterface interface {
+	Get(id uint) (interface{}, error)
+	Create(data interface{}) error
+	Update(data interface{}) error
+	FailUnfinished(errorMessage string, finishedAt int64) (int64, error)
+	IsDiaryEntryImported(userId uint, fingerprint string) (bool, error)
+	SaveDiaryEntryImport(userId uint, fingerprint string, diaryEntryId uint) error
+}
+
+type ImportJobStorage struct{}
+
+var importJobNotFoundError = &models.DbNotFoundError{DbItem: &models.ImportJob{}}
+var failedToParseImportJobError = &models.DbCouldNotParseItemError{DbItem: &models.ImportJob{}}
+
+func (importJobStorage *ImportJobStorage) Get(id uint) (interface{}, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getImportJobQuery, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	if !result.Next() {
+		return nil, importJobNotFoundError
+	}
+
+	scannedImportJob, scanErr := importJobStorage.Scan(result)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	importJob, ok := scannedImportJob.(models.ImportJob)
+
+	if !ok {
+		return nil, failedToParseImportJobError
+	}
+
+	return &importJob, nil
+}
+
+func (importJobStorage *ImportJobStorage) Create(importJob interface{}) error {
+	dbImportJob, ok := importJob.(*models.ImportJob)
+
+	if !ok {
+		return failedToParseImportJobError
+	}
+
+	itemErrors, marshalErr := marshalImportItemErrors(dbImportJob.Errors)
+
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertImportJobQuery,
+		dbImportJob.UserRefer,
+		dbImportJob.Format,
+		dbImportJob.Status,
+		itemErrors,
+		dbImportJob.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	importJobId, idErr := result.LastInsertId()
+
+	if idErr != nil {
+		return idErr
+	}
+
+	dbImportJob.Id = uint(importJobId)
+
+	return nil
+}
+
+// Update saves the status and progress of the import job.
+func (importJobStorage *ImportJobStorage) Update(importJob interface{}) error {
+	dbImportJob, ok := importJob.(*models.ImportJob)
+
+	if !ok {
+		return failedToParseImportJobError
+	}
+
+	itemErrors, marshalErr := marshalImportItemErrors(dbImportJob.Errors)
+
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(updateImportJobQuery,
+		dbImportJob.Status,
+		dbImportJob.TotalItems,
+		dbImportJob.ImportedItems,
+		dbImportJob.SkippedItems,
+		dbImportJob.FailedItems,
+		itemErrors,
+		dbImportJob.Error,
+		dbImportJob.FinishedAt,
+		dbImportJob.Id)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
+	}
+
+	if affectedRows == 0 {
+		return importJobNotFoundError
+	}
+
+	return nil
+}
+
+// FailUnfinished marks the pending and running jobs as failed with the given error, returning how many were updated.
+func (importJobStorage *ImportJobStorage) FailUnfinished(errorMessage string, finishedAt int64) (int64, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(failUnfinishedImportJobsQuery, errorMessage, finishedAt)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// IsDiaryEntryImported reports whether the user already imported a diary entry with the fingerprint.
+func (importJobStorage *ImportJobStorage) IsDiaryEntryImported(userId uint, fingerprint string) (bool, error) {
+	var diaryEntryId uint
+
+	err := database.GetDatabaseInstance().GetConnection().QueryRow(getDiaryEntryImportQuery, userId, fingerprint).Scan(&diaryEntryId)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SaveDiaryEntryImport stores the fingerprint of an imported diary entry. The fingerprint is removed with the entry.
+func (importJobStorage *ImportJobStorage) SaveDiaryEntryImport(userId uint, fingerprint string, diaryEntryId uint) error {
+	_, err := database.GetDatabaseInstance().GetConnection().Exec(insertDiaryEntryImportQuery, userId, fingerprint, diaryEntryId)
+
+	return err
+}
+
+func (importJobStorage *ImportJobStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var importJob models.ImportJob
+	var itemErrors string
+
+	scanErr := rows.Scan(&importJob.Id, &importJob.UserRefer, &importJob.Format, &importJob.Status, &importJob.TotalItems,
+		&importJob.ImportedItems, &importJob.SkippedItems, &importJob.FailedItems, &itemErrors, &importJob.Error,
+		&importJob.CreatedAt, &importJob.FinishedAt)
+
+	if scanErr != nil {
+		return importJob, scanErr
+	}
+
+	importJob.Errors = []models.ImportItemError{}
+	scanErr = json.Unmarshal([]byte(itemErrors), &importJob.Errors)
+
+	return importJob, scanErr
+}
+
+func marshalImportItemErrors(itemErrors []models.ImportItemError) (string, error) {
+	if itemErrors == nil {
+		return "[]", nil
+	}
+
+	marshaledItemErrors, err := json.Marshal(itemErrors)
+
+	return string(marshaledItemErrors), err
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	personalAccessTokenColumns          = "id, name, prefix, token_hash, scopes, user_id, created_at, last_used_at, expires_at"
+	getPersonalAccessTokenQuery         = "SELECT " + personalAccessTokenColumns + " FROM personal_access_token WHERE id = ?;"
+	getPersonalAccessTokenByHashQuery   = "SELECT " + personalAccessTokenColumns + " FROM personal_access_token WHERE token_hash = ?;"
+	getUserPersonalAccessTokensQuery    = "SELECT " + personalAccessTokenColumns + " FROM personal_access_token WHERE user_id = ? ORDER BY created_at DESC, id DESC;"
+	insertPersonalAccessTokenQuery      = "INSERT INTO personal_access_token (name, prefix, token_hash, scopes, user_id, created_at, last_used_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?);"
+	updatePersonalAccessTokenUsageQuery = "UPDATE personal_access_token SET last_used_at = ? WHERE id = ?;"
+	deletePersonalAccessTokenQuery      = "DELETE FROM personal_access_token WHERE id = ?;"
+)
+
+// scopes are stored space separated, as in OAuth scope strings
+const personalAccessTokenScopesSeparator = " "
+
+type PersonalAccessTokenStorageInterface interface {
+	Get(id uint) (interface{}, error)
+	GetByHash(tokenHash string) (interface{}, error)
+	GetByUserId(userId uint) (interface{}, error)
+	Create(data interface{}) error
+	UpdateLastUsed(id uint, lastUsedAt int64) error
+	Delete(id uint) error
+}
+
+type PersonalAccessTokenStorage struct{}
+
+var personalAccessTokenNotFoundError = &models.DbNotFoundError{DbItem: &models.PersonalAccessToken{}}
+var failedToParsePersonalAccessTokenError = &models.DbCouldNotParseItemError{DbItem: &models.PersonalAccessToken{}}
+
+func (personalAccessTokenStorage *PersonalAccessTokenStorage) Get(id uint) (interface{}, error) {
+	return personalAccessTokenStorage.getOne(getPersonalAccessTokenQuery, id)
+}
+
+func (personalAccessTokenStorage *PersonalAccessTokenStorage) GetByHash(tokenHash string) (interface{}, error) {
+	return personalAccessTokenStorage.getOne(getPersonalAccessTokenByHashQuery, tokenHash)
+}
+
+func (personalAccessTokenStorage *PersonalAccessTokenStorage) GetByUserId(userId uint) (interface{}, error) {
+	personalAccessTokens := []*models.PersonalAccessToken{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getUserPersonalAccessTokensQuery, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedPersonalAccessToken, scanErr := personalAccessTokenStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		personalAccessToken, ok := scannedPersonalAccessToken.(models.PersonalAccessToken)
+
+		if !ok {
+			return nil, failedToParsePersonalAccessTokenError
+		}
+
+		personalAccessTokens = append(personalAccessTokens, &personalAccessToken)
+	}
+
+	return personalAccessTokens, nil
+}
+
+func (personalAccessTokenStorage *PersonalAccessTokenStorage) Create(personalAccessToken interface{}) error {
+	dbPersonalAccessToken, ok := personalAccessToken.(*models.PersonalAccessToken)
+
+	if !ok {
+		return failedToParsePersonalAccessTokenError
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertPersonalAccessTokenQuery,
+		dbPersonalAccessToken.Name,
+		dbPersonalAccessToken.Prefix,
+		dbPersonalAccessToken.TokenHash,
+		strings.Join(dbPersonalAccessToken.Scopes, personalAccessTokenScopesSeparator),
+		dbPersonalAccessToken.UserRefer,
+		dbPersonalAccessToken.CreatedAt,
+		dbPersonalAccessToken.LastUsedAt,
+		dbPersonalAccessToken.ExpiresAt)
+
+	if err != nil {
+		return err
+	}
+
+	personalAccessTokenId, idErr := result.LastInsertId()
+	if idErr != nil {
+		return idErr
+	}
+
+	dbPersonalAccessToken.Id = uint(personalAccessTokenId)
+
+	return nil
+}
+
+func (personalAccessTokenStorage *PersonalAccessTokenStorage) UpdateLastUsed(id uint, lastUsedAt int64) error {
+	_, err := database.GetDatabaseInstance().GetConnection().Exec(updatePersonalAccessTokenUsageQuery, lastUsedAt, id)
+
+	return err
+}
+
+func (personalAccessTokenStorage *PersonalAccessTokenStorage) Delete(id uint) error {
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(deletePersonalAccessTokenQuery, id)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, affectedRowsErr := result.RowsAffected()
+
+	if affectedRowsErr != nil {
+		return affectedRowsErr
+	}
+
+	if affectedRows == 0 {
+		return personalAccessTokenNotFoundError
+	}
+
+	return nil
+}
+
+func (personalAccessTokenStorage *PersonalAccessTokenStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var personalAccessToken models.PersonalAccessToken
+	var scopes string
+
+	scanErr := rows.Scan(&personalAccessToken.Id, &personalAccessToken.Name, &personalAccessToken.Prefix,
+		&personalAccessToken.TokenHash, &scopes, &personalAccessToken.UserRefer, &personalAccessToken.CreatedAt,
+		&personalAccessToken.LastUsedAt, &personalAccessToken.ExpiresAt)
+
+	personalAccessToken.Scopes = strings.Fields(scopes)
+
+	return personalAccessToken, scanErr
+}
+
+func (personalAccessTokenStorage *PersonalAccessTokenStorage) getOne(query string, arg interface{}) (interface{}, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query, arg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	if !result.Next() {
+		return nil, personalAccessTokenNotFoundError
+	}
+
+	scannedPersonalAccessToken, scanErr := personalAccessTokenStorage.Scan(result)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	personalAccessToken, ok := scannedPersonalAccessToken.(models.PersonalAccessToken)
+
+	if !ok {
+		return nil, failedToParsePersonalAccessTokenError
+	}
+
+	return &personalAccessToken, nil
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	promptColumns             = "p.id, p.text, p.category, p.language, p.created_at"
+	getPromptQuery            = "SELECT " + promptColumns + " FROM prompt p WHERE p.id = ?;"
+	getPromptsByLanguageQuery = "SELECT " + promptColumns + " FROM prompt p WHERE p.language = ? ORDER BY p.id;"
+	// empty category or language filters are not applied, and the entries in the trash are not counted
+	getPromptUsagesQuery = "SELECT " + promptColumns + ", COUNT(ar.id) FROM prompt p LEFT JOIN diary_entry de ON (de.prompt_id = p.id)" +
+		" LEFT JOIN activity_registration ar ON (de.registration_id = ar.id AND ar.deleted_at = 0)" +
+		" WHERE (? = '' OR p.category = ?) AND (? = '' OR p.language = ?) GROUP BY p.id ORDER BY p.id;"
+	getUserAnsweredPromptIdsQuery = "SELECT DISTINCT de.prompt_id FROM diary_entry de INNER JOIN activity_registration ar ON (de.registration_id = ar.id)" +
+		" WHERE ar.user_id = ? AND ar.deleted_at = 0 AND de.prompt_id IS NOT NULL AND ar.registration_date >= ? AND ar.registration_date < ?;"
+	insertPromptQuery          = "INSERT INTO prompt (text, category, language, created_at) VALUES (?, ?, ?, ?);"
+	updatePromptQuery          = "UPDATE prompt SET text = ?, category = ?, language = ? WHERE id = ?;"
+	deletePromptQuery          = "DELETE FROM prompt WHERE id = ?;"
+	clearDiaryEntryPromptQuery = "UPDATE diary_entry SET prompt_id = NULL WHERE prompt_id = ?;"
+)
+
+type PromptStorageInterface interface {
+	Get(id uint) (interface{}, error)
+	GetByLanguage(language string) (interface{}, error)
+	GetUsages(category string, language string) (interface{}, error)
+	GetAnsweredIdsByUserId(userId uint, startDate int64, endDate int64) (interface{}, error)
+	Create(data interface{}) error
+	Update(data interface{}) error
+	Delete(id uint) error
+}
+
+type PromptStorage struct{}
+
+var promptNotFoundError = &models.DbNotFoundError{DbItem: &models.Prompt{}}
+var failedToParsePromptError = &models.DbCouldNotParseItemError{DbItem: &models.Prompt{}}
+
+func (promptStorage *PromptStorage) Get(id uint) (interface{}, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getPromptQuery, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	if !result.Next() {
+		return nil, promptNotFoundError
+	}
+
+	scannedPrompt, scanErr := promptStorage.Scan(result)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	prompt, ok := scannedPrompt.(models.Prompt)
+
+	if !ok {
+		return nil, failedToParsePromptError
+	}
+
+	return &prompt, nil
+}
+
+// GetByLanguage returns the prompts written in the given language, sorted by id.
+func (promptStorage *PromptStorage) GetByLanguage(language string) (interface{}, error) {
+	prompts := []*models.Prompt{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getPromptsByLanguageQuery, language)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedPrompt, scanErr := promptStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		prompt, ok := scannedPrompt.(models.Prompt)
+
+		if !ok {
+			return nil, failedToParsePromptError
+		}
+
+		prompts = append(prompts, &prompt)
+	}
+
+	return prompts, nil
+}
+
+// GetUsages returns the prompts sorted by id, with the number of diary entries answering them.
+// Empty category or language filters are not applied.
+func (promptStorage *PromptStorage) GetUsages(category string, language string) (interface{}, error) {
+	promptUsages := []*models.PromptUsage{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getPromptUsagesQuery, category, category, language, language)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		var promptUsage models.PromptUsage
+
+		if scanErr := result.Scan(&promptUsage.Id, &promptUsage.Text, &promptUsage.Category, &promptUsage.Language,
+			&promptUsage.CreatedAt, &promptUsage.AnswerCount); scanErr != nil {
+			return nil, scanErr
+		}
+
+		promptUsages = append(promptUsages, &promptUsage)
+	}
+
+	return promptUsages, nil
+}
+
+// GetAnsweredIdsByUserId returns the ids of the prompts answered by diary entries of the user
+// published in the date interval, the end date excluded.
+func (promptStorage *PromptStorage) GetAnsweredIdsByUserId(userId uint, startDate int64, endDate int64) (interface{}, error) {
+	promptIds := []uint{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getUserAnsweredPromptIdsQuery, userId, startDate, endDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		var promptId uint
+
+		if scanErr := result.Scan(&promptId); scanErr != nil {
+			return nil, scanErr
+		}
+
+		promptIds = append(promptIds, promptId)
+	}
+
+	return promptIds, nil
+}
+
+func (promptStorage *PromptStorage) Create(prompt interface{}) error {
+	dbPrompt, ok := prompt.(*models.Prompt)
+
+	if !ok {
+		return failedToParsePromptError
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertPromptQuery,
+		dbPrompt.Text,
+		dbPrompt.Category,
+		dbPrompt.Language,
+		dbPrompt.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	promptId, idErr := result.LastInsertId()
+
+	if idErr != nil {
+		return idErr
+	}
+
+	dbPrompt.Id = uint(promptId)
+
+	return nil
+}
+
+func (promptStorage *PromptStorage) Update(prompt interface{}) error {
+	dbPrompt, ok := prompt.(*models.Prompt)
+
+	if !ok {
+		return failedToParsePromptError
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(updatePromptQuery,
+		dbPrompt.Text,
+		dbPrompt.Category,
+		dbPrompt.Language,
+		dbPrompt.Id)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
+	}
+
+	if affectedRows == 0 {
+		return promptNotFoundError
+	}
+
+	return nil
+}
+
+// Delete deletes the prompt, keeping the diary entries that answered it.
+func (promptStorage *PromptStorage) Delete(id uint) error {
+	transaction, txErr := database.GetDatabaseInstance().GetConnection().Begin()
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer transaction.Rollback()
+
+	if _, err := transaction.Exec(clearDiaryEntryPromptQuery, id); err != nil {
+		return err
+	}
+
+	result, err := transaction.Exec(deletePromptQuery, id)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
+	}
+
+	if affectedRows == 0 {
+		return promptNotFoundError
+	}
+
+	return transaction.Commit()
+}
+
+func (promptStorage *PromptStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var prompt models.Prompt
+
+	scanErr := rows.Scan(&prompt.Id, &prompt.Text, &prompt.Category, &prompt.Language, &prompt.CreatedAt)
+
+	return prompt, scanErr
+}
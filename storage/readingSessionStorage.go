@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	getReadingSessionByIdentifierQuery      = "SELECT id, book_registration_id, user_id, started_at, ended_at, page, percentage, position FROM reading_session WHERE id = ?;"
+	getBookRegistrationReadingSessionsQuery = "SELECT id, book_registration_id, user_id, started_at, ended_at, page, percentage, position FROM reading_session WHERE book_registration_id = ? ORDER BY started_at DESC, id DESC;"
+	// the sessions of books in the trash are left out
+	getUserReadingSessionsByIntervalQuery = "SELECT rs.id, rs.book_registration_id, rs.user_id, rs.started_at, rs.ended_at, rs.page, rs.percentage, rs.position" +
+		" FROM reading_session rs" +
+		" INNER JOIN activity_registration_book arb ON (rs.book_registration_id = arb.id)" +
+		" INNER JOIN activity_registration ar ON (arb.registration_id = ar.id)" +
+		" WHERE rs.user_id = ? AND ar.deleted_at = 0 AND rs.started_at <= ? AND (rs.ended_at = 0 OR rs.ended_at >= ?)" +
+		" ORDER BY rs.started_at, rs.id;"
+	insertReadingSessionQuery = "INSERT INTO reading_session (book_registration_id, user_id, started_at, ended_at, page, percentage, position) VALUES (?, ?, ?, ?, ?, ?, ?);"
+	updateReadingSessionQuery = "UPDATE reading_session SET ended_at = ?, page = ?, percentage = ?, position = ? WHERE id = ?;"
+)
+
+type ReadingSessionStorageInterface interface {
+	Get(id uint) (interface{}, error)
+	GetByBookRegistrationId(bookRegistrationId uint) (interface{}, error)
+	GetByUserIdAndInterval(userId uint, startDate int64, endDate int64) (interface{}, error)
+	Create(data interface{}) error
+	Update(data interface{}) error
+}
+
+type ReadingSessionStorage struct{}
+
+var readingSessionNotFoundError = &models.DbNotFoundError{DbItem: &models.ReadingSession{}}
+var failedToParseReadingSessionError = &models.DbCouldNotParseItemError{DbItem: &models.ReadingSession{}}
+
+func (readingSessionStorage *ReadingSessionStorage) Get(id uint) (interface{}, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getReadingSessionByIdentifierQuery, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	if !result.Next() {
+		return nil, readingSessionNotFoundError
+	}
+
+	scannedReadingSession, scanErr := readingSessionStorage.Scan(result)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	readingSession, ok := scannedReadingSession.(models.ReadingSession)
+
+	if !ok {
+		return nil, failedToParseReadingSessionError
+	}
+
+	return &readingSession, nil
+}
+
+// GetByBookRegistrationId returns the reading sessions of a book registration, most recent first.
+func (readingSessionStorage *ReadingSessionStorage) GetByBookRegistrationId(bookRegistrationId uint) (interface{}, error) {
+	return readingSessionStorage.queryList(getBookRegistrationReadingSessionsQuery, bookRegistrationId)
+}
+
+// GetByUserIdAndInterval returns the reading sessions of the user overlapping the interval, oldest first.
+// Active sessions overlap it when they started before its end.
+func (readingSessionStorage *ReadingSessionStorage) GetByUserIdAndInterval(userId uint, startDate int64, endDate int64) (interface{}, error) {
+	return readingSessionStorage.queryList(getUserReadingSessionsByIntervalQuery, userId, endDate, startDate)
+}
+
+func (readingSessionStorage *ReadingSessionStorage) Create(data interface{}) error {
+	readingSession, ok := data.(*models.ReadingSession)
+
+	if !ok {
+		return failedToParseReadingSessionError
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertReadingSessionQuery,
+		readingSession.BookRegistrationRefer,
+		readingSession.UserRefer,
+		readingSession.StartedAt,
+		readingSession.EndedAt,
+		readingSession.Page,
+		readingSession.Percentage,
+		readingSession.Position)
+
+	if err != nil {
+		return err
+	}
+
+	readingSessionId, idErr := result.LastInsertId()
+	if idErr != nil {
+		return idErr
+	}
+
+	readingSession.Id = uint(readingSessionId)
+
+	return nil
+}
+
+// Update saves the end and the progress of a reading session.
+func (readingSessionStorage *ReadingSessionStorage) Update(data interface{}) error {
+	readingSession, ok := data.(*models.ReadingSession)
+
+	if !ok {
+		return failedToParseReadingSessionError
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(updateReadingSessionQuery,
+		readingSession.EndedAt,
+		readingSession.Page,
+		readingSession.Percentage,
+		readingSession.Position,
+		readingSession.Id)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
+	}
+
+	if affectedRows == 0 {
+		return readingSessionNotFoundError
+	}
+
+	return nil
+}
+
+func (readingSessionStorage *ReadingSessionStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var readingSession models.ReadingSession
+	var page sql.NullInt64
+	var percentage sql.NullFloat64
+
+	scanErr := rows.Scan(&readingSession.Id, &readingSession.BookRegistrationRefer, &readingSession.UserRefer,
+		&readingSession.StartedAt, &readingSession.EndedAt, &page, &percentage, &readingSession.Position)
+
+	if page.Valid {
+		readingSessionPage := int(page.Int64)
+		readingSession.Page = &readingSessionPage
+	}
+	if percentage.Valid {
+		readingSession.Percentage = &percentage.Float64
+	}
+
+	return readingSession, scanErr
+}
+
+func (readingSessionStorage *ReadingSessionStorage) queryList(query string, args ...interface{}) (interface{}, error) {
+	readingSessions := []*models.ReadingSession{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedReadingSession, scanErr := readingSessionStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		readingSession, ok := scannedReadingSession.(models.ReadingSession)
+
+		if !ok {
+			return nil, failedToParseReadingSessionError
+		}
+
+		readingSessions = append(readingSessions, &readingSession)
+	}
+
+	return readingSessions, nil
+}
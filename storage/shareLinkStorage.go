@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	shareLinkColumns                 = "id, diary_entry_id, user_id, prefix, token_hash, passphrase_hash, max_views, view_count, created_at, expires_at"
+	getShareLinkQuery                = "SELECT " + shareLinkColumns + " FROM share_link WHERE id = ?;"
+	getShareLinkByHashQuery          = "SELECT " + shareLinkColumns + " FROM share_link WHERE token_hash = ?;"
+	getUserShareLinksQuery           = "SELECT " + shareLinkColumns + " FROM share_link WHERE user_id = ? ORDER BY created_at DESC, id DESC;"
+	insertShareLinkQuery             = "INSERT INTO share_link (diary_entry_id, user_id, prefix, token_hash, passphrase_hash, max_views, view_count, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);"
+	incrementShareLinkViewCountQuery = "UPDATE share_link SET view_count = view_count + 1 WHERE id = ? AND expires_at > ? AND (max_views = 0 OR view_count < max_views);"
+	deleteShareLinkQuery             = "DELETE FROM share_link WHERE id = ?;"
+)
+
+type ShareLinkStorageInterface interface {
+	Get(id uint) (interface{}, error)
+	GetByHash(tokenHash string) (interface{}, error)
+	GetByUserId(userId uint) (interface{}, error)
+	Create(data interface{}) error
+	IncrementViewCount(id uint, now int64) (bool, error)
+	Delete(id uint) error
+}
+
+type ShareLinkStorage struct{}
+
+var shareLinkNotFoundError = &models.DbNotFoundError{DbItem: &models.ShareLink{}}
+var failedToParseShareLinkError = &models.DbCouldNotParseItemError{DbItem: &models.ShareLink{}}
+
+func (shareLinkStorage *ShareLinkStorage) Get(id uint) (interface{}, error) {
+	return shareLinkStorage.getOne(getShareLinkQuery, id)
+}
+
+func (shareLinkStorage *ShareLinkStorage) GetByHash(tokenHash string) (interface{}, error) {
+	return shareLinkStorage.getOne(getShareLinkByHashQuery, tokenHash)
+}
+
+func (shareLinkStorage *ShareLinkStorage) GetByUserId(userId uint) (interface{}, error) {
+	shareLinks := []*models.ShareLink{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getUserShareLinksQuery, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedShareLink, scanErr := shareLinkStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		shareLink, ok := scannedShareLink.(models.ShareLink)
+
+		if !ok {
+			return nil, failedToParseShareLinkError
+		}
+
+		shareLinks = append(shareLinks, &shareLink)
+	}
+
+	return shareLinks, nil
+}
+
+func (shareLinkStorage *ShareLinkStorage) Create(shareLink interface{}) error {
+	dbShareLink, ok := shareLink.(*models.ShareLink)
+
+	if !ok {
+		return failedToParseShareLinkError
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertShareLinkQuery,
+		dbShareLink.DiaryEntryRefer,
+		dbShareLink.UserRefer,
+		dbShareLink.Prefix,
+		dbShareLink.TokenHash,
+		dbShareLink.PassphraseHash,
+		dbShareLink.MaxViews,
+		dbShareLink.ViewCount,
+		dbShareLink.CreatedAt,
+		dbShareLink.ExpiresAt)
+
+	if err != nil {
+		return err
+	}
+
+	shareLinkId, idErr := result.LastInsertId()
+	if idErr != nil {
+		return idErr
+	}
+
+	dbShareLink.Id = uint(shareLinkId)
+
+	return nil
+}
+
+// IncrementViewCount counts a view of the share link, as long as it has not expired nor reached its view limit.
+// Returns whether the view was counted. The check and the increment are a single statement, so concurrent
+// views cannot exceed the limit.
+func (shareLinkStorage *ShareLinkStorage) IncrementViewCount(id uint, now int64) (bool, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(incrementShareLinkViewCountQuery, id, now)
+
+	if err != nil {
+		return false, err
+	}
+
+	affectedRows, affectedRowsErr := result.RowsAffected()
+
+	if affectedRowsErr != nil {
+		return false, affectedRowsErr
+	}
+
+	return affectedRows > 0, nil
+}
+
+func (shareLinkStorage *ShareLinkStorage) Delete(id uint) error {
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(deleteShareLinkQuery, id)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, affectedRowsErr := result.RowsAffected()
+
+	if affectedRowsErr != nil {
+		return affectedRowsErr
+	}
+
+	if affectedRows == 0 {
+		return shareLinkNotFoundError
+	}
+
+	return nil
+}
+
+func (shareLinkStorage *ShareLinkStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var shareLink models.ShareLink
+
+	scanErr := rows.Scan(&shareLink.Id, &shareLink.DiaryEntryRefer, &shareLink.UserRefer, &shareLink.Prefix,
+		&shareLink.TokenHash, &shareLink.PassphraseHash, &shareLink.MaxViews, &shareLink.ViewCount,
+		&shareLink.CreatedAt, &shareLink.ExpiresAt)
+
+	shareLink.HasPassphrase = len(shareLink.PassphraseHash) > 0
+
+	return shareLink, scanErr
+}
+
+func (shareLinkStorage *ShareLinkStorage) getOne(query string, arg interface{}) (interface{}, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Query(query, arg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	if !result.Next() {
+		return nil, shareLinkNotFoundError
+	}
+
+	scannedShareLink, scanErr := shareLinkStorage.Scan(result)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	shareLink, ok := scannedShareLink.(models.ShareLink)
+
+	if !ok {
+		return nil, failedToParseShareLinkError
+	}
+
+	return &shareLink, nil
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	getTagQuery = "SELECT id, name, user_id FROM tag WHERE id = ?;"
+	// the entries in the trash are not counted
+	getUserTagUsagesQuery = "SELECT t.id, t.name, t.user_id, COUNT(ar.id) FROM tag t LEFT JOIN diary_entry_tag dt ON (dt.tag_id = t.id)" +
+		" LEFT JOIN diary_entry de ON (dt.diary_entry_id = de.id) LEFT JOIN activity_registration ar ON (de.registration_id = ar.id AND ar.deleted_at = 0)" +
+		" WHERE t.user_id = ? GROUP BY t.id ORDER BY t.name;"
+	insertTagQuery         = "INSERT INTO tag (name, user_id) VALUES (?, ?) ON CONFLICT (user_id, name) DO NOTHING;"
+	updateTagNameQuery     = "UPDATE tag SET name = ? WHERE id = ?;"
+	deleteTagQuery         = "DELETE FROM tag WHERE id = ?;"
+	deleteEntryTagsQuery   = "DELETE FROM diary_entry_tag WHERE diary_entry_id = ?;"
+	insertEntryTagQuery    = "INSERT OR IGNORE INTO diary_entry_tag (diary_entry_id, tag_id) SELECT ?, id FROM tag WHERE user_id = ? AND name = ?;"
+	mergeEntryTagsQuery    = "INSERT OR IGNORE INTO diary_entry_tag (diary_entry_id, tag_id) SELECT diary_entry_id, ? FROM diary_entry_tag WHERE tag_id = ?;"
+	deleteTagEntryTagQuery = "DELETE FROM diary_entry_tag WHERE tag_id = ?;"
+)
+
+type TagStorageInterface interface {
+	Get(id uint) (interface{}, error)
+	GetUsagesByUserId(userId uint) (interface{}, error)
+	Rename(id uint, name string) error
+	Merge(sourceId uint, targetId uint) error
+}
+
+type TagStorage struct{}
+
+var tagNotFoundError = &models.DbNotFoundError{DbItem: &models.Tag{}}
+var tagAlreadyExistsError = &models.DbItemAlreadyExistsError{DbItem: &models.Tag{}}
+
+func (tagStorage *TagStorage) Get(id uint) (interface{}, error) {
+	var tag models.Tag
+
+	err := database.GetDatabaseInstance().GetConnection().QueryRow(getTagQuery, id).Scan(&tag.Id, &tag.Name, &tag.UserRefer)
+
+	if err == sql.ErrNoRows {
+		return nil, tagNotFoundError
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// GetUsagesByUserId returns the tags of the user sorted by name, with the number of diary entries using them.
+func (tagStorage *TagStorage) GetUsagesByUserId(userId uint) (interface{}, error) {
+	tagUsages := []*models.TagUsage{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getUserTagUsagesQuery, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		var tagUsage models.TagUsage
+
+		if scanErr := result.Scan(&tagUsage.Id, &tagUsage.Name, &tagUsage.UserRefer, &tagUsage.UsageCount); scanErr != nil {
+			return nil, scanErr
+		}
+
+		tagUsages = append(tagUsages, &tagUsage)
+	}
+
+	return tagUsages, nil
+}
+
+// setDiaryEntryTags replaces the tags of a diary entry within the transaction writing the entry,
+// creating the user tags that do not exist yet.
+func setDiaryEntryTags(transaction *sql.Tx, userId uint, diaryEntryId uint, tagNames []string) error {
+	if _, err := transaction.Exec(deleteEntryTagsQuery, diaryEntryId); err != nil {
+		return err
+	}
+
+	for _, tagName := range tagNames {
+		if _, err := transaction.Exec(insertTagQuery, tagName, userId); err != nil {
+			return err
+		}
+
+		if _, err := transaction.Exec(insertEntryTagQuery, diaryEntryId, userId, tagName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rename changes the name of a tag. Returns error if the user already has a tag with that name.
+func (tagStorage *TagStorage) Rename(id uint, name string) error {
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(updateTagNameQuery, name, id)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return tagAlreadyExistsError
+		}
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
+	}
+
+	if affectedRows == 0 {
+		return tagNotFoundError
+	}
+
+	return nil
+}
+
+// Merge tags the entries of the source tag with the target tag, and deletes the source tag.
+func (tagStorage *TagStorage) Merge(sourceId uint, targetId uint) error {
+	transaction, txErr := database.GetDatabaseInstance().GetConnection().Begin()
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer transaction.Rollback()
+
+	if _, err := transaction.Exec(mergeEntryTagsQuery, targetId, sourceId); err != nil {
+		return err
+	}
+
+	if _, err := transaction.Exec(deleteTagEntryTagQuery, sourceId); err != nil {
+		return err
+	}
+
+	result, err := transaction.Exec(deleteTagQuery, sourceId)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
+	}
+
+	if affectedRows == 0 {
+		return tagNotFoundError
+	}
+
+	return transaction.Commit()
+}
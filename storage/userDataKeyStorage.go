@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	userDataKeyColumns     = "user_id, wrapped_key, master_key_id, created_at"
+	getUserDataKeyQuery    = "SELECT " + userDataKeyColumns + " FROM user_data_key WHERE user_id = ?;"
+	getAllUserDataKeyQuery = "SELECT " + userDataKeyColumns + " FROM user_data_key ORDER BY user_id;"
+	insertUserDataKeyQuery = "INSERT INTO user_data_key (user_id, wrapped_key, master_key_id, created_at) VALUES (?, ?, ?, ?)" +
+		" ON CONFLICT (user_id) DO NOTHING;"
+	updateUserDataKeyQuery = "UPDATE user_data_key SET wrapped_key = ?, master_key_id = ? WHERE user_id = ?;"
+)
+
+type UserDataKeyStorageInterface interface {
+	Get(userId uint) (interface{}, error)
+	GetAll() (interface{}, error)
+	Create(data interface{}) error
+	Update(data interface{}) error
+}
+
+type UserDataKeyStorage struct{}
+
+var userDataKeyNotFoundError = &models.DbNotFoundError{DbItem: &models.UserDataKey{}}
+var failedToParseUserDataKeyError = &models.DbCouldNotParseItemError{DbItem: &models.UserDataKey{}}
+
+func (userDataKeyStorage *UserDataKeyStorage) Get(userId uint) (interface{}, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getUserDataKeyQuery, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	if !result.Next() {
+		return nil, userDataKeyNotFoundError
+	}
+
+	scannedDataKey, scanErr := userDataKeyStorage.Scan(result)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	dataKey, ok := scannedDataKey.(models.UserDataKey)
+
+	if !ok {
+		return nil, failedToParseUserDataKeyError
+	}
+
+	return &dataKey, nil
+}
+
+func (userDataKeyStorage *UserDataKeyStorage) GetAll() (interface{}, error) {
+	dataKeys := []*models.UserDataKey{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getAllUserDataKeyQuery)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedDataKey, scanErr := userDataKeyStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		dataKey, ok := scannedDataKey.(models.UserDataKey)
+
+		if !ok {
+			return nil, failedToParseUserDataKeyError
+		}
+
+		dataKeys = append(dataKeys, &dataKey)
+	}
+
+	return dataKeys, nil
+}
+
+// Create stores the data key, unless the user already has one. Callers must read the stored key back,
+// as a concurrent request may have created it first.
+func (userDataKeyStorage *UserDataKeyStorage) Create(userDataKey interface{}) error {
+	dbDataKey, ok := userDataKey.(*models.UserDataKey)
+
+	if !ok {
+		return failedToParseUserDataKeyError
+	}
+
+	_, err := database.GetDatabaseInstance().GetConnection().Exec(insertUserDataKeyQuery,
+		dbDataKey.UserRefer,
+		dbDataKey.WrappedKey,
+		dbDataKey.MasterKeyId,
+		dbDataKey.CreatedAt)
+
+	return err
+}
+
+// Update replaces the wrapped data key of the user, after wrapping it with another master key.
+func (userDataKeyStorage *UserDataKeyStorage) Update(userDataKey interface{}) error {
+	dbDataKey, ok := userDataKey.(*models.UserDataKey)
+
+	if !ok {
+		return failedToParseUserDataKeyError
+	}
+
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(updateUserDataKeyQuery,
+		dbDataKey.WrappedKey,
+		dbDataKey.MasterKeyId,
+		dbDataKey.UserRefer)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
+	}
+
+	if affectedRows == 0 {
+		return userDataKeyNotFoundError
+	}
+
+	return nil
+}
+
+func (userDataKeyStorage *UserDataKeyStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var dataKey models.UserDataKey
+
+	scanErr := rows.Scan(&dataKey.UserRefer, &dataKey.WrappedKey, &dataKey.MasterKeyId, &dataKey.CreatedAt)
+
+	return dataKey, scanErr
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/adfer-dev/analock-api/database"
+	"github.com/adfer-dev/analock-api/models"
+)
+
+const (
+	userKeyBackupColumns         = "id, key_id, blob, user_id, created_at, updated_at"
+	getUserKeyBackupQuery        = "SELECT " + userKeyBackupColumns + " FROM user_key_backup WHERE user_id = ? AND key_id = ?;"
+	getUserKeyBackupsByUserQuery = "SELECT " + userKeyBackupColumns + " FROM user_key_backup WHERE user_id = ? ORDER BY created_at, id;"
+	upsertUserKeyBackupQuery     = "INSERT INTO user_key_backup (key_id, blob, user_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?)" +
+		" ON CONFLICT (user_id, key_id) DO UPDATE SET blob = excluded.blob, updated_at = excluded.updated_at;"
+	deleteUserKeyBackupQuery = "DELETE FROM user_key_backup WHERE user_id = ? AND key_id = ?;"
+)
+
+type UserKeyBackupStorageInterface interface {
+	Get(userId uint, keyId string) (interface{}, error)
+	GetByUserId(userId uint) (interface{}, error)
+	Save(data interface{}) error
+	Delete(userId uint, keyId string) error
+}
+
+type UserKeyBackupStorage struct{}
+
+var userKeyBackupNotFoundError = &models.DbNotFoundError{DbItem: &models.UserKeyBackup{}}
+var failedToParseUserKeyBackupError = &models.DbCouldNotParseItemError{DbItem: &models.UserKeyBackup{}}
+
+func (userKeyBackupStorage *UserKeyBackupStorage) Get(userId uint, keyId string) (interface{}, error) {
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getUserKeyBackupQuery, userId, keyId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	if !result.Next() {
+		return nil, userKeyBackupNotFoundError
+	}
+
+	scannedKeyBackup, scanErr := userKeyBackupStorage.Scan(result)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	keyBackup, ok := scannedKeyBackup.(models.UserKeyBackup)
+
+	if !ok {
+		return nil, failedToParseUserKeyBackupError
+	}
+
+	return &keyBackup, nil
+}
+
+func (userKeyBackupStorage *UserKeyBackupStorage) GetByUserId(userId uint) (interface{}, error) {
+	keyBackups := []*models.UserKeyBackup{}
+	result, err := database.GetDatabaseInstance().GetConnection().Query(getUserKeyBackupsByUserQuery, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		scannedKeyBackup, scanErr := userKeyBackupStorage.Scan(result)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		keyBackup, ok := scannedKeyBackup.(models.UserKeyBackup)
+
+		if !ok {
+			return nil, failedToParseUserKeyBackupError
+		}
+
+		keyBackups = append(keyBackups, &keyBackup)
+	}
+
+	return keyBackups, nil
+}
+
+// Save creates the key backup, or replaces the blob of the user backup with the same key id.
+func (userKeyBackupStorage *UserKeyBackupStorage) Save(keyBackup interface{}) error {
+	dbKeyBackup, ok := keyBackup.(*models.UserKeyBackup)
+
+	if !ok {
+		return failedToParseUserKeyBackupError
+	}
+
+	_, err := database.GetDatabaseInstance().GetConnection().Exec(upsertUserKeyBackupQuery,
+		dbKeyBackup.KeyId,
+		dbKeyBackup.Blob,
+		dbKeyBackup.UserRefer,
+		dbKeyBackup.CreatedAt,
+		dbKeyBackup.UpdatedAt)
+
+	return err
+}
+
+func (userKeyBackupStorage *UserKeyBackupStorage) Delete(userId uint, keyId string) error {
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(deleteUserKeyBackupQuery, userId, keyId)
+
+	if err != nil {
+		return err
+	}
+
+	affectedRows, errAffectedRows := result.RowsAffected()
+
+	if errAffectedRows != nil {
+		return errAffectedRows
+	}
+
+	if affectedRows == 0 {
+		return userKeyBackupNotFoundError
+	}
+
+	return nil
+}
+
+func (userKeyBackupStorage *UserKeyBackupStorage) Scan(rows *sql.Rows) (interface{}, error) {
+	var keyBackup models.UserKeyBackup
+
+	scanErr := rows.Scan(&keyBackup.Id, &keyBackup.KeyId, &keyBackup.Blob, &keyBackup.UserRefer,
+		&keyBackup.CreatedAt, &keyBackup.UpdatedAt)
+
+	return keyBackup, scanErr
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"log"
 
 	"github.com/adfer-dev/analock-api/database"
@@ -9,10 +10,11 @@ import (
 )
 
 const (
-	getUserQuery            = "SELECT * FROM user where id = ?;"
-	getUserByUserEmailQuery = "SELECT * FROM user where email = ?;"
-	insertUserQuery         = "INSERT INTO user (email, username, role) VALUES (?, ?, ?);"
-	updateUserQuery         = "UPDATE user SET username = ?, role = ? WHERE id = ?;"
+	getUserQuery            = "SELECT id, email, username, role, avatar_url, locale, time_zone, search_index_enabled, version FROM user where id = ?;"
+	getUserByUserEmailQuery = "SELECT id, email, username, role, avatar_url, locale, time_zone, search_index_enabled, version FROM user where email = ?;"
+	insertUserQuery         = "INSERT INTO user (email, username, role, avatar_url, locale, time_zone) VALUES (?, ?, ?, ?, ?, ?);"
+	updateUserQuery         = "UPDATE user SET username = ?, role = ?, avatar_url = ?, locale = ?, time_zone = ?, search_index_enabled = ?, version = version + 1 WHERE id = ? AND version = ?;"
+	getUserVersionQuery     = "SELECT version FROM user WHERE id = ?;"
 	deleteUserQuery         = "DELETE FROM user WHERE id = ?;"
 )
 
@@ -101,7 +103,8 @@ func (userStorage *UserStorage) Create(user interface{}) error {
 		return userAlreadyExistsError
 	}
 
-	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertUserQuery, dbUser.Email, dbUser.UserName, dbUser.Role)
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(insertUserQuery, dbUser.Email, dbUser.UserName, dbUser.Role,
+		dbUser.AvatarUrl, dbUser.Locale, dbUser.TimeZone)
 	if err != nil {
 		storageLogger.ErrorLogger.Printf("error when saving user: %s", err.Error())
 		return err
@@ -113,10 +116,13 @@ func (userStorage *UserStorage) Create(user interface{}) error {
 	}
 
 	dbUser.Id = uint(userId)
+	dbUser.Version = 1
 
 	return nil
 }
 
+// Update updates the user. The user version must be the stored one, otherwise a DbVersionConflictError is returned.
+// It is incremented on success.
 func (userStorage *UserStorage) Update(user interface{}) error {
 	dbUser, ok := user.(*models.User)
 
@@ -124,7 +130,8 @@ func (userStorage *UserStorage) Update(user interface{}) error {
 		return failedToParseUserError
 	}
 
-	result, err := database.GetDatabaseInstance().GetConnection().Exec(updateUserQuery, dbUser.UserName, dbUser.Role, dbUser.Id)
+	result, err := database.GetDatabaseInstance().GetConnection().Exec(updateUserQuery, dbUser.UserName, dbUser.Role,
+		dbUser.AvatarUrl, dbUser.Locale, dbUser.TimeZone, dbUser.SearchIndexEnabled, dbUser.Id, dbUser.Version)
 
 	if err != nil {
 		return err
@@ -137,9 +144,20 @@ func (userStorage *UserStorage) Update(user interface{}) error {
 	}
 
 	if affectedRows == 0 {
-		return userNotFoundError
+		var currentVersion int64
+
+		if versionErr := database.GetDatabaseInstance().GetConnection().QueryRow(getUserVersionQuery, dbUser.Id).Scan(&currentVersion); versionErr != nil {
+			if errors.Is(versionErr, sql.ErrNoRows) {
+				return userNotFoundError
+			}
+			return versionErr
+		}
+
+		return &models.DbVersionConflictError{DbItem: &models.User{}, CurrentVersion: currentVersion}
 	}
 
+	dbUser.Version++
+
 	return nil
 }
 
@@ -161,13 +179,16 @@ func (userStorage *UserStorage) Delete(id uint) error {
 		return userNotFoundError
 	}
 
+	forgetUserDataKey(id)
+
 	return nil
 }
 
 func (userStorage *UserStorage) Scan(rows *sql.Rows) (interface{}, error) {
 	var user models.User
 
-	scanErr := rows.Scan(&user.Id, &user.Email, &user.UserName, &user.Role)
+	scanErr := rows.Scan(&user.Id, &user.Email, &user.UserName, &user.Role, &user.AvatarUrl, &user.Locale, &user.TimeZone,
+		&user.SearchIndexEnabled, &user.Version)
 
 	return &user, scanErr
 }
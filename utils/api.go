@@ -5,7 +5,6 @@ import (
 	"net/http"
 
 	"github.com/adfer-dev/analock-api/models"
-	"github.com/go-playground/validator/v10"
 )
 
 type APIFunc func(res http.ResponseWriter, req *http.Request) error
@@ -31,6 +30,8 @@ func TranslateDbErrorToHttpError(err error) *models.HttpError {
 		httpError.Status = 500
 	case *models.DbItemAlreadyExistsError:
 		httpError.Status = 400
+	case *models.DbVersionConflictError:
+		httpError.Status = 412
 	default:
 		httpError.Status = 500
 	}
@@ -41,20 +42,10 @@ func TranslateDbErrorToHttpError(err error) *models.HttpError {
 }
 
 func HandleValidation(req *http.Request, body interface{}) []*models.HttpError {
-	httpErrors := make([]*models.HttpError, 0)
-
 	if parseErr := ReadJSON(req.Body, body); parseErr != nil {
 		log.Println(parseErr)
-		if validationErrs, ok := parseErr.(validator.ValidationErrors); ok {
-			for _, validationErr := range validationErrs {
-				httpErrors = append(httpErrors,
-					&models.HttpError{Status: 400, Description: "Field" + validationErr.Field() + " must be provided."})
-			}
-		} else {
-			httpError := models.HttpError{Status: 400, Description: "Not valid JSON."}
-			httpErrors = append(httpErrors, &httpError)
-		}
+		return translateBodyError(parseErr)
 	}
 
-	return httpErrors
+	return make([]*models.HttpError, 0)
 }
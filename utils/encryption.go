@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// EncryptedValuePrefix marks the values encrypted at rest, so plaintext values stored before encryption was enabled can still be read.
+	EncryptedValuePrefix = "enc:v1:"
+	DataKeySize          = 32
+	masterKeyEnv         = "DATA_ENCRYPTION_MASTER_KEY"
+	previousMasterKeyEnv = "DATA_ENCRYPTION_PREVIOUS_MASTER_KEYS"
+)
+
+var (
+	ErrInvalidMasterKey     = errors.New("master keys must be base64 encoded 32 byte keys")
+	ErrUnknownMasterKey     = errors.New("the data key was wrapped by a master key that is not configured")
+	ErrInvalidEncryptedData = errors.New("encrypted data is malformed or was tampered with")
+)
+
+// MasterKeyRing holds the master key wrapping new data keys, and the previous master keys still needed
+// to unwrap the data keys that were not re-wrapped after a rotation.
+type MasterKeyRing struct {
+	activeKeyId string
+	keys        map[string][]byte
+}
+
+// LoadMasterKeyRing reads the master keys from the environment. It returns nil when no master key is configured,
+// which disables encryption at rest.
+func LoadMasterKeyRing() (*MasterKeyRing, error) {
+	activeKey := os.Getenv(masterKeyEnv)
+
+	if len(activeKey) == 0 {
+		return nil, nil
+	}
+
+	decodedActiveKey, decodeErr := base64.StdEncoding.DecodeString(activeKey)
+
+	if decodeErr != nil {
+		return nil, ErrInvalidMasterKey
+	}
+
+	previousKeys := [][]byte{}
+
+	for _, previousKey := range strings.Split(os.Getenv(previousMasterKeyEnv), ",") {
+		previousKey = strings.TrimSpace(previousKey)
+
+		if len(previousKey) == 0 {
+			continue
+		}
+
+		decodedPreviousKey, decodeErr := base64.StdEncoding.DecodeString(previousKey)
+
+		if decodeErr != nil {
+			return nil, ErrInvalidMasterKey
+		}
+
+		previousKeys = append(previousKeys, decodedPreviousKey)
+	}
+
+	return NewMasterKeyRing(decodedActiveKey, previousKeys...)
+}
+
+// NewMasterKeyRing returns a key ring wrapping data keys with the active key.
+func NewMasterKeyRing(activeKey []byte, previousKeys ...[]byte) (*MasterKeyRing, error) {
+	keyRing := &MasterKeyRing{keys: make(map[string][]byte)}
+
+	for _, key := range append(previousKeys, activeKey) {
+		if len(key) != DataKeySize {
+			return nil, ErrInvalidMasterKey
+		}
+
+		keyRing.keys[getMasterKeyId(key)] = key
+	}
+
+	keyRing.activeKeyId = getMasterKeyId(activeKey)
+
+	return keyRing, nil
+}
+
+// ActiveKeyId returns the id of the master key wrapping new data keys.
+func (keyRing *MasterKeyRing) ActiveKeyId() string {
+	return keyRing.activeKeyId
+}
+
+// WrapKey encrypts a data key with the active master key, returning the wrapped key and the master key id.
+func (keyRing *MasterKeyRing) WrapKey(dataKey []byte) (string, string, error) {
+	wrappedKey, err := encrypt(keyRing.keys[keyRing.activeKeyId], dataKey, []byte(keyRing.activeKeyId))
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(wrappedKey), keyRing.activeKeyId, nil
+}
+
+// UnwrapKey decrypts a data key wrapped by the master key with the given id.
+func (keyRing *MasterKeyRing) UnwrapKey(wrappedKey string, masterKeyId string) ([]byte, error) {
+	masterKey, found := keyRing.keys[masterKeyId]
+
+	if !found {
+		return nil, ErrUnknownMasterKey
+	}
+
+	decodedWrappedKey, decodeErr := base64.StdEncoding.DecodeString(wrappedKey)
+
+	if decodeErr != nil {
+		return nil, ErrInvalidEncryptedData
+	}
+
+	return decrypt(masterKey, decodedWrappedKey, []byte(masterKeyId))
+}
+
+// GenerateDataKey returns a new random data key.
+func GenerateDataKey() ([]byte, error) {
+	dataKey := make([]byte, DataKeySize)
+
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	return dataKey, nil
+}
+
+// IsEncryptedValue reports whether the value was encrypted by EncryptValue.
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, EncryptedValuePrefix)
+}
+
+// EncryptValue encrypts the value with AES-GCM. The associated data binds the ciphertext to its owner and column,
+// so it cannot be moved to another row or column.
+func EncryptValue(dataKey []byte, value string, associatedData string) (string, error) {
+	ciphertext, err := encrypt(dataKey, []byte(value), []byte(associatedData))
+
+	if err != nil {
+		return "", err
+	}
+
+	return EncryptedValuePrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptValue decrypts a value encrypted by EncryptValue. Plaintext values are returned as they are.
+func DecryptValue(dataKey []byte, value string, associatedData string) (string, error) {
+	if !IsEncryptedValue(value) {
+		return value, nil
+	}
+
+	ciphertext, decodeErr := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedValuePrefix))
+
+	if decodeErr != nil {
+		return "", ErrInvalidEncryptedData
+	}
+
+	plaintext, err := decrypt(dataKey, ciphertext, []byte(associatedData))
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// getMasterKeyId returns a fingerprint of the master key, stored next to the data keys it wraps.
+func getMasterKeyId(masterKey []byte) string {
+	fingerprint := sha256.Sum256(masterKey)
+
+	return hex.EncodeToString(fingerprint[:8])
+}
+
+// encrypt seals the plaintext with AES-GCM, prefixing the random nonce to the ciphertext.
+func encrypt(key []byte, plaintext []byte, associatedData []byte) ([]byte, error) {
+	aead, err := newAesGcm(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func decrypt(key []byte, ciphertext []byte, associatedData []byte) ([]byte, error) {
+	aead, err := newAesGcm(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrInvalidEncryptedData
+	}
+
+	plaintext, openErr := aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], associatedData)
+
+	if openErr != nil {
+		return nil, ErrInvalidEncryptedData
+	}
+
+	return plaintext, nil
+}
+
+func newAesGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
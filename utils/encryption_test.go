@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptValue(t *testing.T) {
+	dataKey, err := GenerateDataKey()
+	assert.NoError(t, err)
+
+	encryptedValue, err := EncryptValue(dataKey, "Dear diary", "diary_entry.title:1")
+	assert.NoError(t, err)
+	assert.True(t, IsEncryptedValue(encryptedValue))
+	assert.NotContains(t, encryptedValue, "Dear diary")
+
+	decryptedValue, err := DecryptValue(dataKey, encryptedValue, "diary_entry.title:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Dear diary", decryptedValue)
+
+	// Test values cannot be moved to other users or columns
+	_, err = DecryptValue(dataKey, encryptedValue, "diary_entry.title:2")
+	assert.ErrorIs(t, err, ErrInvalidEncryptedData)
+	_, err = DecryptValue(dataKey, encryptedValue, "diary_entry.content:1")
+	assert.ErrorIs(t, err, ErrInvalidEncryptedData)
+
+	// Test wrong key and tampered values
+	otherDataKey, _ := GenerateDataKey()
+	_, err = DecryptValue(otherDataKey, encryptedValue, "diary_entry.title:1")
+	assert.ErrorIs(t, err, ErrInvalidEncryptedData)
+	_, err = DecryptValue(dataKey, EncryptedValuePrefix+"AAAA", "diary_entry.title:1")
+	assert.ErrorIs(t, err, ErrInvalidEncryptedData)
+
+	// Test plaintext values are returned as they are
+	decryptedValue, err = DecryptValue(dataKey, "legacy plaintext", "diary_entry.title:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy plaintext", decryptedValue)
+}
+
+func TestMasterKeyRing(t *testing.T) {
+	previousMasterKey := bytes.Repeat([]byte{1}, DataKeySize)
+	activeMasterKey := bytes.Repeat([]byte{2}, DataKeySize)
+	dataKey, _ := GenerateDataKey()
+
+	previousKeyRing, err := NewMasterKeyRing(previousMasterKey)
+	assert.NoError(t, err)
+	wrappedKey, previousKeyId, err := previousKeyRing.WrapKey(dataKey)
+	assert.NoError(t, err)
+	assert.Equal(t, previousKeyRing.ActiveKeyId(), previousKeyId)
+
+	// Test data keys wrapped by previous master keys are unwrapped after a rotation, and re-wrapped with the active one
+	rotatedKeyRing, err := NewMasterKeyRing(activeMasterKey, previousMasterKey)
+	assert.NoError(t, err)
+	unwrappedKey, err := rotatedKeyRing.UnwrapKey(wrappedKey, previousKeyId)
+	assert.NoError(t, err)
+	assert.Equal(t, dataKey, unwrappedKey)
+	rewrappedKey, activeKeyId, err := rotatedKeyRing.WrapKey(unwrappedKey)
+	assert.NoError(t, err)
+	assert.NotEqual(t, previousKeyId, activeKeyId)
+
+	// Test previous master keys can be removed once the data keys are re-wrapped
+	activeKeyRing, _ := NewMasterKeyRing(activeMasterKey)
+	_, err = activeKeyRing.UnwrapKey(wrappedKey, previousKeyId)
+	assert.ErrorIs(t, err, ErrUnknownMasterKey)
+	unwrappedKey, err = activeKeyRing.UnwrapKey(rewrappedKey, activeKeyId)
+	assert.NoError(t, err)
+	assert.Equal(t, dataKey, unwrappedKey)
+
+	// Test invalid master keys
+	_, err = NewMasterKeyRing([]byte("short"))
+	assert.ErrorIs(t, err, ErrInvalidMasterKey)
+}
+
+func TestLoadMasterKeyRing(t *testing.T) {
+	// Test encryption is disabled without master key
+	t.Setenv(masterKeyEnv, "")
+	keyRing, err := LoadMasterKeyRing()
+	assert.NoError(t, err)
+	assert.Nil(t, keyRing)
+
+	activeMasterKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, DataKeySize))
+	previousMasterKeys := []string{
+		base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, DataKeySize)),
+		base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0}, DataKeySize)),
+	}
+	t.Setenv(masterKeyEnv, activeMasterKey)
+	t.Setenv(previousMasterKeyEnv, strings.Join(previousMasterKeys, ", "))
+	keyRing, err = LoadMasterKeyRing()
+	assert.NoError(t, err)
+	assert.Len(t, keyRing.keys, 3)
+
+	// Test invalid master keys
+	t.Setenv(previousMasterKeyEnv, "not base64")
+	_, err = LoadMasterKeyRing()
+	assert.ErrorIs(t, err, ErrInvalidMasterKey)
+}
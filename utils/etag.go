@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidIfMatch = errors.New(`the If-Match header must be * or the ETag of a version, such as "3"`)
+
+// FormatVersionETag returns the strong ETag of a version of a resource.
+func FormatVersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseIfMatchVersion returns the version required by the If-Match header of the request, and whether the header is present.
+// The version is 0 for "*", which matches any version.
+func ParseIfMatchVersion(req *http.Request) (int64, bool, error) {
+	ifMatch := strings.TrimSpace(req.Header.Get("If-Match"))
+
+	if len(ifMatch) == 0 {
+		return 0, false, nil
+	}
+
+	if ifMatch == "*" {
+		return 0, true, nil
+	}
+
+	if len(ifMatch) < 3 || !strings.HasPrefix(ifMatch, `"`) || !strings.HasSuffix(ifMatch, `"`) {
+		return 0, true, ErrInvalidIfMatch
+	}
+
+	version, err := strconv.ParseInt(ifMatch[1:len(ifMatch)-1], 10, 64)
+
+	if err != nil || version <= 0 {
+		return 0, true, ErrInvalidIfMatch
+	}
+
+	return version, true, nil
+}
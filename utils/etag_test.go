@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIfMatchVersion(t *testing.T) {
+	testCases := []struct {
+		ifMatch         string
+		expectedVersion int64
+		expectedPresent bool
+		expectedErr     error
+	}{
+		{ifMatch: "", expectedVersion: 0, expectedPresent: false},
+		{ifMatch: "*", expectedVersion: 0, expectedPresent: true},
+		{ifMatch: `"3"`, expectedVersion: 3, expectedPresent: true},
+		{ifMatch: ` "12" `, expectedVersion: 12, expectedPresent: true},
+		{ifMatch: "3", expectedPresent: true, expectedErr: ErrInvalidIfMatch},
+		{ifMatch: `W/"3"`, expectedPresent: true, expectedErr: ErrInvalidIfMatch},
+		{ifMatch: `"0"`, expectedPresent: true, expectedErr: ErrInvalidIfMatch},
+		{ifMatch: `"abc"`, expectedPresent: true, expectedErr: ErrInvalidIfMatch},
+	}
+
+	for _, testCase := range testCases {
+		req := httptest.NewRequest("PUT", "/api/v1/diaryEntries/1", nil)
+		if len(testCase.ifMatch) > 0 {
+			req.Header.Set("If-Match", testCase.ifMatch)
+		}
+
+		version, present, err := ParseIfMatchVersion(req)
+
+		assert.Equal(t, testCase.expectedErr, err, testCase.ifMatch)
+		assert.Equal(t, testCase.expectedPresent, present, testCase.ifMatch)
+		assert.Equal(t, testCase.expectedVersion, version, testCase.ifMatch)
+	}
+
+	assert.Equal(t, `"7"`, FormatVersionETag(7))
+}
@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"unicode/utf8"
+)
+
+// jsonSchemaTypes are the JSON Schema types supported by JSONSchema.
+var jsonSchemaTypes = []string{"object", "array", "string", "integer", "number", "boolean"}
+
+var ErrInvalidJSONSchema = errors.New("invalid JSON schema")
+
+// JSONSchema is the subset of JSON Schema used to validate JSON documents: types, object properties,
+// array items, enumerations and the length and range keywords. Other keywords are rejected when parsing.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+}
+
+// JSONSchemaValidationError tells the first part of a document that does not match its schema.
+// Path is a JSON Pointer (RFC 6901) to the invalid value.
+type JSONSchemaValidationError struct {
+	Path    string
+	Message string
+}
+
+func (err *JSONSchemaValidationError) Error() string {
+	if len(err.Path) == 0 {
+		return err.Message
+	}
+
+	return err.Path + ": " + err.Message
+}
+
+// ParseJSONSchema reads a JSON schema, failing on unknown keywords and types so typos are not silently ignored.
+func ParseJSONSchema(rawSchema []byte) (*JSONSchema, error) {
+	decoder := json.NewDecoder(bytes.NewReader(rawSchema))
+	decoder.DisallowUnknownFields()
+	schema := &JSONSchema{}
+
+	if err := decoder.Decode(schema); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidJSONSchema, err.Error())
+	}
+
+	if err := schema.check(""); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// Validate checks a JSON document against the schema.
+func (schema *JSONSchema) Validate(document []byte) error {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&value); err != nil {
+		return &JSONSchemaValidationError{Message: "not valid JSON"}
+	}
+
+	return schema.validateValue("", value)
+}
+
+func (schema *JSONSchema) check(path string) error {
+	if !slices.Contains(jsonSchemaTypes, schema.Type) {
+		return fmt.Errorf("%w: the type of %q must be one of %v", ErrInvalidJSONSchema, path, jsonSchemaTypes)
+	}
+
+	for _, requiredProperty := range schema.Required {
+		if _, found := schema.Properties[requiredProperty]; !found {
+			return fmt.Errorf("%w: the required property %q of %q is not declared", ErrInvalidJSONSchema, requiredProperty, path)
+		}
+	}
+
+	for name, property := range schema.Properties {
+		if property == nil {
+			return fmt.Errorf("%w: the property %q of %q has no schema", ErrInvalidJSONSchema, name, path)
+		}
+		if err := property.check(path + "/" + name); err != nil {
+			return err
+		}
+	}
+
+	if schema.Items != nil {
+		return schema.Items.check(path + "/items")
+	}
+
+	return nil
+}
+
+func (schema *JSONSchema) validateValue(path string, value interface{}) error {
+	if len(schema.Enum) > 0 && !schema.enumContains(value) {
+		return &JSONSchemaValidationError{Path: path, Message: fmt.Sprintf("must be one of %v", schema.Enum)}
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+
+		if !ok {
+			return &JSONSchemaValidationError{Path: path, Message: "must be an object"}
+		}
+
+		return schema.validateObject(path, object)
+	case "array":
+		array, ok := value.([]interface{})
+
+		if !ok {
+			return &JSONSchemaValidationError{Path: path, Message: "must be an array"}
+		}
+
+		return schema.validateArray(path, array)
+	case "string":
+		text, ok := value.(string)
+
+		if !ok {
+			return &JSONSchemaValidationError{Path: path, Message: "must be a string"}
+		}
+
+		length := utf8.RuneCountInString(text)
+
+		if schema.MinLength != nil && length < *schema.MinLength {
+			return &JSONSchemaValidationError{Path: path, Message: fmt.Sprintf("must have at least %d characters", *schema.MinLength)}
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			return &JSONSchemaValidationError{Path: path, Message: fmt.Sprintf("must have at most %d characters", *schema.MaxLength)}
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+
+		if !ok {
+			return &JSONSchemaValidationError{Path: path, Message: "must be a " + schema.Type}
+		}
+
+		floatNumber, err := number.Float64()
+
+		if err != nil || (schema.Type == "integer" && floatNumber != math.Trunc(floatNumber)) {
+			return &JSONSchemaValidationError{Path: path, Message: "must be a " + schema.Type}
+		}
+		if schema.Minimum != nil && floatNumber < *schema.Minimum {
+			return &JSONSchemaValidationError{Path: path, Message: fmt.Sprintf("must be at least %v", *schema.Minimum)}
+		}
+		if schema.Maximum != nil && floatNumber > *schema.Maximum {
+			return &JSONSchemaValidationError{Path: path, Message: fmt.Sprintf("must be at most %v", *schema.Maximum)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return &JSONSchemaValidationError{Path: path, Message: "must be a boolean"}
+		}
+	}
+
+	return nil
+}
+
+func (schema *JSONSchema) validateObject(path string, object map[string]interface{}) error {
+	for _, requiredProperty := range schema.Required {
+		if _, found := object[requiredProperty]; !found {
+			return &JSONSchemaValidationError{Path: path + "/" + requiredProperty, Message: "is required"}
+		}
+	}
+
+	// properties are checked in order, so the reported error does not change between requests
+	for _, name := range slices.Sorted(maps.Keys(object)) {
+		propertyValue := object[name]
+		property, found := schema.Properties[name]
+
+		if !found {
+			if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+				return &JSONSchemaValidationError{Path: path + "/" + name, Message: "is not allowed"}
+			}
+			continue
+		}
+
+		if err := property.validateValue(path+"/"+name, propertyValue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (schema *JSONSchema) validateArray(path string, array []interface{}) error {
+	if schema.MinItems != nil && len(array) < *schema.MinItems {
+		return &JSONSchemaValidationError{Path: path, Message: fmt.Sprintf("must have at least %d items", *schema.MinItems)}
+	}
+	if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+		return &JSONSchemaValidationError{Path: path, Message: fmt.Sprintf("must have at most %d items", *schema.MaxItems)}
+	}
+
+	if schema.Items == nil {
+		return nil
+	}
+
+	for i, item := range array {
+		if err := schema.Items.validateValue(fmt.Sprintf("%s/%d", path, i), item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enumContains tells if the value is one of the enumeration. Numbers are compared by value, like 1 and 1.0.
+func (schema *JSONSchema) enumContains(value interface{}) bool {
+	for _, enumValue := range schema.Enum {
+		if reflect.DeepEqual(normalizeJSONValue(enumValue), normalizeJSONValue(value)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeJSONValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case json.Number:
+		floatNumber, _ := typedValue.Float64()
+		return floatNumber
+	default:
+		return value
+	}
+}
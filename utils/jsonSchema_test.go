@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSONSchema(t *testing.T) {
+	schema, err := ParseJSONSchema([]byte(`{"type":"object","properties":{"minutes":{"type":"integer","minimum":1}},"required":["minutes"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, "integer", schema.Properties["minutes"].Type)
+
+	invalidSchemas := []string{
+		`not json`,
+		`{"type":"object","pattern":"^a"}`,
+		`{"type":"date"}`,
+		`{"type":"object","properties":{"steps":{"type":"long"}}}`,
+		`{"type":"object","required":["minutes"]}`,
+		`{"type":"array","items":{"type":"tuple"}}`,
+	}
+
+	for _, invalidSchema := range invalidSchemas {
+		_, err := ParseJSONSchema([]byte(invalidSchema))
+		assert.ErrorIs(t, err, ErrInvalidJSONSchema, invalidSchema)
+	}
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema, err := ParseJSONSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"minutes": {"type": "integer", "minimum": 1, "maximum": 600},
+			"distance": {"type": "number"},
+			"place": {"type": "string", "minLength": 1, "maxLength": 5},
+			"mood": {"type": "string", "enum": ["calm", "tired"]},
+			"outdoors": {"type": "boolean"},
+			"pieces": {"type": "array", "items": {"type": "string"}, "maxItems": 2}
+		},
+		"required": ["minutes"],
+		"additionalProperties": false
+	}`))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		document string
+		path     string
+	}{
+		{document: `{"minutes":30,"distance":2.5,"place":"park","mood":"calm","outdoors":true,"pieces":["a","b"]}`},
+		{document: `{"minutes":30.0}`},
+		{document: `{"place":"park"}`, path: "/minutes"},
+		{document: `{"minutes":0}`, path: "/minutes"},
+		{document: `{"minutes":601}`, path: "/minutes"},
+		{document: `{"minutes":1.5}`, path: "/minutes"},
+		{document: `{"minutes":"30"}`, path: "/minutes"},
+		{document: `{"minutes":30,"distance":"far"}`, path: "/distance"},
+		{document: `{"minutes":30,"place":""}`, path: "/place"},
+		{document: `{"minutes":30,"place":"garden"}`, path: "/place"},
+		{document: `{"minutes":30,"mood":"angry"}`, path: "/mood"},
+		{document: `{"minutes":30,"outdoors":"yes"}`, path: "/outdoors"},
+		{document: `{"minutes":30,"pieces":["a",1]}`, path: "/pieces/1"},
+		{document: `{"minutes":30,"pieces":["a","b","c"]}`, path: "/pieces"},
+		{document: `{"minutes":30,"steps":1000}`, path: "/steps"},
+		{document: `[30]`, path: ""},
+	}
+
+	for _, testCase := range testCases {
+		validationErr := schema.Validate([]byte(testCase.document))
+
+		if len(testCase.path) == 0 && testCase.document[0] == '{' {
+			assert.NoError(t, validationErr, testCase.document)
+			continue
+		}
+
+		var schemaErr *JSONSchemaValidationError
+		assert.ErrorAs(t, validationErr, &schemaErr, testCase.document)
+		assert.Equal(t, testCase.path, schemaErr.Path, testCase.document)
+	}
+
+	var schemaErr *JSONSchemaValidationError
+	assert.ErrorAs(t, schema.Validate([]byte(`{"minutes":`)), &schemaErr)
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"github.com/adfer-dev/analock-api/models"
+	"github.com/go-playground/validator/v10"
+)
+
+// MergePatchContentType is the media type of JSON Merge Patch (RFC 7396) documents.
+const MergePatchContentType = "application/merge-patch+json"
+
+var (
+	ErrUnsupportedMergePatchType = errors.New("the content type must be " + MergePatchContentType)
+	ErrInvalidMergePatch         = errors.New("the merge patch must be a JSON object")
+)
+
+// InvalidBodyError is returned when a request body is not valid JSON or does not pass validation.
+type InvalidBodyError struct {
+	Err error
+}
+
+func (err *InvalidBodyError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *InvalidBodyError) Unwrap() error {
+	return err.Err
+}
+
+// HttpErrors returns the errors responded for the body, like HandleValidation does.
+func (err *InvalidBodyError) HttpErrors() []*models.HttpError {
+	return translateBodyError(err.Err)
+}
+
+// ReadMergePatch returns the JSON Merge Patch document sent in the body of the request.
+func ReadMergePatch(req *http.Request) ([]byte, error) {
+	mediaType, _, parseErr := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	if parseErr != nil || mediaType != MergePatchContentType {
+		return nil, ErrUnsupportedMergePatchType
+	}
+
+	return io.ReadAll(req.Body)
+}
+
+// MergePatch applies a JSON Merge Patch to a JSON document: object members are merged recursively,
+// null members are removed and any other value replaces the original one.
+func MergePatch(original []byte, patch []byte) ([]byte, error) {
+	var originalValue, patchValue interface{}
+
+	if err := json.Unmarshal(original, &originalValue); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatchValue(originalValue, patchValue))
+}
+
+// ApplyMergePatch applies a JSON Merge Patch to a request body holding the current values of a resource,
+// and validates the merged body. Fields of the body are reset before decoding the merged document,
+// so the members removed by the patch end up with their zero value.
+// Patches that are not JSON objects or that produce an invalid body return an InvalidBodyError.
+func ApplyMergePatch(body interface{}, patch []byte) error {
+	var patchObject map[string]interface{}
+
+	if err := json.Unmarshal(patch, &patchObject); err != nil || patchObject == nil {
+		return &InvalidBodyError{Err: ErrInvalidMergePatch}
+	}
+
+	original, marshalErr := json.Marshal(body)
+
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	merged, mergeErr := MergePatch(original, patch)
+
+	if mergeErr != nil {
+		return &InvalidBodyError{Err: mergeErr}
+	}
+
+	bodyValue := reflect.ValueOf(body).Elem()
+	bodyValue.Set(reflect.Zero(bodyValue.Type()))
+
+	if err := json.Unmarshal(merged, body); err != nil {
+		return &InvalidBodyError{Err: err}
+	}
+
+	if err := validateBody(body); err != nil {
+		return &InvalidBodyError{Err: err}
+	}
+
+	return nil
+}
+
+func mergePatchValue(original interface{}, patch interface{}) interface{} {
+	patchObject, patchIsObject := patch.(map[string]interface{})
+
+	if !patchIsObject {
+		return patch
+	}
+
+	originalObject, originalIsObject := original.(map[string]interface{})
+
+	if !originalIsObject {
+		originalObject = map[string]interface{}{}
+	}
+
+	for member, patchMemberValue := range patchObject {
+		if patchMemberValue == nil {
+			delete(originalObject, member)
+			continue
+		}
+
+		originalObject[member] = mergePatchValue(originalObject[member], patchMemberValue)
+	}
+
+	return originalObject
+}
+
+// translateBodyError returns the errors responded for a body that could not be read or validated.
+func translateBodyError(err error) []*models.HttpError {
+	httpErrors := make([]*models.HttpError, 0)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, validationErr := range validationErrs {
+			httpErrors = append(httpErrors,
+				&models.HttpError{Status: 400, Description: "Field" + validationErr.Field() + " must be provided."})
+		}
+	} else if errors.Is(err, ErrInvalidMergePatch) {
+		httpErrors = append(httpErrors, &models.HttpError{Status: 400, Description: err.Error()})
+	} else {
+		httpError := models.HttpError{Status: 400, Description: "Not valid JSON."}
+		httpErrors = append(httpErrors, &httpError)
+	}
+
+	return httpErrors
+}
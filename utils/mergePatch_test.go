@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	// examples from RFC 7396, appendix A
+	testCases := []struct {
+		original string
+		patch    string
+		expected string
+	}{
+		{original: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{original: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{original: `{"a":"b"}`, patch: `{"a":null}`, expected: `{}`},
+		{original: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{original: `{"a":["b"]}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{original: `{"a":"c"}`, patch: `{"a":["b"]}`, expected: `{"a":["b"]}`},
+		{original: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, expected: `{"a":{"b":"d"}}`},
+		{original: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, expected: `{"a":[1]}`},
+		{original: `["a","b"]`, patch: `["c","d"]`, expected: `["c","d"]`},
+		{original: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+		{original: `{"e":null}`, patch: `{"a":1}`, expected: `{"a":1,"e":null}`},
+		{original: `[1,2]`, patch: `{"a":"b","c":null}`, expected: `{"a":"b"}`},
+		{original: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, expected: `{"a":{"bb":{}}}`},
+	}
+
+	for _, testCase := range testCases {
+		merged, err := MergePatch([]byte(testCase.original), []byte(testCase.patch))
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, testCase.expected, string(merged), testCase.patch)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	type patchedBody struct {
+		Name  string `json:"name" validate:"required"`
+		Count int    `json:"count"`
+	}
+
+	body := &patchedBody{Name: "name", Count: 2}
+	assert.NoError(t, ApplyMergePatch(body, []byte(`{"count":3}`)))
+	assert.Equal(t, &patchedBody{Name: "name", Count: 3}, body)
+
+	assert.NoError(t, ApplyMergePatch(body, []byte(`{"count":null}`)))
+	assert.Equal(t, &patchedBody{Name: "name"}, body)
+
+	var invalidBodyErr *InvalidBodyError
+	assert.ErrorAs(t, ApplyMergePatch(body, []byte(`{"name":null}`)), &invalidBodyErr)
+	assert.Len(t, invalidBodyErr.HttpErrors(), 1)
+
+	assert.ErrorIs(t, ApplyMergePatch(body, []byte(`"name"`)), ErrInvalidMergePatch)
+	assert.ErrorIs(t, ApplyMergePatch(body, []byte(`null`)), ErrInvalidMergePatch)
+	assert.ErrorAs(t, ApplyMergePatch(body, []byte(`{"count":"three"}`)), &invalidBodyErr)
+}
+
+func TestReadMergePatch(t *testing.T) {
+	req := httptest.NewRequest("PATCH", "/api/v1/diaryEntries/1", strings.NewReader(`{"title":"title"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json; charset=utf-8")
+
+	patch, err := ReadMergePatch(req)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"title":"title"}`, string(patch))
+
+	req = httptest.NewRequest("PATCH", "/api/v1/diaryEntries/1", strings.NewReader(`{"title":"title"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = ReadMergePatch(req)
+	assert.ErrorIs(t, err, ErrUnsupportedMergePatchType)
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/adfer-dev/analock-api/models"
+)
+
+type requestContextKey string
+
+const requestUserContextKey requestContextKey = "requestUser"
+
+// SetRequestUser returns a copy of the request carrying the authenticated user in its context.
+func SetRequestUser(req *http.Request, user *models.User) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), requestUserContextKey, user))
+}
+
+// GetRequestUser returns the authenticated user stored in the request context by the auth middleware.
+// The second return value is false if the request has no authenticated user.
+func GetRequestUser(req *http.Request) (*models.User, bool) {
+	user, ok := req.Context().Value(requestUserContextKey).(*models.User)
+
+	return user, ok && user != nil
+}
+
+// GetClientIP returns the IP of the client. The X-Forwarded-For header is only
+// trusted when the TRUST_PROXY_HEADERS env variable is true, as clients can forge it.
+func GetClientIP(req *http.Request) string {
+	if trustProxy, _ := strconv.ParseBool(os.Getenv("TRUST_PROXY_HEADERS")); trustProxy {
+		if forwardedFor := req.Header.Get("X-Forwarded-For"); len(forwardedFor) > 0 {
+			return strings.TrimSpace(strings.Split(forwardedFor, ",")[0])
+		}
+	}
+
+	host, _, splitErr := net.SplitHostPort(req.RemoteAddr)
+	if splitErr != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}